@@ -10,10 +10,19 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"okrs/internal/auth"
+	"okrs/internal/datasource"
+	"okrs/internal/digest"
+	"okrs/internal/domain"
 	httpserver "okrs/internal/http"
+	"okrs/internal/okr"
+	"okrs/internal/service"
 	"okrs/internal/store"
+	"okrs/internal/webhook"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -83,12 +92,92 @@ func main() {
 		logger.Info("seed data created")
 	}
 
-	server, err := httpserver.NewServer(pgstore, logger, zone)
+	authenticator := auth.New(pgstore, logger, auth.Config{ProxyHeader: os.Getenv("AUTH_PROXY_HEADER")})
+	if adminEmail, adminPassword := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD"); adminEmail != "" && adminPassword != "" {
+		admin, err := authenticator.EnsurePasswordUser(context.Background(), adminEmail, adminPassword)
+		if err != nil {
+			logger.Error("failed to bootstrap admin", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		if _, err := pgstore.AddRoleAssignment(context.Background(), store.RoleAssignmentInput{UserID: admin.ID, Role: domain.RoleAdmin, Scope: domain.RoleScopeGlobal}); err != nil {
+			logger.Error("failed to grant admin role", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	health, err := healthThresholdsFromEnv()
+	if err != nil {
+		logger.Error("invalid health thresholds", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// svc serves the background jobs, so they use the same time zone and health thresholds as the HTTP server.
+	svc := service.New(pgstore).WithLocation(zone).WithHealthThresholds(health)
+
+	backfilled, err := svc.BackfillKRProgressHistory(context.Background())
+	if err != nil {
+		logger.Error("failed to backfill KR history", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	if backfilled > 0 {
+		logger.Info("KR history backfilled", slog.Int("key_results", backfilled))
+	}
+
+	server, err := httpserver.NewServer(pgstore, authenticator, logger, zone, health, os.Getenv("INGEST_HMAC_SECRET"))
 	if err != nil {
 		logger.Error("failed to start", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
+	tick, err := time.ParseDuration(envOrDefault("DATA_SOURCE_TICK", "1m"))
+	if err != nil || tick < 0 {
+		logger.Error("invalid DATA_SOURCE_TICK", slog.String("value", os.Getenv("DATA_SOURCE_TICK")))
+		os.Exit(1)
+	}
+	allowedHosts := strings.Split(os.Getenv("DATA_SOURCE_ALLOWED_HOSTS"), ",")
+	if tick > 0 {
+		client := datasource.NewHTTPClient(20*time.Second, allowedHosts)
+		scheduler := datasource.NewScheduler(pgstore, svc, map[domain.DataSourceKind]datasource.Fetcher{
+			domain.DataSourceHTTPJSON: datasource.HTTPJSON{Client: client},
+			domain.DataSourcePromQL:   datasource.PromQL{Client: client},
+			domain.DataSourceSQL:      datasource.SQL{Driver: "pgx", DSNs: dataSourceDSNsFromEnv()},
+		}, logger)
+		go scheduler.Run(context.Background(), tick)
+	}
+
+	webhookTick, err := time.ParseDuration(envOrDefault("WEBHOOK_TICK", "10s"))
+	if err != nil || webhookTick < 0 {
+		logger.Error("invalid WEBHOOK_TICK", slog.String("value", os.Getenv("WEBHOOK_TICK")))
+		os.Exit(1)
+	}
+	if webhookTick > 0 {
+		dispatcher := webhook.NewDispatcher(pgstore, datasource.NewHTTPClient(20*time.Second, allowedHosts), logger)
+		go dispatcher.Run(context.Background(), webhookTick)
+	}
+
+	healthTick, err := time.ParseDuration(envOrDefault("HEALTH_TICK", "1h"))
+	if err != nil || healthTick < 0 {
+		logger.Error("invalid HEALTH_TICK", slog.String("value", os.Getenv("HEALTH_TICK")))
+		os.Exit(1)
+	}
+	if healthTick > 0 {
+		job := webhook.NewHealthJob(svc, logger)
+		go job.Run(context.Background(), healthTick)
+	}
+
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		job, digestTick, err := digestJobFromEnv(svc, logger, digest.SMTP{
+			Addr:     smtpAddr,
+			From:     envOrDefault("SMTP_FROM", "okrs@localhost"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		})
+		if err != nil {
+			logger.Error("invalid digest settings", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		go job.Run(context.Background(), digestTick)
+	}
+
 	addr := fmt.Sprintf(":%s", port)
 	logger.Info("listening", slog.String("addr", addr))
 	if err := http.ListenAndServe(addr, server.Routes()); err != nil {
@@ -105,6 +194,70 @@ func envOrDefault(key, def string) string {
 	return value
 }
 
+// healthThresholdsFromEnv reads HEALTH_RISK_GAP, HEALTH_BEHIND_GAP and HEALTH_STALE_DAYS on top of the defaults.
+func healthThresholdsFromEnv() (okr.HealthThresholds, error) {
+	thresholds := okr.DefaultHealthThresholds()
+	for _, item := range []struct {
+		key   string
+		value *int
+	}{
+		{"HEALTH_RISK_GAP", &thresholds.RiskGap},
+		{"HEALTH_BEHIND_GAP", &thresholds.BehindGap},
+	} {
+		raw := os.Getenv(item.key)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 || value > 100 {
+			return thresholds, fmt.Errorf("%s must be 0..100", item.key)
+		}
+		*item.value = value
+	}
+	if thresholds.BehindGap < thresholds.RiskGap {
+		return thresholds, fmt.Errorf("HEALTH_BEHIND_GAP must not be less than HEALTH_RISK_GAP")
+	}
+	if raw := os.Getenv("HEALTH_STALE_DAYS"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 0 {
+			return thresholds, fmt.Errorf("HEALTH_STALE_DAYS must be a non-negative number of days")
+		}
+		thresholds.StaleAfter = time.Duration(days) * 24 * time.Hour
+	}
+	return thresholds, nil
+}
+
+// digestJobFromEnv reads DIGEST_STALE_DAYS, DIGEST_INTERVAL and DIGEST_TICK and builds the email digest job.
+func digestJobFromEnv(source digest.Source, logger *slog.Logger, sender digest.Sender) (*digest.Job, time.Duration, error) {
+	staleDays, err := strconv.Atoi(envOrDefault("DIGEST_STALE_DAYS", "7"))
+	if err != nil || staleDays < 1 {
+		return nil, 0, fmt.Errorf("DIGEST_STALE_DAYS must be a positive number of days")
+	}
+	interval, err := time.ParseDuration(envOrDefault("DIGEST_INTERVAL", "168h"))
+	if err != nil || interval <= 0 {
+		return nil, 0, fmt.Errorf("DIGEST_INTERVAL must be a positive duration")
+	}
+	tick, err := time.ParseDuration(envOrDefault("DIGEST_TICK", "1h"))
+	if err != nil || tick <= 0 {
+		return nil, 0, fmt.Errorf("DIGEST_TICK must be a positive duration")
+	}
+	return digest.NewJob(source, sender, logger, interval, time.Duration(staleDays)*24*time.Hour), tick, nil
+}
+
+// dataSourceDSNsFromEnv collects DATA_SOURCE_DSN_<NAME> variables; SQL data sources refer to them by lower-case name.
+func dataSourceDSNsFromEnv() map[string]string {
+	const prefix = "DATA_SOURCE_DSN_"
+	dsns := make(map[string]string)
+	for _, item := range os.Environ() {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || !strings.HasPrefix(key, prefix) || value == "" {
+			continue
+		}
+		dsns[strings.ToLower(strings.TrimPrefix(key, prefix))] = value
+	}
+	return dsns
+}
+
 func runMigrations(databaseURL string) error {
 	db, err := sql.Open("pgx", databaseURL)
 	if err != nil {
@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// updateAlignmentRequest leaves omitted fields unchanged; parent_goal_id null removes the link.
+type updateAlignmentRequest struct {
+	ParentGoalID         json.RawMessage `json:"parent_goal_id"`
+	ProgressFromChildren *bool           `json:"progress_from_children"`
+}
+
+type goalTreeNode struct {
+	ID                   int64          `json:"id"`
+	TeamID               int64          `json:"team_id"`
+	TeamName             string         `json:"team_name"`
+	PeriodID             int64          `json:"period_id"`
+	ParentGoalID         *int64         `json:"parent_goal_id"`
+	Title                string         `json:"title"`
+	Weight               int            `json:"weight"`
+	Progress             int            `json:"progress"`
+	ProgressFromChildren bool           `json:"progress_from_children"`
+	Children             []goalTreeNode `json:"children"`
+}
+
+type goalTreeResponse struct {
+	Ancestors []goalTreeNode `json:"ancestors"`
+	Goal      goalTreeNode   `json:"goal"`
+}
+
+// handleGoalTree returns the goals aligned to a goal and the chain of its parent goals.
+func (h *Handler) handleGoalTree(w http.ResponseWriter, r *http.Request) {
+	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid goal id", map[string]string{"goal_id": "invalid"})
+		return
+	}
+	tree, err := h.service.GoalTree(r.Context(), goalID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "goal not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load goal tree", nil)
+		return
+	}
+	response := goalTreeResponse{Ancestors: make([]goalTreeNode, 0, len(tree.Ancestors)), Goal: mapGoalTreeNode(tree.Root)}
+	for _, ancestor := range tree.Ancestors {
+		response.Ancestors = append(response.Ancestors, mapGoalTreeNode(ancestor))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleUpdateGoalAlignment sets the parent goal and the progress roll-up option of a goal; omitted fields keep
+// their values.
+func (h *Handler) handleUpdateGoalAlignment(w http.ResponseWriter, r *http.Request) {
+	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid goal id", map[string]string{"goal_id": "invalid"})
+		return
+	}
+	var req updateAlignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	goal, err := h.service.GetGoal(r.Context(), goalID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "goal not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update alignment", nil)
+		return
+	}
+	input := service.GoalAlignmentInput{ParentGoalID: goal.ParentGoalID, ProgressFromChildren: goal.ProgressFromChildren}
+	if req.ParentGoalID != nil {
+		input.ParentGoalID = nil
+		if err := json.Unmarshal(req.ParentGoalID, &input.ParentGoalID); err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid parent goal id", map[string]string{"parent_goal_id": "invalid"})
+			return
+		}
+	}
+	if req.ProgressFromChildren != nil {
+		input.ProgressFromChildren = *req.ProgressFromChildren
+	}
+	err = h.service.SetGoalAlignment(r.Context(), goalID, input)
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidAlignment):
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]string{"parent_goal_id": "invalid"})
+		case errors.Is(err, pgx.ErrNoRows):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "goal not found", nil)
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update alignment", nil)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func mapGoalTreeNode(node service.GoalTreeNode) goalTreeNode {
+	children := make([]goalTreeNode, 0, len(node.Children))
+	for _, child := range node.Children {
+		children = append(children, mapGoalTreeNode(child))
+	}
+	return goalTreeNode{
+		ID:                   node.Goal.ID,
+		TeamID:               node.Goal.TeamID,
+		TeamName:             node.TeamName,
+		PeriodID:             node.Goal.PeriodID,
+		ParentGoalID:         node.Goal.ParentGoalID,
+		Title:                node.Goal.Title,
+		Weight:               node.Goal.Weight,
+		Progress:             node.Goal.Progress,
+		ProgressFromChildren: node.Goal.ProgressFromChildren,
+		Children:             children,
+	}
+}
@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+)
+
+type auditEvent struct {
+	ID        int64           `json:"id"`
+	ActorID   *int64          `json:"actor_id"`
+	ActorName string          `json:"actor_name"`
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entity_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type auditResponse struct {
+	Items []auditEvent `json:"items"`
+}
+
+// handleAudit returns the audit log of an entity: GET /audit?entity=goal&id=1.
+func (h *Handler) handleAudit(w http.ResponseWriter, r *http.Request) {
+	entity := domain.AuditEntity(r.URL.Query().Get("entity"))
+	fields := map[string]string{}
+	if !service.ValidAuditEntity(entity) {
+		fields["entity"] = "invalid"
+	}
+	entityID, err := common.ParseID(r.URL.Query().Get("id"))
+	if err != nil {
+		fields["id"] = "invalid"
+	}
+	if len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid audit query", fields)
+		return
+	}
+	events, err := h.service.ListAuditEvents(r.Context(), entity, entityID)
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load audit events", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, auditResponse{Items: mapAuditEvents(events)})
+}
+
+func mapAuditEvents(events []domain.AuditEvent) []auditEvent {
+	items := make([]auditEvent, 0, len(events))
+	for _, event := range events {
+		items = append(items, auditEvent{
+			ID:        event.ID,
+			ActorID:   event.ActorID,
+			ActorName: event.ActorName,
+			Entity:    string(event.EntityType),
+			EntityID:  event.EntityID,
+			Action:    string(event.Action),
+			Before:    nullableJSON(event.Before),
+			After:     nullableJSON(event.After),
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return items
+}
+
+// nullableJSON keeps missing states as JSON null instead of an invalid empty raw message.
+func nullableJSON(value json.RawMessage) json.RawMessage {
+	if len(value) == 0 {
+		return json.RawMessage("null")
+	}
+	return value
+}
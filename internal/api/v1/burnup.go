@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type burnupPoint struct {
+	Date    string `json:"date"`
+	Planned int    `json:"planned"`
+	Actual  *int   `json:"actual"`
+}
+
+type burnupResponse struct {
+	Items []burnupPoint `json:"items"`
+}
+
+// handleGoalBurnup returns the burn-up series of a goal over its period.
+func (h *Handler) handleGoalBurnup(w http.ResponseWriter, r *http.Request) {
+	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid goal id", map[string]string{"goal_id": "invalid"})
+		return
+	}
+	points, err := h.service.GoalBurnup(r.Context(), goalID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "goal not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load burnup", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, burnupResponse{Items: mapBurnupPoints(points)})
+}
+
+// handleTeamBurnup returns the burn-up series of a team period.
+func (h *Handler) handleTeamBurnup(w http.ResponseWriter, r *http.Request) {
+	teamID, err := common.ParseID(chi.URLParam(r, "teamID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid team id", map[string]string{"team_id": "invalid"})
+		return
+	}
+	periodID, err := common.ParsePeriodID(r)
+	if err != nil || periodID == 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid period id", map[string]string{"period_id": "invalid"})
+		return
+	}
+	points, err := h.service.TeamBurnup(r.Context(), teamID, periodID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team or period not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load burnup", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, burnupResponse{Items: mapBurnupPoints(points)})
+}
+
+func mapBurnupPoints(points []service.BurnupPoint) []burnupPoint {
+	items := make([]burnupPoint, 0, len(points))
+	for _, point := range points {
+		items = append(items, burnupPoint{Date: point.Date.Format("2006-01-02"), Planned: point.Planned, Actual: point.Actual})
+	}
+	return items
+}
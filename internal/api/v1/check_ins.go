@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type createCheckInRequest struct {
+	CurrentValue *float64             `json:"current_value"`
+	Done         *bool                `json:"done"`
+	Stages       []updateProjectStage `json:"stages"`
+	Confidence   int                  `json:"confidence"`
+	Blockers     string               `json:"blockers"`
+	NextSteps    string               `json:"next_steps"`
+}
+
+type checkIn struct {
+	ID          int64     `json:"id"`
+	KeyResultID int64     `json:"kr_id"`
+	AuthorID    *int64    `json:"author_id"`
+	AuthorName  string    `json:"author_name"`
+	Value       float64   `json:"value"`
+	Progress    int       `json:"progress"`
+	Confidence  int       `json:"confidence"`
+	Blockers    string    `json:"blockers"`
+	NextSteps   string    `json:"next_steps"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type checkInsResponse struct {
+	KeyResultID int64     `json:"kr_id"`
+	Items       []checkIn `json:"items"`
+}
+
+type missingCheckIn struct {
+	TeamID        int64      `json:"team_id"`
+	TeamName      string     `json:"team_name"`
+	LastCheckInAt *time.Time `json:"last_check_in_at"`
+}
+
+type missingCheckInsResponse struct {
+	WeekStart string           `json:"week_start"`
+	Items     []missingCheckIn `json:"items"`
+}
+
+// handleCreateCheckIn records a weekly check-in and applies its value to the key result.
+func (h *Handler) handleCreateCheckIn(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	var req createCheckInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	input := service.CheckInInput{
+		Value:      req.CurrentValue,
+		Done:       req.Done,
+		Confidence: req.Confidence,
+		Blockers:   req.Blockers,
+		NextSteps:  req.NextSteps,
+	}
+	for _, stage := range req.Stages {
+		if stage.ID == 0 {
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "stage id required", map[string]string{"stage_id": "required"})
+			return
+		}
+		input.Stages = append(input.Stages, service.ProjectStageUpdate{ID: stage.ID, IsDone: stage.Done})
+	}
+	created, err := h.service.CheckInKeyResult(r.Context(), krID, input)
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidCheckIn):
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]string{"confidence": "invalid"})
+		case errors.Is(err, pgx.ErrNoRows):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "kr not found", nil)
+		default:
+			writeError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+		}
+		return
+	}
+	writeJSON(w, http.StatusCreated, mapCheckIn(created))
+}
+
+// handleCheckIns returns the check-ins of a key result, newest first.
+func (h *Handler) handleCheckIns(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	items, err := h.service.ListKRCheckIns(r.Context(), krID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "kr not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load check-ins", nil)
+		return
+	}
+	response := checkInsResponse{KeyResultID: krID, Items: make([]checkIn, 0, len(items))}
+	for _, item := range items {
+		response.Items = append(response.Items, mapCheckIn(item))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleMissingCheckIns returns teams with key results in the period that have not checked in this week.
+func (h *Handler) handleMissingCheckIns(w http.ResponseWriter, r *http.Request) {
+	periodID, err := common.ParsePeriodID(r)
+	if err != nil || periodID == 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid period id", map[string]string{"period_id": "invalid"})
+		return
+	}
+	items, weekStart, err := h.service.TeamsMissingCheckIn(r.Context(), periodID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load check-ins", nil)
+		return
+	}
+	response := missingCheckInsResponse{WeekStart: weekStart.Format("2006-01-02"), Items: make([]missingCheckIn, 0, len(items))}
+	for _, item := range items {
+		response.Items = append(response.Items, missingCheckIn{TeamID: item.Team.ID, TeamName: item.Team.Name, LastCheckInAt: item.LastCheckInAt})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func mapCheckIn(item domain.KRCheckIn) checkIn {
+	return checkIn{
+		ID:          item.ID,
+		KeyResultID: item.KeyResultID,
+		AuthorID:    item.AuthorID,
+		AuthorName:  item.AuthorName,
+		Value:       item.Value,
+		Progress:    item.Progress,
+		Confidence:  item.Confidence,
+		Blockers:    item.Blockers,
+		NextSteps:   item.NextSteps,
+		CreatedAt:   item.CreatedAt,
+	}
+}
@@ -0,0 +1,149 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type checkpointRequest struct {
+	MetricValue *float64 `json:"metric_value"`
+	Percent     *int     `json:"percent"`
+}
+
+type checkpointsResponse struct {
+	KeyResultID int64               `json:"kr_id"`
+	Items       []percentCheckpoint `json:"items"`
+}
+
+// handleListCheckpoints returns the checkpoints of a percent key result.
+func (h *Handler) handleListCheckpoints(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	items, err := h.service.ListPercentCheckpoints(r.Context(), krID)
+	if err != nil {
+		writeCheckpointError(w, err)
+		return
+	}
+	response := checkpointsResponse{KeyResultID: krID, Items: make([]percentCheckpoint, 0, len(items))}
+	for _, item := range items {
+		response.Items = append(response.Items, mapCheckpoint(item))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleAddCheckpoint adds a checkpoint to a percent key result.
+func (h *Handler) handleAddCheckpoint(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	input, ok := decodeCheckpointRequest(w, r)
+	if !ok {
+		return
+	}
+	checkpoint, err := h.service.AddPercentCheckpoint(r.Context(), krID, input)
+	if err != nil {
+		writeCheckpointError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, mapCheckpoint(checkpoint))
+}
+
+// handleUpdateCheckpoint changes the metric value and the percent of a checkpoint.
+func (h *Handler) handleUpdateCheckpoint(w http.ResponseWriter, r *http.Request) {
+	krID, checkpointID, ok := parseCheckpointIDs(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeCheckpointRequest(w, r)
+	if !ok {
+		return
+	}
+	checkpoint, err := h.service.UpdatePercentCheckpoint(r.Context(), krID, checkpointID, input)
+	if err != nil {
+		writeCheckpointError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, mapCheckpoint(checkpoint))
+}
+
+// handleDeleteCheckpoint removes a checkpoint of a percent key result.
+func (h *Handler) handleDeleteCheckpoint(w http.ResponseWriter, r *http.Request) {
+	krID, checkpointID, ok := parseCheckpointIDs(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeletePercentCheckpoint(r.Context(), krID, checkpointID); err != nil {
+		writeCheckpointError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func parseCheckpointIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return 0, 0, false
+	}
+	checkpointID, err := common.ParseID(chi.URLParam(r, "checkpointID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid checkpoint id", map[string]string{"checkpoint_id": "invalid"})
+		return 0, 0, false
+	}
+	return krID, checkpointID, true
+}
+
+func decodeCheckpointRequest(w http.ResponseWriter, r *http.Request) (service.CheckpointInput, bool) {
+	var req checkpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return service.CheckpointInput{}, false
+	}
+	fields := map[string]string{}
+	if req.MetricValue == nil {
+		fields["metric_value"] = "required"
+	}
+	if req.Percent == nil {
+		fields["percent"] = "required"
+	}
+	if len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "metric_value and percent required", fields)
+		return service.CheckpointInput{}, false
+	}
+	return service.CheckpointInput{MetricValue: *req.MetricValue, KRPercent: *req.Percent}, true
+}
+
+func mapCheckpoint(checkpoint domain.KRPercentCheckpoint) percentCheckpoint {
+	return percentCheckpoint{
+		ID:          checkpoint.ID,
+		MetricValue: checkpoint.MetricValue,
+		Percent:     checkpoint.KRPercent,
+	}
+}
+
+func writeCheckpointError(w http.ResponseWriter, err error) {
+	if writePolicyError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidCheckpoint):
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "checkpoint not found", nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update checkpoints", nil)
+	}
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type updateConfidenceRequest struct {
+	Confidence *int `json:"confidence"`
+}
+
+// handleUpdateGoalConfidence sets or clears the owner confidence of a goal.
+func (h *Handler) handleUpdateGoalConfidence(w http.ResponseWriter, r *http.Request) {
+	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid goal id", map[string]string{"goal_id": "invalid"})
+		return
+	}
+	var req updateConfidenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	writeConfidenceResult(w, h.service.SetGoalConfidence(r.Context(), goalID, req.Confidence), "goal not found")
+}
+
+// handleUpdateKeyResultConfidence sets or clears the owner confidence of a key result.
+func (h *Handler) handleUpdateKeyResultConfidence(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	var req updateConfidenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	writeConfidenceResult(w, h.service.SetKeyResultConfidence(r.Context(), krID, req.Confidence), "kr not found")
+}
+
+func writeConfidenceResult(w http.ResponseWriter, err error, notFound string) {
+	if err == nil {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	if writePolicyError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidConfidence):
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]string{"confidence": "invalid"})
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", notFound, nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update confidence", nil)
+	}
+}
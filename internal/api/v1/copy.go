@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type rolloverResponse struct {
+	GoalIDs []int64 `json:"goal_ids"`
+	Skipped int     `json:"skipped"`
+}
+
+// handleCopyGoal copies a goal with its key results and shares into the period_id period.
+func (h *Handler) handleCopyGoal(w http.ResponseWriter, r *http.Request) {
+	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid goal id", map[string]string{"goal_id": "invalid"})
+		return
+	}
+	periodID, ok := h.parseTargetPeriod(w, r, "period_id")
+	if !ok {
+		return
+	}
+	id, err := h.service.CopyGoal(r.Context(), goalID, periodID, r.URL.Query().Get("keep_current") == "true")
+	if err != nil {
+		writeCopyError(w, err, "goal not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
+}
+
+// handleRollOverGoals copies the unfinished goals of a team from the period_id period into target_period_id.
+func (h *Handler) handleRollOverGoals(w http.ResponseWriter, r *http.Request) {
+	teamID, err := common.ParseID(chi.URLParam(r, "teamID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid team id", map[string]string{"team_id": "invalid"})
+		return
+	}
+	fromPeriodID, err := common.ParsePeriodID(r)
+	if err != nil || fromPeriodID == 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid period id", map[string]string{"period_id": "invalid"})
+		return
+	}
+	toPeriodID, ok := h.parseTargetPeriod(w, r, "target_period_id")
+	if !ok {
+		return
+	}
+	if _, err := h.service.GetTeam(r.Context(), teamID); err != nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found", nil)
+		return
+	}
+	result, err := h.service.RollOverGoals(r.Context(), teamID, fromPeriodID, toPeriodID, r.URL.Query().Get("keep_current") == "true")
+	if err != nil {
+		writeCopyError(w, err, "team not found")
+		return
+	}
+	response := rolloverResponse{GoalIDs: result.GoalIDs, Skipped: result.Skipped}
+	if response.GoalIDs == nil {
+		response.GoalIDs = []int64{}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// parseTargetPeriod reads the period a copy goes to from the query parameter and checks that it exists.
+func (h *Handler) parseTargetPeriod(w http.ResponseWriter, r *http.Request, param string) (int64, bool) {
+	periodID, err := strconv.ParseInt(r.URL.Query().Get(param), 10, 64)
+	if err != nil || periodID <= 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid period id", map[string]string{param: "invalid"})
+		return 0, false
+	}
+	if _, err := h.service.GetPeriod(r.Context(), periodID); err != nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "period not found", map[string]string{param: "not_found"})
+		return 0, false
+	}
+	return periodID, true
+}
+
+func writeCopyError(w http.ResponseWriter, err error, notFound string) {
+	if writePolicyError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidCopy):
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]string{"period_id": "invalid"})
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", notFound, nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to copy goals", nil)
+	}
+}
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type dataSourceRequest struct {
+	Kind            string `json:"kind"`
+	Endpoint        string `json:"endpoint"`
+	Query           string `json:"query"`
+	IntervalMinutes int    `json:"interval_minutes"`
+}
+
+type krDataSource struct {
+	KeyResultID     int64      `json:"kr_id"`
+	Kind            string     `json:"kind"`
+	Endpoint        string     `json:"endpoint"`
+	Query           string     `json:"query"`
+	IntervalMinutes int        `json:"interval_minutes"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	LastValue       *float64   `json:"last_value"`
+	LastError       string     `json:"last_error"`
+}
+
+// handleKRDataSource returns the data source that feeds the key result.
+func (h *Handler) handleKRDataSource(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	source, err := h.service.GetKRDataSource(r.Context(), krID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "data source not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load data source", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, mapKRDataSource(source))
+}
+
+// handleSetKRDataSource binds the key result to a data source; the scheduler fetches it on its next tick.
+func (h *Handler) handleSetKRDataSource(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	var req dataSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	if err := h.service.SetKRDataSource(r.Context(), krID, service.DataSourceInput{
+		Kind:            domain.DataSourceKind(req.Kind),
+		Endpoint:        req.Endpoint,
+		Query:           req.Query,
+		IntervalMinutes: req.IntervalMinutes,
+	}); err != nil {
+		writeDataSourceError(w, err)
+		return
+	}
+	h.handleKRDataSource(w, r)
+}
+
+// handleDeleteKRDataSource unbinds the key result from its data source.
+func (h *Handler) handleDeleteKRDataSource(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	if err := h.service.DeleteKRDataSource(r.Context(), krID); err != nil {
+		writeDataSourceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func mapKRDataSource(source domain.KRDataSource) krDataSource {
+	return krDataSource{
+		KeyResultID:     source.KeyResultID,
+		Kind:            string(source.Kind),
+		Endpoint:        source.Endpoint,
+		Query:           source.Query,
+		IntervalMinutes: source.IntervalMinutes,
+		LastRunAt:       source.LastRunAt,
+		LastValue:       source.LastValue,
+		LastError:       source.LastError,
+	}
+}
+
+func writeDataSourceError(w http.ResponseWriter, err error) {
+	if writePolicyError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidDataSource):
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "kr not found", nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update data source", nil)
+	}
+}
@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type addDependencyRequest struct {
+	DependsOnID int64 `json:"depends_on_id"`
+}
+
+type krDependency struct {
+	KeyResultID int64  `json:"kr_id"`
+	Title       string `json:"title"`
+	GoalID      int64  `json:"goal_id"`
+	GoalTitle   string `json:"goal_title"`
+	TeamID      int64  `json:"team_id"`
+	TeamName    string `json:"team_name"`
+	Progress    int    `json:"progress"`
+	Health      string `json:"health"`
+	HealthLabel string `json:"health_label"`
+	BehindPlan  bool   `json:"behind_plan"`
+}
+
+type krDependenciesResponse struct {
+	KeyResultID int64          `json:"kr_id"`
+	Blocked     bool           `json:"blocked"`
+	Items       []krDependency `json:"items"`
+}
+
+// handleKRDependencies returns the key results the key result depends on.
+func (h *Handler) handleKRDependencies(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	items, err := h.service.ListKRDependencies(r.Context(), krID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "kr not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load dependencies", nil)
+		return
+	}
+	response := krDependenciesResponse{KeyResultID: krID, Items: make([]krDependency, 0, len(items))}
+	for _, item := range items {
+		response.Blocked = response.Blocked || item.BehindPlan()
+		response.Items = append(response.Items, krDependency{
+			KeyResultID: item.KeyResult.ID,
+			Title:       item.KeyResult.Title,
+			GoalID:      item.KeyResult.GoalID,
+			GoalTitle:   item.GoalTitle,
+			TeamID:      item.Team.ID,
+			TeamName:    item.Team.Name,
+			Progress:    item.KeyResult.Progress,
+			Health:      string(item.Health),
+			HealthLabel: common.HealthLabel(item.Health),
+			BehindPlan:  item.BehindPlan(),
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleAddKRDependency records that the key result is blocked by another key result.
+func (h *Handler) handleAddKRDependency(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	var req addDependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	if req.DependsOnID == 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "depends_on_id required", map[string]string{"depends_on_id": "required"})
+		return
+	}
+	writeDependencyResult(w, h.service.AddKRDependency(r.Context(), krID, req.DependsOnID))
+}
+
+// handleDeleteKRDependency removes a dependency of the key result.
+func (h *Handler) handleDeleteKRDependency(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	dependsOnID, err := common.ParseID(chi.URLParam(r, "dependsOnID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid depends_on_id", map[string]string{"depends_on_id": "invalid"})
+		return
+	}
+	writeDependencyResult(w, h.service.RemoveKRDependency(r.Context(), krID, dependsOnID))
+}
+
+func writeDependencyResult(w http.ResponseWriter, err error) {
+	if err == nil {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	if writePolicyError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidDependency):
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]string{"depends_on_id": "invalid"})
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "kr not found", nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update dependencies", nil)
+	}
+}
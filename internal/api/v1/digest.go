@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"okrs/internal/auth"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type digestSettingsRequest struct {
+	OptOut *bool `json:"opt_out"`
+}
+
+type userReferenceRequest struct {
+	UserID *int64 `json:"user_id"`
+}
+
+// handleUpdateDigestSettings turns the weekly email digest of the current user off or back on.
+func (h *Handler) handleUpdateDigestSettings(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		WriteUnauthorized(w, r)
+		return
+	}
+	var req digestSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.OptOut == nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", map[string]string{"opt_out": "required"})
+		return
+	}
+	if err := h.service.SetDigestOptOut(r.Context(), user.ID, *req.OptOut); err != nil {
+		writeDigestError(w, err, "user not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"digest_opt_out": *req.OptOut})
+}
+
+// handleSetGoalOwner links a goal to the user who owns it; a null user_id clears the link.
+func (h *Handler) handleSetGoalOwner(w http.ResponseWriter, r *http.Request) {
+	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid goal id", map[string]string{"goal_id": "invalid"})
+		return
+	}
+	userID, ok := decodeUserReference(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.SetGoalOwner(r.Context(), goalID, userID); err != nil {
+		writeDigestError(w, err, "goal not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleSetTeamLead links a team to the user who leads it; a null user_id clears the link.
+func (h *Handler) handleSetTeamLead(w http.ResponseWriter, r *http.Request) {
+	teamID, err := common.ParseID(chi.URLParam(r, "teamID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid team id", map[string]string{"team_id": "invalid"})
+		return
+	}
+	userID, ok := decodeUserReference(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.SetTeamLead(r.Context(), teamID, userID); err != nil {
+		writeDigestError(w, err, "team not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func decodeUserReference(w http.ResponseWriter, r *http.Request) (*int64, bool) {
+	var req userReferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return nil, false
+	}
+	return req.UserID, true
+}
+
+func writeDigestError(w http.ResponseWriter, err error, notFound string) {
+	if writePolicyError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrUnknownUser):
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]string{"user_id": "not_found"})
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", notFound, nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update", nil)
+	}
+}
@@ -2,7 +2,10 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"okrs/internal/service"
 )
 
 type ErrorResponse struct {
@@ -24,3 +27,23 @@ func writeJSON(w http.ResponseWriter, status int, payload any) {
 func writeError(w http.ResponseWriter, status int, code, message string, fields map[string]string) {
 	writeJSON(w, status, ErrorResponse{Error: ErrorDetail{Code: code, Message: message, Fields: fields}})
 }
+
+// WriteUnauthorized responds to requests without an authenticated user.
+func WriteUnauthorized(w http.ResponseWriter, _ *http.Request) {
+	writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "authentication required", nil)
+}
+
+// writePolicyError writes permission and lifecycle policy errors and reports whether err was handled.
+func writePolicyError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+	case errors.Is(err, service.ErrLocked):
+		writeError(w, http.StatusLocked, "LOCKED", err.Error(), nil)
+	case errors.Is(err, service.ErrInvalidStatusTransition):
+		writeError(w, http.StatusConflict, "CONFLICT", err.Error(), map[string]string{"status": "transition_not_allowed"})
+	default:
+		return false
+	}
+	return true
+}
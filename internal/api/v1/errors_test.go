@@ -2,9 +2,13 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"okrs/internal/service"
 )
 
 func TestWriteError(t *testing.T) {
@@ -27,3 +31,42 @@ func TestWriteError(t *testing.T) {
 		t.Fatalf("expected field error")
 	}
 }
+
+func TestWritePolicyErrorLocked(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := fmt.Errorf("%w: structural mutation in closed period", service.ErrLocked)
+	if !writePolicyError(recorder, err) {
+		t.Fatalf("expected locked error to be handled")
+	}
+	if recorder.Code != http.StatusLocked {
+		t.Fatalf("expected status %d, got %d", http.StatusLocked, recorder.Code)
+	}
+	var response ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if response.Error.Code != "LOCKED" {
+		t.Fatalf("expected code LOCKED, got %s", response.Error.Code)
+	}
+	if writePolicyError(httptest.NewRecorder(), errors.New("boom")) {
+		t.Fatalf("expected unrelated error to be skipped")
+	}
+}
+
+func TestWritePolicyErrorForbidden(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := fmt.Errorf("%w: edit on team 3", service.ErrForbidden)
+	if !writePolicyError(recorder, err) {
+		t.Fatalf("expected forbidden error to be handled")
+	}
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, recorder.Code)
+	}
+	var response ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if response.Error.Code != "FORBIDDEN" {
+		t.Fatalf("expected code FORBIDDEN, got %s", response.Error.Code)
+	}
+}
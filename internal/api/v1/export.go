@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"okrs/internal/export"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// handleExportTeamOKRs downloads the goals and key results of a team in a period as CSV or XLSX.
+func (h *Handler) handleExportTeamOKRs(w http.ResponseWriter, r *http.Request) {
+	teamID, err := common.ParseID(chi.URLParam(r, "teamID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid team id", map[string]string{"team_id": "invalid"})
+		return
+	}
+	format, ok := parseExportFormat(w, r)
+	if !ok {
+		return
+	}
+	periodID, err := common.ParsePeriodID(r)
+	if err != nil || periodID == 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid period id", map[string]string{"period_id": "invalid"})
+		return
+	}
+	period, err := h.service.GetPeriod(r.Context(), periodID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "period not found", map[string]string{"period_id": "not_found"})
+		return
+	}
+	rows, err := h.service.ExportTeamOKRs(r.Context(), teamID, period)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to export okrs", nil)
+		return
+	}
+	writeExport(w, format, fmt.Sprintf("okr-team-%d-period-%d", teamID, periodID), rows)
+}
+
+// handleExportPeriodOKRs downloads the goals and key results of every team in a period as CSV or XLSX.
+func (h *Handler) handleExportPeriodOKRs(w http.ResponseWriter, r *http.Request) {
+	format, ok := parseExportFormat(w, r)
+	if !ok {
+		return
+	}
+	periodID, err := common.ParsePeriodID(r)
+	if err != nil || periodID == 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid period id", map[string]string{"period_id": "invalid"})
+		return
+	}
+	if _, err := h.service.GetPeriod(r.Context(), periodID); err != nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "period not found", map[string]string{"period_id": "not_found"})
+		return
+	}
+	rows, err := h.service.ExportPeriodOKRs(r.Context(), periodID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to export okrs", nil)
+		return
+	}
+	writeExport(w, format, fmt.Sprintf("okr-period-%d", periodID), rows)
+}
+
+// parseExportFormat reads the format query parameter; CSV is the default.
+func parseExportFormat(w http.ResponseWriter, r *http.Request) (export.Format, bool) {
+	switch format := export.Format(r.URL.Query().Get("format")); format {
+	case "", export.FormatCSV:
+		return export.FormatCSV, true
+	case export.FormatXLSX:
+		return format, true
+	default:
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid format", map[string]string{"format": "invalid"})
+		return "", false
+	}
+}
+
+func writeExport(w http.ResponseWriter, format export.Format, name string, rows []service.ExportRow) {
+	var buf bytes.Buffer
+	write := export.WriteCSV
+	if format == export.FormatXLSX {
+		write = export.WriteXLSX
+	}
+	if err := write(&buf, rows); err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to export okrs", nil)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -42,7 +42,12 @@ func (h *Handler) handleGoal(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "NOT_FOUND", "goal not found", nil)
 		return
 	}
-	writeJSON(w, http.StatusOK, mapGoalResponse(goal))
+	period, err := h.service.GetPeriod(r.Context(), goal.PeriodID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load period", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, mapGoalResponse(goal, h.service.GoalHealth(goal, period)))
 }
 
 // handleShareGoal replaces goal share targets.
@@ -74,6 +79,9 @@ func (h *Handler) handleShareGoal(w http.ResponseWriter, r *http.Request) {
 		targets = append(targets, service.ShareTarget{TeamID: target.TeamID, Weight: target.Weight})
 	}
 	if err := h.service.ShareGoal(r.Context(), goalID, targets); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to share goal", nil)
 		return
 	}
@@ -101,6 +109,9 @@ func (h *Handler) handleUpdateGoalWeight(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if err := h.service.UpdateGoalWeight(r.Context(), goalID, req.TeamID, req.Weight); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update weight", nil)
 		return
 	}
@@ -124,6 +135,9 @@ func (h *Handler) handleAddGoalComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := h.service.AddGoalComment(r.Context(), goalID, req.Text); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to add comment", nil)
 		return
 	}
@@ -173,11 +187,17 @@ func (h *Handler) handleUpdateGoal(w http.ResponseWriter, r *http.Request) {
 		FocusType:   focusType,
 		OwnerText:   common.TrimmedFormValue(r, "owner_text"),
 	}); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update goal", nil)
 		return
 	}
 	if teamID != nil {
 		if err := h.service.UpdateGoalWeight(r.Context(), goalID, *teamID, weight); err != nil {
+			if writePolicyError(w, err) {
+				return
+			}
 			writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update weight", nil)
 			return
 		}
@@ -206,7 +226,7 @@ func (h *Handler) handleCreateKeyResult(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid weight", map[string]string{"weight": "0..100"})
 		return
 	}
-	meta, err := parseKeyResultMeta(r, kind)
+	meta, err := parseKeyResultMeta(r.Form, kind)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
 		return
@@ -219,6 +239,9 @@ func (h *Handler) handleCreateKeyResult(w http.ResponseWriter, r *http.Request)
 		Kind:        kind,
 	}, meta)
 	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to create key result", nil)
 		return
 	}
@@ -3,6 +3,7 @@ package v1
 import (
 	"net/http"
 
+	"okrs/internal/auth"
 	"okrs/internal/service"
 
 	"github.com/go-chi/chi/v5"
@@ -20,17 +21,33 @@ func NewHandler(service *service.Service) *Handler {
 
 func (h *Handler) Routes() chi.Router {
 	r := chi.NewRouter()
+	r.Use(h.authenticateBearer, auth.RequireUser(WriteUnauthorized))
 
 	r.Get("/hierarchy", h.handleHierarchy)
 	r.Get("/periods", h.handlePeriods)
 	r.Get("/teams", h.handleTeams)
 	r.Get("/teams/{teamID}", h.handleTeam)
 	r.Get("/teams/{teamID}/okrs", h.handleTeamOKRs)
+	r.Get("/teams/{teamID}/okrs/export", h.handleExportTeamOKRs)
+	r.Get("/okrs/export", h.handleExportPeriodOKRs)
 	r.Get("/goals/{goalID}", h.handleGoal)
+	r.Get("/goals/{goalID}/burnup", h.handleGoalBurnup)
+	r.Get("/goals/{goalID}/tree", h.handleGoalTree)
+	r.Get("/teams/{teamID}/burnup", h.handleTeamBurnup)
+	r.Get("/krs/{krID}/history", h.handleKRHistory)
+	r.Get("/krs/{krID}/checkins", h.handleCheckIns)
+	r.Get("/krs/{krID}/dependencies", h.handleKRDependencies)
+	r.Get("/krs/{krID}/checkpoints", h.handleListCheckpoints)
+	r.Get("/krs/{krID}/data-source", h.handleKRDataSource)
+	r.Get("/checkins/missing", h.handleMissingCheckIns)
 
 	r.Post("/goals/{goalID}/share", h.handleShareGoal)
 	r.Post("/goals/{goalID}/weight", h.handleUpdateGoalWeight)
 	r.Post("/goals/{goalID}/comments", h.handleAddGoalComment)
+	r.Post("/goals/{goalID}/confidence", h.handleUpdateGoalConfidence)
+	r.Post("/goals/{goalID}/alignment", h.handleUpdateGoalAlignment)
+	r.Post("/goals/{goalID}/owner", h.handleSetGoalOwner)
+	r.Post("/goals/{goalID}/copy", h.handleCopyGoal)
 	r.Post("/goals/{goalID}", h.handleUpdateGoal)
 	r.Post("/goals/{goalID}/key-results", h.handleCreateKeyResult)
 	r.Post("/goals/{goalID}/move-up", h.handleMoveGoalUp)
@@ -40,11 +57,44 @@ func (h *Handler) Routes() chi.Router {
 	r.Post("/krs/{krID}/progress/boolean", h.handleUpdateBooleanProgress)
 	r.Post("/krs/{krID}/progress/project", h.handleUpdateProjectProgress)
 	r.Post("/krs/{krID}/comments", h.handleAddKRComment)
+	r.Post("/krs/{krID}/checkins", h.handleCreateCheckIn)
+	r.Post("/krs/{krID}/confidence", h.handleUpdateKeyResultConfidence)
+	r.Post("/krs/{krID}/dependencies", h.handleAddKRDependency)
+	r.Post("/krs/{krID}/dependencies/{dependsOnID}/delete", h.handleDeleteKRDependency)
+	r.Post("/krs/{krID}/checkpoints", h.handleAddCheckpoint)
+	r.Post("/krs/{krID}/checkpoints/{checkpointID}", h.handleUpdateCheckpoint)
+	r.Post("/krs/{krID}/checkpoints/{checkpointID}/delete", h.handleDeleteCheckpoint)
+	r.Post("/krs/{krID}/data-source", h.handleSetKRDataSource)
+	r.Post("/krs/{krID}/data-source/delete", h.handleDeleteKRDataSource)
 	r.Post("/krs/{krID}", h.handleUpdateKeyResult)
 	r.Post("/krs/{krID}/move-up", h.handleMoveKeyResultUp)
 	r.Post("/krs/{krID}/move-down", h.handleMoveKeyResultDown)
 
 	r.Post("/teams/{teamID}/status", h.handleUpdateTeamPeriodStatus)
+	r.Post("/teams/{teamID}/lead", h.handleSetTeamLead)
+	r.Post("/teams/{teamID}/rollover", h.handleRollOverGoals)
+	r.Post("/import", h.handleImport)
+
+	r.Get("/me", h.handleMe)
+	r.Post("/me/digest", h.handleUpdateDigestSettings)
+	r.Get("/users", h.handleUsers)
+	r.Post("/users", h.handleCreateUser)
+	r.Get("/users/{userID}/roles", h.handleUserRoles)
+	r.Post("/users/{userID}/roles", h.handleAssignRole)
+	r.Post("/roles/{assignmentID}/delete", h.handleRevokeRole)
+
+	r.Get("/tokens", h.handleAPITokens)
+	r.Post("/tokens", h.handleCreateAPIToken)
+	r.Post("/tokens/{tokenID}/delete", h.handleRevokeAPIToken)
+
+	r.Get("/audit", h.handleAudit)
+
+	r.Get("/webhooks", h.handleWebhooks)
+	r.Post("/webhooks", h.handleCreateWebhook)
+	r.Get("/webhooks/{webhookID}", h.handleWebhook)
+	r.Post("/webhooks/{webhookID}", h.handleUpdateWebhook)
+	r.Post("/webhooks/{webhookID}/delete", h.handleDeleteWebhook)
+	r.Get("/webhooks/{webhookID}/deliveries", h.handleWebhookDeliveries)
 
 	r.MethodNotAllowed(func(w http.ResponseWriter, _ *http.Request) {
 		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed", nil)
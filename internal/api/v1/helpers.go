@@ -1,10 +1,12 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
-	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"okrs/internal/domain"
 	"okrs/internal/http/handlers/common"
@@ -12,36 +14,49 @@ import (
 	"okrs/internal/store"
 )
 
-// parseKeyResultMeta parses meta fields for a key result based on kind.
-func parseKeyResultMeta(r *http.Request, kind domain.KRKind) (service.KeyResultMetaInput, error) {
+// parseKeyResultMeta parses meta fields for a key result based on kind from a parsed form.
+func parseKeyResultMeta(form url.Values, kind domain.KRKind) (service.KeyResultMetaInput, error) {
 	switch kind {
 	case domain.KRKindPercent:
-		start := common.ParseFloatField(r.FormValue("percent_start"))
-		target := common.ParseFloatField(r.FormValue("percent_target"))
+		start := common.ParseFloatField(form.Get("percent_start"))
+		target := common.ParseFloatField(form.Get("percent_target"))
 		if start == target {
 			return service.KeyResultMetaInput{}, fmt.Errorf("Start и Target не должны быть равны")
 		}
 		return service.KeyResultMetaInput{
 			PercentStart:   start,
 			PercentTarget:  target,
-			PercentCurrent: common.ParseFloatField(r.FormValue("percent_current")),
+			PercentCurrent: common.ParseFloatField(form.Get("percent_current")),
 		}, nil
 	case domain.KRKindLinear:
-		start := common.ParseFloatField(r.FormValue("linear_start"))
-		target := common.ParseFloatField(r.FormValue("linear_target"))
+		start := common.ParseFloatField(form.Get("linear_start"))
+		target := common.ParseFloatField(form.Get("linear_target"))
 		if start == target {
 			return service.KeyResultMetaInput{}, fmt.Errorf("Start и Target не должны быть равны")
 		}
 		return service.KeyResultMetaInput{
 			LinearStart:   start,
 			LinearTarget:  target,
-			LinearCurrent: common.ParseFloatField(r.FormValue("linear_current")),
+			LinearCurrent: common.ParseFloatField(form.Get("linear_current")),
 		}, nil
 	case domain.KRKindBoolean:
-		done := r.FormValue("boolean_done") == "true"
+		done := form.Get("boolean_done") == "true"
 		return service.KeyResultMetaInput{BooleanDone: done}, nil
+	case domain.KRKindRange:
+		min := common.ParseOptionalFloatField(form.Get("range_min"))
+		max := common.ParseOptionalFloatField(form.Get("range_max"))
+		tolerance := common.ParseFloatField(form.Get("range_tolerance"))
+		if msg := common.ValidateRangeMeta(min, max, tolerance); msg != "" {
+			return service.KeyResultMetaInput{}, errors.New(msg)
+		}
+		return service.KeyResultMetaInput{
+			RangeMin:       min,
+			RangeMax:       max,
+			RangeTolerance: tolerance,
+			RangeCurrent:   common.ParseFloatField(form.Get("range_current")),
+		}, nil
 	case domain.KRKindProject:
-		stages, err := parseProjectStages(r)
+		stages, err := parseProjectStages(form)
 		if err != nil {
 			return service.KeyResultMetaInput{}, err
 		}
@@ -51,12 +66,13 @@ func parseKeyResultMeta(r *http.Request, kind domain.KRKind) (service.KeyResultM
 	}
 }
 
-// parseProjectStages parses project stage fields from a multipart form.
-func parseProjectStages(r *http.Request) ([]store.ProjectStageInput, error) {
+// parseProjectStages parses project stage fields from a parsed form.
+func parseProjectStages(form url.Values) ([]store.ProjectStageInput, error) {
 	stages := make([]store.ProjectStageInput, 0, 4)
-	titles := r.Form["step_title[]"]
-	weights := r.Form["step_weight[]"]
-	dones := r.Form["step_done[]"]
+	titles := form["step_title[]"]
+	weights := form["step_weight[]"]
+	dones := form["step_done[]"]
+	dues := form["step_due[]"]
 	sortOrder := 1
 
 	for i, title := range titles {
@@ -76,11 +92,20 @@ func parseProjectStages(r *http.Request) ([]store.ProjectStageInput, error) {
 		if i < len(dones) {
 			isDone = dones[i] == "true"
 		}
+		var dueDate *time.Time
+		if i < len(dues) {
+			parsed, err := common.ParseOptionalDateField(dues[i])
+			if err != nil {
+				return nil, err
+			}
+			dueDate = parsed
+		}
 		stages = append(stages, store.ProjectStageInput{
 			Title:     trimmed,
 			Weight:    weight,
 			IsDone:    isDone,
 			SortOrder: sortOrder,
+			DueDate:   dueDate,
 		})
 		sortOrder++
 	}
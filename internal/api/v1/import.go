@@ -0,0 +1,441 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+	"okrs/internal/store"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxImportSize bounds the size of an import document.
+const maxImportSize = 4 << 20
+
+type importDocument struct {
+	Teams []importTeam `yaml:"teams"`
+}
+
+type importTeam struct {
+	Name   string       `yaml:"name"`
+	Type   string       `yaml:"type"`
+	Parent string       `yaml:"parent"`
+	Goals  []importGoal `yaml:"goals"`
+
+	location string
+}
+
+type importGoal struct {
+	Title       string            `yaml:"title"`
+	Description string            `yaml:"description"`
+	Priority    string            `yaml:"priority"`
+	Weight      int               `yaml:"weight"`
+	WorkType    string            `yaml:"work_type"`
+	FocusType   string            `yaml:"focus_type"`
+	Owner       string            `yaml:"owner"`
+	KeyResults  []importKeyResult `yaml:"key_results"`
+
+	location string
+}
+
+type importKeyResult struct {
+	Title       string        `yaml:"title"`
+	Description string        `yaml:"description"`
+	Weight      int           `yaml:"weight"`
+	Kind        string        `yaml:"kind"`
+	Start       *float64      `yaml:"start"`
+	Target      *float64      `yaml:"target"`
+	Current     *float64      `yaml:"current"`
+	Done        bool          `yaml:"done"`
+	Min         *float64      `yaml:"min"`
+	Max         *float64      `yaml:"max"`
+	Tolerance   *float64      `yaml:"tolerance"`
+	Stages      []importStage `yaml:"stages"`
+
+	location string
+}
+
+type importStage struct {
+	Title  string `yaml:"title"`
+	Weight int    `yaml:"weight"`
+	Done   bool   `yaml:"done"`
+	Due    string `yaml:"due"`
+}
+
+type importIssue struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+type importResponse struct {
+	DryRun       bool          `json:"dry_run"`
+	Applied      bool          `json:"applied"`
+	Valid        bool          `json:"valid"`
+	TeamsCreated int           `json:"teams_created"`
+	Goals        int           `json:"goals"`
+	KeyResults   int           `json:"key_results"`
+	Issues       []importIssue `json:"issues"`
+}
+
+// handleImport creates teams, goals and key results of a period from a YAML or CSV document. With dry_run=true
+// it only returns the validation report; otherwise the document is applied all or nothing.
+func (h *Handler) handleImport(w http.ResponseWriter, r *http.Request) {
+	periodID, err := common.ParsePeriodID(r)
+	if err != nil || periodID == 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid period id", map[string]string{"period_id": "invalid"})
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	var document importDocument
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "yaml":
+		document, err = decodeImportYAML(body)
+	case "csv":
+		document, err = decodeImportCSV(body)
+	default:
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid format", map[string]string{"format": "invalid"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid document", map[string]string{"document": err.Error()})
+		return
+	}
+	if _, err := h.service.GetPeriod(r.Context(), periodID); err != nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "period not found", map[string]string{"period_id": "not_found"})
+		return
+	}
+	input, issues := validateImportDocument(periodID, document)
+	report, err := h.service.ImportOKRs(r.Context(), input, dryRun || len(issues) > 0)
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to import", nil)
+		return
+	}
+	issues = append(issues, report.Issues...)
+	if !dryRun && len(issues) > 0 {
+		fields := make(map[string]string, len(issues))
+		for _, issue := range issues {
+			if previous, ok := fields[issue.Location]; ok {
+				fields[issue.Location] = previous + "; " + issue.Message
+			} else {
+				fields[issue.Location] = issue.Message
+			}
+		}
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "import document has errors", fields)
+		return
+	}
+	response := importResponse{
+		DryRun:       dryRun,
+		Applied:      report.Applied,
+		Valid:        len(issues) == 0,
+		TeamsCreated: report.TeamsCreated,
+		Goals:        report.Goals,
+		KeyResults:   report.KeyResults,
+		Issues:       make([]importIssue, 0, len(issues)),
+	}
+	for _, issue := range issues {
+		response.Issues = append(response.Issues, importIssue{Location: issue.Location, Message: issue.Message})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func decodeImportYAML(body []byte) (importDocument, error) {
+	var document importDocument
+	decoder := yaml.NewDecoder(bytes.NewReader(body))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&document); err != nil && !errors.Is(err, io.EOF) {
+		return importDocument{}, err
+	}
+	for i := range document.Teams {
+		team := &document.Teams[i]
+		team.location = fmt.Sprintf("teams[%d]", i)
+		for j := range team.Goals {
+			goal := &team.Goals[j]
+			goal.location = fmt.Sprintf("%s.goals[%d]", team.location, j)
+			for k := range goal.KeyResults {
+				goal.KeyResults[k].location = fmt.Sprintf("%s.key_results[%d]", goal.location, k)
+			}
+		}
+	}
+	return document, nil
+}
+
+// importCSVColumns are the columns of a CSV import. Every row describes one key result or one project stage;
+// rows with the same team, goal and kr extend the same entities, so goal and kr fields are read from the first row.
+var importCSVColumns = []string{
+	"team", "team_type", "team_parent",
+	"goal", "goal_description", "priority", "goal_weight", "work_type", "focus_type", "owner",
+	"kr", "kr_description", "kr_weight", "kind", "start", "target", "current", "done", "min", "max", "tolerance",
+	"stage", "stage_weight", "stage_done", "stage_due",
+}
+
+func decodeImportCSV(body []byte) (importDocument, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return importDocument{}, err
+	}
+	if len(records) == 0 {
+		return importDocument{}, nil
+	}
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"team", "goal"} {
+		if _, ok := columns[name]; !ok {
+			return importDocument{}, fmt.Errorf("missing column %q", name)
+		}
+	}
+	for name := range columns {
+		if !containsString(importCSVColumns, name) {
+			return importDocument{}, fmt.Errorf("unknown column %q", name)
+		}
+	}
+
+	var document importDocument
+	teams := make(map[string]int)
+	goals := make(map[[2]string]int)
+	keyResults := make(map[[3]string]int)
+	for line, record := range records[1:] {
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		location := fmt.Sprintf("row %d", line+2)
+		teamName, goalTitle, krTitle := field("team"), field("goal"), field("kr")
+		teamIndex, ok := teams[teamName]
+		if !ok {
+			teamIndex = len(document.Teams)
+			teams[teamName] = teamIndex
+			document.Teams = append(document.Teams, importTeam{Name: teamName, Type: field("team_type"), Parent: field("team_parent"), location: location})
+		}
+		team := &document.Teams[teamIndex]
+		if goalTitle == "" {
+			continue
+		}
+		goalKey := [2]string{teamName, goalTitle}
+		goalIndex, ok := goals[goalKey]
+		if !ok {
+			weight, err := csvInt(field("goal_weight"))
+			if err != nil {
+				return importDocument{}, fmt.Errorf("%s: goal_weight: %w", location, err)
+			}
+			goalIndex = len(team.Goals)
+			goals[goalKey] = goalIndex
+			team.Goals = append(team.Goals, importGoal{
+				Title:       goalTitle,
+				Description: field("goal_description"),
+				Priority:    field("priority"),
+				Weight:      weight,
+				WorkType:    field("work_type"),
+				FocusType:   field("focus_type"),
+				Owner:       field("owner"),
+				location:    location,
+			})
+		}
+		goal := &team.Goals[goalIndex]
+		if krTitle == "" {
+			continue
+		}
+		krKey := [3]string{teamName, goalTitle, krTitle}
+		krIndex, ok := keyResults[krKey]
+		if !ok {
+			kr, err := csvKeyResult(field)
+			if err != nil {
+				return importDocument{}, fmt.Errorf("%s: %w", location, err)
+			}
+			kr.location = location
+			krIndex = len(goal.KeyResults)
+			keyResults[krKey] = krIndex
+			goal.KeyResults = append(goal.KeyResults, kr)
+		}
+		if stageTitle := field("stage"); stageTitle != "" {
+			weight, err := csvInt(field("stage_weight"))
+			if err != nil {
+				return importDocument{}, fmt.Errorf("%s: stage_weight: %w", location, err)
+			}
+			goal.KeyResults[krIndex].Stages = append(goal.KeyResults[krIndex].Stages, importStage{
+				Title:  stageTitle,
+				Weight: weight,
+				Done:   field("stage_done") == "true",
+				Due:    field("stage_due"),
+			})
+		}
+	}
+	return document, nil
+}
+
+func csvKeyResult(field func(string) string) (importKeyResult, error) {
+	weight, err := csvInt(field("kr_weight"))
+	if err != nil {
+		return importKeyResult{}, fmt.Errorf("kr_weight: %w", err)
+	}
+	kr := importKeyResult{
+		Title:       field("kr"),
+		Description: field("kr_description"),
+		Weight:      weight,
+		Kind:        field("kind"),
+		Done:        field("done") == "true",
+	}
+	for name, target := range map[string]**float64{
+		"start": &kr.Start, "target": &kr.Target, "current": &kr.Current,
+		"min": &kr.Min, "max": &kr.Max, "tolerance": &kr.Tolerance,
+	} {
+		value := field(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return importKeyResult{}, fmt.Errorf("%s: invalid number %q", name, value)
+		}
+		*target = &parsed
+	}
+	return kr, nil
+}
+
+func csvInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return parsed, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateImportDocument applies the rules of the goal and key result forms to every entity of the document.
+func validateImportDocument(periodID int64, document importDocument) (service.ImportInput, []service.ImportIssue) {
+	input := service.ImportInput{PeriodID: periodID}
+	var issues []service.ImportIssue
+	report := func(location, message string) {
+		issues = append(issues, service.ImportIssue{Location: location, Message: message})
+	}
+	if len(document.Teams) == 0 {
+		report("teams", "Документ не содержит команд")
+	}
+	for _, team := range document.Teams {
+		name := strings.TrimSpace(team.Name)
+		if name == "" {
+			report(team.location, "Название команды обязательно")
+			continue
+		}
+		teamType := domain.TeamType(strings.TrimSpace(team.Type))
+		if teamType != "" && !common.ValidTeamType(teamType) {
+			report(team.location, "Неверный тип команды")
+		}
+		item := service.ImportTeam{Location: team.location, Name: name, Type: teamType, Parent: strings.TrimSpace(team.Parent)}
+		for _, goal := range team.Goals {
+			goalInput := store.GoalInput{
+				PeriodID:    periodID,
+				Title:       strings.TrimSpace(goal.Title),
+				Description: strings.TrimSpace(goal.Description),
+				Priority:    domain.Priority(goal.Priority),
+				Weight:      goal.Weight,
+				WorkType:    domain.WorkType(goal.WorkType),
+				FocusType:   domain.FocusType(goal.FocusType),
+				OwnerText:   strings.TrimSpace(goal.Owner),
+			}
+			if goalInput.Title == "" {
+				report(goal.location, "Название цели обязательно")
+			}
+			if message := common.ValidateGoalInput(goalInput.Priority, goalInput.WorkType, goalInput.FocusType, goalInput.Weight); message != "" {
+				report(goal.location, message)
+			}
+			importGoal := service.ImportGoal{Goal: goalInput}
+			for _, kr := range goal.KeyResults {
+				krInput := store.KeyResultInput{
+					Title:       strings.TrimSpace(kr.Title),
+					Description: strings.TrimSpace(kr.Description),
+					Weight:      kr.Weight,
+					Kind:        domain.KRKind(kr.Kind),
+				}
+				if krInput.Title == "" {
+					report(kr.location, "Название KR обязательно")
+				}
+				if krInput.Weight < 0 || krInput.Weight > 100 {
+					report(kr.location, "Вес KR должен быть 0..100")
+				}
+				if !common.ValidKRKind(krInput.Kind) {
+					report(kr.location, "Неверный тип KR")
+					continue
+				}
+				meta, err := parseKeyResultMeta(kr.form(), krInput.Kind)
+				if err != nil {
+					report(kr.location, err.Error())
+					continue
+				}
+				importGoal.KeyResults = append(importGoal.KeyResults, service.ImportKeyResult{KeyResult: krInput, Meta: meta})
+			}
+			item.Goals = append(item.Goals, importGoal)
+		}
+		input.Teams = append(input.Teams, item)
+	}
+	return input, issues
+}
+
+// form renders the key result as the fields of the key result form, so parseKeyResultMeta validates it.
+func (kr importKeyResult) form() url.Values {
+	form := url.Values{}
+	set := func(name string, value *float64) {
+		if value != nil {
+			form.Set(name, strconv.FormatFloat(*value, 'f', -1, 64))
+		}
+	}
+	switch domain.KRKind(kr.Kind) {
+	case domain.KRKindPercent:
+		set("percent_start", kr.Start)
+		set("percent_target", kr.Target)
+		set("percent_current", kr.Current)
+	case domain.KRKindLinear:
+		set("linear_start", kr.Start)
+		set("linear_target", kr.Target)
+		set("linear_current", kr.Current)
+	case domain.KRKindBoolean:
+		form.Set("boolean_done", strconv.FormatBool(kr.Done))
+	case domain.KRKindRange:
+		set("range_min", kr.Min)
+		set("range_max", kr.Max)
+		set("range_tolerance", kr.Tolerance)
+		set("range_current", kr.Current)
+	case domain.KRKindProject:
+		for _, stage := range kr.Stages {
+			form.Add("step_title[]", stage.Title)
+			form.Add("step_weight[]", strconv.Itoa(stage.Weight))
+			form.Add("step_done[]", strconv.FormatBool(stage.Done))
+			form.Add("step_due[]", stage.Due)
+		}
+	}
+	return form
+}
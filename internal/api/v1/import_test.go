@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"strings"
+	"testing"
+
+	"okrs/internal/domain"
+)
+
+func TestDecodeImportYAML(t *testing.T) {
+	document, err := decodeImportYAML([]byte(`
+teams:
+  - name: Core
+    goals:
+      - title: Grow
+        priority: P1
+        weight: 60
+        work_type: Delivery
+        focus_type: PROFITABILITY
+        key_results:
+          - title: Revenue
+            weight: 100
+            kind: LINEAR
+            start: 10
+            target: 20
+          - title: Launch
+            kind: PROJECT
+            stages:
+              - {title: Beta, weight: 40, done: true}
+              - {title: GA, weight: 60, due: 2024-03-31}
+  - name: Mobile
+    type: team
+    parent: Core
+`))
+	if err != nil {
+		t.Fatalf("decode yaml: %v", err)
+	}
+	input, issues := validateImportDocument(1, document)
+	if len(issues) != 0 {
+		t.Fatalf("unexpected issues %+v", issues)
+	}
+	if len(input.Teams) != 2 || input.Teams[1].Type != domain.TeamTypeTeam || input.Teams[1].Parent != "Core" {
+		t.Fatalf("unexpected teams %+v", input.Teams)
+	}
+	krs := input.Teams[0].Goals[0].KeyResults
+	if len(krs) != 2 || krs[0].Meta.LinearStart != 10 || krs[0].Meta.LinearTarget != 20 {
+		t.Fatalf("unexpected key results %+v", krs)
+	}
+	if stages := krs[1].Meta.ProjectStages; len(stages) != 2 || !stages[0].IsDone || stages[1].DueDate == nil {
+		t.Fatalf("unexpected stages %+v", stages)
+	}
+
+	if _, err := decodeImportYAML([]byte("teams:\n  - name: Core\n    lead: someone\n")); err == nil {
+		t.Fatalf("expected an unknown field to be rejected")
+	}
+}
+
+func TestDecodeImportCSV(t *testing.T) {
+	document, err := decodeImportCSV([]byte("\ufeff" + `team,team_type,team_parent,goal,priority,goal_weight,work_type,focus_type,kr,kr_weight,kind,min,max,stage,stage_weight
+Core,,,Grow,P1,60,Delivery,STABILITY,Latency,50,RANGE,,200,,
+Core,,,Grow,,,,,Launch,50,PROJECT,,,Beta,40
+Core,,,Grow,,,,,Launch,50,PROJECT,,,GA,60
+Mobile,team,Core,Ship,P2,40,Discovery,SPEED_EFFICIENCY,,,,,,,
+`))
+	if err != nil {
+		t.Fatalf("decode csv: %v", err)
+	}
+	input, issues := validateImportDocument(1, document)
+	if len(issues) != 0 {
+		t.Fatalf("unexpected issues %+v", issues)
+	}
+	if len(input.Teams) != 2 || len(input.Teams[0].Goals) != 1 || input.Teams[1].Location != "row 5" {
+		t.Fatalf("unexpected teams %+v", input.Teams)
+	}
+	krs := input.Teams[0].Goals[0].KeyResults
+	if len(krs) != 2 || krs[0].Meta.RangeMin != nil || *krs[0].Meta.RangeMax != 200 || len(krs[1].Meta.ProjectStages) != 2 {
+		t.Fatalf("unexpected key results %+v", krs)
+	}
+	if goals := input.Teams[1].Goals; len(goals) != 1 || len(goals[0].KeyResults) != 0 {
+		t.Fatalf("expected a goal without key results, got %+v", goals)
+	}
+
+	if _, err := decodeImportCSV([]byte("team,goal,owner_email\n")); err == nil || !strings.Contains(err.Error(), "owner_email") {
+		t.Fatalf("expected an unknown column to be rejected, got %v", err)
+	}
+	if _, err := decodeImportCSV([]byte("team,goal,goal_weight\nCore,Grow,heavy\n")); err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Fatalf("expected an invalid number to point at its row, got %v", err)
+	}
+}
+
+func TestValidateImportDocument(t *testing.T) {
+	document, err := decodeImportYAML([]byte(`
+teams:
+  - name: ""
+  - name: Core
+    type: squad
+    goals:
+      - title: ""
+        priority: P9
+        work_type: Delivery
+        focus_type: STABILITY
+        key_results:
+          - {title: Revenue, kind: PERCENT, start: 5, target: 5}
+          - {title: Unknown, kind: SCORE}
+`))
+	if err != nil {
+		t.Fatalf("decode yaml: %v", err)
+	}
+	_, issues := validateImportDocument(1, document)
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Location+": "+issue.Message)
+	}
+	expected := []string{
+		"teams[0]: Название команды обязательно",
+		"teams[1]: Неверный тип команды",
+		"teams[1].goals[0]: Название цели обязательно",
+		"teams[1].goals[0]: Неверный приоритет",
+		"teams[1].goals[0].key_results[0]: Start и Target не должны быть равны",
+		"teams[1].goals[0].key_results[1]: Неверный тип KR",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected issues\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// IngestSignatureHeader carries the "sha256=<hex>" HMAC of the raw ingest body.
+const IngestSignatureHeader = "X-OKR-Signature"
+
+// maxIngestBody limits the size of a pushed payload.
+const maxIngestBody = 64 << 10
+
+type ingestRequest struct {
+	KRID      int64                `json:"kr_id"`
+	Timestamp time.Time            `json:"timestamp"`
+	Value     *float64             `json:"value"`
+	Done      *bool                `json:"done"`
+	Stages    []updateProjectStage `json:"stages"`
+}
+
+// IngestRoutes serves pushed KR values. Requests are authenticated by their HMAC signature, not by a user,
+// so the router is mounted outside Routes.
+func (h *Handler) IngestRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/krs/{krID}", h.handleIngest)
+	return r
+}
+
+// handleIngest applies a signed value pushed by CI or analytics jobs; retries with the same Idempotency-Key are no-ops.
+func (h *Handler) handleIngest(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxIngestBody+1))
+	if err != nil || len(body) > maxIngestBody {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	if err := h.service.VerifyIngestSignature(body, r.Header.Get(IngestSignatureHeader)); err != nil {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid signature", nil)
+		return
+	}
+	var req ingestRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	stages := make([]service.ProjectStageUpdate, 0, len(req.Stages))
+	for _, stage := range req.Stages {
+		stages = append(stages, service.ProjectStageUpdate{ID: stage.ID, IsDone: stage.Done})
+	}
+	applied, err := h.service.IngestKRValue(r.Context(), krID, service.IngestInput{
+		KeyResultID:    req.KRID,
+		IdempotencyKey: strings.TrimSpace(r.Header.Get("Idempotency-Key")),
+		Payload:        body,
+		Timestamp:      req.Timestamp,
+		Value:          req.Value,
+		Done:           req.Done,
+		Stages:         stages,
+	})
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidIngest):
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+		case errors.Is(err, service.ErrIdempotencyConflict):
+			writeError(w, http.StatusConflict, "CONFLICT", err.Error(), map[string]string{"idempotency_key": "reused"})
+		case errors.Is(err, pgx.ErrNoRows):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "kr not found", nil)
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to ingest value", nil)
+		}
+		return
+	}
+	status := "applied"
+	if !applied {
+		status = "duplicate"
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"kr_id": krID, "status": status})
+}
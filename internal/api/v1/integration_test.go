@@ -6,16 +6,22 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"okrs/internal/auth"
+	"okrs/internal/digest"
 	"okrs/internal/domain"
 	"okrs/internal/service"
 	"okrs/internal/store"
+	"okrs/internal/webhook"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-migrate/migrate/v4"
@@ -102,6 +108,7 @@ func TestUpdateKRProgressIntegration(t *testing.T) {
 	}
 
 	svc := service.New(repo)
+	token := issueTestToken(t, ctx, repo, svc)
 	handler := NewHandler(svc)
 	router := chi.NewRouter()
 	router.Mount("/api/v1", handler.Routes())
@@ -110,7 +117,7 @@ func TestUpdateKRProgressIntegration(t *testing.T) {
 	defer server.Close()
 
 	payload, _ := json.Marshal(map[string]float64{"current_value": 50})
-	resp, err := http.Post(fmt.Sprintf("%s/api/v1/krs/%d/progress/percent", server.URL, krID), "application/json", bytes.NewBuffer(payload))
+	resp, err := requestWithToken(http.MethodPost, fmt.Sprintf("%s/api/v1/krs/%d/progress/percent", server.URL, krID), token, bytes.NewBuffer(payload))
 	if err != nil {
 		t.Fatalf("post progress: %v", err)
 	}
@@ -119,7 +126,7 @@ func TestUpdateKRProgressIntegration(t *testing.T) {
 		t.Fatalf("expected 200, got %d", resp.StatusCode)
 	}
 
-	getResp, err := http.Get(fmt.Sprintf("%s/api/v1/teams/%d/okrs?period_id=%d", server.URL, teamID, periodID))
+	getResp, err := requestWithToken(http.MethodGet, fmt.Sprintf("%s/api/v1/teams/%d/okrs?period_id=%d", server.URL, teamID, periodID), token, nil)
 	if err != nil {
 		t.Fatalf("get okrs: %v", err)
 	}
@@ -141,6 +148,15 @@ func TestUpdateKRProgressIntegration(t *testing.T) {
 	if okrResponse.Goals[0].Progress != 50 {
 		t.Fatalf("expected goal progress 50, got %d", okrResponse.Goals[0].Progress)
 	}
+
+	tokenResp, err := requestWithToken(http.MethodPost, server.URL+"/api/v1/tokens", token, strings.NewReader(`{"name":"child"}`))
+	if err != nil {
+		t.Fatalf("post token: %v", err)
+	}
+	defer tokenResp.Body.Close()
+	if tokenResp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected a token to be unable to create tokens, got %d", tokenResp.StatusCode)
+	}
 }
 
 func TestAddKRCommentPreservesMultilineIntegration(t *testing.T) {
@@ -214,6 +230,7 @@ func TestAddKRCommentPreservesMultilineIntegration(t *testing.T) {
 	}
 
 	svc := service.New(repo)
+	token := issueTestToken(t, ctx, repo, svc)
 	handler := NewHandler(svc)
 	router := chi.NewRouter()
 	router.Mount("/api/v1", handler.Routes())
@@ -223,7 +240,7 @@ func TestAddKRCommentPreservesMultilineIntegration(t *testing.T) {
 
 	commentText := "Первая строка\r\nВторая строка\r\nТретья строка"
 	payload, _ := json.Marshal(map[string]string{"text": commentText})
-	resp, err := http.Post(fmt.Sprintf("%s/api/v1/krs/%d/comments", server.URL, krID), "application/json", bytes.NewBuffer(payload))
+	resp, err := requestWithToken(http.MethodPost, fmt.Sprintf("%s/api/v1/krs/%d/comments", server.URL, krID), token, bytes.NewBuffer(payload))
 	if err != nil {
 		t.Fatalf("post comment: %v", err)
 	}
@@ -245,6 +262,712 @@ func TestAddKRCommentPreservesMultilineIntegration(t *testing.T) {
 	}
 }
 
+func TestIngestKRValueIntegration(t *testing.T) {
+	ctx := context.Background()
+	container, err := tcpostgres.RunContainer(ctx,
+		tcpostgres.WithDatabase("okrs"),
+		tcpostgres.WithUsername("postgres"),
+		tcpostgres.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(10*time.Second),
+		),
+	)
+	if err != nil {
+		t.Skipf("docker unavailable: %v", err)
+	}
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dbURL, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("conn string: %v", err)
+	}
+	if err := runMigrations(dbURL); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	repo := store.New(pool)
+	var teamID int64
+	if err := pool.QueryRow(ctx, `INSERT INTO teams (name) VALUES ('API') RETURNING id`).Scan(&teamID); err != nil {
+		t.Fatalf("insert team: %v", err)
+	}
+	var periodID int64
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO periods (name, start_date, end_date, sort_order)
+		VALUES ('2024 Q3', '2024-07-01', '2024-09-30', 1)
+		RETURNING id`).Scan(&periodID); err != nil {
+		t.Fatalf("insert period: %v", err)
+	}
+
+	goalID, err := repo.CreateGoal(ctx, store.GoalInput{
+		TeamID:      teamID,
+		PeriodID:    periodID,
+		Title:       "API Goal",
+		Description: "desc",
+		Priority:    domain.PriorityP1,
+		Weight:      100,
+		WorkType:    domain.WorkTypeDelivery,
+		FocusType:   domain.FocusStability,
+		OwnerText:   "Owner",
+	})
+	if err != nil {
+		t.Fatalf("create goal: %v", err)
+	}
+
+	krID, err := repo.CreateKeyResult(ctx, store.KeyResultInput{
+		GoalID:      goalID,
+		Title:       "KR",
+		Description: "",
+		Weight:      100,
+		Kind:        domain.KRKindPercent,
+	})
+	if err != nil {
+		t.Fatalf("create kr: %v", err)
+	}
+	if err := repo.UpsertPercentMeta(ctx, store.PercentMetaInput{KeyResultID: krID, StartValue: 0, TargetValue: 100, CurrentValue: 0}); err != nil {
+		t.Fatalf("meta: %v", err)
+	}
+
+	svc := service.New(repo).WithIngestSecret("s3cret")
+	handler := NewHandler(svc)
+	router := chi.NewRouter()
+	router.Mount("/api/v1/ingest", handler.IngestRoutes())
+	router.Mount("/api/v1", handler.Routes())
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	payload, _ := json.Marshal(map[string]any{"kr_id": krID, "value": 40, "timestamp": time.Now().UTC().Format(time.RFC3339)})
+	ingest := func(signature string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/v1/ingest/krs/%d", server.URL, krID), bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		req.Header.Set(IngestSignatureHeader, signature)
+		req.Header.Set("Idempotency-Key", "nightly-2024-07-01")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post ingest: %v", err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Status string `json:"status"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.Status
+	}
+
+	if code, _ := ingest(auth.Sign([]byte("wrong"), payload)); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a foreign signature, got %d", code)
+	}
+	if code, status := ingest(auth.Sign([]byte("s3cret"), payload)); code != http.StatusOK || status != "applied" {
+		t.Fatalf("expected value to be applied, got %d %q", code, status)
+	}
+	if code, status := ingest(auth.Sign([]byte("s3cret"), payload)); code != http.StatusOK || status != "duplicate" {
+		t.Fatalf("expected retry to be a duplicate, got %d %q", code, status)
+	}
+	history, err := repo.ListKRProgressEvents(ctx, krID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if len(history) != 1 || history[0].Value != 40 || history[0].Source != service.IngestProgressSource {
+		t.Fatalf("expected one ingested history point, got %+v", history)
+	}
+}
+
+func TestWebhookDeliveryIntegration(t *testing.T) {
+	ctx := context.Background()
+	container, err := tcpostgres.RunContainer(ctx,
+		tcpostgres.WithDatabase("okrs"),
+		tcpostgres.WithUsername("postgres"),
+		tcpostgres.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(10*time.Second),
+		),
+	)
+	if err != nil {
+		t.Skipf("docker unavailable: %v", err)
+	}
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dbURL, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("conn string: %v", err)
+	}
+	if err := runMigrations(dbURL); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	repo := store.New(pool)
+	var teamID int64
+	if err := pool.QueryRow(ctx, `INSERT INTO teams (name) VALUES ('API') RETURNING id`).Scan(&teamID); err != nil {
+		t.Fatalf("insert team: %v", err)
+	}
+	var periodID int64
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO periods (name, start_date, end_date, sort_order)
+		VALUES ('2024 Q3', '2024-07-01', '2024-09-30', 1)
+		RETURNING id`).Scan(&periodID); err != nil {
+		t.Fatalf("insert period: %v", err)
+	}
+
+	goalID, err := repo.CreateGoal(ctx, store.GoalInput{
+		TeamID:      teamID,
+		PeriodID:    periodID,
+		Title:       "API Goal",
+		Description: "desc",
+		Priority:    domain.PriorityP1,
+		Weight:      100,
+		WorkType:    domain.WorkTypeDelivery,
+		FocusType:   domain.FocusStability,
+		OwnerText:   "Owner",
+	})
+	if err != nil {
+		t.Fatalf("create goal: %v", err)
+	}
+
+	krID, err := repo.CreateKeyResult(ctx, store.KeyResultInput{
+		GoalID:      goalID,
+		Title:       "KR",
+		Description: "",
+		Weight:      100,
+		Kind:        domain.KRKindPercent,
+	})
+	if err != nil {
+		t.Fatalf("create kr: %v", err)
+	}
+	if err := repo.UpsertPercentMeta(ctx, store.PercentMetaInput{KeyResultID: krID, StartValue: 0, TargetValue: 100, CurrentValue: 0}); err != nil {
+		t.Fatalf("meta: %v", err)
+	}
+
+	received := make(chan http.Header, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !auth.CheckSignature([]byte("hook-secret"), body, r.Header.Get(webhook.SignatureHeader)) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		received <- r.Header.Clone()
+	}))
+	defer receiver.Close()
+
+	handler := NewHandler(service.New(repo))
+	router := chi.NewRouter()
+	router.Mount("/api/v1", handler.Routes())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(path string, body any) *http.Response {
+		payload, _ := json.Marshal(body)
+		resp, err := http.Post(server.URL+path, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		return resp
+	}
+	resp := post("/api/v1/webhooks", map[string]any{
+		"url":     receiver.URL,
+		"secret":  "hook-secret",
+		"events":  []string{"kr.progress_changed"},
+		"team_id": teamID,
+	})
+	var created struct {
+		Item struct {
+			ID int64 `json:"id"`
+		} `json:"item"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || created.Item.ID == 0 {
+		t.Fatalf("expected webhook to be created, got %d", resp.StatusCode)
+	}
+
+	resp = post(fmt.Sprintf("/api/v1/krs/%d/progress/percent", krID), map[string]any{"current_value": 30})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected progress update, got %d", resp.StatusCode)
+	}
+
+	dispatcher := webhook.NewDispatcher(repo, receiver.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if delivered := dispatcher.DeliverDue(ctx); delivered != 1 {
+		t.Fatalf("expected one delivery, got %d", delivered)
+	}
+	if headers := <-received; headers.Get(webhook.EventHeader) != "kr.progress_changed" {
+		t.Fatalf("unexpected event header %q", headers.Get(webhook.EventHeader))
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/api/v1/webhooks/%d/deliveries", server.URL, created.Item.ID))
+	if err != nil {
+		t.Fatalf("get deliveries: %v", err)
+	}
+	defer resp.Body.Close()
+	var log struct {
+		Items []struct {
+			Event    string `json:"event"`
+			Status   string `json:"status"`
+			Attempts int    `json:"attempts"`
+			Payload  struct {
+				Data struct {
+					KeyResultID int64 `json:"kr_id"`
+					Progress    int   `json:"progress"`
+				} `json:"data"`
+			} `json:"payload"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&log); err != nil {
+		t.Fatalf("decode deliveries: %v", err)
+	}
+	if len(log.Items) != 1 || log.Items[0].Status != "delivered" || log.Items[0].Attempts != 1 ||
+		log.Items[0].Payload.Data.KeyResultID != krID || log.Items[0].Payload.Data.Progress != 30 {
+		t.Fatalf("unexpected delivery log %+v", log.Items)
+	}
+
+	messages := make(chan service.ChatMessage, 1)
+	chat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message service.ChatMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		messages <- message
+	}))
+	defer chat.Close()
+	resp = post("/api/v1/webhooks", map[string]any{"url": chat.URL, "format": "chat", "team_id": teamID})
+	var chatHook struct {
+		Item struct {
+			Format string   `json:"format"`
+			Events []string `json:"events"`
+		} `json:"item"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&chatHook)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || chatHook.Item.Format != "chat" || len(chatHook.Item.Events) != len(service.ChatWebhookEvents) {
+		t.Fatalf("expected chat webhook with default events, got %d %+v", resp.StatusCode, chatHook.Item)
+	}
+
+	resp = post(fmt.Sprintf("/api/v1/krs/%d/progress/percent", krID), map[string]any{"current_value": 100})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected progress update, got %d", resp.StatusCode)
+	}
+	if delivered := dispatcher.DeliverDue(ctx); delivered != 2 {
+		t.Fatalf("expected progress and completion deliveries, got %d", delivered)
+	}
+	<-received
+	if message := <-messages; message.Text != "API: KR «KR» выполнен" {
+		t.Fatalf("unexpected chat message %q", message.Text)
+	}
+}
+
+func TestDigestIntegration(t *testing.T) {
+	ctx := context.Background()
+	container, err := tcpostgres.RunContainer(ctx,
+		tcpostgres.WithDatabase("okrs"),
+		tcpostgres.WithUsername("postgres"),
+		tcpostgres.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(10*time.Second),
+		),
+	)
+	if err != nil {
+		t.Skipf("docker unavailable: %v", err)
+	}
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dbURL, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("conn string: %v", err)
+	}
+	if err := runMigrations(dbURL); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	repo := store.New(pool)
+	var teamID int64
+	if err := pool.QueryRow(ctx, `INSERT INTO teams (name) VALUES ('API') RETURNING id`).Scan(&teamID); err != nil {
+		t.Fatalf("insert team: %v", err)
+	}
+	now := time.Now()
+	periodID, err := repo.CreatePeriod(ctx, store.PeriodInput{Name: "Current", StartDate: now.AddDate(0, 0, -30), EndDate: now.AddDate(0, 0, 60)})
+	if err != nil {
+		t.Fatalf("create period: %v", err)
+	}
+	goalID, err := repo.CreateGoal(ctx, store.GoalInput{
+		TeamID:    teamID,
+		PeriodID:  periodID,
+		Title:     "API Goal",
+		Priority:  domain.PriorityP1,
+		Weight:    100,
+		WorkType:  domain.WorkTypeDelivery,
+		FocusType: domain.FocusStability,
+	})
+	if err != nil {
+		t.Fatalf("create goal: %v", err)
+	}
+	krID, err := repo.CreateKeyResult(ctx, store.KeyResultInput{GoalID: goalID, Title: "Stale KR", Weight: 100, Kind: domain.KRKindBoolean})
+	if err != nil {
+		t.Fatalf("create kr: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE key_results SET updated_at = NOW() - INTERVAL '10 days' WHERE id=$1`, krID); err != nil {
+		t.Fatalf("age kr: %v", err)
+	}
+	ownerID, err := repo.CreateUser(ctx, store.UserInput{Email: "owner@example.com", Name: "Owner"})
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	leadID, err := repo.CreateUser(ctx, store.UserInput{Email: "lead@example.com", Name: "Lead"})
+	if err != nil {
+		t.Fatalf("create lead: %v", err)
+	}
+
+	svc := service.New(repo)
+	handler := NewHandler(svc)
+	router := chi.NewRouter()
+	router.Mount("/api/v1", handler.Routes())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(path string, body any) int {
+		payload, _ := json.Marshal(body)
+		resp, err := http.Post(server.URL+path, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post(fmt.Sprintf("/api/v1/goals/%d/owner", goalID), map[string]any{"user_id": 999}); status != http.StatusBadRequest {
+		t.Fatalf("expected unknown owner to be rejected, got %d", status)
+	}
+	if status := post(fmt.Sprintf("/api/v1/goals/%d/owner", goalID), map[string]any{"user_id": ownerID}); status != http.StatusOK {
+		t.Fatalf("expected owner to be set, got %d", status)
+	}
+	if status := post(fmt.Sprintf("/api/v1/teams/%d/lead", teamID), map[string]any{"user_id": leadID}); status != http.StatusOK {
+		t.Fatalf("expected lead to be set, got %d", status)
+	}
+	if err := svc.SetDigestOptOut(ctx, leadID, true); err != nil {
+		t.Fatalf("opt out: %v", err)
+	}
+
+	sender := &recordingSender{}
+	job := digest.NewJob(svc, sender, slog.New(slog.NewTextHandler(io.Discard, nil)), 7*24*time.Hour, 7*24*time.Hour)
+	if sent := job.SendDue(ctx); sent != 1 {
+		t.Fatalf("expected one digest, got %d", sent)
+	}
+	if len(sender.to) != 1 || sender.to[0] != "owner@example.com" || !strings.Contains(sender.bodies[0], "Stale KR") {
+		t.Fatalf("unexpected digests to %v: %v", sender.to, sender.bodies)
+	}
+	if sent := job.SendDue(ctx); sent != 0 {
+		t.Fatalf("expected the digest to be sent once per interval, got %d", sent)
+	}
+}
+
+func TestImportIntegration(t *testing.T) {
+	ctx := context.Background()
+	container, err := tcpostgres.RunContainer(ctx,
+		tcpostgres.WithDatabase("okrs"),
+		tcpostgres.WithUsername("postgres"),
+		tcpostgres.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(10*time.Second),
+		),
+	)
+	if err != nil {
+		t.Skipf("docker unavailable: %v", err)
+	}
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dbURL, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("conn string: %v", err)
+	}
+	if err := runMigrations(dbURL); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	repo := store.New(pool)
+	var teamID int64
+	if err := pool.QueryRow(ctx, `INSERT INTO teams (name) VALUES ('Core') RETURNING id`).Scan(&teamID); err != nil {
+		t.Fatalf("insert team: %v", err)
+	}
+	now := time.Now()
+	periodID, err := repo.CreatePeriod(ctx, store.PeriodInput{Name: "Current", StartDate: now.AddDate(0, 0, -30), EndDate: now.AddDate(0, 0, 60)})
+	if err != nil {
+		t.Fatalf("create period: %v", err)
+	}
+
+	svc := service.New(repo)
+	handler := NewHandler(svc)
+	router := chi.NewRouter()
+	router.Mount("/api/v1", handler.Routes())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	importDocument := func(query, document string) (int, map[string]any) {
+		resp, err := http.Post(fmt.Sprintf("%s/api/v1/import?period_id=%d&%s", server.URL, periodID, query), "text/plain", strings.NewReader(document))
+		if err != nil {
+			t.Fatalf("import: %v", err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+	document := `
+teams:
+  - name: Core
+    goals:
+      - title: Grow
+        priority: P1
+        weight: 60
+        work_type: Delivery
+        focus_type: PROFITABILITY
+        key_results:
+          - {title: Revenue, weight: 100, kind: PERCENT, start: 0, target: 100, current: 20}
+  - name: Mobile
+    type: team
+    parent: Core
+    goals:
+      - title: Ship
+        priority: P2
+        weight: 40
+        work_type: Discovery
+        focus_type: SPEED_EFFICIENCY
+        key_results:
+          - title: Release
+            weight: 100
+            kind: PROJECT
+            stages:
+              - {title: Beta, weight: 40, done: true}
+              - {title: GA, weight: 60}
+`
+	status, body := importDocument("dry_run=true", document)
+	if status != http.StatusOK || body["valid"] != true || body["applied"] != false || body["teams_created"] != float64(1) || body["key_results"] != float64(2) {
+		t.Fatalf("unexpected dry run %d %+v", status, body)
+	}
+	status, body = importDocument("", strings.Replace(document, "kind: PROJECT", "kind: SCORE", 1))
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected an invalid document to be rejected, got %d %+v", status, body)
+	}
+	if goals, err := repo.ListGoalsByPeriod(ctx, periodID); err != nil || len(goals) != 0 {
+		t.Fatalf("expected nothing to be written, got %+v %v", goals, err)
+	}
+
+	status, body = importDocument("", document)
+	if status != http.StatusOK || body["applied"] != true {
+		t.Fatalf("unexpected import %d %+v", status, body)
+	}
+	goals, err := repo.ListGoalsByPeriod(ctx, periodID)
+	if err != nil || len(goals) != 2 {
+		t.Fatalf("expected two goals, got %+v %v", goals, err)
+	}
+	teams, err := repo.ListTeams(ctx)
+	if err != nil || len(teams) != 2 || teams[1].ParentID == nil || *teams[1].ParentID != teamID {
+		t.Fatalf("expected Mobile to be created under Core, got %+v %v", teams, err)
+	}
+	if status, err := repo.GetTeamPeriodStatus(ctx, teamID, periodID); err != nil || status != domain.TeamPeriodStatusForming {
+		t.Fatalf("expected Core to start forming, got %s %v", status, err)
+	}
+	krs, err := repo.ListKeyResultsByGoal(ctx, goals[0].Goal.ID)
+	if err != nil || len(krs) != 1 || krs[0].Percent == nil || krs[0].Percent.CurrentValue != 20 {
+		t.Fatalf("unexpected imported key results %+v %v", krs, err)
+	}
+}
+
+func TestCopyGoalIntegration(t *testing.T) {
+	ctx := context.Background()
+	container, err := tcpostgres.RunContainer(ctx,
+		tcpostgres.WithDatabase("okrs"),
+		tcpostgres.WithUsername("postgres"),
+		tcpostgres.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(10*time.Second),
+		),
+	)
+	if err != nil {
+		t.Skipf("docker unavailable: %v", err)
+	}
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dbURL, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("conn string: %v", err)
+	}
+	if err := runMigrations(dbURL); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	repo := store.New(pool)
+	var teamID, sharedTeamID int64
+	if err := pool.QueryRow(ctx, `INSERT INTO teams (name) VALUES ('Core') RETURNING id`).Scan(&teamID); err != nil {
+		t.Fatalf("insert team: %v", err)
+	}
+	if err := pool.QueryRow(ctx, `INSERT INTO teams (name) VALUES ('Sales') RETURNING id`).Scan(&sharedTeamID); err != nil {
+		t.Fatalf("insert team: %v", err)
+	}
+	now := time.Now()
+	fromID, err := repo.CreatePeriod(ctx, store.PeriodInput{Name: "Q1", StartDate: now.AddDate(0, -3, 0), EndDate: now})
+	if err != nil {
+		t.Fatalf("create period: %v", err)
+	}
+	toID, err := repo.CreatePeriod(ctx, store.PeriodInput{Name: "Q2", StartDate: now.AddDate(0, 0, 1), EndDate: now.AddDate(0, 3, 0)})
+	if err != nil {
+		t.Fatalf("create period: %v", err)
+	}
+	goalID, err := repo.CreateGoal(ctx, store.GoalInput{
+		TeamID:    teamID,
+		PeriodID:  fromID,
+		Title:     "Grow",
+		Priority:  domain.PriorityP1,
+		Weight:    60,
+		WorkType:  domain.WorkTypeDelivery,
+		FocusType: domain.FocusProfitability,
+	})
+	if err != nil {
+		t.Fatalf("create goal: %v", err)
+	}
+	krID, err := repo.CreateKeyResult(ctx, store.KeyResultInput{GoalID: goalID, Title: "Revenue", Weight: 100, Kind: domain.KRKindPercent})
+	if err != nil {
+		t.Fatalf("create kr: %v", err)
+	}
+	if err := repo.UpsertPercentMeta(ctx, store.PercentMetaInput{KeyResultID: krID, StartValue: 0, TargetValue: 100, CurrentValue: 40}); err != nil {
+		t.Fatalf("percent meta: %v", err)
+	}
+	if err := repo.ReplaceGoalShares(ctx, goalID, []store.GoalShareInput{{TeamID: sharedTeamID, Weight: 20}}); err != nil {
+		t.Fatalf("share goal: %v", err)
+	}
+
+	svc := service.New(repo)
+	handler := NewHandler(svc)
+	router := chi.NewRouter()
+	router.Mount("/api/v1", handler.Routes())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(path string, result any) int {
+		resp, err := http.Post(server.URL+path, "application/json", nil)
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		_ = json.NewDecoder(resp.Body).Decode(result)
+		return resp.StatusCode
+	}
+	var rollover rolloverResponse
+	if status := post(fmt.Sprintf("/api/v1/teams/%d/rollover?period_id=%d&target_period_id=%d", teamID, fromID, toID), &rollover); status != http.StatusOK || len(rollover.GoalIDs) != 1 {
+		t.Fatalf("unexpected rollover %d %+v", status, rollover)
+	}
+	copied, err := repo.GetGoal(ctx, rollover.GoalIDs[0])
+	if err != nil {
+		t.Fatalf("get copy: %v", err)
+	}
+	if copied.PeriodID != toID || copied.OriginGoalID == nil || *copied.OriginGoalID != goalID || len(copied.KeyResults) != 1 {
+		t.Fatalf("unexpected copy %+v", copied)
+	}
+	if meta := copied.KeyResults[0].Percent; meta == nil || meta.TargetValue != 100 || meta.CurrentValue != 0 {
+		t.Fatalf("expected the copied KR to start over, got %+v", meta)
+	}
+	if shares, err := repo.ListGoalShares(ctx, copied.ID); err != nil || len(shares) != 1 || shares[0].TeamID != sharedTeamID || shares[0].Weight != 20 {
+		t.Fatalf("expected the share to be copied, got %+v %v", shares, err)
+	}
+	if status, err := repo.GetTeamPeriodStatus(ctx, teamID, toID); err != nil || status != domain.TeamPeriodStatusForming {
+		t.Fatalf("expected the target period to start forming, got %s %v", status, err)
+	}
+
+	rollover = rolloverResponse{}
+	if status := post(fmt.Sprintf("/api/v1/teams/%d/rollover?period_id=%d&target_period_id=%d", teamID, fromID, toID), &rollover); status != http.StatusOK || len(rollover.GoalIDs) != 0 || rollover.Skipped != 1 {
+		t.Fatalf("expected a repeated rollover to skip the goal, got %d %+v", status, rollover)
+	}
+
+	var created map[string]int64
+	if status := post(fmt.Sprintf("/api/v1/goals/%d/copy?period_id=%d&keep_current=true", goalID, toID), &created); status != http.StatusCreated {
+		t.Fatalf("unexpected copy status %d", status)
+	}
+	krs, err := repo.ListKeyResultsByGoal(ctx, created["id"])
+	if err != nil || len(krs) != 1 || krs[0].Percent == nil || krs[0].Percent.CurrentValue != 40 {
+		t.Fatalf("expected the copy to keep the current value, got %+v %v", krs, err)
+	}
+	if status := post(fmt.Sprintf("/api/v1/goals/%d/copy?period_id=%d", goalID, fromID), &created); status != http.StatusBadRequest {
+		t.Fatalf("expected a copy into the same period to be rejected, got %d", status)
+	}
+}
+
+type recordingSender struct {
+	to     []string
+	bodies []string
+}
+
+func (s *recordingSender) Send(_ context.Context, to, _, body string) error {
+	s.to = append(s.to, to)
+	s.bodies = append(s.bodies, body)
+	return nil
+}
+
+// issueTestToken creates a user with a global editor role and returns its bearer token.
+func issueTestToken(t *testing.T, ctx context.Context, repo *store.Store, svc *service.Service) string {
+	t.Helper()
+	userID, err := repo.CreateUser(ctx, store.UserInput{Email: "ci@example.com", Name: "CI"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := repo.AddRoleAssignment(ctx, store.RoleAssignmentInput{UserID: userID, Role: domain.RoleEditor, Scope: domain.RoleScopeGlobal}); err != nil {
+		t.Fatalf("add role: %v", err)
+	}
+	token, _, err := svc.CreateAPIToken(auth.WithUser(ctx, domain.User{ID: userID}), "ci", false, nil)
+	if err != nil {
+		t.Fatalf("create api token: %v", err)
+	}
+	return token
+}
+
+func requestWithToken(method, url, token string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
+
 func runMigrations(databaseURL string) error {
 	db, err := sql.Open("pgx", databaseURL)
 	if err != nil {
@@ -2,11 +2,13 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
 	"okrs/internal/domain"
 	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
 	"okrs/internal/store"
 
 	"github.com/go-chi/chi/v5"
@@ -30,6 +32,9 @@ func (h *Handler) handleAddKRComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := h.service.AddKeyResultComment(r.Context(), krID, req.Text); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to add comment", nil)
 		return
 	}
@@ -57,7 +62,7 @@ func (h *Handler) handleUpdateKeyResult(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid weight", map[string]string{"weight": "0..100"})
 		return
 	}
-	meta, err := parseKeyResultMeta(r, kind)
+	meta, err := parseKeyResultMeta(r.Form, kind)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
 		return
@@ -69,6 +74,13 @@ func (h *Handler) handleUpdateKeyResult(w http.ResponseWriter, r *http.Request)
 		Weight:      weight,
 		Kind:        kind,
 	}, meta); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidCheckpoint) {
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]string{"percent_start": "invalid", "percent_target": "invalid"})
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update key result", nil)
 		return
 	}
@@ -97,6 +109,9 @@ func (h *Handler) handleMoveKeyResult(w http.ResponseWriter, r *http.Request, di
 		return
 	}
 	if err := h.service.MoveKeyResult(r.Context(), krID, direction); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to move key result", nil)
 		return
 	}
@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type krProgressPoint struct {
+	Value     float64   `json:"value"`
+	Progress  int       `json:"progress"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type krHistoryResponse struct {
+	KeyResultID int64             `json:"kr_id"`
+	Items       []krProgressPoint `json:"items"`
+}
+
+// handleKRHistory returns the progress time series of a key result, oldest first.
+func (h *Handler) handleKRHistory(w http.ResponseWriter, r *http.Request) {
+	krID, err := common.ParseID(chi.URLParam(r, "krID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid kr id", map[string]string{"kr_id": "invalid"})
+		return
+	}
+	events, err := h.service.ListKRProgressHistory(r.Context(), krID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "kr not found", nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load kr history", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, krHistoryResponse{KeyResultID: krID, Items: mapKRProgressPoints(events)})
+}
+
+func mapKRProgressPoints(events []domain.KRProgressEvent) []krProgressPoint {
+	items := make([]krProgressPoint, 0, len(events))
+	for _, event := range events {
+		items = append(items, krProgressPoint{Value: event.Value, Progress: event.Progress, Source: event.Source, CreatedAt: event.CreatedAt})
+	}
+	return items
+}
@@ -30,6 +30,9 @@ func (h *Handler) handleMoveGoal(w http.ResponseWriter, r *http.Request, directi
 		return
 	}
 	if err := h.service.MoveGoal(r.Context(), goalID, direction); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to move goal", nil)
 		return
 	}
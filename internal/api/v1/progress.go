@@ -27,7 +27,7 @@ type updateProjectStage struct {
 	Done bool  `json:"done"`
 }
 
-// handleUpdatePercentProgress updates percent/linear current value.
+// handleUpdatePercentProgress updates percent/linear/range current value.
 func (h *Handler) handleUpdatePercentProgress(w http.ResponseWriter, r *http.Request) {
 	krID, err := common.ParseID(chi.URLParam(r, "krID"))
 	if err != nil {
@@ -40,6 +40,9 @@ func (h *Handler) handleUpdatePercentProgress(w http.ResponseWriter, r *http.Req
 		return
 	}
 	if err := h.service.UpdateKRProgressPercent(r.Context(), krID, req.CurrentValue); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
 		return
 	}
@@ -59,6 +62,9 @@ func (h *Handler) handleUpdateBooleanProgress(w http.ResponseWriter, r *http.Req
 		return
 	}
 	if err := h.service.UpdateKRProgressBoolean(r.Context(), krID, req.Done); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
 		return
 	}
@@ -90,6 +96,9 @@ func (h *Handler) handleUpdateProjectProgress(w http.ResponseWriter, r *http.Req
 		updates = append(updates, service.ProjectStageUpdate{ID: stage.ID, IsDone: stage.Done})
 	}
 	if err := h.service.UpdateKRProgressProject(r.Context(), krID, updates); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
 		return
 	}
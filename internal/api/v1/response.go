@@ -5,6 +5,7 @@ import (
 
 	"okrs/internal/domain"
 	"okrs/internal/http/handlers/common"
+	"okrs/internal/okr"
 	"okrs/internal/service"
 )
 
@@ -46,6 +47,10 @@ type teamSummary struct {
 	Status         string            `json:"status"`
 	StatusLabel    string            `json:"status_label"`
 	PeriodProgress int               `json:"period_progress"`
+	RollupProgress int               `json:"rollup_progress"`
+	Confidence     *int              `json:"confidence"`
+	Health         string            `json:"health"`
+	HealthLabel    string            `json:"health_label"`
 	GoalsCount     int               `json:"goals_count"`
 	GoalsWeight    int               `json:"goals_weight"`
 	Goals          []teamGoalSummary `json:"goals"`
@@ -56,6 +61,7 @@ type teamGoalSummary struct {
 	Title      string      `json:"title"`
 	Weight     int         `json:"weight"`
 	Progress   int         `json:"progress"`
+	Confidence *int        `json:"confidence"`
 	ShareTeams []shareTeam `json:"share_teams"`
 	Priority   string      `json:"priority"`
 }
@@ -74,35 +80,54 @@ type teamOKRResponse struct {
 	PeriodStatus   string        `json:"period_status"`
 	StatusLabel    string        `json:"status_label"`
 	PeriodProgress int           `json:"period_progress"`
+	Confidence     *int          `json:"confidence"`
+	Health         string        `json:"health"`
+	HealthLabel    string        `json:"health_label"`
 	GoalsCount     int           `json:"goals_count"`
 	GoalsWeight    int           `json:"goals_weight"`
 	Goals          []goalDetails `json:"goals"`
+	Permissions    permissions   `json:"permissions"`
+}
+
+type permissions struct {
+	CanEdit     bool `json:"can_edit"`
+	CanValidate bool `json:"can_validate"`
+	CanAdmin    bool `json:"can_admin"`
 }
 
 type teamInfo struct {
-	ID        int64  `json:"id"`
-	Name      string `json:"name"`
-	Type      string `json:"type"`
-	TypeLabel string `json:"type_label"`
-	ParentID  *int64 `json:"parent_id,omitempty"`
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	TypeLabel  string `json:"type_label"`
+	ParentID   *int64 `json:"parent_id,omitempty"`
+	LeadUserID *int64 `json:"lead_user_id,omitempty"`
 }
 
 type goalDetails struct {
-	ID          int64       `json:"id"`
-	TeamID      int64       `json:"team_id"`
-	PeriodID    int64       `json:"period_id"`
-	Title       string      `json:"title"`
-	Description string      `json:"description"`
-	Priority    string      `json:"priority"`
-	Weight      int         `json:"weight"`
-	WorkType    string      `json:"work_type"`
-	FocusType   string      `json:"focus_type"`
-	OwnerText   string      `json:"owner_text"`
-	Progress    int         `json:"progress"`
-	KeyResults  []keyResult `json:"key_results"`
-	ShareTeams  []shareTeam `json:"share_teams"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID                   int64       `json:"id"`
+	TeamID               int64       `json:"team_id"`
+	PeriodID             int64       `json:"period_id"`
+	Title                string      `json:"title"`
+	Description          string      `json:"description"`
+	Priority             string      `json:"priority"`
+	Weight               int         `json:"weight"`
+	WorkType             string      `json:"work_type"`
+	FocusType            string      `json:"focus_type"`
+	OwnerText            string      `json:"owner_text"`
+	OwnerUserID          *int64      `json:"owner_user_id"`
+	Progress             int         `json:"progress"`
+	Confidence           *int        `json:"confidence"`
+	ConfidenceRollup     *int        `json:"confidence_rollup"`
+	Health               string      `json:"health"`
+	HealthLabel          string      `json:"health_label"`
+	ParentGoalID         *int64      `json:"parent_goal_id"`
+	ProgressFromChildren bool        `json:"progress_from_children"`
+	OriginGoalID         *int64      `json:"origin_goal_id"`
+	KeyResults           []keyResult `json:"key_results"`
+	ShareTeams           []shareTeam `json:"share_teams"`
+	CreatedAt            time.Time   `json:"created_at"`
+	UpdatedAt            time.Time   `json:"updated_at"`
 }
 
 type keyResult struct {
@@ -113,6 +138,7 @@ type keyResult struct {
 	Weight      int         `json:"weight"`
 	Kind        string      `json:"kind"`
 	Progress    int         `json:"progress"`
+	Confidence  *int        `json:"confidence"`
 	Measure     measure     `json:"measure"`
 	Comments    []krComment `json:"comments"`
 	CreatedAt   time.Time   `json:"created_at"`
@@ -141,6 +167,7 @@ type measure struct {
 	Percent     *percentMeasure     `json:"percent,omitempty"`
 	Linear      *linearMeasure      `json:"linear,omitempty"`
 	Boolean     *booleanMeasure     `json:"boolean,omitempty"`
+	Range       *rangeMeasure       `json:"range,omitempty"`
 	Project     *projectMeasure     `json:"project,omitempty"`
 	Checkpoints []percentCheckpoint `json:"checkpoints,omitempty"`
 }
@@ -157,19 +184,30 @@ type linearMeasure struct {
 	CurrentValue float64 `json:"current_value"`
 }
 
+type rangeMeasure struct {
+	MinValue     *float64 `json:"min_value"`
+	MaxValue     *float64 `json:"max_value"`
+	Tolerance    float64  `json:"tolerance"`
+	CurrentValue float64  `json:"current_value"`
+}
+
 type booleanMeasure struct {
 	IsDone bool `json:"is_done"`
 }
 
 type projectMeasure struct {
-	Stages []projectStage `json:"stages"`
+	Stages          []projectStage `json:"stages"`
+	PlannedProgress int            `json:"planned_progress"`
 }
 
 type projectStage struct {
-	ID     int64  `json:"id"`
-	Title  string `json:"title"`
-	Weight int    `json:"weight"`
-	IsDone bool   `json:"is_done"`
+	ID      int64      `json:"id"`
+	Title   string     `json:"title"`
+	Weight  int        `json:"weight"`
+	IsDone  bool       `json:"is_done"`
+	DueDate *string    `json:"due_date"`
+	DoneAt  *time.Time `json:"done_at"`
+	Overdue bool       `json:"overdue"`
 }
 
 type percentCheckpoint struct {
@@ -230,6 +268,7 @@ func mapTeamsResponse(period domain.Period, teams []service.TeamSummary) teamsRe
 				Title:      goal.Title,
 				Weight:     goal.Weight,
 				Progress:   goal.Progress,
+				Confidence: goal.Confidence,
 				ShareTeams: shareTeams,
 				Priority:   goal.Priority,
 			})
@@ -243,6 +282,10 @@ func mapTeamsResponse(period domain.Period, teams []service.TeamSummary) teamsRe
 			Status:         string(team.Status),
 			StatusLabel:    common.TeamPeriodStatusLabel(team.Status),
 			PeriodProgress: team.PeriodProgress,
+			RollupProgress: team.RollupProgress,
+			Confidence:     team.Confidence,
+			Health:         string(team.Health),
+			HealthLabel:    common.HealthLabel(team.Health),
 			GoalsCount:     team.GoalsCount,
 			GoalsWeight:    team.GoalsWeight,
 			Goals:          goals,
@@ -268,9 +311,17 @@ func mapTeamOKRResponse(data service.TeamOKR) teamOKRResponse {
 		PeriodStatus:   string(data.PeriodStatus),
 		StatusLabel:    common.TeamPeriodStatusLabel(data.PeriodStatus),
 		PeriodProgress: data.PeriodProgress,
+		Confidence:     data.Confidence,
+		Health:         string(data.Health),
+		HealthLabel:    common.HealthLabel(data.Health),
 		GoalsCount:     data.GoalsCount,
 		GoalsWeight:    data.GoalsWeight,
 		Goals:          goals,
+		Permissions: permissions{
+			CanEdit:     data.Permissions.CanEdit,
+			CanValidate: data.Permissions.CanValidate,
+			CanAdmin:    data.Permissions.CanAdmin,
+		},
 	}
 }
 
@@ -291,25 +342,33 @@ func mapGoalDetails(detail service.GoalDetails) goalDetails {
 	}
 	goal := detail.Goal
 	return goalDetails{
-		ID:          goal.ID,
-		TeamID:      goal.TeamID,
-		PeriodID:    goal.PeriodID,
-		Title:       goal.Title,
-		Description: goal.Description,
-		Priority:    string(goal.Priority),
-		Weight:      goal.Weight,
-		WorkType:    string(goal.WorkType),
-		FocusType:   string(goal.FocusType),
-		OwnerText:   goal.OwnerText,
-		Progress:    goal.Progress,
-		KeyResults:  krList,
-		ShareTeams:  shareTeams,
-		CreatedAt:   goal.CreatedAt,
-		UpdatedAt:   goal.UpdatedAt,
+		ID:                   goal.ID,
+		TeamID:               goal.TeamID,
+		PeriodID:             goal.PeriodID,
+		Title:                goal.Title,
+		Description:          goal.Description,
+		Priority:             string(goal.Priority),
+		Weight:               goal.Weight,
+		WorkType:             string(goal.WorkType),
+		FocusType:            string(goal.FocusType),
+		OwnerText:            goal.OwnerText,
+		OwnerUserID:          goal.OwnerUserID,
+		Progress:             goal.Progress,
+		Confidence:           goal.Confidence,
+		ConfidenceRollup:     okr.GoalConfidence(goal),
+		Health:               string(detail.Health),
+		HealthLabel:          common.HealthLabel(detail.Health),
+		ParentGoalID:         goal.ParentGoalID,
+		ProgressFromChildren: goal.ProgressFromChildren,
+		OriginGoalID:         goal.OriginGoalID,
+		KeyResults:           krList,
+		ShareTeams:           shareTeams,
+		CreatedAt:            goal.CreatedAt,
+		UpdatedAt:            goal.UpdatedAt,
 	}
 }
 
-func mapGoalResponse(goal domain.Goal) goalResponse {
+func mapGoalResponse(goal domain.Goal, health okr.Health) goalResponse {
 	comments := make([]goalComment, 0, len(goal.Comments))
 	for _, comment := range goal.Comments {
 		comments = append(comments, goalComment{ID: comment.ID, Text: comment.Text, CreatedAt: comment.CreatedAt})
@@ -319,20 +378,28 @@ func mapGoalResponse(goal domain.Goal) goalResponse {
 		krList = append(krList, mapKeyResult(kr))
 	}
 	goalDetail := goalDetails{
-		ID:          goal.ID,
-		TeamID:      goal.TeamID,
-		PeriodID:    goal.PeriodID,
-		Title:       goal.Title,
-		Description: goal.Description,
-		Priority:    string(goal.Priority),
-		Weight:      goal.Weight,
-		WorkType:    string(goal.WorkType),
-		FocusType:   string(goal.FocusType),
-		OwnerText:   goal.OwnerText,
-		Progress:    goal.Progress,
-		KeyResults:  krList,
-		CreatedAt:   goal.CreatedAt,
-		UpdatedAt:   goal.UpdatedAt,
+		ID:                   goal.ID,
+		TeamID:               goal.TeamID,
+		PeriodID:             goal.PeriodID,
+		Title:                goal.Title,
+		Description:          goal.Description,
+		Priority:             string(goal.Priority),
+		Weight:               goal.Weight,
+		WorkType:             string(goal.WorkType),
+		FocusType:            string(goal.FocusType),
+		OwnerText:            goal.OwnerText,
+		OwnerUserID:          goal.OwnerUserID,
+		Progress:             goal.Progress,
+		Confidence:           goal.Confidence,
+		ConfidenceRollup:     okr.GoalConfidence(goal),
+		Health:               string(health),
+		HealthLabel:          common.HealthLabel(health),
+		ParentGoalID:         goal.ParentGoalID,
+		ProgressFromChildren: goal.ProgressFromChildren,
+		OriginGoalID:         goal.OriginGoalID,
+		KeyResults:           krList,
+		CreatedAt:            goal.CreatedAt,
+		UpdatedAt:            goal.UpdatedAt,
 	}
 	return goalResponse{Goal: goalDetail, Comments: comments}
 }
@@ -350,6 +417,7 @@ func mapKeyResult(kr domain.KeyResult) keyResult {
 		Weight:      kr.Weight,
 		Kind:        string(kr.Kind),
 		Progress:    kr.Progress,
+		Confidence:  kr.Confidence,
 		Measure:     buildMeasure(kr),
 		Comments:    comments,
 		CreatedAt:   kr.CreatedAt,
@@ -365,11 +433,7 @@ func buildMeasure(kr domain.KeyResult) measure {
 		}
 		checkpoints := make([]percentCheckpoint, 0, len(kr.Percent.Checkpoints))
 		for _, cp := range kr.Percent.Checkpoints {
-			checkpoints = append(checkpoints, percentCheckpoint{
-				ID:          cp.ID,
-				MetricValue: cp.MetricValue,
-				Percent:     cp.KRPercent,
-			})
+			checkpoints = append(checkpoints, mapCheckpoint(cp))
 		}
 		return measure{
 			Kind:        string(kr.Kind),
@@ -386,15 +450,25 @@ func buildMeasure(kr domain.KeyResult) measure {
 			return measure{Kind: string(kr.Kind)}
 		}
 		return measure{Kind: string(kr.Kind), Boolean: &booleanMeasure{IsDone: kr.Boolean.IsDone}}
+	case domain.KRKindRange:
+		if kr.Range == nil {
+			return measure{Kind: string(kr.Kind)}
+		}
+		return measure{Kind: string(kr.Kind), Range: &rangeMeasure{MinValue: kr.Range.MinValue, MaxValue: kr.Range.MaxValue, Tolerance: kr.Range.Tolerance, CurrentValue: kr.Range.CurrentValue}}
 	case domain.KRKindProject:
 		if kr.Project == nil {
 			return measure{Kind: string(kr.Kind)}
 		}
 		stages := make([]projectStage, 0, len(kr.Project.Stages))
 		for _, stage := range kr.Project.Stages {
-			stages = append(stages, projectStage{ID: stage.ID, Title: stage.Title, Weight: stage.Weight, IsDone: stage.IsDone})
+			var dueDate *string
+			if stage.DueDate != nil {
+				formatted := stage.DueDate.Format("2006-01-02")
+				dueDate = &formatted
+			}
+			stages = append(stages, projectStage{ID: stage.ID, Title: stage.Title, Weight: stage.Weight, IsDone: stage.IsDone, DueDate: dueDate, DoneAt: stage.DoneAt, Overdue: stage.Overdue})
 		}
-		return measure{Kind: string(kr.Kind), Project: &projectMeasure{Stages: stages}}
+		return measure{Kind: string(kr.Kind), Project: &projectMeasure{Stages: stages, PlannedProgress: kr.Project.PlannedProgress}}
 	default:
 		return measure{Kind: string(kr.Kind)}
 	}
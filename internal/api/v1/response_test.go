@@ -79,3 +79,22 @@ func TestBuildMeasureProject(t *testing.T) {
 		t.Fatalf("expected project measure")
 	}
 }
+
+func TestBuildMeasureRange(t *testing.T) {
+	max := 200.0
+	kr := domain.KeyResult{
+		Kind: domain.KRKindRange,
+		Range: &domain.KRRange{
+			MaxValue:     &max,
+			Tolerance:    50,
+			CurrentValue: 180,
+		},
+	}
+	measure := buildMeasure(kr)
+	if measure.Kind != string(domain.KRKindRange) {
+		t.Fatalf("expected kind %s, got %s", domain.KRKindRange, measure.Kind)
+	}
+	if measure.Range == nil || measure.Range.MinValue != nil || measure.Range.MaxValue == nil {
+		t.Fatalf("expected range measure with open lower bound, got %+v", measure.Range)
+	}
+}
@@ -47,11 +47,12 @@ func (h *Handler) handleTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	writeJSON(w, http.StatusOK, teamInfo{
-		ID:        team.ID,
-		Name:      team.Name,
-		Type:      string(team.Type),
-		TypeLabel: common.TeamTypeLabel(team.Type),
-		ParentID:  team.ParentID,
+		ID:         team.ID,
+		Name:       team.Name,
+		Type:       string(team.Type),
+		TypeLabel:  common.TeamTypeLabel(team.Type),
+		ParentID:   team.ParentID,
+		LeadUserID: team.LeadUserID,
 	})
 }
 
@@ -102,6 +103,9 @@ func (h *Handler) handleUpdateTeamPeriodStatus(w http.ResponseWriter, r *http.Re
 		return
 	}
 	if err := h.service.UpdateTeamPeriodStatus(r.Context(), teamID, periodID, status); err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update status", nil)
 		return
 	}
@@ -0,0 +1,159 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type apiToken struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	ReadOnly   bool       `json:"read_only"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type apiTokensResponse struct {
+	Items []apiToken `json:"items"`
+}
+
+type createAPITokenRequest struct {
+	Name      string     `json:"name"`
+	ReadOnly  bool       `json:"read_only"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createAPITokenResponse struct {
+	Token string   `json:"token"`
+	Item  apiToken `json:"item"`
+}
+
+// bearerKey marks requests authenticated with an API token.
+type bearerKey struct{}
+
+// authenticateBearer resolves the user from an "Authorization: Bearer <token>" header.
+// Requests without the header keep the user resolved by the session middleware.
+// Read-only tokens may only issue GET requests.
+func (h *Handler) authenticateBearer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		raw, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(raw) == "" {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid authorization header", nil)
+			return
+		}
+		user, token, err := h.service.AuthenticateAPIToken(r.Context(), strings.TrimSpace(raw))
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIToken) {
+				writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid api token", nil)
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to check api token", nil)
+			return
+		}
+		if token.ReadOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "api token is read-only", nil)
+			return
+		}
+		ctx := context.WithValue(auth.WithUser(r.Context(), user), bearerKey{}, token.ID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// handleAPITokens returns the API tokens of the current user.
+func (h *Handler) handleAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.service.ListAPITokens(r.Context())
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load api tokens", nil)
+		return
+	}
+	items := make([]apiToken, 0, len(tokens))
+	for _, token := range tokens {
+		items = append(items, mapAPIToken(token))
+	}
+	writeJSON(w, http.StatusOK, apiTokensResponse{Items: items})
+}
+
+// handleCreateAPIToken issues a token; the raw value is only returned once.
+// Tokens are issued from a session only, so a leaked token cannot mint tokens that outlive it.
+func (h *Handler) handleCreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	if r.Context().Value(bearerKey{}) != nil {
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "api tokens cannot be created with an api token", nil)
+		return
+	}
+	var req createAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name is required", map[string]string{"name": "required"})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "expires_at must be in the future", map[string]string{"expires_at": "invalid"})
+		return
+	}
+	raw, token, err := h.service.CreateAPIToken(r.Context(), name, req.ReadOnly, req.ExpiresAt)
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to create api token", nil)
+		return
+	}
+	writeJSON(w, http.StatusCreated, createAPITokenResponse{Token: raw, Item: mapAPIToken(token)})
+}
+
+// handleRevokeAPIToken deletes a token of the current user.
+func (h *Handler) handleRevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := common.ParseID(chi.URLParam(r, "tokenID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid token id", map[string]string{"token_id": "invalid"})
+		return
+	}
+	if err := h.service.RevokeAPIToken(r.Context(), tokenID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "api token not found", nil)
+			return
+		}
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to revoke api token", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func mapAPIToken(token domain.APIToken) apiToken {
+	return apiToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		ReadOnly:   token.ReadOnly,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
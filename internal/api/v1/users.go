@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+	"okrs/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type userInfo struct {
+	ID           int64     `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	DigestOptOut bool      `json:"digest_opt_out"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type roleAssignment struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Role      string    `json:"role"`
+	Scope     string    `json:"scope"`
+	TeamID    *int64    `json:"team_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type meResponse struct {
+	User  userInfo         `json:"user"`
+	Roles []roleAssignment `json:"roles"`
+}
+
+type usersResponse struct {
+	Items []userInfo `json:"items"`
+}
+
+type rolesResponse struct {
+	Items []roleAssignment `json:"items"`
+}
+
+type createUserRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type assignRoleRequest struct {
+	Role   string `json:"role"`
+	Scope  string `json:"scope"`
+	TeamID *int64 `json:"team_id"`
+}
+
+// handleMe returns the current user with role assignments.
+func (h *Handler) handleMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		WriteUnauthorized(w, r)
+		return
+	}
+	assignments, err := h.service.ListRoleAssignments(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load roles", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, meResponse{User: mapUserInfo(user), Roles: mapRoleAssignments(assignments)})
+}
+
+// handleUsers returns all users.
+func (h *Handler) handleUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.ListUsers(r.Context())
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load users", nil)
+		return
+	}
+	items := make([]userInfo, 0, len(users))
+	for _, user := range users {
+		items = append(items, mapUserInfo(user))
+	}
+	writeJSON(w, http.StatusOK, usersResponse{Items: items})
+}
+
+// handleCreateUser creates a password user.
+func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	fields := map[string]string{}
+	if !strings.Contains(req.Email, "@") {
+		fields["email"] = "invalid"
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		fields["name"] = "required"
+	}
+	if req.Password == "" {
+		fields["password"] = "required"
+	}
+	if len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid user", fields)
+		return
+	}
+	id, err := h.service.CreateUser(r.Context(), req.Email, strings.TrimSpace(req.Name), req.Password)
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to create user", nil)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
+}
+
+// handleUserRoles returns role assignments of a user.
+func (h *Handler) handleUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ParseID(chi.URLParam(r, "userID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid user id", map[string]string{"user_id": "invalid"})
+		return
+	}
+	assignments, err := h.service.ListRoleAssignments(r.Context(), userID)
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to load roles", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, rolesResponse{Items: mapRoleAssignments(assignments)})
+}
+
+// handleAssignRole grants a role to a user.
+func (h *Handler) handleAssignRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ParseID(chi.URLParam(r, "userID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid user id", map[string]string{"user_id": "invalid"})
+		return
+	}
+	var req assignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return
+	}
+	input := store.RoleAssignmentInput{
+		UserID: userID,
+		Role:   domain.Role(req.Role),
+		Scope:  domain.RoleScope(req.Scope),
+		TeamID: req.TeamID,
+	}
+	fields := map[string]string{}
+	if !service.ValidRole(input.Role) {
+		fields["role"] = "invalid"
+	}
+	if !service.ValidRoleScope(input.Scope) {
+		fields["scope"] = "invalid"
+	} else if (input.Scope == domain.RoleScopeGlobal) != (input.TeamID == nil) {
+		fields["team_id"] = "invalid"
+	}
+	if len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid role assignment", fields)
+		return
+	}
+	id, err := h.service.AssignRole(r.Context(), input)
+	if err != nil {
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to assign role", nil)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
+}
+
+// handleRevokeRole removes a role assignment.
+func (h *Handler) handleRevokeRole(w http.ResponseWriter, r *http.Request) {
+	assignmentID, err := common.ParseID(chi.URLParam(r, "assignmentID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid assignment id", map[string]string{"assignment_id": "invalid"})
+		return
+	}
+	if err := h.service.RevokeRole(r.Context(), assignmentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "role assignment not found", nil)
+			return
+		}
+		if writePolicyError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to revoke role", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func mapUserInfo(user domain.User) userInfo {
+	return userInfo{ID: user.ID, Email: user.Email, Name: user.Name, DigestOptOut: user.DigestOptOut, CreatedAt: user.CreatedAt}
+}
+
+func mapRoleAssignments(assignments []domain.RoleAssignment) []roleAssignment {
+	items := make([]roleAssignment, 0, len(assignments))
+	for _, assignment := range assignments {
+		items = append(items, roleAssignment{
+			ID:        assignment.ID,
+			UserID:    assignment.UserID,
+			Role:      string(assignment.Role),
+			Scope:     string(assignment.Scope),
+			TeamID:    assignment.TeamID,
+			CreatedAt: assignment.CreatedAt,
+		})
+	}
+	return items
+}
@@ -0,0 +1,221 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type apiWebhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Format    string    `json:"format"`
+	Events    []string  `json:"events"`
+	TeamID    *int64    `json:"team_id"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type webhooksResponse struct {
+	Items []apiWebhook `json:"items"`
+}
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Format string   `json:"format"`
+	Events []string `json:"events"`
+	TeamID *int64   `json:"team_id"`
+	Active *bool    `json:"active"`
+}
+
+type createWebhookResponse struct {
+	Secret string     `json:"secret"`
+	Item   apiWebhook `json:"item"`
+}
+
+type webhookDelivery struct {
+	ID             int64           `json:"id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+type webhookDeliveriesResponse struct {
+	Items []webhookDelivery `json:"items"`
+}
+
+// handleWebhooks returns the webhooks the current user administers.
+func (h *Handler) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.ListWebhooks(r.Context())
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	items := make([]apiWebhook, 0, len(webhooks))
+	for _, item := range webhooks {
+		items = append(items, mapWebhook(item))
+	}
+	writeJSON(w, http.StatusOK, webhooksResponse{Items: items})
+}
+
+// handleWebhook returns one webhook without its secret.
+func (h *Handler) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+	item, err := h.service.GetWebhook(r.Context(), webhookID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, mapWebhook(item))
+}
+
+// handleCreateWebhook registers a webhook; the signing secret is only returned here.
+func (h *Handler) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	input, ok := decodeWebhookRequest(w, r)
+	if !ok {
+		return
+	}
+	item, err := h.service.CreateWebhook(r.Context(), input)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, createWebhookResponse{Secret: item.Secret, Item: mapWebhook(item)})
+}
+
+// handleUpdateWebhook replaces the subscription of a webhook; an empty secret keeps the current one.
+func (h *Handler) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeWebhookRequest(w, r)
+	if !ok {
+		return
+	}
+	item, err := h.service.UpdateWebhook(r.Context(), webhookID, input)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, mapWebhook(item))
+}
+
+// handleDeleteWebhook removes a webhook with its delivery log.
+func (h *Handler) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteWebhook(r.Context(), webhookID); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleWebhookDeliveries returns the delivery log of a webhook, newest first.
+func (h *Handler) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+	deliveries, err := h.service.ListWebhookDeliveries(r.Context(), webhookID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	items := make([]webhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		items = append(items, mapWebhookDelivery(delivery))
+	}
+	writeJSON(w, http.StatusOK, webhookDeliveriesResponse{Items: items})
+}
+
+func parseWebhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	webhookID, err := common.ParseID(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid webhook id", map[string]string{"webhook_id": "invalid"})
+		return 0, false
+	}
+	return webhookID, true
+}
+
+func decodeWebhookRequest(w http.ResponseWriter, r *http.Request) (service.WebhookInput, bool) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid payload", nil)
+		return service.WebhookInput{}, false
+	}
+	input := service.WebhookInput{URL: req.URL, Secret: req.Secret, Format: domain.WebhookFormat(req.Format), TeamID: req.TeamID, Active: req.Active == nil || *req.Active}
+	for _, event := range req.Events {
+		input.Events = append(input.Events, domain.WebhookEvent(event))
+	}
+	return input, true
+}
+
+func mapWebhook(item domain.Webhook) apiWebhook {
+	events := make([]string, 0, len(item.Events))
+	for _, event := range item.Events {
+		events = append(events, string(event))
+	}
+	return apiWebhook{
+		ID:        item.ID,
+		URL:       item.URL,
+		Format:    string(item.Format),
+		Events:    events,
+		TeamID:    item.TeamID,
+		Active:    item.Active,
+		CreatedAt: item.CreatedAt,
+	}
+}
+
+func mapWebhookDelivery(delivery domain.WebhookDelivery) webhookDelivery {
+	item := webhookDelivery{
+		ID:             delivery.ID,
+		Event:          string(delivery.Event),
+		Payload:        json.RawMessage(delivery.Payload),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+	if delivery.Status == domain.WebhookDeliveryPending {
+		item.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return item
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	if writePolicyError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhook):
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "webhook not found", nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "failed to update webhook", nil)
+	}
+}
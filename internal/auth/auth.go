@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"okrs/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SessionCookieName is the cookie that carries the session token.
+const SessionCookieName = "okrs_session"
+
+// SessionTTL is how long a session stays valid after login.
+const SessionTTL = 30 * 24 * time.Hour
+
+// ErrInvalidCredentials is returned when the email or password does not match.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type Store interface {
+	GetUserByEmail(ctx context.Context, email string) (domain.User, error)
+	EnsureUser(ctx context.Context, email, name string) (domain.User, error)
+	UpdateUserPassword(ctx context.Context, id int64, passwordHash string) error
+	CreateSession(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error
+	GetSessionUser(ctx context.Context, tokenHash string) (domain.User, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+	DeleteExpiredSessions(ctx context.Context) error
+}
+
+// Config configures how requests are authenticated.
+type Config struct {
+	// ProxyHeader, when set, names a header with the user email set by a trusted reverse proxy.
+	ProxyHeader string
+}
+
+type Authenticator struct {
+	store  Store
+	logger *slog.Logger
+	config Config
+}
+
+func New(store Store, logger *slog.Logger, config Config) *Authenticator {
+	return &Authenticator{store: store, logger: logger, config: config}
+}
+
+// NormalizeEmail trims and lowercases an email so lookups are case-insensitive.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// EnsurePasswordUser creates the user or resets its password. It is used to bootstrap the first admin.
+func (a *Authenticator) EnsurePasswordUser(ctx context.Context, email, password string) (domain.User, error) {
+	email = NormalizeEmail(email)
+	user, err := a.store.EnsureUser(ctx, email, email)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if CheckPassword(user.PasswordHash, password) {
+		return user, nil
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if err := a.store.UpdateUserPassword(ctx, user.ID, hash); err != nil {
+		return domain.User{}, err
+	}
+	user.PasswordHash = hash
+	return user, nil
+}
+
+// Login checks the credentials and starts a session. It returns the raw session token.
+func (a *Authenticator) Login(ctx context.Context, email, password string) (string, time.Time, error) {
+	user, err := a.store.GetUserByEmail(ctx, NormalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", time.Time{}, ErrInvalidCredentials
+		}
+		return "", time.Time{}, err
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+	if err := a.store.DeleteExpiredSessions(ctx); err != nil {
+		a.logger.Warn("failed to delete expired sessions", slog.String("error", err.Error()))
+	}
+	token, err := NewToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(SessionTTL)
+	if err := a.store.CreateSession(ctx, HashToken(token), user.ID, expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Logout ends the session identified by the raw token.
+func (a *Authenticator) Logout(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	return a.store.DeleteSession(ctx, HashToken(token))
+}
+
+// Middleware resolves the current user from the proxy header or the session cookie
+// and stores it in the request context. Anonymous requests pass through unchanged.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok, err := a.resolveUser(r)
+		if err != nil {
+			a.logger.Error("authentication failed", slog.String("error", err.Error()))
+		}
+		if ok {
+			r = r.WithContext(WithUser(r.Context(), user))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Authenticator) resolveUser(r *http.Request) (domain.User, bool, error) {
+	ctx := r.Context()
+	if a.config.ProxyHeader != "" {
+		if email := NormalizeEmail(r.Header.Get(a.config.ProxyHeader)); email != "" {
+			user, err := a.store.EnsureUser(ctx, email, email)
+			if err != nil {
+				return domain.User{}, false, err
+			}
+			return user, true, nil
+		}
+	}
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return domain.User{}, false, nil
+	}
+	user, err := a.store.GetSessionUser(ctx, HashToken(cookie.Value))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.User{}, false, nil
+		}
+		return domain.User{}, false, err
+	}
+	return user, true, nil
+}
+
+// RequireUser calls onMissing instead of next when the request has no authenticated user.
+func RequireUser(onMissing http.HandlerFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := UserFromContext(r.Context()); !ok {
+				onMissing(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RedirectToLogin sends the browser to the login page and keeps the requested URL.
+func RedirectToLogin(w http.ResponseWriter, r *http.Request) {
+	target := "/login"
+	if r.Method == http.MethodGet {
+		target += "?next=" + url.QueryEscape(r.URL.RequestURI())
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// SafeRedirect returns next when it is a local path and fallback otherwise.
+func SafeRedirect(next, fallback string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return fallback
+	}
+	return next
+}
+
+// SetSessionCookie writes the session cookie.
+func SetSessionCookie(w http.ResponseWriter, r *http.Request, token string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie removes the session cookie.
+func ClearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
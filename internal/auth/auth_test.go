@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"okrs/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type fakeStore struct {
+	users    map[string]domain.User
+	sessions map[string]int64
+	nextID   int64
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{users: make(map[string]domain.User), sessions: make(map[string]int64)}
+}
+
+func (f *fakeStore) GetUserByEmail(_ context.Context, email string) (domain.User, error) {
+	user, ok := f.users[email]
+	if !ok {
+		return domain.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+func (f *fakeStore) EnsureUser(_ context.Context, email, name string) (domain.User, error) {
+	if user, ok := f.users[email]; ok {
+		return user, nil
+	}
+	f.nextID++
+	user := domain.User{ID: f.nextID, Email: email, Name: name}
+	f.users[email] = user
+	return user, nil
+}
+func (f *fakeStore) UpdateUserPassword(_ context.Context, id int64, passwordHash string) error {
+	for email, user := range f.users {
+		if user.ID == id {
+			user.PasswordHash = passwordHash
+			f.users[email] = user
+		}
+	}
+	return nil
+}
+func (f *fakeStore) CreateSession(_ context.Context, tokenHash string, userID int64, _ time.Time) error {
+	f.sessions[tokenHash] = userID
+	return nil
+}
+func (f *fakeStore) GetSessionUser(_ context.Context, tokenHash string) (domain.User, error) {
+	userID, ok := f.sessions[tokenHash]
+	if !ok {
+		return domain.User{}, pgx.ErrNoRows
+	}
+	for _, user := range f.users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+	return domain.User{}, pgx.ErrNoRows
+}
+func (f *fakeStore) DeleteSession(_ context.Context, tokenHash string) error {
+	delete(f.sessions, tokenHash)
+	return nil
+}
+func (f *fakeStore) DeleteExpiredSessions(context.Context) error {
+	return nil
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func currentUserHandler(t *testing.T, expectEmail string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
+		if expectEmail == "" {
+			if ok {
+				t.Fatalf("expected anonymous request, got %s", user.Email)
+			}
+			return
+		}
+		if !ok || user.Email != expectEmail {
+			t.Fatalf("expected user %s, got %+v", expectEmail, user)
+		}
+	})
+}
+
+func TestLoginAndSessionCookie(t *testing.T) {
+	store := newFakeStore()
+	authenticator := New(store, testLogger(), Config{})
+	ctx := context.Background()
+	if _, err := authenticator.EnsurePasswordUser(ctx, "Admin@Example.com", "secret"); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+
+	if _, _, err := authenticator.Login(ctx, "admin@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	token, _, err := authenticator.Login(ctx, " ADMIN@example.com ", "secret")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/teamOkrs", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: token})
+	authenticator.Middleware(currentUserHandler(t, "admin@example.com")).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := authenticator.Logout(ctx, token); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/teamOkrs", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: token})
+	authenticator.Middleware(currentUserHandler(t, "")).ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestProxyHeaderCreatesUser(t *testing.T) {
+	store := newFakeStore()
+	authenticator := New(store, testLogger(), Config{ProxyHeader: "X-Forwarded-Email"})
+
+	req := httptest.NewRequest(http.MethodGet, "/teamOkrs", nil)
+	req.Header.Set("X-Forwarded-Email", "Lead@Example.com")
+	authenticator.Middleware(currentUserHandler(t, "lead@example.com")).ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, ok := store.users["lead@example.com"]; !ok {
+		t.Fatalf("expected proxy user to be created")
+	}
+	if CheckPassword(store.users["lead@example.com"].PasswordHash, "") {
+		t.Fatalf("expected proxy user without password")
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := RequireUser(RedirectToLogin)(next)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/teams?x=1", nil))
+	if recorder.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d", recorder.Code)
+	}
+	if location := recorder.Header().Get("Location"); location != "/login?next=%2Fteams%3Fx%3D1" {
+		t.Fatalf("unexpected redirect %q", location)
+	}
+
+	recorder = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/teams", nil)
+	req = req.WithContext(WithUser(req.Context(), domain.User{ID: 1}))
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected pass through, got %d", recorder.Code)
+	}
+}
+
+func TestSafeRedirect(t *testing.T) {
+	cases := map[string]string{
+		"/goals/1":             "/goals/1",
+		"":                     "/teamOkrs",
+		"//evil.example":       "/teamOkrs",
+		"https://evil.example": "/teamOkrs",
+	}
+	for next, expect := range cases {
+		if got := SafeRedirect(next, "/teamOkrs"); got != expect {
+			t.Fatalf("%q: expected %q got %q", next, expect, got)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+	secret := []byte("s3cret")
+	body := []byte(`{"value":42}`)
+	signature := Sign(secret, body)
+	if !CheckSignature(secret, body, signature) {
+		t.Fatalf("expected signature %q to match", signature)
+	}
+	for _, candidate := range []string{"", signature[len("sha256="):], Sign([]byte("other"), body), Sign(secret, []byte(`{"value":43}`))} {
+		if CheckSignature(secret, body, candidate) {
+			t.Fatalf("expected %q not to match", candidate)
+		}
+	}
+	if CheckSignature(nil, body, Sign(nil, body)) {
+		t.Fatalf("expected empty secret never to match")
+	}
+}
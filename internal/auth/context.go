@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"context"
+
+	"okrs/internal/domain"
+)
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx that carries the authenticated user.
+func WithUser(ctx context.Context, user domain.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user stored by the middleware.
+func UserFromContext(ctx context.Context) (domain.User, bool) {
+	user, ok := ctx.Value(contextKey{}).(domain.User)
+	return user, ok
+}
@@ -0,0 +1,176 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+func newMetricsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/kr", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"data":{"items":[{"value":12.5},{"value":"40"},{"value":"s3cret"}]}}`)
+	})
+	mux.HandleFunc("/prom/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") != `sum(rate(orders_total[5m]))` {
+			_, _ = io.WriteString(w, `{"status":"error","error":"unexpected query"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1719835200,"73.2"]}]}}`)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPJSONFetch(t *testing.T) {
+	server := newMetricsServer(t)
+	fetcher := HTTPJSON{Client: server.Client()}
+	ctx := context.Background()
+
+	for path, want := range map[string]float64{"data.items.0.value": 12.5, "data.items.1.value": 40} {
+		value, err := fetcher.Fetch(ctx, domain.KRDataSource{Endpoint: server.URL + "/kr", Query: path})
+		if err != nil {
+			t.Fatalf("fetch %s: %v", path, err)
+		}
+		if value != want {
+			t.Fatalf("expected %v at %s, got %v", want, path, value)
+		}
+	}
+	for _, path := range []string{"data.missing", "data.items.5.value", "data.items"} {
+		if _, err := fetcher.Fetch(ctx, domain.KRDataSource{Endpoint: server.URL + "/kr", Query: path}); err == nil {
+			t.Fatalf("expected %s to fail", path)
+		}
+	}
+	if _, err := fetcher.Fetch(ctx, domain.KRDataSource{Endpoint: server.URL + "/broken", Query: "value"}); err == nil {
+		t.Fatalf("expected non-2xx response to fail")
+	}
+	if _, err := fetcher.Fetch(ctx, domain.KRDataSource{Endpoint: server.URL + "/kr", Query: "data.items.2.value"}); err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Fatalf("expected a non-number to fail without echoing the value, got %v", err)
+	}
+}
+
+func TestNewHTTPClientRefusesPrivateAddresses(t *testing.T) {
+	server := newMetricsServer(t)
+	source := domain.KRDataSource{Endpoint: server.URL + "/kr", Query: "data.items.0.value"}
+
+	fetcher := HTTPJSON{Client: NewHTTPClient(time.Second, nil)}
+	if _, err := fetcher.Fetch(context.Background(), source); !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("expected loopback address to be refused, got %v", err)
+	}
+	fetcher = HTTPJSON{Client: NewHTTPClient(time.Second, []string{" 127.0.0.1 "})}
+	if value, err := fetcher.Fetch(context.Background(), source); err != nil || value != 12.5 {
+		t.Fatalf("expected allowed host to be fetched, got %v, %v", value, err)
+	}
+}
+
+func TestPromQLFetch(t *testing.T) {
+	server := newMetricsServer(t)
+	fetcher := PromQL{Client: server.Client()}
+	ctx := context.Background()
+
+	value, err := fetcher.Fetch(ctx, domain.KRDataSource{Endpoint: server.URL + "/prom/", Query: `sum(rate(orders_total[5m]))`})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if value != 73.2 {
+		t.Fatalf("expected 73.2, got %v", value)
+	}
+	if _, err := fetcher.Fetch(ctx, domain.KRDataSource{Endpoint: server.URL + "/prom", Query: "up"}); err == nil {
+		t.Fatalf("expected error status to fail")
+	}
+}
+
+func TestSQLFetchUnknownDSN(t *testing.T) {
+	fetcher := SQL{Driver: "pgx", DSNs: map[string]string{"analytics": "postgres://localhost/analytics"}}
+	if _, err := fetcher.Fetch(context.Background(), domain.KRDataSource{Endpoint: "billing", Query: "SELECT 1"}); err == nil {
+		t.Fatalf("expected unknown dsn to fail")
+	}
+	if _, err := fetcher.Fetch(context.Background(), domain.KRDataSource{Endpoint: "Analytics", Query: "SELECT 1"}); err == nil || strings.Contains(err.Error(), "unknown dsn") {
+		t.Fatalf("expected dsn name to be matched case-insensitively, got %v", err)
+	}
+}
+
+type fakeStore struct {
+	due  []domain.KRDataSource
+	runs []store.DataSourceRunInput
+}
+
+func (f *fakeStore) ListDueKRDataSources(context.Context, time.Time) ([]domain.KRDataSource, error) {
+	return f.due, nil
+}
+
+func (f *fakeStore) RecordKRDataSourceRun(_ context.Context, input store.DataSourceRunInput) error {
+	f.runs = append(f.runs, input)
+	return nil
+}
+
+type fakeUpdater struct {
+	values map[int64]float64
+	err    error
+}
+
+func (f *fakeUpdater) UpdateKRProgressPercent(_ context.Context, krID int64, current float64) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.values[krID] = current
+	return nil
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	server := newMetricsServer(t)
+	unchanged := 40.0
+	store := &fakeStore{due: []domain.KRDataSource{
+		{KeyResultID: 1, Kind: domain.DataSourceHTTPJSON, Endpoint: server.URL + "/kr", Query: "data.items.0.value"},
+		{KeyResultID: 2, Kind: domain.DataSourceHTTPJSON, Endpoint: server.URL + "/kr", Query: "data.items.1.value", LastValue: &unchanged},
+		{KeyResultID: 3, Kind: domain.DataSourceHTTPJSON, Endpoint: server.URL + "/broken", Query: "value"},
+		{KeyResultID: 4, Kind: domain.DataSourceSQL, Endpoint: "analytics", Query: "SELECT 1"},
+	}}
+	updater := &fakeUpdater{values: make(map[int64]float64)}
+	scheduler := NewScheduler(store, updater, map[domain.DataSourceKind]Fetcher{
+		domain.DataSourceHTTPJSON: HTTPJSON{Client: server.Client()},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	runAt := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	scheduler.now = func() time.Time { return runAt }
+
+	if succeeded := scheduler.RunDue(context.Background()); succeeded != 2 {
+		t.Fatalf("expected two successful runs, got %d", succeeded)
+	}
+	if len(updater.values) != 1 || updater.values[1] != 12.5 {
+		t.Fatalf("expected only the changed value to be written, got %v", updater.values)
+	}
+	if len(store.runs) != 4 {
+		t.Fatalf("expected every due source to record a run, got %+v", store.runs)
+	}
+	for _, run := range store.runs {
+		if !run.RunAt.Equal(runAt) {
+			t.Fatalf("unexpected run time %v", run.RunAt)
+		}
+		failed := run.KeyResultID == 3 || run.KeyResultID == 4
+		if failed != (run.Error != "") || failed != (run.Value == nil) {
+			t.Fatalf("unexpected run %+v", run)
+		}
+	}
+
+	updater.err = errors.New("locked")
+	store.due = store.due[:1]
+	store.runs = nil
+	if succeeded := scheduler.RunDue(context.Background()); succeeded != 0 || store.runs[0].Error != "locked" {
+		t.Fatalf("expected update failure to be recorded, got %+v", store.runs)
+	}
+}
@@ -0,0 +1,254 @@
+// Package datasource reads KR metrics from external systems and feeds them into key result progress.
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/service"
+)
+
+// maxResponseBytes limits the body read from HTTP_JSON and PROMQL endpoints.
+const maxResponseBytes = 1 << 20
+
+// Fetcher reads the current metric value of a data source.
+type Fetcher interface {
+	Fetch(ctx context.Context, source domain.KRDataSource) (float64, error)
+}
+
+// errPrivateAddress is returned when an endpoint resolves to an address a data source may not reach.
+var errPrivateAddress = errors.New("endpoint resolves to a loopback or private address")
+
+// fetchError keeps the details of a failure out of the last error of a source, which editors can read:
+// driver and decoder errors may echo fetched data. The scheduler logs the wrapped error.
+type fetchError struct {
+	message string
+	err     error
+}
+
+func (e *fetchError) Error() string { return e.message }
+
+func (e *fetchError) Unwrap() error { return e.err }
+
+// NewHTTPClient returns the client for HTTP_JSON and PROMQL sources and for webhook deliveries. It refuses to connect
+// to loopback, private and link-local addresses, redirects included, unless the host name is listed in allowedHosts.
+func NewHTTPClient(timeout time.Duration, allowedHosts []string) *http.Client {
+	allowed := make(map[string]bool, len(allowedHosts))
+	for _, host := range allowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			allowed[host] = true
+		}
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if allowed[strings.ToLower(host)] {
+			return dialer.DialContext(ctx, network, address)
+		}
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if !service.PublicAddr(addr) {
+				return nil, errPrivateAddress
+			}
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("no addresses for %s", host)
+		}
+		return dialer.DialContext(ctx, network, net.JoinHostPort(addrs[0].String(), port))
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// HTTPJSON GETs Endpoint and reads the number at the dot-separated path in Query, e.g. "data.items.0.value".
+// Numbers encoded as JSON strings are accepted.
+type HTTPJSON struct {
+	Client *http.Client
+}
+
+func (f HTTPJSON) Fetch(ctx context.Context, source domain.KRDataSource) (float64, error) {
+	var payload any
+	if err := getJSON(ctx, f.Client, source.Endpoint, &payload); err != nil {
+		return 0, err
+	}
+	value, err := lookupPath(payload, source.Query)
+	if err != nil {
+		return 0, err
+	}
+	return toNumber(value)
+}
+
+// PromQL runs the instant query in Query against the Prometheus-compatible HTTP API at Endpoint.
+// The query must return a scalar or a vector with exactly one sample.
+type PromQL struct {
+	Client *http.Client
+}
+
+type promResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+func (f PromQL) Fetch(ctx context.Context, source domain.KRDataSource) (float64, error) {
+	endpoint, err := url.Parse(source.Endpoint)
+	if err != nil {
+		return 0, err
+	}
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/api/v1/query"
+	query := endpoint.Query()
+	query.Set("query", source.Query)
+	endpoint.RawQuery = query.Encode()
+
+	var response promResponse
+	if err := getJSON(ctx, f.Client, endpoint.String(), &response); err != nil {
+		return 0, err
+	}
+	if response.Status != "success" {
+		return 0, &fetchError{message: "promql: query failed", err: errors.New(response.Error)}
+	}
+	var sample []any
+	switch response.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(response.Data.Result, &sample); err != nil {
+			return 0, err
+		}
+	case "vector":
+		var series []struct {
+			Value []any `json:"value"`
+		}
+		if err := json.Unmarshal(response.Data.Result, &series); err != nil {
+			return 0, err
+		}
+		if len(series) != 1 {
+			return 0, fmt.Errorf("promql: expected one sample, got %d", len(series))
+		}
+		sample = series[0].Value
+	default:
+		return 0, fmt.Errorf("promql: unsupported result type %q", response.Data.ResultType)
+	}
+	if len(sample) != 2 {
+		return 0, errors.New("promql: malformed sample")
+	}
+	return toNumber(sample[1])
+}
+
+// SQL runs Query in a read-only transaction against the DSN named by Endpoint. DSNs are configured
+// by lower-case name so credentials never leave the server environment. The query must return one non-NULL number.
+type SQL struct {
+	Driver string
+	DSNs   map[string]string
+}
+
+func (f SQL) Fetch(ctx context.Context, source domain.KRDataSource) (float64, error) {
+	name := strings.ToLower(strings.TrimSpace(source.Endpoint))
+	dsn, ok := f.DSNs[name]
+	if !ok {
+		return 0, fmt.Errorf("sql: unknown dsn %q", name)
+	}
+	db, err := sql.Open(f.Driver, dsn)
+	if err != nil {
+		return 0, &fetchError{message: "sql: cannot open dsn", err: err}
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return 0, &fetchError{message: "sql: cannot connect", err: err}
+	}
+	defer func() { _ = tx.Rollback() }()
+	var value sql.NullFloat64
+	if err := tx.QueryRowContext(ctx, source.Query).Scan(&value); err != nil {
+		return 0, &fetchError{message: "sql: query failed", err: err}
+	}
+	if !value.Valid {
+		return 0, errors.New("sql: query returned NULL")
+	}
+	return value.Float64, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint string, target any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, errPrivateAddress) {
+			return errPrivateAddress
+		}
+		return &fetchError{message: fmt.Sprintf("request to %s failed", req.URL.Host), err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, req.URL.Host)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(target); err != nil {
+		return &fetchError{message: "response is not valid JSON", err: err}
+	}
+	return nil
+}
+
+// lookupPath walks object keys and array indexes of a dot-separated path.
+func lookupPath(value any, path string) (any, error) {
+	for _, part := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			next, ok := node[part]
+			if !ok {
+				return nil, fmt.Errorf("json path %q: key %q not found", path, part)
+			}
+			value = next
+		case []any:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("json path %q: invalid index %q", path, part)
+			}
+			value = node[index]
+		default:
+			return nil, fmt.Errorf("json path %q: %q is not an object or array", path, part)
+		}
+	}
+	return value, nil
+}
+
+// toNumber converts a fetched value; its errors do not echo the value.
+func toNumber(value any) (float64, error) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		if err != nil {
+			return 0, errors.New("value is not a number")
+		}
+		return number, nil
+	default:
+		return 0, fmt.Errorf("value of type %T is not a number", value)
+	}
+}
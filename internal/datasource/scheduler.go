@@ -0,0 +1,109 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/service"
+	"okrs/internal/store"
+)
+
+// fetchTimeout bounds a single fetch so a slow source does not hold back the others.
+const fetchTimeout = 30 * time.Second
+
+// Store lists due data sources and records their runs; *store.Store implements it.
+type Store interface {
+	ListDueKRDataSources(ctx context.Context, now time.Time) ([]domain.KRDataSource, error)
+	RecordKRDataSourceRun(ctx context.Context, input store.DataSourceRunInput) error
+}
+
+// ProgressUpdater sets the current value of a percent, linear or range key result; *service.Service implements it.
+type ProgressUpdater interface {
+	UpdateKRProgressPercent(ctx context.Context, krID int64, current float64) error
+}
+
+// Scheduler periodically fetches due data sources and updates the progress of their key results.
+type Scheduler struct {
+	store    Store
+	updater  ProgressUpdater
+	fetchers map[domain.DataSourceKind]Fetcher
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func NewScheduler(store Store, updater ProgressUpdater, fetchers map[domain.DataSourceKind]Fetcher, logger *slog.Logger) *Scheduler {
+	return &Scheduler{store: store, updater: updater, fetchers: fetchers, logger: logger, now: time.Now}
+}
+
+// Run calls RunDue immediately and then every tick until ctx is done.
+func (s *Scheduler) Run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		s.RunDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue fetches every due data source once and returns the number of successful runs.
+// Failures are stored as the last error of the source and retried after its interval.
+func (s *Scheduler) RunDue(ctx context.Context) int {
+	sources, err := s.store.ListDueKRDataSources(ctx, s.now())
+	if err != nil {
+		s.logger.Error("list due data sources", slog.String("error", err.Error()))
+		return 0
+	}
+	succeeded := 0
+	for _, source := range sources {
+		run := store.DataSourceRunInput{KeyResultID: source.KeyResultID, RunAt: s.now()}
+		value, err := s.run(ctx, source)
+		if err != nil {
+			run.Error = err.Error()
+			detail := err.Error()
+			if cause := errors.Unwrap(err); cause != nil {
+				detail += ": " + cause.Error()
+			}
+			s.logger.Warn("data source run failed",
+				slog.Int64("kr_id", source.KeyResultID),
+				slog.String("kind", string(source.Kind)),
+				slog.String("error", detail))
+		} else {
+			run.Value = &value
+			succeeded++
+		}
+		if err := s.store.RecordKRDataSourceRun(ctx, run); err != nil {
+			s.logger.Error("record data source run", slog.Int64("kr_id", source.KeyResultID), slog.String("error", err.Error()))
+		}
+	}
+	return succeeded
+}
+
+// run fetches the value and updates the key result unless the value is unchanged since the last run.
+func (s *Scheduler) run(ctx context.Context, source domain.KRDataSource) (float64, error) {
+	fetcher, ok := s.fetchers[source.Kind]
+	if !ok {
+		return 0, fmt.Errorf("no fetcher for %s", source.Kind)
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	value, err := fetcher.Fetch(fetchCtx, source)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	if source.LastValue != nil && *source.LastValue == value {
+		return value, nil
+	}
+	updateCtx := service.WithProgressSource(ctx, service.DataSourceProgressSource(source.Kind))
+	if err := s.updater.UpdateKRProgressPercent(updateCtx, source.KeyResultID, value); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
@@ -0,0 +1,191 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime/quotedprintable"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/okr"
+	"okrs/internal/service"
+)
+
+type fakeMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTPServer accepts plain SMTP sessions on a local port and reports every received message.
+func fakeSMTPServer(t *testing.T) (string, <-chan fakeMail) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	mails := make(chan fakeMail, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, mails)
+		}
+	}()
+	return listener.Addr().String(), mails
+}
+
+func serveSMTP(conn net.Conn, mails chan<- fakeMail) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost fake smtp")
+	var mail fakeMail
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			mail = fakeMail{From: strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")}
+			reply("250 ok")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			mail.To = append(mail.To, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 ok")
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			mail.Data = data.String()
+			mails <- mail
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	addr, mails := fakeSMTPServer(t)
+	sender := SMTP{Addr: addr, From: "okr@example.com"}
+
+	if err := sender.Send(context.Background(), "lead@example.com", Subject, "Привет\n"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	mail := <-mails
+	if mail.From != "okr@example.com" || len(mail.To) != 1 || mail.To[0] != "lead@example.com" {
+		t.Fatalf("unexpected envelope %+v", mail)
+	}
+	header, body, ok := strings.Cut(mail.Data, "\r\n\r\n")
+	if !ok || !strings.Contains(header, "Subject: =?utf-8?q?") || !strings.Contains(header, "Content-Type: text/plain; charset=utf-8") {
+		t.Fatalf("unexpected headers %q", header)
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+	if err != nil || !strings.Contains(string(decoded), "Привет") {
+		t.Fatalf("unexpected body %q (%v)", decoded, err)
+	}
+}
+
+type fakeSource struct {
+	digests []service.Digest
+	marked  []int64
+}
+
+func (f *fakeSource) DueDigests(context.Context, time.Duration, time.Duration) ([]service.Digest, error) {
+	return f.digests, nil
+}
+
+func (f *fakeSource) MarkDigestSent(_ context.Context, userID int64) error {
+	f.marked = append(f.marked, userID)
+	return nil
+}
+
+type failingSender struct {
+	Sender
+	failFor string
+}
+
+func (s failingSender) Send(ctx context.Context, to, subject, body string) error {
+	if to == s.failFor {
+		return errors.New("relay refused")
+	}
+	return s.Sender.Send(ctx, to, subject, body)
+}
+
+func TestSendDue(t *testing.T) {
+	now := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+	addr, mails := fakeSMTPServer(t)
+	source := &fakeSource{digests: []service.Digest{
+		{
+			User: domain.User{ID: 1, Email: "lead@example.com", Name: "Лид"},
+			StaleKeyResults: []service.DigestKeyResult{{
+				KeyResult: domain.KeyResult{Title: "NPS 40", UpdatedAt: now.AddDate(0, 0, -9)},
+				GoalTitle: "Лояльность",
+				TeamName:  "Core",
+			}},
+			GoalsAtRisk: []service.DigestGoal{{Goal: domain.Goal{Title: "Рост", Progress: 10}, TeamName: "Core", Health: okr.HealthBehind, Planned: 50}},
+			PendingStatuses: []service.DigestStatus{{
+				Team:   domain.Team{Name: "Core"},
+				Period: domain.Period{Name: "Q1"},
+				Status: domain.TeamPeriodStatusInProgress,
+				Next:   domain.TeamPeriodStatusValidated,
+			}},
+		},
+		{User: domain.User{ID: 2, Email: "idle@example.com"}},
+		{
+			User:        domain.User{ID: 3, Email: "broken@example.com"},
+			GoalsAtRisk: []service.DigestGoal{{Goal: domain.Goal{Title: "Рост"}, Health: okr.HealthAtRisk}},
+		},
+	}}
+	sender := failingSender{Sender: SMTP{Addr: addr, From: "okr@example.com"}, failFor: "broken@example.com"}
+	job := NewJob(source, sender, slog.New(slog.NewTextHandler(io.Discard, nil)), 7*24*time.Hour, 7*24*time.Hour)
+	job.now = func() time.Time { return now }
+
+	if sent := job.SendDue(context.Background()); sent != 1 {
+		t.Fatalf("expected one digest to be sent, got %d", sent)
+	}
+	if len(source.marked) != 2 || source.marked[0] != 1 || source.marked[1] != 2 {
+		t.Fatalf("expected sent and empty digests to be marked, got %v", source.marked)
+	}
+	mail := <-mails
+	_, body, _ := strings.Cut(mail.Data, "\r\n\r\n")
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	for _, expected := range []string{
+		"Здравствуйте, Лид!",
+		"- Core / Лояльность: NPS 40 (не обновлялся 9 дн.)",
+		"- Core / Рост: Отставание, прогресс 10% при плане 50%",
+		"- Core, Q1: «Готовы к валидации» → «Провалидировано»",
+	} {
+		if !strings.Contains(string(decoded), expected) {
+			t.Fatalf("expected %q in digest:\n%s", expected, decoded)
+		}
+	}
+}
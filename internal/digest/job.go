@@ -0,0 +1,115 @@
+package digest
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
+)
+
+// Subject is the subject of every digest email.
+const Subject = "OKR: еженедельная сводка"
+
+// Source builds due digests and records that a user was handled; *service.Service implements it.
+type Source interface {
+	DueDigests(ctx context.Context, interval, staleAfter time.Duration) ([]service.Digest, error)
+	MarkDigestSent(ctx context.Context, userID int64) error
+}
+
+// Job periodically emails goal owners and team leads their digest. Every user is considered at most once
+// per interval; users with nothing to report are skipped without an email.
+type Job struct {
+	source     Source
+	sender     Sender
+	logger     *slog.Logger
+	interval   time.Duration
+	staleAfter time.Duration
+	now        func() time.Time
+}
+
+func NewJob(source Source, sender Sender, logger *slog.Logger, interval, staleAfter time.Duration) *Job {
+	return &Job{source: source, sender: sender, logger: logger, interval: interval, staleAfter: staleAfter, now: time.Now}
+}
+
+// Run calls SendDue immediately and then every tick until ctx is done.
+func (j *Job) Run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		j.SendDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends every due digest once and returns the number of emails sent.
+// A digest that fails to send is retried on the next tick.
+func (j *Job) SendDue(ctx context.Context) int {
+	digests, err := j.source.DueDigests(ctx, j.interval, j.staleAfter)
+	if err != nil {
+		j.logger.Error("build digests", slog.String("error", err.Error()))
+		return 0
+	}
+	sent := 0
+	for _, digest := range digests {
+		if !digest.Empty() {
+			body, err := j.render(digest)
+			if err != nil {
+				j.logger.Error("render digest", slog.Int64("user_id", digest.User.ID), slog.String("error", err.Error()))
+				continue
+			}
+			if err := j.sender.Send(ctx, digest.User.Email, Subject, body); err != nil {
+				j.logger.Warn("send digest failed", slog.Int64("user_id", digest.User.ID), slog.String("error", err.Error()))
+				continue
+			}
+			sent++
+		}
+		if err := j.source.MarkDigestSent(ctx, digest.User.ID); err != nil {
+			j.logger.Error("mark digest sent", slog.Int64("user_id", digest.User.ID), slog.String("error", err.Error()))
+		}
+	}
+	return sent
+}
+
+func (j *Job) render(digest service.Digest) (string, error) {
+	var buf strings.Builder
+	err := bodyTemplate.Execute(&buf, struct {
+		service.Digest
+		Now time.Time
+	}{Digest: digest, Now: j.now()})
+	return buf.String(), err
+}
+
+var bodyTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"status": common.TeamPeriodStatusLabel,
+	"health": common.HealthLabel,
+	"days": func(now, since time.Time) int {
+		return int(now.Sub(since) / (24 * time.Hour))
+	},
+	"name": func(user domain.User) string {
+		if user.Name != "" {
+			return user.Name
+		}
+		return user.Email
+	},
+}).Parse(`Здравствуйте, {{ name .User }}!
+{{ if .StaleKeyResults }}
+Ключевые результаты без обновлений:
+{{ range .StaleKeyResults }}- {{ .TeamName }} / {{ .GoalTitle }}: {{ .KeyResult.Title }} (не обновлялся {{ days $.Now .KeyResult.UpdatedAt }} дн.)
+{{ end }}{{ end }}{{ if .GoalsAtRisk }}
+Цели под угрозой:
+{{ range .GoalsAtRisk }}- {{ .TeamName }} / {{ .Goal.Title }}: {{ health .Health }}, прогресс {{ .Goal.Progress }}% при плане {{ .Planned }}%
+{{ end }}{{ end }}{{ if .PendingStatuses }}
+Ожидают смены статуса:
+{{ range .PendingStatuses }}- {{ .Team.Name }}, {{ .Period.Name }}: «{{ status .Status }}» → «{{ status .Next }}»
+{{ end }}{{ end }}
+Отключить сводку: POST /api/v1/me/digest с {"opt_out": true}.
+`))
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Priority string
 
@@ -34,6 +37,7 @@ const (
 	KRKindPercent KRKind = "PERCENT"
 	KRKindLinear  KRKind = "LINEAR"
 	KRKindBoolean KRKind = "BOOLEAN"
+	KRKindRange   KRKind = "RANGE"
 )
 
 type TeamType string
@@ -54,15 +58,65 @@ const (
 	TeamPeriodStatusClosed     TeamPeriodStatus = "closed"
 )
 
+type Role string
+
+const (
+	RoleViewer    Role = "viewer"
+	RoleEditor    Role = "editor"
+	RoleValidator Role = "validator"
+	RoleAdmin     Role = "admin"
+)
+
+type RoleScope string
+
+const (
+	RoleScopeGlobal  RoleScope = "global"
+	RoleScopeSubtree RoleScope = "subtree"
+	RoleScopeTeam    RoleScope = "team"
+)
+
+type AuditEntity string
+
+const (
+	AuditEntityGoal           AuditEntity = "goal"
+	AuditEntityKeyResult      AuditEntity = "key_result"
+	AuditEntityTeam           AuditEntity = "team"
+	AuditEntityPeriod         AuditEntity = "period"
+	AuditEntityUser           AuditEntity = "user"
+	AuditEntityRoleAssignment AuditEntity = "role_assignment"
+	AuditEntityAPIToken       AuditEntity = "api_token"
+	AuditEntityWebhook        AuditEntity = "webhook"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate     AuditAction = "create"
+	AuditActionUpdate     AuditAction = "update"
+	AuditActionDelete     AuditAction = "delete"
+	AuditActionShare      AuditAction = "share"
+	AuditActionWeight     AuditAction = "weight"
+	AuditActionMove       AuditAction = "move"
+	AuditActionComment    AuditAction = "comment"
+	AuditActionProgress   AuditAction = "progress"
+	AuditActionStatus     AuditAction = "status"
+	AuditActionConfidence AuditAction = "confidence"
+	AuditActionAlign      AuditAction = "align"
+	AuditActionDependency AuditAction = "dependency"
+)
+
 type Team struct {
 	ID          int64
 	Name        string
 	Type        TeamType
 	ParentID    *int64
 	Lead        string
+	LeadUserID  *int64
 	Description string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// RollupWeight overrides the goal weight sum of the team in the aggregated progress of its ancestors.
+	RollupWeight *int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 type Goal struct {
@@ -76,11 +130,19 @@ type Goal struct {
 	WorkType    WorkType
 	FocusType   FocusType
 	OwnerText   string
+	OwnerUserID *int64
 	Progress    int
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	KeyResults  []KeyResult
-	Comments    []GoalComment
+	Confidence  *int
+	// ParentGoalID links the goal to the goal of an ancestor team it contributes to.
+	ParentGoalID *int64
+	// ProgressFromChildren makes the goal progress roll up from its aligned child goals.
+	ProgressFromChildren bool
+	// OriginGoalID links a goal copied or rolled over from another period to the goal it was copied from.
+	OriginGoalID *int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	KeyResults   []KeyResult
+	Comments     []GoalComment
 }
 
 type GoalComment struct {
@@ -98,11 +160,13 @@ type KeyResult struct {
 	Weight      int
 	Kind        KRKind
 	Progress    int
+	Confidence  *int
 	SortOrder   int
 	Project     *KRProject
 	Percent     *KRPercent
 	Linear      *KRLinear
 	Boolean     *KRBoolean
+	Range       *KRRange
 	Comments    []KeyResultComment
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -117,6 +181,8 @@ type KeyResultComment struct {
 
 type KRProject struct {
 	Stages []KRProjectStage
+	// PlannedProgress is the weight of the stages due by now, set together with Progress.
+	PlannedProgress int
 }
 
 type KRProjectStage struct {
@@ -126,6 +192,9 @@ type KRProjectStage struct {
 	Weight      int
 	IsDone      bool
 	SortOrder   int
+	DueDate     *time.Time
+	DoneAt      *time.Time
+	Overdue     bool
 }
 
 type KRPercent struct {
@@ -152,6 +221,15 @@ type KRBoolean struct {
 	IsDone bool
 }
 
+// KRRange is a metric that has to stay within [MinValue, MaxValue]; a nil bound is open, so a KR with
+// only MaxValue is a "stay below" threshold. Tolerance is the distance outside the range at which progress drops to 0.
+type KRRange struct {
+	MinValue     *float64
+	MaxValue     *float64
+	Tolerance    float64
+	CurrentValue float64
+}
+
 type Period struct {
 	ID        int64
 	Name      string
@@ -161,3 +239,155 @@ type Period struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+type User struct {
+	ID           int64
+	Email        string
+	Name         string
+	PasswordHash string
+	// DigestOptOut stops the weekly email digest for the user.
+	DigestOptOut bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type RoleAssignment struct {
+	ID        int64
+	UserID    int64
+	Role      Role
+	Scope     RoleScope
+	TeamID    *int64
+	CreatedAt time.Time
+}
+
+type APIToken struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	ReadOnly   bool
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type AuditEvent struct {
+	ID         int64
+	ActorID    *int64
+	ActorName  string
+	EntityType AuditEntity
+	EntityID   int64
+	Action     AuditAction
+	Before     json.RawMessage
+	After      json.RawMessage
+	CreatedAt  time.Time
+}
+
+// ProgressSourceManual marks progress events recorded by users; data source runs record "data_source:<kind>".
+const ProgressSourceManual = "manual"
+
+type KRProgressEvent struct {
+	ID          int64
+	KeyResultID int64
+	Value       float64
+	Progress    int
+	Source      string
+	CreatedAt   time.Time
+}
+
+// DataSourceKind is the way a KR data source fetches its metric.
+type DataSourceKind string
+
+const (
+	DataSourceHTTPJSON DataSourceKind = "HTTP_JSON"
+	DataSourcePromQL   DataSourceKind = "PROMQL"
+	DataSourceSQL      DataSourceKind = "SQL"
+)
+
+// KRDataSource binds a percent, linear or range key result to an external metric. Endpoint is the URL for
+// HTTP_JSON and PROMQL and the name of a configured DSN for SQL; Query is the JSON path, the PromQL expression
+// or the SQL query returning one number.
+type KRDataSource struct {
+	KeyResultID     int64
+	Kind            DataSourceKind
+	Endpoint        string
+	Query           string
+	IntervalMinutes int
+	LastRunAt       *time.Time
+	LastValue       *float64
+	LastError       string
+	UpdatedAt       time.Time
+}
+
+// WebhookEvent names a change delivered to webhook subscribers.
+type WebhookEvent string
+
+const (
+	WebhookEventGoalCreated         WebhookEvent = "goal.created"
+	WebhookEventKRProgressChanged   WebhookEvent = "kr.progress_changed"
+	WebhookEventGoalShared          WebhookEvent = "goal.shared"
+	WebhookEventTeamPeriodValidated WebhookEvent = "team_period.validated"
+	WebhookEventTeamPeriodClosed    WebhookEvent = "team_period.closed"
+	WebhookEventKRCompleted         WebhookEvent = "kr.completed"
+	WebhookEventTeamBehindPlan      WebhookEvent = "team.behind_plan"
+)
+
+// WebhookFormat is the body of the deliveries of a webhook.
+type WebhookFormat string
+
+const (
+	// WebhookFormatJSON delivers the signed event payload.
+	WebhookFormatJSON WebhookFormat = "json"
+	// WebhookFormatChat delivers a Slack/Mattermost-compatible incoming webhook message {"text": "..."}.
+	WebhookFormatChat WebhookFormat = "chat"
+)
+
+// Webhook subscribes a URL to events. An empty Events list receives every event; a TeamID limits
+// the subscription to events of that team and its descendants. Secret signs every delivery.
+type Webhook struct {
+	ID        int64
+	URL       string
+	Secret    string
+	Format    WebhookFormat
+	Events    []WebhookEvent
+	TeamID    *int64
+	Active    bool
+	CreatedBy *int64
+	CreatedAt time.Time
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event queued for a webhook. Pending deliveries are retried at NextAttemptAt
+// until they are delivered or run out of attempts.
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	Event          WebhookEvent
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+type KRCheckIn struct {
+	ID          int64
+	KeyResultID int64
+	AuthorID    *int64
+	AuthorName  string
+	Value       float64
+	Progress    int
+	Confidence  int
+	Blockers    string
+	NextSteps   string
+	CreatedAt   time.Time
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"okrs/internal/service"
+)
+
+// utf8BOM lets spreadsheet applications detect UTF-8 instead of falling back to a local code page.
+const utf8BOM = "\ufeff"
+
+// WriteCSV writes the rows as comma-separated values with a header line.
+func WriteCSV(w io.Writer, rows []service.ExportRow) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	for _, line := range table(rows) {
+		record := make([]string, len(line))
+		for i, cell := range line {
+			record[i] = csvValue(cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formulaPrefixes start cells that spreadsheet applications evaluate as formulas.
+const formulaPrefixes = "=+-@\t\r"
+
+// csvValue formats a cell; text that would be evaluated as a formula is prefixed with ' so it stays text.
+func csvValue(cell any) string {
+	switch value := cell.(type) {
+	case string:
+		if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+			return "'" + value
+		}
+		return value
+	case int:
+		return strconv.Itoa(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
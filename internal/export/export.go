@@ -0,0 +1,82 @@
+// Package export writes OKR export rows as spreadsheets: one row per key result with its goal.
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"okrs/internal/service"
+)
+
+// Format is a spreadsheet format of an export.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+var header = []string{
+	"Команда",
+	"Цель",
+	"Приоритет",
+	"Вес цели",
+	"Прогресс цели",
+	"KR",
+	"Вес KR",
+	"Тип KR",
+	"Старт",
+	"Цель KR",
+	"Текущее",
+	"Прогресс KR",
+	"Расшарена командам",
+}
+
+// table returns the header and the cells of every row; a cell is a string, an int, a float64 or nil for an empty cell.
+func table(rows []service.ExportRow) [][]any {
+	cells := make([][]any, 0, len(rows)+1)
+	head := make([]any, 0, len(header))
+	for _, title := range header {
+		head = append(head, title)
+	}
+	cells = append(cells, head)
+	for _, row := range rows {
+		line := []any{row.TeamName, row.Goal.Title, string(row.Goal.Priority), row.Goal.Weight, row.Goal.Progress}
+		if row.KeyResult.ID == 0 {
+			line = append(line, nil, nil, nil, nil, nil, nil, nil)
+		} else {
+			line = append(line, row.KeyResult.Title, row.KeyResult.Weight, string(row.KeyResult.Kind),
+				optional(row.Start), optional(row.Target), optional(row.Current), row.Progress)
+		}
+		line = append(line, shareTeams(row))
+		cells = append(cells, line)
+	}
+	return cells
+}
+
+func optional(value *float64) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// shareTeams lists the teams other than the owner the goal is shared with, with their weights.
+func shareTeams(row service.ExportRow) string {
+	names := make([]string, 0, len(row.ShareTeams))
+	for _, team := range row.ShareTeams {
+		if team.ID == row.Goal.TeamID {
+			continue
+		}
+		names = append(names, fmt.Sprintf("%s (%d)", team.Name, team.Weight))
+	}
+	return strings.Join(names, ", ")
+}
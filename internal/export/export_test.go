@@ -0,0 +1,140 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"okrs/internal/domain"
+	"okrs/internal/service"
+)
+
+func exportRows() []service.ExportRow {
+	start, target, current := 10.0, 20.0, 12.5
+	goal := domain.Goal{ID: 1, TeamID: 1, Title: "Рост, \"выручки\"", Priority: domain.PriorityP1, Weight: 60, Progress: 25}
+	return []service.ExportRow{
+		{
+			TeamName:   "Core",
+			Goal:       goal,
+			KeyResult:  domain.KeyResult{ID: 5, Title: "MRR", Weight: 100, Kind: domain.KRKindLinear},
+			Start:      &start,
+			Target:     &target,
+			Current:    &current,
+			Progress:   25,
+			ShareTeams: []service.TeamShareInfo{{ID: 1, Name: "Core", Weight: 60}, {ID: 2, Name: "Sales", Weight: 20}},
+		},
+		{TeamName: "Core", Goal: domain.Goal{ID: 2, TeamID: 1, Title: "Без KR", Priority: domain.PriorityP3, Weight: 40}},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, exportRows()); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	body, ok := strings.CutPrefix(buf.String(), utf8BOM)
+	if !ok {
+		t.Fatalf("expected UTF-8 BOM")
+	}
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "Команда" {
+		t.Fatalf("unexpected records %v", records)
+	}
+	if got := strings.Join(records[1], "|"); got != "Core|Рост, \"выручки\"|P1|60|25|MRR|100|LINEAR|10|20|12.5|25|Sales (20)" {
+		t.Fatalf("unexpected key result row %q", got)
+	}
+	if got := strings.Join(records[2], "|"); got != "Core|Без KR|P3|40|0||||||||" {
+		t.Fatalf("unexpected goal row %q", got)
+	}
+
+	rows := exportRows()[1:]
+	rows[0].TeamName = "=HYPERLINK(\"http://evil\")"
+	rows[0].Goal.Title = "@SUM(A1)"
+	buf.Reset()
+	if err := WriteCSV(&buf, rows); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	records, err = csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), utf8BOM))).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if records[1][0] != "'=HYPERLINK(\"http://evil\")" || records[1][1] != "'@SUM(A1)" {
+		t.Fatalf("expected formula cells to be escaped, got %v", records[1])
+	}
+	for _, value := range []string{"-1", "+7", "\tx", "\rx"} {
+		if got := csvValue(value); got != "'"+value {
+			t.Fatalf("expected %q to be escaped, got %q", value, got)
+		}
+	}
+	if got := csvValue(-1.5); got != "-1.5" {
+		t.Fatalf("expected numbers to stay numbers, got %q", got)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, exportRows()); err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open xlsx: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		parts[file.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("missing part %s", name)
+		}
+	}
+	var sheet struct {
+		Rows []struct {
+			Ref   string `xml:"r,attr"`
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatalf("parse sheet: %v", err)
+	}
+	if len(sheet.Rows) != 3 || len(sheet.Rows[0].Cells) != len(header) {
+		t.Fatalf("unexpected sheet %+v", sheet.Rows)
+	}
+	cells := sheet.Rows[1].Cells
+	if cells[1].Ref != "B2" || cells[1].Type != "inlineStr" || cells[1].Inline != "Рост, \"выручки\"" {
+		t.Fatalf("unexpected goal cell %+v", cells[1])
+	}
+	if cells[10].Ref != "K2" || cells[10].Type != "" || cells[10].Value != "12.5" {
+		t.Fatalf("unexpected current value cell %+v", cells[10])
+	}
+	if goalOnly := sheet.Rows[2].Cells; len(goalOnly) != 6 || goalOnly[5].Ref != "M3" {
+		t.Fatalf("expected empty key result cells to be skipped, got %+v", goalOnly)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for index, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(index); got != expected {
+			t.Fatalf("column %d: expected %s, got %s", index, expected, got)
+		}
+	}
+}
@@ -46,7 +46,10 @@ func (h *Handler) HandleAPITeams(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		for i := range goals {
-			goals[i].Progress = common.CalculateGoalProgress(goals[i])
+			if goals[i].Progress, err = h.deps.Service.GoalProgress(ctx, &goals[i]); err != nil {
+				common.RenderJSONError(w, h.deps.Logger, err)
+				return
+			}
 		}
 		response = append(response, teamRow{ID: team.ID, Name: team.Name, PeriodProgress: okr.PeriodProgress(goals), GoalsCount: len(goals)})
 	}
@@ -72,7 +75,10 @@ func (h *Handler) HandleAPITeamGoals(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	for i := range goals {
-		goals[i].Progress = common.CalculateGoalProgress(goals[i])
+		if goals[i].Progress, err = h.deps.Service.GoalProgress(ctx, &goals[i]); err != nil {
+			common.RenderJSONError(w, h.deps.Logger, err)
+			return
+		}
 	}
 	common.WriteJSON(w, goals)
 }
@@ -89,6 +95,9 @@ func (h *Handler) HandleAPIGoal(w http.ResponseWriter, r *http.Request) {
 		common.RenderJSONError(w, h.deps.Logger, err)
 		return
 	}
-	goal.Progress = common.CalculateGoalProgress(goal)
+	if goal.Progress, err = h.deps.Service.GoalProgress(ctx, &goal); err != nil {
+		common.RenderJSONError(w, h.deps.Logger, err)
+		return
+	}
 	common.WriteJSON(w, goal)
 }
@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"okrs/internal/auth"
 	"okrs/internal/domain"
 	"okrs/internal/okr"
 	"okrs/internal/service"
@@ -23,6 +24,7 @@ import (
 type Dependencies struct {
 	Store     *store.Store
 	Service   *service.Service
+	Auth      *auth.Authenticator
 	Logger    *slog.Logger
 	Templates *template.Template
 	Zone      *time.Location
@@ -82,6 +84,40 @@ func RenderError(w http.ResponseWriter, logger *slog.Logger, err error) {
 	http.Error(w, "Произошла ошибка", http.StatusInternalServerError)
 }
 
+// LockedMessage is shown when the team period status forbids a change.
+const LockedMessage = "Статус периода не позволяет вносить эти изменения"
+
+// ForbiddenMessage is shown when the user role does not allow a change.
+const ForbiddenMessage = "Недостаточно прав для этого действия"
+
+// PolicyMessage returns the user-facing text for permission and lifecycle errors.
+func PolicyMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return ForbiddenMessage, true
+	case errors.Is(err, service.ErrLocked):
+		return LockedMessage, true
+	default:
+		return "", false
+	}
+}
+
+// RenderMutationError responds with 403 for missing permissions, 423 for lifecycle locks
+// and falls back to RenderError otherwise.
+func RenderMutationError(w http.ResponseWriter, logger *slog.Logger, err error) {
+	if errors.Is(err, service.ErrForbidden) {
+		logger.Warn("mutation forbidden", slog.String("error", err.Error()))
+		http.Error(w, ForbiddenMessage, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrLocked) {
+		logger.Warn("mutation locked", slog.String("error", err.Error()))
+		http.Error(w, LockedMessage, http.StatusLocked)
+		return
+	}
+	RenderError(w, logger, err)
+}
+
 func RenderJSONError(w http.ResponseWriter, logger *slog.Logger, err error) {
 	logger.Error("api failed", slog.String("error", err.Error()))
 	w.Header().Set("Content-Type", "application/json")
@@ -111,40 +147,6 @@ func ParsePeriodID(r *http.Request) (int64, error) {
 	return ParseID(value)
 }
 
-func CalculateGoalProgress(goal domain.Goal) int {
-	for i := range goal.KeyResults {
-		goal.KeyResults[i].Progress = CalculateKRProgress(goal.KeyResults[i])
-	}
-	return okr.GoalProgress(goal.KeyResults)
-}
-
-func CalculateKRProgress(kr domain.KeyResult) int {
-	switch kr.Kind {
-	case domain.KRKindProject:
-		if kr.Project == nil {
-			return 0
-		}
-		return okr.ProjectProgress(kr.Project.Stages)
-	case domain.KRKindPercent:
-		if kr.Percent == nil {
-			return 0
-		}
-		return okr.PercentProgress(kr.Percent.StartValue, kr.Percent.TargetValue, kr.Percent.CurrentValue, kr.Percent.Checkpoints)
-	case domain.KRKindLinear:
-		if kr.Linear == nil {
-			return 0
-		}
-		return okr.LinearProgress(kr.Linear.StartValue, kr.Linear.TargetValue, kr.Linear.CurrentValue)
-	case domain.KRKindBoolean:
-		if kr.Boolean == nil {
-			return 0
-		}
-		return okr.BooleanProgress(kr.Boolean.IsDone)
-	default:
-		return 0
-	}
-}
-
 func ValidateGoalInput(priority domain.Priority, workType domain.WorkType, focusType domain.FocusType, weight int) string {
 	if weight < 0 || weight > 100 {
 		return "Вес должен быть 0..100"
@@ -236,9 +238,53 @@ func TeamPeriodStatusLabel(status domain.TeamPeriodStatus) string {
 	}
 }
 
+func HealthLabel(health okr.Health) string {
+	switch health {
+	case okr.HealthOnTrack:
+		return "В норме"
+	case okr.HealthAtRisk:
+		return "Риск"
+	case okr.HealthBehind:
+		return "Отставание"
+	default:
+		return "Нет данных"
+	}
+}
+
+func AuditActionLabel(action domain.AuditAction) string {
+	switch action {
+	case domain.AuditActionCreate:
+		return "Создание"
+	case domain.AuditActionUpdate:
+		return "Изменение"
+	case domain.AuditActionDelete:
+		return "Удаление"
+	case domain.AuditActionShare:
+		return "Изменение команд"
+	case domain.AuditActionWeight:
+		return "Изменение веса"
+	case domain.AuditActionMove:
+		return "Перемещение"
+	case domain.AuditActionComment:
+		return "Комментарий"
+	case domain.AuditActionProgress:
+		return "Обновление прогресса"
+	case domain.AuditActionStatus:
+		return "Смена статуса"
+	case domain.AuditActionConfidence:
+		return "Изменение уверенности"
+	case domain.AuditActionAlign:
+		return "Изменение родительской цели"
+	case domain.AuditActionDependency:
+		return "Изменение зависимостей"
+	default:
+		return string(action)
+	}
+}
+
 func ValidKRKind(k domain.KRKind) bool {
 	switch k {
-	case domain.KRKindProject, domain.KRKindPercent, domain.KRKindLinear, domain.KRKindBoolean:
+	case domain.KRKindProject, domain.KRKindPercent, domain.KRKindLinear, domain.KRKindBoolean, domain.KRKindRange:
 		return true
 	default:
 		return false
@@ -261,6 +307,12 @@ func FindGoalIDByStage(ctx context.Context, store *store.Store, stageID int64) (
 	return goalID, err
 }
 
+func FindKeyResultIDByStage(ctx context.Context, store *store.Store, stageID int64) (int64, error) {
+	var krID int64
+	err := store.DB.QueryRow(ctx, `SELECT key_result_id FROM kr_project_stages WHERE id=$1`, stageID).Scan(&krID)
+	return krID, err
+}
+
 func ParseID(raw string) (int64, error) {
 	id, err := strconv.ParseInt(raw, 10, 64)
 	if err != nil {
@@ -281,6 +333,57 @@ func ParseFloatField(value string) float64 {
 	return result
 }
 
+// ParseOptionalFloatField returns nil for an empty value, so an unset range bound stays open.
+func ParseOptionalFloatField(value string) *float64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	result := ParseFloatField(value)
+	return &result
+}
+
+// RangeBoundsLabel formats range KR bounds, e.g. "100.00 … 200.00" or "≤ 200.00".
+func RangeBoundsLabel(min, max *float64) string {
+	switch {
+	case min != nil && max != nil:
+		return fmt.Sprintf("%.2f … %.2f", *min, *max)
+	case min != nil:
+		return fmt.Sprintf("≥ %.2f", *min)
+	case max != nil:
+		return fmt.Sprintf("≤ %.2f", *max)
+	default:
+		return "—"
+	}
+}
+
+// ValidateRangeMeta returns the user-facing error for range KR bounds or "" when they are valid.
+func ValidateRangeMeta(min, max *float64, tolerance float64) string {
+	if min == nil && max == nil {
+		return "Задайте хотя бы одну границу диапазона"
+	}
+	if min != nil && max != nil && *min > *max {
+		return "Нижняя граница не может быть больше верхней"
+	}
+	if tolerance < 0 {
+		return "Допуск не может быть отрицательным"
+	}
+	return ""
+}
+
+// ParseOptionalDateField parses a YYYY-MM-DD form value; an empty value gives nil.
+func ParseOptionalDateField(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, errors.New("Неверная дата, ожидается ГГГГ-ММ-ДД")
+	}
+	return &date, nil
+}
+
 func ParseIntField(value string) int {
 	result, _ := strconv.Atoi(value)
 	return result
@@ -1,12 +1,17 @@
 package goals
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"okrs/internal/domain"
 	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
 	"okrs/internal/store"
 
 	"github.com/go-chi/chi/v5"
@@ -22,6 +27,39 @@ func New(deps common.Dependencies) *Handler {
 	return &Handler{deps: deps}
 }
 
+type goalPage struct {
+	Team            domain.Team
+	TeamTypeLabel   string
+	Goal            domain.Goal
+	Period          domain.Period
+	IsClosed        bool
+	FormError       string
+	History         []historyItem
+	CheckIns        []checkInItem
+	Dependencies    map[int64]krDependencies
+	PageTitle       string
+	ContentTemplate string
+}
+
+// checkInItem is a row of the goal check-in timeline.
+type checkInItem struct {
+	CheckIn        domain.KRCheckIn
+	KeyResultTitle string
+}
+
+// krDependencies are the upstream key results of a key result; Blocked is set when one of them is behind plan.
+type krDependencies struct {
+	Items   []service.KRDependency
+	Blocked bool
+}
+
+// historyItem is a row of the goal history tab: an audit event of the goal or one of its key results.
+type historyItem struct {
+	Event       domain.AuditEvent
+	ActionLabel string
+	Subject     string
+}
+
 func (h *Handler) HandleGoalDetail(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
@@ -61,21 +99,157 @@ func (h *Handler) HandleGoalDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	goal.Progress = common.CalculateGoalProgress(goal)
+	history, err := h.loadGoalHistory(ctx, goal)
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	checkIns, err := h.loadCheckIns(ctx, goal)
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	dependencies, err := h.loadDependencies(ctx, goal)
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
 
-	page := struct {
-		Team            domain.Team
-		TeamTypeLabel   string
-		Goal            domain.Goal
-		Period          domain.Period
-		IsClosed        bool
-		FormError       string
-		PageTitle       string
-		ContentTemplate string
-	}{Team: team, TeamTypeLabel: common.TeamTypeLabel(team.Type), Goal: goal, Period: period, IsClosed: status == domain.TeamPeriodStatusClosed, PageTitle: "Цель", ContentTemplate: "goal-content"}
+	if goal.Progress, err = h.deps.Service.GoalProgress(ctx, &goal); err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+
+	page := goalPage{Team: team, TeamTypeLabel: common.TeamTypeLabel(team.Type), Goal: goal, Period: period, IsClosed: status == domain.TeamPeriodStatusClosed, History: history, CheckIns: checkIns, Dependencies: dependencies, PageTitle: "Цель", ContentTemplate: "goal-content"}
 	common.RenderTemplate(w, h.deps.Templates, "base", page, h.deps.Logger)
 }
 
+// loadGoalHistory merges audit events of the goal and its key results, newest first.
+func (h *Handler) loadGoalHistory(ctx context.Context, goal domain.Goal) ([]historyItem, error) {
+	events, err := h.deps.Store.ListAuditEvents(ctx, domain.AuditEntityGoal, goal.ID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]historyItem, 0, len(events))
+	for _, event := range events {
+		items = append(items, historyItem{Event: event, ActionLabel: common.AuditActionLabel(event.Action), Subject: "Цель"})
+	}
+	for _, kr := range goal.KeyResults {
+		events, err := h.deps.Store.ListAuditEvents(ctx, domain.AuditEntityKeyResult, kr.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			items = append(items, historyItem{Event: event, ActionLabel: common.AuditActionLabel(event.Action), Subject: "KR: " + kr.Title})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Event.CreatedAt.Equal(items[j].Event.CreatedAt) {
+			return items[i].Event.ID > items[j].Event.ID
+		}
+		return items[i].Event.CreatedAt.After(items[j].Event.CreatedAt)
+	})
+	return items, nil
+}
+
+// loadCheckIns merges check-ins of the goal key results, newest first.
+func (h *Handler) loadCheckIns(ctx context.Context, goal domain.Goal) ([]checkInItem, error) {
+	var items []checkInItem
+	for _, kr := range goal.KeyResults {
+		checkIns, err := h.deps.Store.ListKRCheckIns(ctx, kr.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, checkIn := range checkIns {
+			items = append(items, checkInItem{CheckIn: checkIn, KeyResultTitle: kr.Title})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].CheckIn.CreatedAt.Equal(items[j].CheckIn.CreatedAt) {
+			return items[i].CheckIn.ID > items[j].CheckIn.ID
+		}
+		return items[i].CheckIn.CreatedAt.After(items[j].CheckIn.CreatedAt)
+	})
+	return items, nil
+}
+
+func (h *Handler) loadDependencies(ctx context.Context, goal domain.Goal) (map[int64]krDependencies, error) {
+	dependencies := make(map[int64]krDependencies)
+	for _, kr := range goal.KeyResults {
+		items, err := h.deps.Service.ListKRDependencies(ctx, kr.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			continue
+		}
+		entry := krDependencies{Items: items}
+		for _, item := range items {
+			entry.Blocked = entry.Blocked || item.BehindPlan()
+		}
+		dependencies[kr.ID] = entry
+	}
+	return dependencies, nil
+}
+
+// HandleAddCheckIn records a weekly check-in of one of the goal key results.
+func (h *Handler) HandleAddCheckIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	krID, err := common.ParseID(r.FormValue("kr_id"))
+	if err != nil {
+		h.renderGoalWithError(w, r, goalID, "Выберите Key Result")
+		return
+	}
+	goal, err := h.deps.Store.GetGoal(ctx, goalID)
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	var kr *domain.KeyResult
+	for i := range goal.KeyResults {
+		if goal.KeyResults[i].ID == krID {
+			kr = &goal.KeyResults[i]
+		}
+	}
+	if kr == nil {
+		h.renderGoalWithError(w, r, goalID, "Выберите Key Result")
+		return
+	}
+	input := service.CheckInInput{
+		Confidence: common.ParseIntField(r.FormValue("confidence")),
+		Blockers:   r.FormValue("blockers"),
+		NextSteps:  r.FormValue("next_steps"),
+	}
+	switch kr.Kind {
+	case domain.KRKindPercent, domain.KRKindLinear, domain.KRKindRange:
+		if value := common.TrimmedFormValue(r, "value"); value != "" {
+			current := common.ParseFloatField(value)
+			input.Value = &current
+		}
+	case domain.KRKindBoolean:
+		done := r.FormValue("done") == "true"
+		input.Done = &done
+	}
+	if _, err := h.deps.Service.CheckInKeyResult(ctx, krID, input); err != nil {
+		if errors.Is(err, service.ErrInvalidCheckIn) {
+			h.renderGoalWithError(w, r, goalID, "Уверенность должна быть 1..10")
+			return
+		}
+		h.renderMutationError(w, r, goalID, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/goals/%d", goalID), http.StatusSeeOther)
+}
+
 func (h *Handler) HandleAddGoalComment(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	goalID, err := common.ParseID(chi.URLParam(r, "goalID"))
@@ -87,6 +261,10 @@ func (h *Handler) HandleAddGoalComment(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	if err := h.deps.Service.CheckGoalMutation(ctx, goalID, service.MutationComment); err != nil {
+		h.renderMutationError(w, r, goalID, err)
+		return
+	}
 	text := common.TrimmedFormValue(r, "text")
 	if text == "" {
 		if returnURL := r.FormValue("return"); returnURL != "" {
@@ -96,8 +274,8 @@ func (h *Handler) HandleAddGoalComment(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, fmt.Sprintf("/goals/%d", goalID), http.StatusSeeOther)
 		return
 	}
-	if err := h.deps.Store.AddGoalComment(ctx, goalID, text); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.AddGoalComment(ctx, goalID, text); err != nil {
+		h.renderMutationError(w, r, goalID, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -118,6 +296,10 @@ func (h *Handler) HandleAddKeyResult(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	if err := h.deps.Service.CheckGoalMutation(ctx, goalID, service.MutationStructural); err != nil {
+		h.renderMutationError(w, r, goalID, err)
+		return
+	}
 	weight := common.ParseIntField(r.FormValue("weight"))
 	kind := domain.KRKind(r.FormValue("kind"))
 	if !common.ValidKRKind(kind) || weight < 0 || weight > 100 {
@@ -125,66 +307,52 @@ func (h *Handler) HandleAddKeyResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	krID, err := h.deps.Store.CreateKeyResult(ctx, store.KeyResultInput{
-		GoalID:      goalID,
-		Title:       common.TrimmedFormValue(r, "title"),
-		Description: common.TrimmedFormValue(r, "description"),
-		Weight:      weight,
-		Kind:        kind,
-	})
-	if err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-
-	if kind == domain.KRKindPercent {
-		start := common.ParseFloatField(r.FormValue("percent_start"))
-		target := common.ParseFloatField(r.FormValue("percent_target"))
-		current := common.ParseFloatField(r.FormValue("percent_current"))
-		if start == target {
+	var meta service.KeyResultMetaInput
+	switch kind {
+	case domain.KRKindPercent:
+		meta.PercentStart = common.ParseFloatField(r.FormValue("percent_start"))
+		meta.PercentTarget = common.ParseFloatField(r.FormValue("percent_target"))
+		meta.PercentCurrent = common.ParseFloatField(r.FormValue("percent_current"))
+		if meta.PercentStart == meta.PercentTarget {
 			h.renderGoalWithError(w, r, goalID, "Start и Target не должны быть равны")
 			return
 		}
-		if err := h.deps.Store.UpsertPercentMeta(ctx, store.PercentMetaInput{KeyResultID: krID, StartValue: start, TargetValue: target, CurrentValue: current}); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
-	}
-
-	if kind == domain.KRKindLinear {
-		start := common.ParseFloatField(r.FormValue("linear_start"))
-		target := common.ParseFloatField(r.FormValue("linear_target"))
-		current := common.ParseFloatField(r.FormValue("linear_current"))
-		if start == target {
+	case domain.KRKindLinear:
+		meta.LinearStart = common.ParseFloatField(r.FormValue("linear_start"))
+		meta.LinearTarget = common.ParseFloatField(r.FormValue("linear_target"))
+		meta.LinearCurrent = common.ParseFloatField(r.FormValue("linear_current"))
+		if meta.LinearStart == meta.LinearTarget {
 			h.renderGoalWithError(w, r, goalID, "Start и Target не должны быть равны")
 			return
 		}
-		if err := h.deps.Store.UpsertLinearMeta(ctx, store.LinearMetaInput{KeyResultID: krID, StartValue: start, TargetValue: target, CurrentValue: current}); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
+	case domain.KRKindRange:
+		meta.RangeMin = common.ParseOptionalFloatField(r.FormValue("range_min"))
+		meta.RangeMax = common.ParseOptionalFloatField(r.FormValue("range_max"))
+		meta.RangeTolerance = common.ParseFloatField(r.FormValue("range_tolerance"))
+		meta.RangeCurrent = common.ParseFloatField(r.FormValue("range_current"))
+		if msg := common.ValidateRangeMeta(meta.RangeMin, meta.RangeMax, meta.RangeTolerance); msg != "" {
+			h.renderGoalWithError(w, r, goalID, msg)
 			return
 		}
-	}
-
-	if kind == domain.KRKindBoolean {
-		done := r.FormValue("boolean_done") == "true"
-		if err := h.deps.Store.UpsertBooleanMeta(ctx, krID, done); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
-	}
-	if kind == domain.KRKindProject {
+	case domain.KRKindBoolean:
+		meta.BooleanDone = r.FormValue("boolean_done") == "true"
+	case domain.KRKindProject:
 		stages, err := parseProjectStages(r)
 		if err != nil {
 			h.renderGoalWithError(w, r, goalID, err.Error())
 			return
 		}
-		for i := range stages {
-			stages[i].KeyResultID = krID
-		}
-		if err := h.deps.Store.ReplaceProjectStages(ctx, krID, stages); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
+		meta.ProjectStages = stages
+	}
+	if _, err := h.deps.Service.CreateKeyResultWithMeta(ctx, store.KeyResultInput{
+		GoalID:      goalID,
+		Title:       common.TrimmedFormValue(r, "title"),
+		Description: common.TrimmedFormValue(r, "description"),
+		Weight:      weight,
+		Kind:        kind,
+	}, meta); err != nil {
+		h.renderMutationError(w, r, goalID, err)
+		return
 	}
 
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -210,6 +378,7 @@ func (h *Handler) HandleUpdateKeyResultWeights(w http.ResponseWriter, r *http.Re
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	weights := make(map[int64]int, len(goal.KeyResults))
 	for _, kr := range goal.KeyResults {
 		field := fmt.Sprintf("kr_weight_%d", kr.ID)
 		weight := common.ParseIntField(r.FormValue(field))
@@ -217,10 +386,11 @@ func (h *Handler) HandleUpdateKeyResultWeights(w http.ResponseWriter, r *http.Re
 			common.RenderError(w, h.deps.Logger, fmt.Errorf("Вес KR должен быть 0..100"))
 			return
 		}
-		if err := h.deps.Store.UpdateKeyResultWeight(ctx, kr.ID, weight); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
+		weights[kr.ID] = weight
+	}
+	if err := h.deps.Service.UpdateKeyResultWeights(ctx, goalID, weights); err != nil {
+		h.renderMutationError(w, r, goalID, err)
+		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
 		http.Redirect(w, r, returnURL, http.StatusSeeOther)
@@ -246,46 +416,11 @@ func (h *Handler) HandleDeleteGoal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	teamID := parseOptionalTeamID(r.FormValue("team_id"), goal.TeamID)
-	if teamID != goal.TeamID {
-		if err := h.deps.Store.DeleteGoalShare(ctx, goalID, teamID); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
-		redirectToTeam(w, r, teamID, goal.PeriodID)
+	if err := h.deps.Service.RemoveGoalFromTeam(ctx, goalID, teamID); err != nil {
+		h.renderMutationError(w, r, goalID, err)
 		return
 	}
-	shares, err := h.deps.Store.ListGoalShares(ctx, goalID)
-	if err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-	if len(shares) > 0 {
-		newOwner := shares[0]
-		if err := h.deps.Store.UpdateGoalOwner(ctx, goalID, newOwner.TeamID, newOwner.Weight); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
-		if err := h.deps.Store.DeleteGoalShare(ctx, goalID, newOwner.TeamID); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
-		redirectToTeam(w, r, teamID, goal.PeriodID)
-		return
-	}
-	status, err := h.deps.Store.GetTeamPeriodStatus(ctx, goal.TeamID, goal.PeriodID)
-	if err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-	if status == domain.TeamPeriodStatusClosed {
-		h.renderGoalWithError(w, r, goalID, "Период закрыт, изменения недоступны")
-		return
-	}
-	if err := h.deps.Store.DeleteGoal(ctx, goalID); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-	http.Redirect(w, r, fmt.Sprintf("/teams/%d/okr?period_id=%d", goal.TeamID, goal.PeriodID), http.StatusSeeOther)
+	redirectToTeam(w, r, teamID, goal.PeriodID)
 }
 
 func (h *Handler) HandleMoveGoalUp(w http.ResponseWriter, r *http.Request) {
@@ -321,8 +456,8 @@ func (h *Handler) handleMoveGoal(w http.ResponseWriter, r *http.Request, directi
 		redirectToTeam(w, r, teamID, goal.PeriodID)
 		return
 	}
-	if err := h.deps.Store.MoveGoal(ctx, goalID, direction); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.MoveGoal(ctx, goalID, direction); err != nil {
+		h.renderMutationError(w, r, goalID, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -348,16 +483,8 @@ func (h *Handler) HandleUpdateGoal(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	// team_id only selects whose weight of a shared goal is edited.
 	teamID := parseOptionalTeamID(r.FormValue("team_id"), goal.TeamID)
-	status, err := h.deps.Store.GetTeamPeriodStatus(ctx, teamID, goal.PeriodID)
-	if err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-	if status == domain.TeamPeriodStatusClosed {
-		h.renderGoalWithError(w, r, goalID, "Период закрыт, изменения недоступны")
-		return
-	}
 	priority := domain.Priority(r.FormValue("priority"))
 	workType := domain.WorkType(r.FormValue("work_type"))
 	focusType := domain.FocusType(r.FormValue("focus_type"))
@@ -366,7 +493,7 @@ func (h *Handler) HandleUpdateGoal(w http.ResponseWriter, r *http.Request) {
 		h.renderGoalWithError(w, r, goalID, errMsg)
 		return
 	}
-	if err := h.deps.Store.UpdateGoalFields(ctx, store.GoalFieldsUpdateInput{
+	if err := h.deps.Service.UpdateGoalFields(ctx, store.GoalFieldsUpdateInput{
 		ID:          goalID,
 		Title:       common.TrimmedFormValue(r, "title"),
 		Description: common.TrimmedFormValue(r, "description"),
@@ -374,12 +501,8 @@ func (h *Handler) HandleUpdateGoal(w http.ResponseWriter, r *http.Request) {
 		WorkType:    workType,
 		FocusType:   focusType,
 		OwnerText:   common.TrimmedFormValue(r, "owner_text"),
-	}); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-	if err := h.deps.Store.UpdateGoalTeamWeight(ctx, goalID, teamID, weight); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	}, teamID, weight); err != nil {
+		h.renderMutationError(w, r, goalID, err)
 		return
 	}
 	redirectToTeam(w, r, teamID, goal.PeriodID)
@@ -477,6 +600,7 @@ func parseProjectStages(r *http.Request) ([]store.ProjectStageInput, error) {
 	titles := r.Form["step_title[]"]
 	weights := r.Form["step_weight[]"]
 	dones := r.Form["step_done[]"]
+	dues := r.Form["step_due[]"]
 	sortOrder := 1
 	for i, title := range titles {
 		trimmed := strings.TrimSpace(title)
@@ -495,11 +619,20 @@ func parseProjectStages(r *http.Request) ([]store.ProjectStageInput, error) {
 		if i < len(dones) {
 			isDone = dones[i] == "true"
 		}
+		var dueDate *time.Time
+		if i < len(dues) {
+			parsed, err := common.ParseOptionalDateField(dues[i])
+			if err != nil {
+				return nil, err
+			}
+			dueDate = parsed
+		}
 		stages = append(stages, store.ProjectStageInput{
 			Title:     trimmed,
 			Weight:    weight,
 			IsDone:    isDone,
 			SortOrder: sortOrder,
+			DueDate:   dueDate,
 		})
 		sortOrder++
 	}
@@ -541,17 +674,26 @@ func (h *Handler) renderGoalWithError(w http.ResponseWriter, r *http.Request, go
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
-	goal.Progress = common.CalculateGoalProgress(goal)
-	page := struct {
-		Team            domain.Team
-		TeamTypeLabel   string
-		Goal            domain.Goal
-		Period          domain.Period
-		IsClosed        bool
-		FormError       string
-		PageTitle       string
-		ContentTemplate string
-	}{Team: team, TeamTypeLabel: common.TeamTypeLabel(team.Type), Goal: goal, Period: period, IsClosed: status == domain.TeamPeriodStatusClosed, FormError: message, PageTitle: "Цель", ContentTemplate: "goal-content"}
+	history, err := h.loadGoalHistory(r.Context(), goal)
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	checkIns, err := h.loadCheckIns(r.Context(), goal)
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	dependencies, err := h.loadDependencies(r.Context(), goal)
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	if goal.Progress, err = h.deps.Service.GoalProgress(r.Context(), &goal); err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	page := goalPage{Team: team, TeamTypeLabel: common.TeamTypeLabel(team.Type), Goal: goal, Period: period, IsClosed: status == domain.TeamPeriodStatusClosed, FormError: message, History: history, CheckIns: checkIns, Dependencies: dependencies, PageTitle: "Цель", ContentTemplate: "goal-content"}
 	common.RenderTemplate(w, h.deps.Templates, "base", page, h.deps.Logger)
 }
 
@@ -589,53 +731,9 @@ func (h *Handler) HandleUpdateGoalShare(w http.ResponseWriter, r *http.Request)
 		selectedSet[teamID] = struct{}{}
 		selectedIDs = append(selectedIDs, teamID)
 	}
-	sharesList, err := h.deps.Store.ListGoalShares(ctx, goalID)
+	ownerID, err := h.deps.Service.SetGoalTeams(ctx, goalID, selectedIDs)
 	if err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-	shareWeights := make(map[int64]int, len(sharesList))
-	for _, share := range sharesList {
-		shareWeights[share.TeamID] = share.Weight
-	}
-	ownerID := goal.TeamID
-	if _, ok := selectedSet[ownerID]; !ok {
-		ownerID = selectedIDs[0]
-	}
-	shares := make([]store.GoalShareInput, 0, len(selectedIDs))
-	for _, teamID := range selectedIDs {
-		status, err := h.deps.Store.GetTeamPeriodStatus(ctx, teamID, goal.PeriodID)
-		if err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
-		if status == domain.TeamPeriodStatusValidated || status == domain.TeamPeriodStatusClosed {
-			common.RenderError(w, h.deps.Logger, fmt.Errorf("Нельзя шарить цель с закрытым периодом"))
-			return
-		}
-		if teamID == ownerID {
-			ownerWeight := goal.Weight
-			if ownerID != goal.TeamID {
-				if existingWeight, ok := shareWeights[ownerID]; ok {
-					ownerWeight = existingWeight
-				} else {
-					ownerWeight = 0
-				}
-			}
-			if err := h.deps.Store.UpdateGoalOwner(ctx, goalID, ownerID, ownerWeight); err != nil {
-				common.RenderError(w, h.deps.Logger, err)
-				return
-			}
-			continue
-		}
-		weight := 0
-		if existingWeight, ok := shareWeights[teamID]; ok {
-			weight = existingWeight
-		}
-		shares = append(shares, store.GoalShareInput{TeamID: teamID, Weight: weight})
-	}
-	if err := h.deps.Store.ReplaceGoalShares(ctx, goalID, shares); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+		h.renderMutationError(w, r, goalID, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -645,6 +743,15 @@ func (h *Handler) HandleUpdateGoalShare(w http.ResponseWriter, r *http.Request)
 	http.Redirect(w, r, fmt.Sprintf("/teams/%d/okr?period_id=%d", ownerID, goal.PeriodID), http.StatusSeeOther)
 }
 
+// renderMutationError shows permission and lifecycle errors on the goal page and falls back to RenderError otherwise.
+func (h *Handler) renderMutationError(w http.ResponseWriter, r *http.Request, goalID int64, err error) {
+	if message, ok := common.PolicyMessage(err); ok {
+		h.renderGoalWithError(w, r, goalID, message)
+		return
+	}
+	common.RenderError(w, h.deps.Logger, err)
+}
+
 func parseOptionalTeamID(value string, fallback int64) int64 {
 	if value == "" {
 		return fallback
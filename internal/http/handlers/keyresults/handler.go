@@ -1,12 +1,15 @@
 package keyresults
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"okrs/internal/domain"
 	"okrs/internal/http/handlers/common"
+	"okrs/internal/service"
 	"okrs/internal/store"
 
 	"github.com/go-chi/chi/v5"
@@ -33,6 +36,10 @@ func (h *Handler) HandleAddStage(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	if err := h.deps.Service.CheckKeyResultMutation(ctx, krID, service.MutationStructural); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
+		return
+	}
 	weight := common.ParseIntField(r.FormValue("weight"))
 	sortOrder := common.ParseIntField(r.FormValue("sort_order"))
 	stages, err := h.deps.Store.ListProjectStages(ctx, krID)
@@ -44,10 +51,15 @@ func (h *Handler) HandleAddStage(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
-	if err := h.deps.Store.AddProjectStage(ctx, store.ProjectStageInput{KeyResultID: krID, Title: common.TrimmedFormValue(r, "title"), Weight: weight, SortOrder: sortOrder}); err != nil {
+	dueDate, err := common.ParseOptionalDateField(r.FormValue("due_date"))
+	if err != nil {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	if err := h.deps.Service.AddProjectStage(ctx, store.ProjectStageInput{KeyResultID: krID, Title: common.TrimmedFormValue(r, "title"), Weight: weight, SortOrder: sortOrder, DueDate: dueDate}); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
+		return
+	}
 	goalID, _ := common.FindGoalIDByKR(ctx, h.deps.Store, krID)
 	http.Redirect(w, r, formatGoalRedirect(goalID), http.StatusSeeOther)
 }
@@ -63,6 +75,10 @@ func (h *Handler) HandleUpdateKeyResult(w http.ResponseWriter, r *http.Request)
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	if err := h.deps.Service.CheckKeyResultMutation(ctx, krID, service.MutationStructural); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
+		return
+	}
 	kind := r.FormValue("kind")
 	weight := common.ParseIntField(r.FormValue("weight"))
 	if weight < 0 || weight > 100 {
@@ -74,46 +90,33 @@ func (h *Handler) HandleUpdateKeyResult(w http.ResponseWriter, r *http.Request)
 		common.RenderError(w, h.deps.Logger, fmt.Errorf("Неверный тип KR"))
 		return
 	}
-	if err := h.deps.Store.UpdateKeyResult(ctx, store.KeyResultUpdateInput{
-		ID:          krID,
-		Title:       common.TrimmedFormValue(r, "title"),
-		Description: common.TrimmedFormValue(r, "description"),
-		Weight:      weight,
-		Kind:        krKind,
-	}); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-
+	var meta service.KeyResultMetaInput
 	switch krKind {
 	case domain.KRKindPercent:
-		start := common.ParseFloatField(r.FormValue("percent_start"))
-		target := common.ParseFloatField(r.FormValue("percent_target"))
-		current := common.ParseFloatField(r.FormValue("percent_current"))
-		if start == target {
+		meta.PercentStart = common.ParseFloatField(r.FormValue("percent_start"))
+		meta.PercentTarget = common.ParseFloatField(r.FormValue("percent_target"))
+		meta.PercentCurrent = common.ParseFloatField(r.FormValue("percent_current"))
+		if meta.PercentStart == meta.PercentTarget {
 			common.RenderError(w, h.deps.Logger, fmt.Errorf("Start и Target не должны быть равны"))
 			return
 		}
-		if err := h.deps.Store.UpsertPercentMeta(ctx, store.PercentMetaInput{KeyResultID: krID, StartValue: start, TargetValue: target, CurrentValue: current}); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
 	case domain.KRKindLinear:
-		start := common.ParseFloatField(r.FormValue("linear_start"))
-		target := common.ParseFloatField(r.FormValue("linear_target"))
-		current := common.ParseFloatField(r.FormValue("linear_current"))
-		if start == target {
+		meta.LinearStart = common.ParseFloatField(r.FormValue("linear_start"))
+		meta.LinearTarget = common.ParseFloatField(r.FormValue("linear_target"))
+		meta.LinearCurrent = common.ParseFloatField(r.FormValue("linear_current"))
+		if meta.LinearStart == meta.LinearTarget {
 			common.RenderError(w, h.deps.Logger, fmt.Errorf("Start и Target не должны быть равны"))
 			return
 		}
-		if err := h.deps.Store.UpsertLinearMeta(ctx, store.LinearMetaInput{KeyResultID: krID, StartValue: start, TargetValue: target, CurrentValue: current}); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
 	case domain.KRKindBoolean:
-		done := r.FormValue("boolean_done") == "true"
-		if err := h.deps.Store.UpsertBooleanMeta(ctx, krID, done); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
+		meta.BooleanDone = r.FormValue("boolean_done") == "true"
+	case domain.KRKindRange:
+		meta.RangeMin = common.ParseOptionalFloatField(r.FormValue("range_min"))
+		meta.RangeMax = common.ParseOptionalFloatField(r.FormValue("range_max"))
+		meta.RangeTolerance = common.ParseFloatField(r.FormValue("range_tolerance"))
+		meta.RangeCurrent = common.ParseFloatField(r.FormValue("range_current"))
+		if msg := common.ValidateRangeMeta(meta.RangeMin, meta.RangeMax, meta.RangeTolerance); msg != "" {
+			common.RenderError(w, h.deps.Logger, fmt.Errorf("%s", msg))
 			return
 		}
 	case domain.KRKindProject:
@@ -122,15 +125,22 @@ func (h *Handler) HandleUpdateKeyResult(w http.ResponseWriter, r *http.Request)
 			common.RenderError(w, h.deps.Logger, err)
 			return
 		}
-		for i := range stages {
-			stages[i].KeyResultID = krID
-		}
-		if err := h.deps.Store.ReplaceProjectStages(ctx, krID, stages); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
+		meta.ProjectStages = stages
+	}
+	if err := h.deps.Service.UpdateKeyResultWithMeta(ctx, store.KeyResultUpdateInput{
+		ID:          krID,
+		Title:       common.TrimmedFormValue(r, "title"),
+		Description: common.TrimmedFormValue(r, "description"),
+		Weight:      weight,
+		Kind:        krKind,
+	}, meta); err != nil {
+		if errors.Is(err, service.ErrInvalidCheckpoint) {
+			http.Error(w, "Некорректная контрольная точка: значения должны лежать между Start и Target, а проценты не убывать", http.StatusBadRequest)
 			return
 		}
+		common.RenderMutationError(w, h.deps.Logger, err)
+		return
 	}
-
 	if returnURL := r.FormValue("return"); returnURL != "" {
 		http.Redirect(w, r, returnURL, http.StatusSeeOther)
 		return
@@ -150,17 +160,30 @@ func (h *Handler) HandleToggleStage(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
-	done := r.FormValue("done") == "true"
-	if err := h.deps.Store.UpdateProjectStageDone(ctx, stageID, done); err != nil {
+	stageGoalID, err := common.FindGoalIDByStage(ctx, h.deps.Store, stageID)
+	if err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	if err := h.deps.Service.CheckGoalMutation(ctx, stageGoalID, service.MutationProgress); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
+		return
+	}
+	krID, err := common.FindKeyResultIDByStage(ctx, h.deps.Store, stageID)
+	if err != nil {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	done := r.FormValue("done") == "true"
+	if err := h.deps.Service.UpdateKRProgressProject(ctx, krID, []service.ProjectStageUpdate{{ID: stageID, IsDone: done}}); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
+		return
+	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
 		http.Redirect(w, r, returnURL, http.StatusSeeOther)
 		return
 	}
-	goalID, _ := common.FindGoalIDByStage(ctx, h.deps.Store, stageID)
-	http.Redirect(w, r, formatGoalRedirect(goalID), http.StatusSeeOther)
+	http.Redirect(w, r, formatGoalRedirect(stageGoalID), http.StatusSeeOther)
 }
 
 func (h *Handler) HandleMoveKeyResultUp(w http.ResponseWriter, r *http.Request) {
@@ -182,8 +205,8 @@ func (h *Handler) handleMoveKeyResult(w http.ResponseWriter, r *http.Request, di
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
-	if err := h.deps.Store.MoveKeyResult(ctx, krID, direction); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.MoveKeyResult(ctx, krID, direction); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -206,8 +229,8 @@ func (h *Handler) HandleUpdatePercentCurrent(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	current := common.ParseFloatField(r.FormValue("current"))
-	if err := h.deps.Store.UpdatePercentCurrent(ctx, krID, current); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.UpdateKRProgressPercent(ctx, krID, current); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -230,8 +253,8 @@ func (h *Handler) HandleUpdateLinearCurrent(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	current := common.ParseFloatField(r.FormValue("current"))
-	if err := h.deps.Store.UpdateLinearCurrent(ctx, krID, current); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.UpdateKRProgressPercent(ctx, krID, current); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -259,8 +282,12 @@ func (h *Handler) HandleAddCheckpoint(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, errInvalidPercent())
 		return
 	}
-	if err := h.deps.Store.AddPercentCheckpoint(ctx, store.PercentCheckpointInput{KeyResultID: krID, MetricValue: metricValue, KRPercent: krPercent}); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if _, err := h.deps.Service.AddPercentCheckpoint(ctx, krID, service.CheckpointInput{MetricValue: metricValue, KRPercent: krPercent}); err != nil {
+		if errors.Is(err, service.ErrInvalidCheckpoint) {
+			http.Error(w, "Некорректная контрольная точка: значения должны лежать между Start и Target, а проценты не убывать", http.StatusBadRequest)
+			return
+		}
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -283,8 +310,8 @@ func (h *Handler) HandleUpdateBoolean(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	done := r.FormValue("done") == "true"
-	if err := h.deps.Store.UpsertBooleanMeta(ctx, krID, done); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.UpdateKRProgressBoolean(ctx, krID, done); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -311,17 +338,18 @@ func (h *Handler) HandleUpdateProjectStages(w http.ResponseWriter, r *http.Reque
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	updates := make([]service.ProjectStageUpdate, 0, len(stages))
 	for _, stage := range stages {
 		field := fmt.Sprintf("stage_done_%d", stage.ID)
 		value := r.FormValue(field)
 		if value == "" {
 			continue
 		}
-		done := value == "true"
-		if err := h.deps.Store.UpdateProjectStageDone(ctx, stage.ID, done); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
-			return
-		}
+		updates = append(updates, service.ProjectStageUpdate{ID: stage.ID, IsDone: value == "true"})
+	}
+	if err := h.deps.Service.UpdateKRProgressProject(ctx, krID, updates); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
+		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
 		http.Redirect(w, r, returnURL, http.StatusSeeOther)
@@ -342,10 +370,14 @@ func (h *Handler) HandleAddKRComment(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
+	if err := h.deps.Service.CheckKeyResultMutation(ctx, krID, service.MutationComment); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
+		return
+	}
 	text := common.TrimmedFormValue(r, "text")
 	if text != "" {
-		if err := h.deps.Store.AddKeyResultComment(ctx, krID, text); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
+		if err := h.deps.Service.AddKeyResultComment(ctx, krID, text); err != nil {
+			common.RenderMutationError(w, h.deps.Logger, err)
 			return
 		}
 	}
@@ -369,8 +401,8 @@ func (h *Handler) HandleDeleteKeyResult(w http.ResponseWriter, r *http.Request)
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
-	if err := h.deps.Store.DeleteKeyResult(ctx, krID); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.DeleteKeyResult(ctx, krID); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	if returnURL := r.FormValue("return"); returnURL != "" {
@@ -389,6 +421,7 @@ func parseProjectStages(r *http.Request) ([]store.ProjectStageInput, error) {
 	titles := r.Form["step_title[]"]
 	weights := r.Form["step_weight[]"]
 	dones := r.Form["step_done[]"]
+	dues := r.Form["step_due[]"]
 	sortOrder := 1
 
 	for i, title := range titles {
@@ -408,11 +441,20 @@ func parseProjectStages(r *http.Request) ([]store.ProjectStageInput, error) {
 		if i < len(dones) {
 			isDone = dones[i] == "true"
 		}
+		var dueDate *time.Time
+		if i < len(dues) {
+			parsed, err := common.ParseOptionalDateField(dues[i])
+			if err != nil {
+				return nil, err
+			}
+			dueDate = parsed
+		}
 		stages = append(stages, store.ProjectStageInput{
 			Title:     trimmed,
 			Weight:    weight,
 			IsDone:    isDone,
 			SortOrder: sortOrder,
+			DueDate:   dueDate,
 		})
 		sortOrder++
 	}
@@ -93,12 +93,12 @@ func (h *Handler) HandleCreatePeriod(w http.ResponseWriter, r *http.Request) {
 		h.renderPeriodsWithError(w, r, "Дата окончания должна быть позже даты начала")
 		return
 	}
-	if _, err := h.deps.Store.CreatePeriod(r.Context(), store.PeriodInput{
+	if _, err := h.deps.Service.CreatePeriod(r.Context(), store.PeriodInput{
 		Name:      name,
 		StartDate: startDate,
 		EndDate:   endDate,
 	}); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	http.Redirect(w, r, "/periods", http.StatusSeeOther)
@@ -135,12 +135,12 @@ func (h *Handler) HandleUpdatePeriod(w http.ResponseWriter, r *http.Request) {
 		h.renderPeriodEditWithError(w, r, periodID, "Дата окончания должна быть позже даты начала")
 		return
 	}
-	if err := h.deps.Store.UpdatePeriod(r.Context(), periodID, store.PeriodInput{
+	if err := h.deps.Service.UpdatePeriod(r.Context(), periodID, store.PeriodInput{
 		Name:      name,
 		StartDate: startDate,
 		EndDate:   endDate,
 	}); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	http.Redirect(w, r, "/periods", http.StatusSeeOther)
@@ -152,8 +152,8 @@ func (h *Handler) HandleDeletePeriod(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
-	if err := h.deps.Store.DeletePeriod(r.Context(), periodID); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.DeletePeriod(r.Context(), periodID); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	http.Redirect(w, r, "/periods", http.StatusSeeOther)
@@ -173,8 +173,8 @@ func (h *Handler) handleMove(w http.ResponseWriter, r *http.Request, direction i
 		common.RenderError(w, h.deps.Logger, fmt.Errorf("invalid period id"))
 		return
 	}
-	if err := h.deps.Store.MovePeriod(r.Context(), periodID, direction); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.MovePeriod(r.Context(), periodID, direction); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	http.Redirect(w, r, "/periods", http.StatusSeeOther)
@@ -0,0 +1,68 @@
+package sessions
+
+import (
+	"errors"
+	"net/http"
+
+	"okrs/internal/auth"
+	"okrs/internal/http/handlers/common"
+)
+
+type Handler struct {
+	deps common.Dependencies
+}
+
+func New(deps common.Dependencies) *Handler {
+	return &Handler{deps: deps}
+}
+
+type loginPage struct {
+	Email     string
+	Next      string
+	FormError string
+	PageTitle string
+}
+
+const defaultRedirect = "/teamOkrs"
+
+func (h *Handler) HandleLoginPage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auth.UserFromContext(r.Context()); ok {
+		http.Redirect(w, r, auth.SafeRedirect(r.URL.Query().Get("next"), defaultRedirect), http.StatusSeeOther)
+		return
+	}
+	page := loginPage{Next: r.URL.Query().Get("next"), PageTitle: "Вход"}
+	common.RenderTemplate(w, h.deps.Templates, "login", page, h.deps.Logger)
+}
+
+func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	email := common.TrimmedFormValue(r, "email")
+	next := r.FormValue("next")
+	token, expiresAt, err := h.deps.Auth.Login(r.Context(), email, r.FormValue("password"))
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			w.WriteHeader(http.StatusUnauthorized)
+			page := loginPage{Email: email, Next: next, FormError: "Неверный email или пароль", PageTitle: "Вход"}
+			common.RenderTemplate(w, h.deps.Templates, "login", page, h.deps.Logger)
+			return
+		}
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	auth.SetSessionCookie(w, r, token, expiresAt)
+	http.Redirect(w, r, auth.SafeRedirect(next, defaultRedirect), http.StatusSeeOther)
+}
+
+func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(auth.SessionCookieName); err == nil {
+		if err := h.deps.Auth.Logout(r.Context(), cookie.Value); err != nil {
+			common.RenderError(w, h.deps.Logger, err)
+			return
+		}
+	}
+	auth.ClearSessionCookie(w, r)
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
@@ -2,6 +2,7 @@ package teams
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -12,6 +13,7 @@ import (
 	"okrs/internal/domain"
 	"okrs/internal/http/handlers/common"
 	"okrs/internal/okr"
+	"okrs/internal/service"
 	"okrs/internal/store"
 
 	"github.com/go-chi/chi/v5"
@@ -118,6 +120,7 @@ type teamFormPage struct {
 	TeamName        string
 	TeamLead        string
 	TeamDescription string
+	TeamRollup      string
 	TeamTypes       []teamTypeOption
 	ParentTeams     []teamParentOption
 }
@@ -271,6 +274,7 @@ func (h *Handler) HandleCreateTeam(w http.ResponseWriter, r *http.Request) {
 	parentID, err := parseOptionalID(r.FormValue("parent_id"))
 	lead := common.TrimmedFormValue(r, "lead")
 	description := common.TrimmedFormValue(r, "description")
+	rollupWeight := common.TrimmedFormValue(r, "rollup_weight")
 	if err != nil {
 		common.RenderError(w, h.deps.Logger, err)
 		return
@@ -283,39 +287,62 @@ func (h *Handler) HandleCreateTeam(w http.ResponseWriter, r *http.Request) {
 
 	if name == "" {
 		h.renderTeamForm(w, r, teamFormValues{
-			Message:     "Название команды обязательно",
-			Name:        name,
-			Type:        teamType,
-			ParentID:    parentID,
-			Lead:        lead,
-			Description: description,
+			Message:      "Название команды обязательно",
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
 		}, teams, 0, false)
 		return
 	}
 	if !common.ValidTeamType(teamType) {
 		h.renderTeamForm(w, r, teamFormValues{
-			Message:     "Неверный тип команды",
-			Name:        name,
-			Type:        teamType,
-			ParentID:    parentID,
-			Lead:        lead,
-			Description: description,
+			Message:      "Неверный тип команды",
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
+		}, teams, 0, false)
+		return
+	}
+	weight, err := parseRollupWeight(rollupWeight)
+	if err != nil {
+		h.renderTeamForm(w, r, teamFormValues{
+			Message:      "Вес в агрегации должен быть числом от 0 до 100",
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
 		}, teams, 0, false)
 		return
 	}
 	if parentID != nil && !teamExists(teams, *parentID) {
 		h.renderTeamForm(w, r, teamFormValues{
-			Message:     "Выбранная родительская команда не найдена",
-			Name:        name,
-			Type:        teamType,
-			ParentID:    parentID,
-			Lead:        lead,
-			Description: description,
+			Message:      "Выбранная родительская команда не найдена",
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
 		}, teams, 0, false)
 		return
 	}
-	if _, err := h.deps.Store.CreateTeam(ctx, store.TeamInput{Name: name, Type: teamType, ParentID: parentID, Lead: lead, Description: description}); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if _, err := h.deps.Service.CreateTeam(ctx, store.TeamInput{Name: name, Type: teamType, ParentID: parentID, Lead: lead, Description: description, RollupWeight: weight}); err != nil {
+		h.renderTeamAuthorizationError(w, r, err, teamFormValues{
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
+		}, teams, 0, false)
 		return
 	}
 	http.Redirect(w, r, "/teams", http.StatusSeeOther)
@@ -353,11 +380,12 @@ func (h *Handler) HandleEditTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.renderTeamForm(w, r, teamFormValues{
-		Name:        team.Name,
-		Type:        team.Type,
-		ParentID:    team.ParentID,
-		Lead:        team.Lead,
-		Description: team.Description,
+		Name:         team.Name,
+		Type:         team.Type,
+		ParentID:     team.ParentID,
+		Lead:         team.Lead,
+		Description:  team.Description,
+		RollupWeight: formatRollupWeight(team.RollupWeight),
 	}, teams, teamID, true)
 }
 
@@ -377,6 +405,7 @@ func (h *Handler) HandleUpdateTeam(w http.ResponseWriter, r *http.Request) {
 	parentID, err := parseOptionalID(r.FormValue("parent_id"))
 	lead := common.TrimmedFormValue(r, "lead")
 	description := common.TrimmedFormValue(r, "description")
+	rollupWeight := common.TrimmedFormValue(r, "rollup_weight")
 	if err != nil {
 		common.RenderError(w, h.deps.Logger, err)
 		return
@@ -389,76 +418,112 @@ func (h *Handler) HandleUpdateTeam(w http.ResponseWriter, r *http.Request) {
 
 	if name == "" {
 		h.renderTeamForm(w, r, teamFormValues{
-			Message:     "Название команды обязательно",
-			Name:        name,
-			Type:        teamType,
-			ParentID:    parentID,
-			Lead:        lead,
-			Description: description,
+			Message:      "Название команды обязательно",
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
 		}, teams, teamID, true)
 		return
 	}
 	if !common.ValidTeamType(teamType) {
 		h.renderTeamForm(w, r, teamFormValues{
-			Message:     "Неверный тип команды",
-			Name:        name,
-			Type:        teamType,
-			ParentID:    parentID,
-			Lead:        lead,
-			Description: description,
+			Message:      "Неверный тип команды",
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
+		}, teams, teamID, true)
+		return
+	}
+	weight, err := parseRollupWeight(rollupWeight)
+	if err != nil {
+		h.renderTeamForm(w, r, teamFormValues{
+			Message:      "Вес в агрегации должен быть числом от 0 до 100",
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
 		}, teams, teamID, true)
 		return
 	}
 	if parentID != nil {
 		if *parentID == teamID {
 			h.renderTeamForm(w, r, teamFormValues{
-				Message:     "Команда не может быть родителем самой себя",
-				Name:        name,
-				Type:        teamType,
-				ParentID:    parentID,
-				Lead:        lead,
-				Description: description,
+				Message:      "Команда не может быть родителем самой себя",
+				Name:         name,
+				Type:         teamType,
+				ParentID:     parentID,
+				Lead:         lead,
+				Description:  description,
+				RollupWeight: rollupWeight,
 			}, teams, teamID, true)
 			return
 		}
 		if !teamExists(teams, *parentID) {
 			h.renderTeamForm(w, r, teamFormValues{
-				Message:     "Выбранная родительская команда не найдена",
-				Name:        name,
-				Type:        teamType,
-				ParentID:    parentID,
-				Lead:        lead,
-				Description: description,
+				Message:      "Выбранная родительская команда не найдена",
+				Name:         name,
+				Type:         teamType,
+				ParentID:     parentID,
+				Lead:         lead,
+				Description:  description,
+				RollupWeight: rollupWeight,
 			}, teams, teamID, true)
 			return
 		}
 		descendants := collectDescendants(teams, teamID)
 		if descendants[*parentID] {
 			h.renderTeamForm(w, r, teamFormValues{
-				Message:     "Нельзя привязать команду к её дочерней команде",
-				Name:        name,
-				Type:        teamType,
-				ParentID:    parentID,
-				Lead:        lead,
-				Description: description,
+				Message:      "Нельзя привязать команду к её дочерней команде",
+				Name:         name,
+				Type:         teamType,
+				ParentID:     parentID,
+				Lead:         lead,
+				Description:  description,
+				RollupWeight: rollupWeight,
 			}, teams, teamID, true)
 			return
 		}
 	}
-	if err := h.deps.Store.UpdateTeam(ctx, store.TeamInput{Name: name, Type: teamType, ParentID: parentID, Lead: lead, Description: description}, teamID); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.UpdateTeam(ctx, teamID, store.TeamInput{Name: name, Type: teamType, ParentID: parentID, Lead: lead, Description: description, RollupWeight: weight}); err != nil {
+		h.renderTeamAuthorizationError(w, r, err, teamFormValues{
+			Name:         name,
+			Type:         teamType,
+			ParentID:     parentID,
+			Lead:         lead,
+			Description:  description,
+			RollupWeight: rollupWeight,
+		}, teams, teamID, true)
 		return
 	}
 	http.Redirect(w, r, "/teams", http.StatusSeeOther)
 }
 
+// renderTeamAuthorizationError shows missing permissions on the team form and falls back to RenderError otherwise.
+func (h *Handler) renderTeamAuthorizationError(w http.ResponseWriter, r *http.Request, err error, values teamFormValues, teams []domain.Team, teamID int64, isEdit bool) {
+	if !errors.Is(err, service.ErrForbidden) {
+		common.RenderError(w, h.deps.Logger, err)
+		return
+	}
+	values.Message = common.ForbiddenMessage
+	h.renderTeamForm(w, r, values, teams, teamID, isEdit)
+}
+
 type teamFormValues struct {
-	Message     string
-	Name        string
-	Type        domain.TeamType
-	ParentID    *int64
-	Lead        string
-	Description string
+	Message      string
+	Name         string
+	Type         domain.TeamType
+	ParentID     *int64
+	Lead         string
+	Description  string
+	RollupWeight string
 }
 
 func (h *Handler) renderTeamForm(w http.ResponseWriter, r *http.Request, values teamFormValues, teams []domain.Team, teamID int64, isEdit bool) {
@@ -475,6 +540,7 @@ func (h *Handler) renderTeamForm(w http.ResponseWriter, r *http.Request, values
 		TeamName:        values.Name,
 		TeamLead:        values.Lead,
 		TeamDescription: values.Description,
+		TeamRollup:      values.RollupWeight,
 		TeamTypes:       types,
 		ParentTeams:     parentOptions,
 	}
@@ -502,7 +568,15 @@ func (h *Handler) HandleUpdateTeamPeriodStatus(w http.ResponseWriter, r *http.Re
 		common.RenderError(w, h.deps.Logger, fmt.Errorf("invalid team period status"))
 		return
 	}
-	if err := h.deps.Store.SetTeamPeriodStatus(ctx, teamID, periodID, status); err != nil {
+	if err := h.deps.Service.UpdateTeamPeriodStatus(ctx, teamID, periodID, status); err != nil {
+		if errors.Is(err, service.ErrInvalidStatusTransition) {
+			h.renderTeamOKRWithError(w, r, teamID, periodID, "Недопустимый переход статуса периода")
+			return
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			h.renderTeamOKRWithError(w, r, teamID, periodID, common.ForbiddenMessage)
+			return
+		}
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
@@ -516,8 +590,8 @@ func (h *Handler) HandleDeleteTeam(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
-	if err := h.deps.Store.DeleteTeam(ctx, teamID); err != nil {
-		common.RenderError(w, h.deps.Logger, err)
+	if err := h.deps.Service.DeleteTeam(ctx, teamID); err != nil {
+		common.RenderMutationError(w, h.deps.Logger, err)
 		return
 	}
 	http.Redirect(w, r, "/teams", http.StatusSeeOther)
@@ -570,15 +644,14 @@ func (h *Handler) HandleCreateGoal(w http.ResponseWriter, r *http.Request) {
 		common.RenderError(w, h.deps.Logger, fmt.Errorf("invalid period id"))
 		return
 	}
-	status, err := h.deps.Store.GetTeamPeriodStatus(ctx, teamID, periodID)
-	if err != nil {
+	if err := h.deps.Service.CheckTeamPeriodMutation(ctx, teamID, periodID, service.MutationStructural); err != nil {
+		if message, ok := common.PolicyMessage(err); ok {
+			h.renderTeamOKRWithError(w, r, teamID, periodID, message)
+			return
+		}
 		common.RenderError(w, h.deps.Logger, err)
 		return
 	}
-	if status == domain.TeamPeriodStatusClosed {
-		h.renderTeamOKRWithError(w, r, teamID, periodID, "Период закрыт, изменения недоступны")
-		return
-	}
 	weight := common.ParseIntField(r.FormValue("weight"))
 	priority := domain.Priority(r.FormValue("priority"))
 	workType := domain.WorkType(r.FormValue("work_type"))
@@ -590,7 +663,7 @@ func (h *Handler) HandleCreateGoal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.deps.Store.CreateGoal(ctx, store.GoalInput{
+	if _, err := h.deps.Service.CreateGoal(ctx, store.GoalInput{
 		TeamID:      teamID,
 		PeriodID:    periodID,
 		Title:       common.TrimmedFormValue(r, "title"),
@@ -600,16 +673,13 @@ func (h *Handler) HandleCreateGoal(w http.ResponseWriter, r *http.Request) {
 		WorkType:    workType,
 		FocusType:   focusType,
 		OwnerText:   common.TrimmedFormValue(r, "owner_text"),
-	})
-	if err != nil {
-		common.RenderError(w, h.deps.Logger, err)
-		return
-	}
-	if status == domain.TeamPeriodStatusNoGoals {
-		if err := h.deps.Store.SetTeamPeriodStatus(ctx, teamID, periodID, domain.TeamPeriodStatusForming); err != nil {
-			common.RenderError(w, h.deps.Logger, err)
+	}); err != nil {
+		if message, ok := common.PolicyMessage(err); ok {
+			h.renderTeamOKRWithError(w, r, teamID, periodID, message)
 			return
 		}
+		common.RenderError(w, h.deps.Logger, err)
+		return
 	}
 
 	http.Redirect(w, r, fmt.Sprintf("/teams/%d/okr?period_id=%d", teamID, periodID), http.StatusSeeOther)
@@ -668,7 +738,10 @@ func (h *Handler) renderTeamOKRWithError(w http.ResponseWriter, r *http.Request,
 	}
 	var totalWeight int
 	for i := range goals {
-		goals[i].Progress = common.CalculateGoalProgress(goals[i])
+		if goals[i].Progress, err = h.deps.Service.GoalProgress(r.Context(), &goals[i]); err != nil {
+			common.RenderError(w, h.deps.Logger, err)
+			return
+		}
 		totalWeight += goals[i].Weight
 	}
 	page := teamOKRPage{
@@ -704,7 +777,9 @@ func (h *Handler) appendTeamRows(ctx context.Context, rows *[]teamRow, team doma
 	}
 	goalRows := make([]teamGoalRow, 0, len(goals))
 	for i := range goals {
-		goals[i].Progress = common.CalculateGoalProgress(goals[i])
+		if goals[i].Progress, err = h.deps.Service.GoalProgress(ctx, &goals[i]); err != nil {
+			return err
+		}
 		shareTeams, err := h.buildGoalShareTeams(ctx, goals[i], teamsByID)
 		if err != nil {
 			return err
@@ -972,6 +1047,28 @@ func parseOptionalID(value string) (*int64, error) {
 	return &parsed, nil
 }
 
+// parseRollupWeight parses the optional aggregation weight of a team, 0..100; empty means the goal weight sum.
+func parseRollupWeight(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	weight, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	if weight < 0 || weight > 100 {
+		return nil, fmt.Errorf("rollup weight out of range")
+	}
+	return &weight, nil
+}
+
+func formatRollupWeight(weight *int) string {
+	if weight == nil {
+		return ""
+	}
+	return strconv.Itoa(*weight)
+}
+
 func teamExists(teams []domain.Team, id int64) bool {
 	for _, team := range teams {
 		if team.ID == id {
@@ -11,13 +11,16 @@ import (
 	"time"
 
 	apiv1 "okrs/internal/api/v1"
+	"okrs/internal/auth"
 	"okrs/internal/domain"
 	"okrs/internal/http/handlers/api"
 	"okrs/internal/http/handlers/common"
 	"okrs/internal/http/handlers/goals"
 	"okrs/internal/http/handlers/keyresults"
 	"okrs/internal/http/handlers/periods"
+	"okrs/internal/http/handlers/sessions"
 	"okrs/internal/http/handlers/teams"
+	"okrs/internal/okr"
 	"okrs/internal/service"
 	"okrs/internal/store"
 
@@ -33,9 +36,11 @@ type Server struct {
 	tmpl    *template.Template
 	zone    *time.Location
 	service *service.Service
+	auth    *auth.Authenticator
 }
 
-func NewServer(store *store.Store, logger *slog.Logger, zone *time.Location) (*Server, error) {
+func NewServer(store *store.Store, authenticator *auth.Authenticator, logger *slog.Logger, zone *time.Location, health okr.HealthThresholds, ingestSecret string) (*Server, error) {
+	svc := service.New(store).WithLocation(zone).WithHealthThresholds(health).WithIngestSecret(ingestSecret)
 	tmpl, err := template.New("").Funcs(template.FuncMap{
 		"sumKRWeights": func(keyResults []domain.KeyResult) int {
 			total := 0
@@ -103,28 +108,10 @@ func NewServer(store *store.Store, logger *slog.Logger, zone *time.Location) (*S
 			return t.Format("02.01.2006 15:04")
 		},
 		"goalStatusLabel": func(goal domain.Goal, period domain.Period) string {
-			if len(goal.KeyResults) == 0 {
-				return "Нет данных"
-			}
-			totalWeight := 0
-			latestUpdate := goal.UpdatedAt
-			for _, kr := range goal.KeyResults {
-				totalWeight += kr.Weight
-				if kr.UpdatedAt.After(latestUpdate) {
-					latestUpdate = kr.UpdatedAt
-				}
-			}
-			if totalWeight == 0 {
-				return "Нет данных"
-			}
-			start, end := periodBounds(period, zone)
-			planned := plannedProgress(nowInZone(zone), start, end)
-			status := progressToStatus(goal.Progress, planned)
-			if time.Since(latestUpdate) > 21*24*time.Hour && status == "В норме" {
-				return "Риск"
-			}
-			return status
+			return common.HealthLabel(svc.GoalHealth(goal, period))
 		},
+		"healthLabel": common.HealthLabel,
+		"rangeBounds": common.RangeBoundsLabel,
 		"goalStatusClass": func(label string) string {
 			switch strings.ToLower(label) {
 			case strings.ToLower("В норме"):
@@ -137,10 +124,7 @@ func NewServer(store *store.Store, logger *slog.Logger, zone *time.Location) (*S
 				return "text-bg-secondary"
 			}
 		},
-		"plannedProgress": func(period domain.Period) int {
-			start, end := periodBounds(period, zone)
-			return plannedProgress(nowInZone(zone), start, end)
-		},
+		"plannedProgress": svc.PlannedProgress,
 		"krContribution": func(weight, progress, totalWeight int) float64 {
 			if totalWeight == 0 {
 				return 0
@@ -159,6 +143,11 @@ func NewServer(store *store.Store, logger *slog.Logger, zone *time.Location) (*S
 					return fmt.Sprintf("Число: Start %.2f → Target %.2f (текущее %.2f)", kr.Linear.StartValue, kr.Linear.TargetValue, kr.Linear.CurrentValue)
 				}
 				return "Число: Start → Target"
+			case domain.KRKindRange:
+				if kr.Range != nil {
+					return fmt.Sprintf("Диапазон: %s (текущее %.2f, допуск %.2f)", common.RangeBoundsLabel(kr.Range.MinValue, kr.Range.MaxValue), kr.Range.CurrentValue, kr.Range.Tolerance)
+				}
+				return "Диапазон: границы не заданы"
 			case domain.KRKindBoolean:
 				if kr.Boolean != nil {
 					if kr.Boolean.IsDone {
@@ -180,72 +169,87 @@ func NewServer(store *store.Store, logger *slog.Logger, zone *time.Location) (*S
 	if err != nil {
 		return nil, err
 	}
-	return &Server{store: store, logger: logger, tmpl: tmpl, zone: zone, service: service.New(store)}, nil
+	return &Server{store: store, logger: logger, tmpl: tmpl, zone: zone, service: svc, auth: authenticator}, nil
 }
 
 func (s *Server) Routes() http.Handler {
-	deps := common.Dependencies{Store: s.store, Service: s.service, Logger: s.logger, Templates: s.tmpl, Zone: s.zone}
+	deps := common.Dependencies{Store: s.store, Service: s.service, Auth: s.auth, Logger: s.logger, Templates: s.tmpl, Zone: s.zone}
 	teamsHandler := teams.New(deps)
 	goalsHandler := goals.New(deps)
 	krHandler := keyresults.New(deps)
 	apiHandler := api.New(deps)
 	periodsHandler := periods.New(deps)
+	sessionsHandler := sessions.New(deps)
 	apiV1Handler := apiv1.NewHandler(s.service)
 
 	r := chi.NewRouter()
+	r.Use(s.auth.Middleware)
 
 	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("internal/web/static"))))
+	r.Get("/login", sessionsHandler.HandleLoginPage)
+	r.Post("/login", sessionsHandler.HandleLogin)
+	r.Post("/logout", sessionsHandler.HandleLogout)
 
-	r.Get("/teams", teamsHandler.HandleTeamManagement)
-	r.Get("/teamOkrs", teamsHandler.HandleTeamOKRs)
-	r.Get("/teams/new", teamsHandler.HandleNewTeam)
-	r.Post("/teams", teamsHandler.HandleCreateTeam)
-	r.Get("/teams/{teamID}/edit", teamsHandler.HandleEditTeam)
-	r.Post("/teams/{teamID}/update", teamsHandler.HandleUpdateTeam)
-	r.Post("/teams/{teamID}/delete", teamsHandler.HandleDeleteTeam)
-	r.Get("/teams/{teamID}/okr", teamsHandler.HandleTeamOKR)
-	r.Post("/teams/{teamID}/okr", teamsHandler.HandleCreateGoal)
-	r.Post("/teams/{teamID}/okr/status", teamsHandler.HandleUpdateTeamPeriodStatus)
+	r.Route("/api", func(r chi.Router) {
+		// Ingest requests are authenticated by their HMAC signature instead of a user.
+		r.Mount("/v1/ingest", apiV1Handler.IngestRoutes())
+		// The v1 router authenticates on its own so it can accept API tokens.
+		r.Mount("/v1", apiV1Handler.Routes())
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireUser(apiv1.WriteUnauthorized))
+			r.Get("/teams", apiHandler.HandleAPITeams)
+			r.Get("/teams/{teamID}/goals", apiHandler.HandleAPITeamGoals)
+			r.Get("/goals/{goalID}", apiHandler.HandleAPIGoal)
+		})
+	})
 
-	r.Get("/periods", periodsHandler.HandlePeriods)
-	r.Post("/periods", periodsHandler.HandleCreatePeriod)
-	r.Get("/periods/{periodID}/edit", periodsHandler.HandleEditPeriod)
-	r.Post("/periods/{periodID}/update", periodsHandler.HandleUpdatePeriod)
-	r.Post("/periods/{periodID}/delete", periodsHandler.HandleDeletePeriod)
-	r.Post("/periods/{periodID}/move-up", periodsHandler.HandleMovePeriodUp)
-	r.Post("/periods/{periodID}/move-down", periodsHandler.HandleMovePeriodDown)
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireUser(auth.RedirectToLogin))
 
-	r.Get("/goals/{goalID}", goalsHandler.HandleGoalDetail)
-	r.Post("/goals/{goalID}/comments", goalsHandler.HandleAddGoalComment)
-	r.Post("/goals/{goalID}/key-results", goalsHandler.HandleAddKeyResult)
-	r.Post("/goals/{goalID}/key-results/weights", goalsHandler.HandleUpdateKeyResultWeights)
-	r.Post("/goals/{goalID}/move-up", goalsHandler.HandleMoveGoalUp)
-	r.Post("/goals/{goalID}/move-down", goalsHandler.HandleMoveGoalDown)
-	r.Post("/goals/{goalID}/delete", goalsHandler.HandleDeleteGoal)
-	r.Post("/goals/{goalID}/update", goalsHandler.HandleUpdateGoal)
-	r.Post("/goals/{goalID}/share", goalsHandler.HandleUpdateGoalShare)
-	r.Get("/goals/period", goalsHandler.HandlePeriodGoals)
+		r.Get("/teams", teamsHandler.HandleTeamManagement)
+		r.Get("/teamOkrs", teamsHandler.HandleTeamOKRs)
+		r.Get("/teams/new", teamsHandler.HandleNewTeam)
+		r.Post("/teams", teamsHandler.HandleCreateTeam)
+		r.Get("/teams/{teamID}/edit", teamsHandler.HandleEditTeam)
+		r.Post("/teams/{teamID}/update", teamsHandler.HandleUpdateTeam)
+		r.Post("/teams/{teamID}/delete", teamsHandler.HandleDeleteTeam)
+		r.Get("/teams/{teamID}/okr", teamsHandler.HandleTeamOKR)
+		r.Post("/teams/{teamID}/okr", teamsHandler.HandleCreateGoal)
+		r.Post("/teams/{teamID}/okr/status", teamsHandler.HandleUpdateTeamPeriodStatus)
 
-	r.Post("/key-results/{krID}/stages", krHandler.HandleAddStage)
-	r.Post("/stages/{stageID}/toggle", krHandler.HandleToggleStage)
-	r.Post("/key-results/{krID}/percent", krHandler.HandleUpdatePercentCurrent)
-	r.Post("/key-results/{krID}/linear", krHandler.HandleUpdateLinearCurrent)
-	r.Post("/key-results/{krID}/checkpoints", krHandler.HandleAddCheckpoint)
-	r.Post("/key-results/{krID}/boolean", krHandler.HandleUpdateBoolean)
-	r.Post("/key-results/{krID}/project-stages", krHandler.HandleUpdateProjectStages)
-	r.Post("/key-results/{krID}/comments", krHandler.HandleAddKRComment)
-	r.Post("/key-results/{krID}/move-up", krHandler.HandleMoveKeyResultUp)
-	r.Post("/key-results/{krID}/move-down", krHandler.HandleMoveKeyResultDown)
-	r.Post("/key-results/{krID}/delete", krHandler.HandleDeleteKeyResult)
-	r.Post("/key-results/{krID}/update", krHandler.HandleUpdateKeyResult)
+		r.Get("/periods", periodsHandler.HandlePeriods)
+		r.Post("/periods", periodsHandler.HandleCreatePeriod)
+		r.Get("/periods/{periodID}/edit", periodsHandler.HandleEditPeriod)
+		r.Post("/periods/{periodID}/update", periodsHandler.HandleUpdatePeriod)
+		r.Post("/periods/{periodID}/delete", periodsHandler.HandleDeletePeriod)
+		r.Post("/periods/{periodID}/move-up", periodsHandler.HandleMovePeriodUp)
+		r.Post("/periods/{periodID}/move-down", periodsHandler.HandleMovePeriodDown)
 
-	r.Route("/api", func(r chi.Router) {
-		r.Get("/teams", apiHandler.HandleAPITeams)
-		r.Get("/teams/{teamID}/goals", apiHandler.HandleAPITeamGoals)
-		r.Get("/goals/{goalID}", apiHandler.HandleAPIGoal)
-	})
+		r.Get("/goals/{goalID}", goalsHandler.HandleGoalDetail)
+		r.Post("/goals/{goalID}/comments", goalsHandler.HandleAddGoalComment)
+		r.Post("/goals/{goalID}/check-ins", goalsHandler.HandleAddCheckIn)
+		r.Post("/goals/{goalID}/key-results", goalsHandler.HandleAddKeyResult)
+		r.Post("/goals/{goalID}/key-results/weights", goalsHandler.HandleUpdateKeyResultWeights)
+		r.Post("/goals/{goalID}/move-up", goalsHandler.HandleMoveGoalUp)
+		r.Post("/goals/{goalID}/move-down", goalsHandler.HandleMoveGoalDown)
+		r.Post("/goals/{goalID}/delete", goalsHandler.HandleDeleteGoal)
+		r.Post("/goals/{goalID}/update", goalsHandler.HandleUpdateGoal)
+		r.Post("/goals/{goalID}/share", goalsHandler.HandleUpdateGoalShare)
+		r.Get("/goals/period", goalsHandler.HandlePeriodGoals)
 
-	r.Mount("/api/v1", apiV1Handler.Routes())
+		r.Post("/key-results/{krID}/stages", krHandler.HandleAddStage)
+		r.Post("/stages/{stageID}/toggle", krHandler.HandleToggleStage)
+		r.Post("/key-results/{krID}/percent", krHandler.HandleUpdatePercentCurrent)
+		r.Post("/key-results/{krID}/linear", krHandler.HandleUpdateLinearCurrent)
+		r.Post("/key-results/{krID}/checkpoints", krHandler.HandleAddCheckpoint)
+		r.Post("/key-results/{krID}/boolean", krHandler.HandleUpdateBoolean)
+		r.Post("/key-results/{krID}/project-stages", krHandler.HandleUpdateProjectStages)
+		r.Post("/key-results/{krID}/comments", krHandler.HandleAddKRComment)
+		r.Post("/key-results/{krID}/move-up", krHandler.HandleMoveKeyResultUp)
+		r.Post("/key-results/{krID}/move-down", krHandler.HandleMoveKeyResultDown)
+		r.Post("/key-results/{krID}/delete", krHandler.HandleDeleteKeyResult)
+		r.Post("/key-results/{krID}/update", krHandler.HandleUpdateKeyResult)
+	})
 
 	return r
 }
@@ -263,53 +267,3 @@ func pluralizeRu(value int, singular, few, many string) string {
 		return many
 	}
 }
-
-func periodBounds(period domain.Period, zone *time.Location) (time.Time, time.Time) {
-	if zone == nil {
-		zone = time.Local
-	}
-	start := time.Date(period.StartDate.Year(), period.StartDate.Month(), period.StartDate.Day(), 0, 0, 0, 0, zone)
-	end := time.Date(period.EndDate.Year(), period.EndDate.Month(), period.EndDate.Day(), 23, 59, 59, 0, zone)
-	if end.Before(start) {
-		end = start
-	}
-	return start, end
-}
-
-func nowInZone(zone *time.Location) time.Time {
-	if zone != nil {
-		return time.Now().In(zone)
-	}
-	return time.Now()
-}
-
-func plannedProgress(now, start, end time.Time) int {
-	if end.Before(start) {
-		return 0
-	}
-	if now.Before(start) {
-		return 0
-	}
-	if now.After(end) {
-		return 100
-	}
-	total := end.Sub(start).Seconds()
-	elapsed := now.Sub(start).Seconds()
-	if total <= 0 {
-		return 0
-	}
-	return int(math.Round((elapsed / total) * 100))
-}
-
-func progressToStatus(progress, planned int) string {
-	switch {
-	case planned == 0 && progress == 0:
-		return "Нет данных"
-	case progress >= planned-10:
-		return "В норме"
-	case progress >= planned-25:
-		return "Риск"
-	default:
-		return "Отставание"
-	}
-}
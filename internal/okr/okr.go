@@ -3,6 +3,7 @@ package okr
 import (
 	"math"
 	"sort"
+	"time"
 
 	"okrs/internal/domain"
 )
@@ -55,6 +56,33 @@ func ProjectProgress(stages []domain.KRProjectStage) int {
 	return total
 }
 
+// ProjectPlannedProgress returns the sum of weights of the stages whose due date has passed at now, clamp 0..100.
+// Stages without a due date are not planned.
+func ProjectPlannedProgress(stages []domain.KRProjectStage, now time.Time) int {
+	var total int
+	for _, stage := range stages {
+		if stage.DueDate != nil && now.After(dueDateEnd(*stage.DueDate, now.Location())) {
+			total += stage.Weight
+		}
+	}
+	if total > 100 {
+		return 100
+	}
+	return total
+}
+
+// StageOverdue reports whether a stage is not done after the end of its due date.
+func StageOverdue(stage domain.KRProjectStage, now time.Time) bool {
+	if stage.IsDone || stage.DueDate == nil {
+		return false
+	}
+	return now.After(dueDateEnd(*stage.DueDate, now.Location()))
+}
+
+func dueDateEnd(due time.Time, zone *time.Location) time.Time {
+	return time.Date(due.Year(), due.Month(), due.Day(), 23, 59, 59, 0, zone)
+}
+
 func BooleanProgress(done bool) int {
 	if done {
 		return 100
@@ -105,6 +133,28 @@ func LinearProgress(start, target, current float64) int {
 	return clampPercent(linearPercent(start, target, current))
 }
 
+// RangeProgress returns 100 while current is within the bounds and falls linearly to 0 as current moves
+// tolerance away from the nearest bound; with zero tolerance any deviation gives 0. Without bounds → 0.
+func RangeProgress(min, max *float64, tolerance, current float64) int {
+	if min == nil && max == nil {
+		return 0
+	}
+	var distance float64
+	if min != nil && current < *min {
+		distance = *min - current
+	}
+	if max != nil && current > *max {
+		distance = current - *max
+	}
+	if distance == 0 {
+		return 100
+	}
+	if tolerance <= 0 {
+		return 0
+	}
+	return clampPercent(100 - distance/tolerance*100)
+}
+
 type point struct {
 	Value   float64
 	Percent int
@@ -131,3 +181,173 @@ func clampPercent(value float64) int {
 	}
 	return int(math.Round(value))
 }
+
+// WeightedProgress is the progress of a team with its weight in the aggregated progress of its ancestors.
+type WeightedProgress struct {
+	Progress int
+	Weight   int
+}
+
+// RollupProgress returns the weighted average progress (if the total weight is 0 → 0).
+func RollupProgress(items []WeightedProgress) int {
+	var sumWeight int
+	var weighted float64
+	for _, item := range items {
+		sumWeight += item.Weight
+		weighted += float64(item.Progress * item.Weight)
+	}
+	if sumWeight == 0 {
+		return 0
+	}
+	return int(math.Round(weighted / float64(sumWeight)))
+}
+
+// PeriodBounds returns the first and the last second of a period in the given zone.
+func PeriodBounds(period domain.Period, zone *time.Location) (time.Time, time.Time) {
+	if zone == nil {
+		zone = time.Local
+	}
+	start := time.Date(period.StartDate.Year(), period.StartDate.Month(), period.StartDate.Day(), 0, 0, 0, 0, zone)
+	end := time.Date(period.EndDate.Year(), period.EndDate.Month(), period.EndDate.Day(), 23, 59, 59, 0, zone)
+	if end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+// PlannedProgress returns the share of the period elapsed at now, 0..100.
+func PlannedProgress(now, start, end time.Time) int {
+	if end.Before(start) {
+		return 0
+	}
+	if now.Before(start) {
+		return 0
+	}
+	if now.After(end) {
+		return 100
+	}
+	total := end.Sub(start).Seconds()
+	elapsed := now.Sub(start).Seconds()
+	if total <= 0 {
+		return 0
+	}
+	return int(math.Round((elapsed / total) * 100))
+}
+
+// GoalConfidence returns the owner-set confidence of a goal or, when it is not set,
+// the weighted average of the confidences of its key results. It returns nil when none is set.
+func GoalConfidence(goal domain.Goal) *int {
+	if goal.Confidence != nil {
+		return goal.Confidence
+	}
+	values := make([]weightedConfidence, 0, len(goal.KeyResults))
+	for _, kr := range goal.KeyResults {
+		if kr.Confidence != nil {
+			values = append(values, weightedConfidence{Value: *kr.Confidence, Weight: kr.Weight})
+		}
+	}
+	return averageConfidence(values)
+}
+
+// PeriodConfidence returns the average of GoalConfidence weighted by goal weight; nil when no goal has a confidence.
+func PeriodConfidence(goals []domain.Goal) *int {
+	values := make([]weightedConfidence, 0, len(goals))
+	for _, goal := range goals {
+		if confidence := GoalConfidence(goal); confidence != nil {
+			values = append(values, weightedConfidence{Value: *confidence, Weight: goal.Weight})
+		}
+	}
+	return averageConfidence(values)
+}
+
+type weightedConfidence struct {
+	Value  int
+	Weight int
+}
+
+// averageConfidence falls back to the plain mean when all weights are zero.
+func averageConfidence(values []weightedConfidence) *int {
+	if len(values) == 0 {
+		return nil
+	}
+	var sumWeight, sum int
+	var weighted float64
+	for _, item := range values {
+		sumWeight += item.Weight
+		sum += item.Value
+		weighted += float64(item.Value * item.Weight)
+	}
+	var result int
+	if sumWeight == 0 {
+		result = int(math.Round(float64(sum) / float64(len(values))))
+	} else {
+		result = int(math.Round(weighted / float64(sumWeight)))
+	}
+	return &result
+}
+
+// Health is the status of a goal or a team period against the planned progress of the period.
+type Health string
+
+const (
+	HealthNoData  Health = "no_data"
+	HealthOnTrack Health = "on_track"
+	HealthAtRisk  Health = "at_risk"
+	HealthBehind  Health = "behind"
+)
+
+// HealthThresholds configures Health. Progress more than RiskGap points behind the planned progress
+// is at risk, more than BehindGap points is behind. An on-track goal with no update for longer
+// than StaleAfter is at risk; zero disables the check.
+type HealthThresholds struct {
+	RiskGap    int
+	BehindGap  int
+	StaleAfter time.Duration
+}
+
+// DefaultHealthThresholds returns the thresholds used when none are configured.
+func DefaultHealthThresholds() HealthThresholds {
+	return HealthThresholds{RiskGap: 10, BehindGap: 25, StaleAfter: 21 * 24 * time.Hour}
+}
+
+// ProgressHealth compares progress with the planned progress.
+func ProgressHealth(progress, planned int, thresholds HealthThresholds) Health {
+	switch {
+	case planned == 0 && progress == 0:
+		return HealthNoData
+	case progress >= planned-thresholds.RiskGap:
+		return HealthOnTrack
+	case progress >= planned-thresholds.BehindGap:
+		return HealthAtRisk
+	default:
+		return HealthBehind
+	}
+}
+
+// GoalHealth returns the health of a goal with calculated progress. A goal without weighted key results has no data.
+func GoalHealth(goal domain.Goal, planned int, now time.Time, thresholds HealthThresholds) Health {
+	totalWeight := 0
+	latestUpdate := goal.UpdatedAt
+	for _, kr := range goal.KeyResults {
+		totalWeight += kr.Weight
+		if kr.UpdatedAt.After(latestUpdate) {
+			latestUpdate = kr.UpdatedAt
+		}
+	}
+	if totalWeight == 0 {
+		return HealthNoData
+	}
+	health := ProgressHealth(goal.Progress, planned, thresholds)
+	if health == HealthOnTrack && thresholds.StaleAfter > 0 && now.Sub(latestUpdate) > thresholds.StaleAfter {
+		return HealthAtRisk
+	}
+	return health
+}
+
+// PeriodHealth returns the health of a team period from its progress. A period without goals has no data.
+func PeriodHealth(goals []domain.Goal, planned int, thresholds HealthThresholds) Health {
+	if len(goals) == 0 {
+		return HealthNoData
+	}
+	return ProgressHealth(PeriodProgress(goals), planned, thresholds)
+}
@@ -2,6 +2,7 @@ package okr
 
 import (
 	"testing"
+	"time"
 
 	"okrs/internal/domain"
 )
@@ -67,3 +68,137 @@ func TestPercentProgressCheckpoints(t *testing.T) {
 		t.Fatalf("expected 100 got %d", got)
 	}
 }
+
+func TestPlannedProgress(t *testing.T) {
+	period := domain.Period{
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+	}
+	start, end := PeriodBounds(period, time.UTC)
+	if end.Day() != 10 || end.Hour() != 23 {
+		t.Fatalf("expected end of the last day, got %v", end)
+	}
+	if got := PlannedProgress(start.Add(-time.Hour), start, end); got != 0 {
+		t.Fatalf("expected 0 before start got %d", got)
+	}
+	if got := PlannedProgress(start.AddDate(0, 0, 5), start, end); got != 50 {
+		t.Fatalf("expected 50 got %d", got)
+	}
+	if got := PlannedProgress(end.Add(time.Hour), start, end); got != 100 {
+		t.Fatalf("expected 100 after end got %d", got)
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	high, low := 9, 3
+	goal := domain.Goal{Weight: 75, KeyResults: []domain.KeyResult{{Weight: 50, Confidence: &high}, {Weight: 50, Confidence: &low}, {Weight: 100}}}
+	if got := GoalConfidence(goal); got == nil || *got != 6 {
+		t.Fatalf("expected rolled up confidence 6 got %v", got)
+	}
+	goal.Confidence = &low
+	if got := GoalConfidence(goal); got == nil || *got != 3 {
+		t.Fatalf("expected owner confidence 3 got %v", got)
+	}
+	goals := []domain.Goal{goal, {Weight: 25, Confidence: &high}, {Weight: 100}}
+	if got := PeriodConfidence(goals); got == nil || *got != 5 {
+		t.Fatalf("expected period confidence 5 got %v", got)
+	}
+	if got := PeriodConfidence([]domain.Goal{{Weight: 100}}); got != nil {
+		t.Fatalf("expected nil without confidences got %v", *got)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	thresholds := DefaultHealthThresholds()
+	cases := []struct {
+		progress, planned int
+		want              Health
+	}{
+		{0, 0, HealthNoData},
+		{40, 50, HealthOnTrack},
+		{30, 50, HealthAtRisk},
+		{20, 50, HealthBehind},
+	}
+	for _, tc := range cases {
+		if got := ProgressHealth(tc.progress, tc.planned, thresholds); got != tc.want {
+			t.Fatalf("progress %d planned %d: expected %s got %s", tc.progress, tc.planned, tc.want, got)
+		}
+	}
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	goal := domain.Goal{Progress: 50, UpdatedAt: now.AddDate(0, 0, -30), KeyResults: []domain.KeyResult{{Weight: 100, UpdatedAt: now.AddDate(0, 0, -1)}}}
+	if got := GoalHealth(goal, 50, now, thresholds); got != HealthOnTrack {
+		t.Fatalf("expected on track got %s", got)
+	}
+	goal.KeyResults[0].UpdatedAt = now.AddDate(0, 0, -22)
+	if got := GoalHealth(goal, 50, now, thresholds); got != HealthAtRisk {
+		t.Fatalf("expected stale goal at risk got %s", got)
+	}
+	thresholds.StaleAfter = 0
+	if got := GoalHealth(goal, 50, now, thresholds); got != HealthOnTrack {
+		t.Fatalf("expected on track without stale check got %s", got)
+	}
+	if got := GoalHealth(domain.Goal{Progress: 50}, 50, now, thresholds); got != HealthNoData {
+		t.Fatalf("expected no data without key results got %s", got)
+	}
+	if got := PeriodHealth(nil, 50, thresholds); got != HealthNoData {
+		t.Fatalf("expected no data without goals got %s", got)
+	}
+}
+
+func TestRollupProgress(t *testing.T) {
+	if got := RollupProgress([]WeightedProgress{{Progress: 80, Weight: 30}, {Progress: 40, Weight: 10}}); got != 70 {
+		t.Fatalf("expected 70 got %d", got)
+	}
+	if got := RollupProgress([]WeightedProgress{{Progress: 80}}); got != 0 {
+		t.Fatalf("expected 0 for zero weights got %d", got)
+	}
+}
+
+func TestRangeProgress(t *testing.T) {
+	low, high := 100.0, 200.0
+	cases := []struct {
+		name      string
+		min, max  *float64
+		tolerance float64
+		current   float64
+		expect    int
+	}{
+		{name: "no bounds", current: 150, expect: 0},
+		{name: "inside", min: &low, max: &high, tolerance: 50, current: 150, expect: 100},
+		{name: "on bound", min: &low, max: &high, tolerance: 50, current: 200, expect: 100},
+		{name: "above within tolerance", min: &low, max: &high, tolerance: 50, current: 225, expect: 50},
+		{name: "below within tolerance", min: &low, max: &high, tolerance: 50, current: 90, expect: 80},
+		{name: "beyond tolerance", min: &low, max: &high, tolerance: 50, current: 300, expect: 0},
+		{name: "zero tolerance", min: &low, max: &high, current: 201, expect: 0},
+		{name: "threshold below", max: &high, tolerance: 100, current: 10, expect: 100},
+		{name: "threshold exceeded", max: &high, tolerance: 100, current: 250, expect: 50},
+	}
+	for _, tc := range cases {
+		if got := RangeProgress(tc.min, tc.max, tc.tolerance, tc.current); got != tc.expect {
+			t.Fatalf("%s: expected %d got %d", tc.name, tc.expect, got)
+		}
+	}
+}
+
+func TestProjectSchedule(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	past := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	future := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	stages := []domain.KRProjectStage{
+		{Weight: 30, IsDone: true, DueDate: &past},
+		{Weight: 20, DueDate: &past},
+		{Weight: 10, DueDate: &today},
+		{Weight: 40, DueDate: &future},
+		{Weight: 15},
+	}
+	if got := ProjectPlannedProgress(stages, now); got != 50 {
+		t.Fatalf("expected planned 50 got %d", got)
+	}
+	expected := []bool{false, true, false, false, false}
+	for i, stage := range stages {
+		if got := StageOverdue(stage, now); got != expected[i] {
+			t.Fatalf("stage %d: expected overdue %v got %v", i, expected[i], got)
+		}
+	}
+}
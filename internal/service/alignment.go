@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"okrs/internal/domain"
+	"okrs/internal/okr"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidAlignment is returned when a parent goal is missing, belongs to another period,
+// is not owned by an ancestor team or would make the alignment cyclic.
+var ErrInvalidAlignment = errors.New("invalid goal alignment")
+
+// GoalAlignmentInput links a goal to the goal of an ancestor team; nil ParentGoalID removes the link.
+type GoalAlignmentInput struct {
+	ParentGoalID         *int64
+	ProgressFromChildren bool
+}
+
+// GoalTreeNode is a goal with calculated progress and the goals aligned to it.
+type GoalTreeNode struct {
+	Goal     domain.Goal
+	TeamName string
+	Children []GoalTreeNode
+}
+
+// GoalTree is the alignment tree below a goal together with the chain of its parent goals, top first.
+type GoalTree struct {
+	Ancestors []GoalTreeNode
+	Root      GoalTreeNode
+}
+
+// SetGoalAlignment sets the parent goal and the progress roll-up option of a goal.
+func (s *Service) SetGoalAlignment(ctx context.Context, goalID int64, input GoalAlignmentInput) error {
+	if err := s.CheckGoalMutation(ctx, goalID, MutationStructural); err != nil {
+		return err
+	}
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	if input.ParentGoalID != nil {
+		if err := s.validateParentGoal(ctx, goal, *input.ParentGoalID); err != nil {
+			return err
+		}
+	}
+	return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionAlign, func(ctx context.Context) error {
+		return s.store.UpdateGoalAlignment(ctx, goalID, input.ParentGoalID, input.ProgressFromChildren)
+	})
+}
+
+func (s *Service) validateParentGoal(ctx context.Context, goal domain.Goal, parentID int64) error {
+	if parentID == goal.ID {
+		return fmt.Errorf("%w: goal cannot be its own parent", ErrInvalidAlignment)
+	}
+	parent, err := s.store.GetGoal(ctx, parentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: parent goal not found", ErrInvalidAlignment)
+		}
+		return err
+	}
+	if parent.PeriodID != goal.PeriodID {
+		return fmt.Errorf("%w: parent goal belongs to another period", ErrInvalidAlignment)
+	}
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAncestorTeam(teams, parent.TeamID, goal.TeamID) {
+		return fmt.Errorf("%w: parent goal must belong to an ancestor team", ErrInvalidAlignment)
+	}
+	visited := map[int64]bool{goal.ID: true}
+	for current := parent; current.ParentGoalID != nil; {
+		if visited[current.ID] || visited[*current.ParentGoalID] {
+			return fmt.Errorf("%w: alignment cycle", ErrInvalidAlignment)
+		}
+		visited[current.ID] = true
+		current, err = s.store.GetGoal(ctx, *current.ParentGoalID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropStaleAlignment runs after the owner team of a goal changes and removes the alignment links the change made
+// invalid: the link of the goal to its parent goal and the links of the goals aligned to it.
+func (s *Service) dropStaleAlignment(ctx context.Context, goalID int64) error {
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	if goal.ParentGoalID != nil {
+		err := s.validateParentGoal(ctx, goal, *goal.ParentGoalID)
+		if errors.Is(err, ErrInvalidAlignment) {
+			err = s.store.UpdateGoalAlignment(ctx, goal.ID, nil, goal.ProgressFromChildren)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	children, err := s.store.ListChildGoals(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		err := s.validateParentGoal(ctx, child, goalID)
+		if errors.Is(err, ErrInvalidAlignment) {
+			err = s.audited(ctx, domain.AuditEntityGoal, child.ID, domain.AuditActionAlign, func(ctx context.Context) error {
+				return s.store.UpdateGoalAlignment(ctx, child.ID, nil, child.ProgressFromChildren)
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isAncestorTeam reports whether ancestorID is a parent, grandparent and so on of teamID.
+func isAncestorTeam(teams []domain.Team, ancestorID, teamID int64) bool {
+	parents := make(map[int64]*int64, len(teams))
+	for _, team := range teams {
+		parents[team.ID] = team.ParentID
+	}
+	visited := make(map[int64]bool)
+	for parentID := parents[teamID]; parentID != nil && !visited[*parentID]; parentID = parents[*parentID] {
+		if *parentID == ancestorID {
+			return true
+		}
+		visited[*parentID] = true
+	}
+	return false
+}
+
+// GoalProgress calculates the progress of a goal. A goal with ProgressFromChildren takes the average of
+// its aligned child goals weighted by goal weight; without children it keeps the progress of its key results.
+// It also marks overdue stages of project key results, see ApplyProjectSchedule.
+func (s *Service) GoalProgress(ctx context.Context, goal *domain.Goal) (int, error) {
+	s.ApplyProjectSchedule(goal.KeyResults)
+	return s.goalProgress(ctx, goal, make(map[int64]bool))
+}
+
+func (s *Service) goalProgress(ctx context.Context, goal *domain.Goal, visited map[int64]bool) (int, error) {
+	progress := CalculateGoalProgress(goal)
+	if !goal.ProgressFromChildren || visited[goal.ID] {
+		return progress, nil
+	}
+	visited[goal.ID] = true
+	children, err := s.store.ListChildGoals(ctx, goal.ID)
+	if err != nil {
+		return 0, err
+	}
+	if len(children) == 0 {
+		return progress, nil
+	}
+	for i := range children {
+		if children[i].Progress, err = s.goalProgress(ctx, &children[i], visited); err != nil {
+			return 0, err
+		}
+	}
+	return okr.PeriodProgress(children), nil
+}
+
+// GoalTree returns the goals aligned to a goal, recursively, and the chain of its parent goals.
+func (s *Service) GoalTree(ctx context.Context, goalID int64) (GoalTree, error) {
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return GoalTree{}, err
+	}
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return GoalTree{}, err
+	}
+	teamNames := make(map[int64]string, len(teams))
+	for _, team := range teams {
+		teamNames[team.ID] = team.Name
+	}
+	visited := map[int64]bool{goal.ID: true}
+	root, err := s.goalTreeNode(ctx, goal, teamNames, visited)
+	if err != nil {
+		return GoalTree{}, err
+	}
+	tree := GoalTree{Root: root}
+	for parentID := goal.ParentGoalID; parentID != nil && !visited[*parentID]; {
+		visited[*parentID] = true
+		parent, err := s.store.GetGoal(ctx, *parentID)
+		if err != nil {
+			return GoalTree{}, err
+		}
+		if parent.Progress, err = s.GoalProgress(ctx, &parent); err != nil {
+			return GoalTree{}, err
+		}
+		tree.Ancestors = append([]GoalTreeNode{{Goal: parent, TeamName: teamNames[parent.TeamID]}}, tree.Ancestors...)
+		parentID = parent.ParentGoalID
+	}
+	return tree, nil
+}
+
+func (s *Service) goalTreeNode(ctx context.Context, goal domain.Goal, teamNames map[int64]string, visited map[int64]bool) (GoalTreeNode, error) {
+	var err error
+	if goal.Progress, err = s.GoalProgress(ctx, &goal); err != nil {
+		return GoalTreeNode{}, err
+	}
+	node := GoalTreeNode{Goal: goal, TeamName: teamNames[goal.TeamID]}
+	children, err := s.store.ListChildGoals(ctx, goal.ID)
+	if err != nil {
+		return GoalTreeNode{}, err
+	}
+	for _, child := range children {
+		if visited[child.ID] {
+			continue
+		}
+		visited[child.ID] = true
+		childNode, err := s.goalTreeNode(ctx, child, teamNames, visited)
+		if err != nil {
+			return GoalTreeNode{}, err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return node, nil
+}
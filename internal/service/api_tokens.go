@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidAPIToken is returned when a bearer token is unknown or expired.
+var ErrInvalidAPIToken = errors.New("invalid api token")
+
+// CreateAPIToken issues a token for the user in ctx and returns the raw value, which is not stored.
+func (s *Service) CreateAPIToken(ctx context.Context, name string, readOnly bool, expiresAt *time.Time) (string, domain.APIToken, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return "", domain.APIToken{}, ErrForbidden
+	}
+	raw, err := auth.NewToken()
+	if err != nil {
+		return "", domain.APIToken{}, err
+	}
+	var token domain.APIToken
+	if err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		token, err = s.store.CreateAPIToken(ctx, store.APITokenInput{
+			UserID:    user.ID,
+			Name:      name,
+			TokenHash: auth.HashToken(raw),
+			ReadOnly:  readOnly,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityAPIToken, token.ID, domain.AuditActionCreate, nil, token)
+	}); err != nil {
+		return "", domain.APIToken{}, err
+	}
+	return raw, token, nil
+}
+
+// ListAPITokens returns the tokens of the user in ctx.
+func (s *Service) ListAPITokens(ctx context.Context) ([]domain.APIToken, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, ErrForbidden
+	}
+	return s.store.ListAPITokens(ctx, user.ID)
+}
+
+// RevokeAPIToken deletes a token of the user in ctx.
+func (s *Service) RevokeAPIToken(ctx context.Context, id int64) error {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return ErrForbidden
+	}
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.DeleteAPIToken(ctx, user.ID, id); err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityAPIToken, id, domain.AuditActionDelete, nil, nil)
+	})
+}
+
+// AuthenticateAPIToken resolves a raw bearer token and records its use.
+func (s *Service) AuthenticateAPIToken(ctx context.Context, raw string) (domain.User, domain.APIToken, error) {
+	token, user, err := s.store.GetAPITokenUser(ctx, auth.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.User{}, domain.APIToken{}, ErrInvalidAPIToken
+		}
+		return domain.User{}, domain.APIToken{}, err
+	}
+	if err := s.store.TouchAPIToken(ctx, token.ID); err != nil {
+		return domain.User{}, domain.APIToken{}, err
+	}
+	return user, token, nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// goalAuditState is the audited state of a goal: its own fields and the teams it is shared with.
+type goalAuditState struct {
+	Goal   domain.Goal
+	Shares []store.GoalShare
+}
+
+// commentAuditState is the after state of a comment event.
+type commentAuditState struct {
+	Text string
+}
+
+// statusAuditState is the before/after state of a team period status event.
+type statusAuditState struct {
+	PeriodID int64
+	Status   domain.TeamPeriodStatus
+}
+
+// userAuditState is the audited state of a user; the password hash is never recorded.
+type userAuditState struct {
+	Email        string
+	Name         string
+	DigestOptOut bool
+}
+
+// ValidAuditEntity reports whether the entity type is audited.
+func ValidAuditEntity(entity domain.AuditEntity) bool {
+	switch entity {
+	case domain.AuditEntityGoal, domain.AuditEntityKeyResult, domain.AuditEntityTeam, domain.AuditEntityPeriod,
+		domain.AuditEntityUser, domain.AuditEntityRoleAssignment, domain.AuditEntityAPIToken, domain.AuditEntityWebhook:
+		return true
+	default:
+		return false
+	}
+}
+
+// Audit records a mutation made by the user in ctx. before and after are stored as JSON; nil is stored as NULL.
+func (s *Service) Audit(ctx context.Context, entity domain.AuditEntity, entityID int64, action domain.AuditAction, before, after any) error {
+	input := store.AuditEventInput{EntityType: entity, EntityID: entityID, Action: action}
+	if user, ok := auth.UserFromContext(ctx); ok {
+		input.ActorID = &user.ID
+	}
+	var err error
+	if input.Before, err = marshalAuditState(before); err != nil {
+		return err
+	}
+	if input.After, err = marshalAuditState(after); err != nil {
+		return err
+	}
+	return s.store.AddAuditEvent(ctx, input)
+}
+
+// AuditChange records a mutation using the given before state and the current state of the entity as after.
+func (s *Service) AuditChange(ctx context.Context, entity domain.AuditEntity, entityID int64, action domain.AuditAction, before any) error {
+	after, err := s.Snapshot(ctx, entity, entityID)
+	if err != nil {
+		return err
+	}
+	return s.Audit(ctx, entity, entityID, action, before, after)
+}
+
+// AuditComment records a comment added to a goal or key result.
+func (s *Service) AuditComment(ctx context.Context, entity domain.AuditEntity, entityID int64, text string) error {
+	return s.Audit(ctx, entity, entityID, domain.AuditActionComment, nil, commentAuditState{Text: text})
+}
+
+// AuditStatusChange records a team period status change.
+func (s *Service) AuditStatusChange(ctx context.Context, teamID, periodID int64, from, to domain.TeamPeriodStatus) error {
+	return s.Audit(ctx, domain.AuditEntityTeam, teamID, domain.AuditActionStatus,
+		statusAuditState{PeriodID: periodID, Status: from},
+		statusAuditState{PeriodID: periodID, Status: to})
+}
+
+// audited runs mutate between two snapshots of the entity and records the change in the same transaction,
+// so a mutation is never saved without its audit event. mutate must use the context it is given.
+func (s *Service) audited(ctx context.Context, entity domain.AuditEntity, entityID int64, action domain.AuditAction, mutate func(ctx context.Context) error) error {
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		before, err := s.Snapshot(ctx, entity, entityID)
+		if err != nil {
+			return err
+		}
+		if err := mutate(ctx); err != nil {
+			return err
+		}
+		return s.AuditChange(ctx, entity, entityID, action, before)
+	})
+}
+
+// Snapshot loads the audited state of goals, key results, teams and periods.
+// It returns nil for other entities and for entities that do not exist (for example after a delete).
+func (s *Service) Snapshot(ctx context.Context, entity domain.AuditEntity, entityID int64) (any, error) {
+	var state any
+	var err error
+	switch entity {
+	case domain.AuditEntityGoal:
+		state, err = s.goalSnapshot(ctx, entityID)
+	case domain.AuditEntityKeyResult:
+		var kr domain.KeyResult
+		kr, err = s.keyResultWithMeta(ctx, entityID)
+		kr.Comments = nil
+		state = kr
+	case domain.AuditEntityTeam:
+		state, err = s.store.GetTeam(ctx, entityID)
+	case domain.AuditEntityPeriod:
+		state, err = s.store.GetPeriod(ctx, entityID)
+	default:
+		return nil, nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// ListAuditEvents returns the history of an entity, newest first.
+// Goals, key results, teams and periods are readable by every user; users, roles and tokens require a global admin.
+func (s *Service) ListAuditEvents(ctx context.Context, entity domain.AuditEntity, entityID int64) ([]domain.AuditEvent, error) {
+	switch entity {
+	case domain.AuditEntityGoal, domain.AuditEntityKeyResult, domain.AuditEntityTeam, domain.AuditEntityPeriod:
+	default:
+		if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.ListAuditEvents(ctx, entity, entityID)
+}
+
+func (s *Service) goalSnapshot(ctx context.Context, goalID int64) (goalAuditState, error) {
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return goalAuditState{}, err
+	}
+	shares, err := s.store.ListGoalShares(ctx, goalID)
+	if err != nil {
+		return goalAuditState{}, err
+	}
+	goal.KeyResults = nil
+	goal.Comments = nil
+	return goalAuditState{Goal: goal, Shares: shares}, nil
+}
+
+// keyResultWithMeta returns the key result with its meta, which is only loaded together with the goal.
+func (s *Service) keyResultWithMeta(ctx context.Context, krID int64) (domain.KeyResult, error) {
+	kr, err := s.store.GetKeyResult(ctx, krID)
+	if err != nil {
+		return domain.KeyResult{}, err
+	}
+	goal, err := s.store.GetGoal(ctx, kr.GoalID)
+	if err != nil {
+		return domain.KeyResult{}, err
+	}
+	for _, item := range goal.KeyResults {
+		if item.ID == krID {
+			return item, nil
+		}
+	}
+	return kr, nil
+}
+
+func marshalAuditState(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"okrs/internal/domain"
+	"okrs/internal/okr"
+)
+
+// BurnupPoint is one day of a burn-up chart. Actual is nil for days that have not started yet.
+type BurnupPoint struct {
+	Date    time.Time
+	Planned int
+	Actual  *int
+}
+
+// GoalBurnup returns the daily planned and actual progress of a goal over its period.
+func (s *Service) GoalBurnup(ctx context.Context, goalID int64) ([]BurnupPoint, error) {
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return nil, err
+	}
+	period, err := s.store.GetPeriod(ctx, goal.PeriodID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.goalProgressEvents(ctx, goal)
+	if err != nil {
+		return nil, err
+	}
+	start, end := okr.PeriodBounds(period, s.zone)
+	return burnupSeries(start, end, s.now(), func(at time.Time) int {
+		return goalProgressAt(goal, events, at)
+	}), nil
+}
+
+// TeamBurnup returns the daily planned and actual progress of a team period.
+func (s *Service) TeamBurnup(ctx context.Context, teamID, periodID int64) ([]BurnupPoint, error) {
+	if _, err := s.store.GetTeam(ctx, teamID); err != nil {
+		return nil, err
+	}
+	period, err := s.store.GetPeriod(ctx, periodID)
+	if err != nil {
+		return nil, err
+	}
+	goals, err := s.store.ListGoalsByTeamPeriod(ctx, teamID, periodID)
+	if err != nil {
+		return nil, err
+	}
+	events := make(map[int64][]domain.KRProgressEvent)
+	for _, goal := range goals {
+		goalEvents, err := s.goalProgressEvents(ctx, goal)
+		if err != nil {
+			return nil, err
+		}
+		for krID, items := range goalEvents {
+			events[krID] = items
+		}
+	}
+	start, end := okr.PeriodBounds(period, s.zone)
+	return burnupSeries(start, end, s.now(), func(at time.Time) int {
+		snapshot := make([]domain.Goal, len(goals))
+		for i, goal := range goals {
+			snapshot[i] = goal
+			snapshot[i].Progress = goalProgressAt(goal, events, at)
+		}
+		return okr.PeriodProgress(snapshot)
+	}), nil
+}
+
+func (s *Service) goalProgressEvents(ctx context.Context, goal domain.Goal) (map[int64][]domain.KRProgressEvent, error) {
+	events := make(map[int64][]domain.KRProgressEvent, len(goal.KeyResults))
+	for _, kr := range goal.KeyResults {
+		items, err := s.store.ListKRProgressEvents(ctx, kr.ID)
+		if err != nil {
+			return nil, err
+		}
+		events[kr.ID] = items
+	}
+	return events, nil
+}
+
+func (s *Service) now() time.Time {
+	if s.zone != nil {
+		return time.Now().In(s.zone)
+	}
+	return time.Now()
+}
+
+// burnupSeries builds one point per day from start to end. The planned value is taken at the end of the day;
+// the actual value is taken at the end of the day or at now for the current day.
+func burnupSeries(start, end, now time.Time, actualAt func(at time.Time) int) []BurnupPoint {
+	var points []BurnupPoint
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1).Add(-time.Second)
+		if dayEnd.After(end) {
+			dayEnd = end
+		}
+		point := BurnupPoint{Date: day, Planned: okr.PlannedProgress(dayEnd, start, end)}
+		if !day.After(now) {
+			at := dayEnd
+			if at.After(now) {
+				at = now
+			}
+			actual := actualAt(at)
+			point.Actual = &actual
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// goalProgressAt returns the goal progress from the last recorded progress of each key result at or before at.
+// Key results without history at that moment count as 0.
+func goalProgressAt(goal domain.Goal, events map[int64][]domain.KRProgressEvent, at time.Time) int {
+	keyResults := make([]domain.KeyResult, len(goal.KeyResults))
+	for i, kr := range goal.KeyResults {
+		keyResults[i] = kr
+		keyResults[i].Progress = 0
+		for _, event := range events[kr.ID] {
+			if event.CreatedAt.After(at) {
+				break
+			}
+			keyResults[i].Progress = event.Progress
+		}
+	}
+	return okr.GoalProgress(keyResults)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"okrs/internal/domain"
+	"okrs/internal/okr"
+)
+
+// ChatMessage is the body of chat webhook deliveries; Slack and Mattermost incoming webhooks accept it as is.
+type ChatMessage struct {
+	Text string `json:"text"`
+}
+
+// chatEscaper escapes the characters Slack and Mattermost treat as markup, so a title like <!channel> is shown
+// as text instead of mentioning everyone.
+var chatEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// chatText renders the payload data of an event as a chat message about the team.
+func (s *Service) chatText(ctx context.Context, data any, teamName string, teamsByID map[int64]domain.Team) (string, error) {
+	teamName = chatEscaper.Replace(teamName)
+	switch data := data.(type) {
+	case goalWebhookData:
+		return fmt.Sprintf("%s: новая цель «%s»", teamName, chatEscaper.Replace(data.Title)), nil
+	case goalSharedWebhookData:
+		if len(data.Shares) == 0 {
+			return fmt.Sprintf("%s: цель «%s» больше ни с кем не расшарена", teamName, chatEscaper.Replace(data.Title)), nil
+		}
+		names := make([]string, 0, len(data.Shares))
+		for _, share := range data.Shares {
+			names = append(names, fmt.Sprintf("%s (%d%%)", chatEscaper.Replace(teamsByID[share.TeamID].Name), share.Weight))
+		}
+		return fmt.Sprintf("%s: цель «%s» расшарена командам %s", teamName, chatEscaper.Replace(data.Title), strings.Join(names, ", ")), nil
+	case krProgressWebhookData:
+		if data.Progress >= 100 && (data.PreviousProgress == nil || *data.PreviousProgress < 100) {
+			return fmt.Sprintf("%s: KR «%s» выполнен", teamName, chatEscaper.Replace(data.Title)), nil
+		}
+		if data.PreviousProgress != nil {
+			return fmt.Sprintf("%s: прогресс KR «%s» %d%% (было %d%%)", teamName, chatEscaper.Replace(data.Title), data.Progress, *data.PreviousProgress), nil
+		}
+		return fmt.Sprintf("%s: прогресс KR «%s» %d%%", teamName, chatEscaper.Replace(data.Title), data.Progress), nil
+	case teamPeriodWebhookData:
+		period, err := s.store.GetPeriod(ctx, data.PeriodID)
+		if err != nil {
+			return "", err
+		}
+		if data.To == domain.TeamPeriodStatusClosed {
+			return fmt.Sprintf("%s: период %s закрыт", teamName, chatEscaper.Replace(period.Name)), nil
+		}
+		return fmt.Sprintf("%s: цели периода %s провалидированы", teamName, chatEscaper.Replace(period.Name)), nil
+	case teamHealthWebhookData:
+		period, err := s.store.GetPeriod(ctx, data.PeriodID)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s отстаёт от плана в периоде %s: прогресс %d%% при плане %d%%", teamName, chatEscaper.Replace(period.Name), data.Progress, data.Planned), nil
+	default:
+		return "", fmt.Errorf("no chat message for %T", data)
+	}
+}
+
+// notifyGoalTeamsHealth recalculates the period health of the owner and shared teams of the goal and sends
+// team.behind_plan to the teams that have just fallen behind the plan.
+func (s *Service) notifyGoalTeamsHealth(ctx context.Context, goal domain.Goal) error {
+	shares, err := s.store.ListGoalShares(ctx, goal.ID)
+	if err != nil {
+		return err
+	}
+	period, err := s.store.GetPeriod(ctx, goal.PeriodID)
+	if err != nil {
+		return err
+	}
+	teamIDs := []int64{goal.TeamID}
+	for _, share := range shares {
+		teamIDs = append(teamIDs, share.TeamID)
+	}
+	for _, teamID := range teamIDs {
+		if _, err := s.updateTeamHealth(ctx, teamID, period); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckTeamsHealth recalculates the health of every team in the periods that are running now and sends
+// team.behind_plan to the teams that have fallen behind since the last check. The planned progress grows with
+// time, so a team falls behind without any key result update; a periodic job calls it. It returns the number of
+// teams that were notified.
+func (s *Service) CheckTeamsHealth(ctx context.Context) (int, error) {
+	now := s.now()
+	periods, err := s.store.ListPeriods(ctx)
+	if err != nil {
+		return 0, err
+	}
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return 0, err
+	}
+	notified := 0
+	for _, period := range periods {
+		start, end := okr.PeriodBounds(period, s.zone)
+		if now.Before(start) || now.After(end) {
+			continue
+		}
+		for _, team := range teams {
+			behind, err := s.updateTeamHealth(ctx, team.ID, period)
+			if err != nil {
+				return notified, err
+			}
+			if behind {
+				notified++
+			}
+		}
+	}
+	return notified, nil
+}
+
+// updateTeamHealth stores the health of the team in the period and queues team.behind_plan in the same transaction
+// when the team has just fallen behind the plan; it reports whether the event was queued.
+func (s *Service) updateTeamHealth(ctx context.Context, teamID int64, period domain.Period) (bool, error) {
+	goals, err := s.store.ListGoalsByTeamPeriod(ctx, teamID, period.ID)
+	if err != nil {
+		return false, err
+	}
+	for i := range goals {
+		if goals[i].Progress, err = s.GoalProgress(ctx, &goals[i]); err != nil {
+			return false, err
+		}
+	}
+	planned := s.PlannedProgress(period)
+	health := okr.PeriodHealth(goals, planned, s.health)
+	behind := false
+	err = s.store.InTx(ctx, func(ctx context.Context) error {
+		previous, err := s.store.SwapTeamPeriodHealth(ctx, teamID, period.ID, string(health))
+		if err != nil {
+			return err
+		}
+		if health != okr.HealthBehind || okr.Health(previous) == okr.HealthBehind {
+			return nil
+		}
+		behind = true
+		data := teamHealthWebhookData{TeamID: teamID, PeriodID: period.ID, Progress: okr.PeriodProgress(goals), Planned: planned, Health: health}
+		return s.emitWebhookEvent(ctx, domain.WebhookEventTeamBehindPlan, data, teamID)
+	})
+	return behind && err == nil, err
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+// ErrInvalidCheckIn is returned when a check-in does not match the key result or has an invalid confidence.
+var ErrInvalidCheckIn = errors.New("invalid check-in")
+
+// CheckInInput is a weekly check-in of a key result. Only the field matching the KR kind is used:
+// Value for percent and linear KRs, Done for boolean KRs and Stages for project KRs.
+// When it is not set the check-in keeps the current value.
+type CheckInInput struct {
+	Value      *float64
+	Done       *bool
+	Stages     []ProjectStageUpdate
+	Confidence int
+	Blockers   string
+	NextSteps  string
+}
+
+// TeamCheckInStatus is a team that has not checked in since the start of the week.
+type TeamCheckInStatus struct {
+	Team          domain.Team
+	LastCheckInAt *time.Time
+}
+
+// CheckInKeyResult validates the check-in, then applies the new value like the regular progress update, with its
+// history point and webhooks, sets the KR confidence and records the check-in in one transaction, so a rejected or
+// failed check-in leaves the KR unchanged and notifies nobody.
+func (s *Service) CheckInKeyResult(ctx context.Context, krID int64, input CheckInInput) (domain.KRCheckIn, error) {
+	kr, err := s.store.GetKeyResult(ctx, krID)
+	if err != nil {
+		return domain.KRCheckIn{}, err
+	}
+	if input.Confidence < 1 || input.Confidence > 10 {
+		return domain.KRCheckIn{}, fmt.Errorf("%w: confidence must be 1..10", ErrInvalidCheckIn)
+	}
+	if err := s.CheckGoalMutation(ctx, kr.GoalID, MutationProgress); err != nil {
+		return domain.KRCheckIn{}, err
+	}
+	user, hasUser := auth.UserFromContext(ctx)
+	var created domain.KRCheckIn
+	if err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		switch kr.Kind {
+		case domain.KRKindPercent, domain.KRKindLinear, domain.KRKindRange:
+			if input.Value != nil {
+				err = s.writeKRCurrent(ctx, kr, *input.Value)
+			}
+		case domain.KRKindBoolean:
+			if input.Done != nil {
+				err = s.writeKRBoolean(ctx, krID, *input.Done)
+			}
+		case domain.KRKindProject:
+			if len(input.Stages) > 0 {
+				err = s.writeKRStages(ctx, krID, input.Stages)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if kr.Confidence == nil || *kr.Confidence != input.Confidence {
+			confidence := input.Confidence
+			if err := s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionConfidence, func(ctx context.Context) error {
+				return s.store.UpdateKeyResultConfidence(ctx, krID, &confidence)
+			}); err != nil {
+				return err
+			}
+		}
+
+		current, err := s.keyResultWithMeta(ctx, krID)
+		if err != nil {
+			return err
+		}
+		checkIn := store.KRCheckInInput{
+			KeyResultID: krID,
+			Value:       KRCurrentValue(current),
+			Progress:    CalculateKRProgress(current),
+			Confidence:  input.Confidence,
+			Blockers:    strings.TrimSpace(input.Blockers),
+			NextSteps:   strings.TrimSpace(input.NextSteps),
+		}
+		if hasUser {
+			checkIn.AuthorID = &user.ID
+		}
+		created, err = s.store.AddKRCheckIn(ctx, checkIn)
+		return err
+	}); err != nil {
+		return domain.KRCheckIn{}, err
+	}
+	created.AuthorName = user.Name
+	return created, nil
+}
+
+// ListKRCheckIns returns the check-ins of a key result, newest first.
+func (s *Service) ListKRCheckIns(ctx context.Context, krID int64) ([]domain.KRCheckIn, error) {
+	if _, err := s.store.GetKeyResult(ctx, krID); err != nil {
+		return nil, err
+	}
+	return s.store.ListKRCheckIns(ctx, krID)
+}
+
+// TeamsMissingCheckIn returns the teams with key results in the period that have no check-in since the start
+// of the current week (Monday 00:00 in the service zone), together with that week start.
+func (s *Service) TeamsMissingCheckIn(ctx context.Context, periodID int64) ([]TeamCheckInStatus, time.Time, error) {
+	weekStart := WeekStart(s.now())
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return nil, weekStart, err
+	}
+	teamsByID := make(map[int64]domain.Team, len(teams))
+	for _, team := range teams {
+		teamsByID[team.ID] = team
+	}
+	lastCheckIns, err := s.store.ListTeamLastCheckIns(ctx, periodID)
+	if err != nil {
+		return nil, weekStart, err
+	}
+	items := make([]TeamCheckInStatus, 0, len(lastCheckIns))
+	for _, item := range lastCheckIns {
+		if item.LastCheckInAt != nil && !item.LastCheckInAt.Before(weekStart) {
+			continue
+		}
+		team, ok := teamsByID[item.TeamID]
+		if !ok {
+			continue
+		}
+		items = append(items, TeamCheckInStatus{Team: team, LastCheckInAt: item.LastCheckInAt})
+	}
+	return items, weekStart, nil
+}
+
+// WeekStart returns Monday 00:00 of the week containing now, in the location of now.
+func WeekStart(now time.Time) time.Time {
+	offset := (int(now.Weekday()) + 6) % 7
+	day := now.AddDate(0, 0, -offset)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"okrs/internal/domain"
+	"okrs/internal/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidCheckpoint is returned when percent KR checkpoints would break the interpolation between start and target.
+var ErrInvalidCheckpoint = errors.New("invalid checkpoint")
+
+// CheckpointInput is a metric value of a percent KR and the KR progress at that value.
+type CheckpointInput struct {
+	MetricValue float64
+	KRPercent   int
+}
+
+// ListPercentCheckpoints returns the checkpoints of a percent key result ordered by metric value.
+func (s *Service) ListPercentCheckpoints(ctx context.Context, krID int64) ([]domain.KRPercentCheckpoint, error) {
+	meta, err := s.percentMeta(ctx, krID)
+	if err != nil {
+		return nil, err
+	}
+	return meta.Checkpoints, nil
+}
+
+// AddPercentCheckpoint validates the new checkpoint together with the existing ones and stores it.
+func (s *Service) AddPercentCheckpoint(ctx context.Context, krID int64, input CheckpointInput) (domain.KRPercentCheckpoint, error) {
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationStructural); err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	meta, err := s.percentMeta(ctx, krID)
+	if err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	checkpoint := domain.KRPercentCheckpoint{KeyResultID: krID, MetricValue: input.MetricValue, KRPercent: input.KRPercent}
+	if err := validateCheckpoints(meta.StartValue, meta.TargetValue, append(meta.Checkpoints, checkpoint)); err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	if err := s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		if checkpoint.ID, err = s.store.AddPercentCheckpoint(ctx, store.PercentCheckpointInput{KeyResultID: krID, MetricValue: input.MetricValue, KRPercent: input.KRPercent}); err != nil {
+			return err
+		}
+		return s.RecordKRProgress(ctx, krID)
+	}); err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	return checkpoint, nil
+}
+
+// UpdatePercentCheckpoint changes a checkpoint of the key result.
+func (s *Service) UpdatePercentCheckpoint(ctx context.Context, krID, checkpointID int64, input CheckpointInput) (domain.KRPercentCheckpoint, error) {
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationStructural); err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	if err := s.checkpointOf(ctx, krID, checkpointID); err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	meta, err := s.percentMeta(ctx, krID)
+	if err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	checkpoint := domain.KRPercentCheckpoint{ID: checkpointID, KeyResultID: krID, MetricValue: input.MetricValue, KRPercent: input.KRPercent}
+	checkpoints := make([]domain.KRPercentCheckpoint, 0, len(meta.Checkpoints))
+	for _, cp := range meta.Checkpoints {
+		if cp.ID == checkpointID {
+			cp = checkpoint
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	if err := validateCheckpoints(meta.StartValue, meta.TargetValue, checkpoints); err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	if err := s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		if err := s.store.UpdatePercentCheckpoint(ctx, checkpointID, store.PercentCheckpointInput{KeyResultID: krID, MetricValue: input.MetricValue, KRPercent: input.KRPercent}); err != nil {
+			return err
+		}
+		return s.RecordKRProgress(ctx, krID)
+	}); err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	return checkpoint, nil
+}
+
+// DeletePercentCheckpoint removes a checkpoint of the key result.
+func (s *Service) DeletePercentCheckpoint(ctx context.Context, krID, checkpointID int64) error {
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationStructural); err != nil {
+		return err
+	}
+	if err := s.checkpointOf(ctx, krID, checkpointID); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		if err := s.store.DeletePercentCheckpoint(ctx, krID, checkpointID); err != nil {
+			return err
+		}
+		return s.RecordKRProgress(ctx, krID)
+	})
+}
+
+// checkpointOf returns pgx.ErrNoRows when the checkpoint does not belong to the key result.
+func (s *Service) checkpointOf(ctx context.Context, krID, checkpointID int64) error {
+	checkpoint, err := s.store.GetPercentCheckpoint(ctx, checkpointID)
+	if err != nil {
+		return err
+	}
+	if checkpoint.KeyResultID != krID {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Service) percentMeta(ctx context.Context, krID int64) (*domain.KRPercent, error) {
+	kr, err := s.store.GetKeyResult(ctx, krID)
+	if err != nil {
+		return nil, err
+	}
+	if kr.Kind != domain.KRKindPercent {
+		return nil, fmt.Errorf("%w: checkpoints are supported only for PERCENT key results", ErrInvalidCheckpoint)
+	}
+	meta, checkpoints, err := s.store.GetPercentMeta(ctx, krID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: start and target are not set", ErrInvalidCheckpoint)
+		}
+		return nil, err
+	}
+	meta.Checkpoints = checkpoints
+	return meta, nil
+}
+
+// validateCheckpoints checks that every percent is 0..100, every metric value lies strictly between start and
+// target and is unique, and that percents do not decrease from start towards target.
+func validateCheckpoints(start, target float64, checkpoints []domain.KRPercentCheckpoint) error {
+	if start == target {
+		return fmt.Errorf("%w: start equals target", ErrInvalidCheckpoint)
+	}
+	low, high := math.Min(start, target), math.Max(start, target)
+	sorted := append([]domain.KRPercentCheckpoint(nil), checkpoints...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return math.Abs(sorted[i].MetricValue-start) < math.Abs(sorted[j].MetricValue-start)
+	})
+	for i, checkpoint := range sorted {
+		if checkpoint.KRPercent < 0 || checkpoint.KRPercent > 100 {
+			return fmt.Errorf("%w: percent must be 0..100", ErrInvalidCheckpoint)
+		}
+		if checkpoint.MetricValue <= low || checkpoint.MetricValue >= high {
+			return fmt.Errorf("%w: metric value must be between start and target", ErrInvalidCheckpoint)
+		}
+		if i == 0 {
+			continue
+		}
+		previous := sorted[i-1]
+		if previous.MetricValue == checkpoint.MetricValue {
+			return fmt.Errorf("%w: duplicate metric value", ErrInvalidCheckpoint)
+		}
+		if checkpoint.KRPercent < previous.KRPercent {
+			return fmt.Errorf("%w: percents must not decrease from start to target", ErrInvalidCheckpoint)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"okrs/internal/domain"
+)
+
+// ErrInvalidConfidence is returned when a confidence is outside 0..10.
+var ErrInvalidConfidence = errors.New("invalid confidence")
+
+// SetGoalConfidence sets the owner confidence of a goal, 0..10; nil clears it.
+func (s *Service) SetGoalConfidence(ctx context.Context, goalID int64, confidence *int) error {
+	if err := validateConfidence(confidence); err != nil {
+		return err
+	}
+	if err := s.CheckGoalMutation(ctx, goalID, MutationProgress); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionConfidence, func(ctx context.Context) error {
+		return s.store.UpdateGoalConfidence(ctx, goalID, confidence)
+	})
+}
+
+// SetKeyResultConfidence sets the owner confidence of a key result, 0..10; nil clears it.
+func (s *Service) SetKeyResultConfidence(ctx context.Context, krID int64, confidence *int) error {
+	if err := validateConfidence(confidence); err != nil {
+		return err
+	}
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationProgress); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionConfidence, func(ctx context.Context) error {
+		return s.store.UpdateKeyResultConfidence(ctx, krID, confidence)
+	})
+}
+
+func validateConfidence(confidence *int) error {
+	if confidence != nil && (*confidence < 0 || *confidence > 10) {
+		return fmt.Errorf("%w: must be 0..10", ErrInvalidConfidence)
+	}
+	return nil
+}
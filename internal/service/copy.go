@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+// ErrInvalidCopy is returned when a goal is copied into the period it belongs to.
+var ErrInvalidCopy = errors.New("goal is already in the target period")
+
+// RolloverResult lists the copies made by RollOverGoals.
+type RolloverResult struct {
+	GoalIDs []int64
+	// Skipped counts unfinished goals that already have a copy in the target period.
+	Skipped int
+}
+
+// CopyGoal clones the goal with its key results, meta and shares into another period of the same team and links
+// the copy to its origin. Without keepCurrent the key results of the copy start over: current values return to
+// the start, BOOLEAN KRs and project stages are not done.
+func (s *Service) CopyGoal(ctx context.Context, goalID, periodID int64, keepCurrent bool) (int64, error) {
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := s.copyGoals(ctx, goal.TeamID, periodID, []domain.Goal{goal}, keepCurrent)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// RollOverGoals copies the unfinished goals the team owns in one period into another, all or nothing.
+// Goals shared with the team are rolled over by their owner team; goals copied into the target period before
+// are skipped, so the rollover can be repeated.
+func (s *Service) RollOverGoals(ctx context.Context, teamID, fromPeriodID, toPeriodID int64, keepCurrent bool) (RolloverResult, error) {
+	if fromPeriodID == toPeriodID {
+		return RolloverResult{}, ErrInvalidCopy
+	}
+	goals, err := s.store.ListGoalsByTeamPeriod(ctx, teamID, fromPeriodID)
+	if err != nil {
+		return RolloverResult{}, err
+	}
+	origins, err := s.store.ListGoalOrigins(ctx, toPeriodID)
+	if err != nil {
+		return RolloverResult{}, err
+	}
+	copied := make(map[int64]bool, len(origins))
+	for _, id := range origins {
+		copied[id] = true
+	}
+	var result RolloverResult
+	unfinished := make([]domain.Goal, 0, len(goals))
+	for _, goal := range goals {
+		if goal.TeamID != teamID {
+			continue
+		}
+		if goal.Progress, err = s.GoalProgress(ctx, &goal); err != nil {
+			return RolloverResult{}, err
+		}
+		if goal.Progress >= 100 {
+			continue
+		}
+		if copied[goal.ID] {
+			result.Skipped++
+			continue
+		}
+		unfinished = append(unfinished, goal)
+	}
+	if len(unfinished) == 0 {
+		return result, s.CheckTeamPeriodMutation(ctx, teamID, toPeriodID, MutationStructural)
+	}
+	if result.GoalIDs, err = s.copyGoals(ctx, teamID, toPeriodID, unfinished, keepCurrent); err != nil {
+		return RolloverResult{}, err
+	}
+	return result, nil
+}
+
+// copyGoals creates copies of goals of the team in the period in one transaction, audits them like created goals
+// and sends goal.shared for the copies that keep shares.
+// The period must accept structural changes for the team and for every team the goals are shared with.
+func (s *Service) copyGoals(ctx context.Context, teamID, periodID int64, goals []domain.Goal, keepCurrent bool) ([]int64, error) {
+	for _, goal := range goals {
+		if goal.PeriodID == periodID {
+			return nil, ErrInvalidCopy
+		}
+	}
+	statuses := make(map[int64]domain.TeamPeriodStatus)
+	checkTeam := func(teamID int64) error {
+		if _, ok := statuses[teamID]; ok {
+			return nil
+		}
+		if err := s.CheckTeamPeriodMutation(ctx, teamID, periodID, MutationStructural); err != nil {
+			return err
+		}
+		status, err := s.store.GetTeamPeriodStatus(ctx, teamID, periodID)
+		if err != nil {
+			return err
+		}
+		statuses[teamID] = status
+		return nil
+	}
+	if err := checkTeam(teamID); err != nil {
+		return nil, err
+	}
+	team := store.ImportTeamInput{ID: teamID, Goals: make([]store.ImportGoalInput, 0, len(goals))}
+	for _, goal := range goals {
+		shares, err := s.store.ListGoalShares(ctx, goal.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			if err := checkTeam(share.TeamID); err != nil {
+				return nil, err
+			}
+		}
+		originID := goal.ID
+		item := store.ImportGoalInput{
+			Goal: store.GoalInput{
+				Title:       goal.Title,
+				Description: goal.Description,
+				Priority:    goal.Priority,
+				Weight:      goal.Weight,
+				WorkType:    goal.WorkType,
+				FocusType:   goal.FocusType,
+				OwnerText:   goal.OwnerText,
+			},
+			OriginGoalID: &originID,
+			OwnerUserID:  goal.OwnerUserID,
+		}
+		for _, share := range shares {
+			item.Shares = append(item.Shares, store.GoalShareInput{TeamID: share.TeamID, Weight: share.Weight})
+		}
+		for _, kr := range goal.KeyResults {
+			item.KeyResults = append(item.KeyResults, copyKeyResultInput(kr, keepCurrent))
+		}
+		team.Goals = append(team.Goals, item)
+	}
+	batch := store.ImportInput{PeriodID: periodID, Teams: []store.ImportTeamInput{team}}
+	if err := s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.ImportOKRs(ctx, &batch); err != nil {
+			return err
+		}
+		if err := s.auditCreatedOKRs(ctx, batch, nil, statuses); err != nil {
+			return err
+		}
+		if err := s.notifyCreatedOKRs(ctx, batch); err != nil {
+			return err
+		}
+		for _, goal := range batch.Teams[0].Goals {
+			if len(goal.Shares) > 0 {
+				if err := s.NotifyGoalShared(ctx, goal.ID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(batch.Teams[0].Goals))
+	for _, goal := range batch.Teams[0].Goals {
+		ids = append(ids, goal.ID)
+	}
+	return ids, nil
+}
+
+func copyKeyResultInput(kr domain.KeyResult, keepCurrent bool) store.ImportKeyResultInput {
+	item := store.ImportKeyResultInput{
+		KeyResult: store.KeyResultInput{Title: kr.Title, Description: kr.Description, Weight: kr.Weight, Kind: kr.Kind},
+	}
+	switch kr.Kind {
+	case domain.KRKindPercent:
+		if meta := kr.Percent; meta != nil {
+			item.Percent = store.PercentMetaInput{StartValue: meta.StartValue, TargetValue: meta.TargetValue, CurrentValue: meta.StartValue}
+			if keepCurrent {
+				item.Percent.CurrentValue = meta.CurrentValue
+			}
+		}
+	case domain.KRKindLinear:
+		if meta := kr.Linear; meta != nil {
+			item.Linear = store.LinearMetaInput{StartValue: meta.StartValue, TargetValue: meta.TargetValue, CurrentValue: meta.StartValue}
+			if keepCurrent {
+				item.Linear.CurrentValue = meta.CurrentValue
+			}
+		}
+	case domain.KRKindRange:
+		if meta := kr.Range; meta != nil {
+			item.Range = store.RangeMetaInput{MinValue: meta.MinValue, MaxValue: meta.MaxValue, Tolerance: meta.Tolerance}
+			if keepCurrent {
+				item.Range.CurrentValue = meta.CurrentValue
+			}
+		}
+	case domain.KRKindBoolean:
+		item.Boolean = keepCurrent && kr.Boolean != nil && kr.Boolean.IsDone
+	case domain.KRKindProject:
+		if kr.Project != nil {
+			for _, stage := range kr.Project.Stages {
+				item.Stages = append(item.Stages, store.ProjectStageInput{
+					Title:     stage.Title,
+					Weight:    stage.Weight,
+					SortOrder: stage.SortOrder,
+					IsDone:    keepCurrent && stage.IsDone,
+					DueDate:   stage.DueDate,
+				})
+			}
+		}
+	}
+	return item
+}
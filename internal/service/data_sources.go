@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+// ErrInvalidDataSource is returned when a data source binding is incomplete or the key result cannot be fed by it.
+var ErrInvalidDataSource = errors.New("invalid data source")
+
+// DefaultDataSourceInterval is the fetch interval in minutes used when none is given.
+const DefaultDataSourceInterval = 60
+
+// DataSourceInput binds a key result to an external metric; see domain.KRDataSource for the meaning of the fields.
+type DataSourceInput struct {
+	Kind            domain.DataSourceKind
+	Endpoint        string
+	Query           string
+	IntervalMinutes int
+}
+
+// ValidDataSourceKind reports whether the data source kind is known.
+func ValidDataSourceKind(kind domain.DataSourceKind) bool {
+	switch kind {
+	case domain.DataSourceHTTPJSON, domain.DataSourcePromQL, domain.DataSourceSQL:
+		return true
+	default:
+		return false
+	}
+}
+
+// DataSourceProgressSource is the KR history source of progress recorded by a data source run.
+func DataSourceProgressSource(kind domain.DataSourceKind) string {
+	return "data_source:" + string(kind)
+}
+
+// GetKRDataSource returns the data source of a key result or pgx.ErrNoRows when it has none.
+func (s *Service) GetKRDataSource(ctx context.Context, krID int64) (domain.KRDataSource, error) {
+	if _, err := s.store.GetKeyResult(ctx, krID); err != nil {
+		return domain.KRDataSource{}, err
+	}
+	return s.store.GetKRDataSource(ctx, krID)
+}
+
+// SetKRDataSource binds a percent, linear or range key result to a data source, replacing the previous binding.
+func (s *Service) SetKRDataSource(ctx context.Context, krID int64, input DataSourceInput) error {
+	if err := validateDataSource(&input); err != nil {
+		return err
+	}
+	kr, err := s.store.GetKeyResult(ctx, krID)
+	if err != nil {
+		return err
+	}
+	switch kr.Kind {
+	case domain.KRKindPercent, domain.KRKindLinear, domain.KRKindRange:
+	default:
+		return fmt.Errorf("%w: %s key results cannot be fed by a data source", ErrInvalidDataSource, kr.Kind)
+	}
+	if err := s.CheckGoalMutation(ctx, kr.GoalID, MutationStructural); err != nil {
+		return err
+	}
+	if input.Kind == domain.DataSourceSQL {
+		// SQL sources run arbitrary queries against server-wide DSNs, so only global admins may bind them.
+		if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+			return err
+		}
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		return s.store.UpsertKRDataSource(ctx, store.KRDataSourceInput{
+			KeyResultID:     krID,
+			Kind:            input.Kind,
+			Endpoint:        input.Endpoint,
+			Query:           input.Query,
+			IntervalMinutes: input.IntervalMinutes,
+		})
+	})
+}
+
+// DeleteKRDataSource unbinds the key result from its data source; its progress stays as last fetched.
+func (s *Service) DeleteKRDataSource(ctx context.Context, krID int64) error {
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationStructural); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		return s.store.DeleteKRDataSource(ctx, krID)
+	})
+}
+
+// validateDataSource trims the input and fills the default interval.
+func validateDataSource(input *DataSourceInput) error {
+	input.Endpoint = strings.TrimSpace(input.Endpoint)
+	input.Query = strings.TrimSpace(input.Query)
+	if !ValidDataSourceKind(input.Kind) {
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidDataSource, input.Kind)
+	}
+	if input.Endpoint == "" || input.Query == "" {
+		return fmt.Errorf("%w: endpoint and query are required", ErrInvalidDataSource)
+	}
+	if input.Kind == domain.DataSourceSQL {
+		input.Endpoint = strings.ToLower(input.Endpoint)
+	} else {
+		parsed, err := url.Parse(input.Endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: endpoint must be an http(s) URL", ErrInvalidDataSource)
+		}
+		if !publicHost(parsed.Hostname()) {
+			return fmt.Errorf("%w: endpoint must not point to a loopback or private address", ErrInvalidDataSource)
+		}
+	}
+	if input.IntervalMinutes == 0 {
+		input.IntervalMinutes = DefaultDataSourceInterval
+	}
+	if input.IntervalMinutes < 1 {
+		return fmt.Errorf("%w: interval must be at least one minute", ErrInvalidDataSource)
+	}
+	return nil
+}
+
+// publicHost rejects localhost and literal non-public addresses; host names are checked again by the fetch client
+// when they resolve.
+func publicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	return err != nil || PublicAddr(addr)
+}
+
+// PublicAddr reports whether a data source may connect to addr: loopback, private, link-local (including cloud
+// metadata endpoints), multicast and unspecified addresses are refused.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() && !addr.IsUnspecified()
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"okrs/internal/domain"
+	"okrs/internal/okr"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidDependency is returned when a key result would depend on itself, on a missing key result
+// or on a key result that already depends on it.
+var ErrInvalidDependency = errors.New("invalid kr dependency")
+
+// KRDependency is an upstream key result with calculated progress and its health against the planned
+// progress of its period.
+type KRDependency struct {
+	KeyResult domain.KeyResult
+	GoalTitle string
+	Team      domain.Team
+	Health    okr.Health
+}
+
+// BehindPlan reports whether the upstream key result is at risk or behind its planned progress.
+func (d KRDependency) BehindPlan() bool {
+	return d.Health == okr.HealthAtRisk || d.Health == okr.HealthBehind
+}
+
+// AddKRDependency records that the key result is blocked by dependsOnID. The cycle check and the insert run in one
+// transaction that holds the dependency graph lock, so two concurrent opposite edges cannot both pass the check.
+func (s *Service) AddKRDependency(ctx context.Context, krID, dependsOnID int64) error {
+	if krID == dependsOnID {
+		return fmt.Errorf("%w: key result cannot depend on itself", ErrInvalidDependency)
+	}
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationStructural); err != nil {
+		return err
+	}
+	if _, err := s.store.GetKeyResult(ctx, dependsOnID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: upstream key result not found", ErrInvalidDependency)
+		}
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionDependency, func(ctx context.Context) error {
+		if err := s.store.LockKRDependencies(ctx); err != nil {
+			return err
+		}
+		reachable, err := s.dependsOn(ctx, dependsOnID, krID)
+		if err != nil {
+			return err
+		}
+		if reachable {
+			return fmt.Errorf("%w: dependency cycle", ErrInvalidDependency)
+		}
+		return s.store.AddKRDependency(ctx, krID, dependsOnID)
+	})
+}
+
+// RemoveKRDependency removes the dependency of the key result on dependsOnID.
+func (s *Service) RemoveKRDependency(ctx context.Context, krID, dependsOnID int64) error {
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationStructural); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionDependency, func(ctx context.Context) error {
+		return s.store.DeleteKRDependency(ctx, krID, dependsOnID)
+	})
+}
+
+// dependsOn reports whether targetID is reachable from krID over the dependency graph.
+func (s *Service) dependsOn(ctx context.Context, krID, targetID int64) (bool, error) {
+	visited := map[int64]bool{krID: true}
+	queue := []int64{krID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		ids, err := s.store.ListKRDependencyIDs(ctx, current)
+		if err != nil {
+			return false, err
+		}
+		for _, id := range ids {
+			if id == targetID {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+	return false, nil
+}
+
+// ListKRDependencies returns the key results the key result depends on.
+func (s *Service) ListKRDependencies(ctx context.Context, krID int64) ([]KRDependency, error) {
+	if _, err := s.store.GetKeyResult(ctx, krID); err != nil {
+		return nil, err
+	}
+	ids, err := s.store.ListKRDependencyIDs(ctx, krID)
+	if err != nil {
+		return nil, err
+	}
+	planned := make(map[int64]int)
+	items := make([]KRDependency, 0, len(ids))
+	for _, id := range ids {
+		kr, err := s.keyResultWithMeta(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		kr.Progress = CalculateKRProgress(kr)
+		kr.Comments = nil
+		goal, err := s.store.GetGoal(ctx, kr.GoalID)
+		if err != nil {
+			return nil, err
+		}
+		team, err := s.store.GetTeam(ctx, goal.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := planned[goal.PeriodID]; !ok {
+			if planned[goal.PeriodID], err = s.periodPlannedProgress(ctx, goal.PeriodID); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, KRDependency{
+			KeyResult: kr,
+			GoalTitle: goal.Title,
+			Team:      team,
+			Health:    okr.ProgressHealth(kr.Progress, planned[goal.PeriodID], s.health),
+		})
+	}
+	return items, nil
+}
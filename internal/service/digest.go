@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/okr"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrUnknownUser is returned when a goal owner or a team lead refers to a missing user.
+var ErrUnknownUser = errors.New("unknown user")
+
+// Digest is the weekly reminder of a goal owner or team lead. A digest without items is not sent.
+type Digest struct {
+	User            domain.User
+	StaleKeyResults []DigestKeyResult
+	GoalsAtRisk     []DigestGoal
+	PendingStatuses []DigestStatus
+}
+
+// DigestKeyResult is a key result of a current period that has not been updated for the stale interval.
+type DigestKeyResult struct {
+	KeyResult domain.KeyResult
+	GoalTitle string
+	TeamName  string
+}
+
+// DigestGoal is a goal of a current period that is at risk or behind its planned progress.
+type DigestGoal struct {
+	Goal     domain.Goal
+	TeamName string
+	Health   okr.Health
+	Planned  int
+}
+
+// DigestStatus is a team period that waits for its next lifecycle status.
+type DigestStatus struct {
+	Team   domain.Team
+	Period domain.Period
+	Status domain.TeamPeriodStatus
+	Next   domain.TeamPeriodStatus
+}
+
+// Empty reports whether the digest has nothing to remind about.
+func (d Digest) Empty() bool {
+	return len(d.StaleKeyResults) == 0 && len(d.GoalsAtRisk) == 0 && len(d.PendingStatuses) == 0
+}
+
+// SetGoalOwner links the goal to the user who owns it; nil clears the link. The owner receives the goal in the digest.
+func (s *Service) SetGoalOwner(ctx context.Context, goalID int64, userID *int64) error {
+	if err := s.CheckGoalMutation(ctx, goalID, MutationStructural); err != nil {
+		return err
+	}
+	if err := s.checkUserExists(ctx, userID); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		return s.store.SetGoalOwnerUser(ctx, goalID, userID)
+	})
+}
+
+// SetTeamLead links the team to the user who leads it; nil clears the link. It requires an admin of the team.
+func (s *Service) SetTeamLead(ctx context.Context, teamID int64, userID *int64) error {
+	if _, err := s.store.GetTeam(ctx, teamID); err != nil {
+		return err
+	}
+	if err := s.Authorize(ctx, teamID, ActionAdmin); err != nil {
+		return err
+	}
+	if err := s.checkUserExists(ctx, userID); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityTeam, teamID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		return s.store.SetTeamLeadUser(ctx, teamID, userID)
+	})
+}
+
+// SetDigestOptOut turns the digest of a user off or back on. Users may always change their own setting.
+func (s *Service) SetDigestOptOut(ctx context.Context, userID int64, optOut bool) error {
+	if user, ok := auth.UserFromContext(ctx); !ok || user.ID != userID {
+		if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+			return err
+		}
+	}
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DigestOptOut == optOut {
+		return nil
+	}
+	before := userAuditState{Email: user.Email, Name: user.Name, DigestOptOut: user.DigestOptOut}
+	after := before
+	after.DigestOptOut = optOut
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.SetUserDigestOptOut(ctx, userID, optOut); err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityUser, userID, domain.AuditActionUpdate, before, after)
+	})
+}
+
+// DueDigests builds the digests of the users who have not opted out and have not been considered within interval.
+// Owners get their goals, leads every goal of their teams and the team periods waiting for a status change.
+// Goals and key results are taken from the periods that are running now; a status change is also pending for
+// the last ended period until it is closed.
+func (s *Service) DueDigests(ctx context.Context, interval, staleAfter time.Duration) ([]Digest, error) {
+	now := s.now()
+	users, err := s.store.ListDigestDueUsers(ctx, now.Add(-interval))
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+	digests := make([]Digest, len(users))
+	byUser := make(map[int64]*Digest, len(users))
+	for i, user := range users {
+		digests[i].User = user
+		byUser[user.ID] = &digests[i]
+	}
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	periods, err := s.store.ListPeriods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var lastEnded *domain.Period
+	var lastEndedAt time.Time
+	for i, period := range periods {
+		start, end := okr.PeriodBounds(period, s.zone)
+		if now.After(end) {
+			if lastEnded == nil || end.After(lastEndedAt) {
+				lastEnded, lastEndedAt = &periods[i], end
+			}
+			continue
+		}
+		if now.Before(start) {
+			continue
+		}
+		if err := s.collectPeriodDigests(ctx, byUser, teams, period, staleAfter); err != nil {
+			return nil, err
+		}
+	}
+	if lastEnded != nil {
+		for _, team := range teams {
+			digest := digestFor(byUser, team.LeadUserID)
+			if digest == nil {
+				continue
+			}
+			status, err := s.store.GetTeamPeriodStatus(ctx, team.ID, lastEnded.ID)
+			if err != nil {
+				return nil, err
+			}
+			if status != domain.TeamPeriodStatusNoGoals && status != domain.TeamPeriodStatusClosed {
+				digest.PendingStatuses = append(digest.PendingStatuses, DigestStatus{Team: team, Period: *lastEnded, Status: status, Next: statusTransitions[status][0]})
+			}
+		}
+	}
+	return digests, nil
+}
+
+// MarkDigestSent records that the user was considered for the digest now, whether or not it was sent.
+func (s *Service) MarkDigestSent(ctx context.Context, userID int64) error {
+	return s.store.MarkDigestSent(ctx, userID, s.now())
+}
+
+// collectPeriodDigests adds the stale key results, goals at risk and pending statuses of a running period.
+func (s *Service) collectPeriodDigests(ctx context.Context, byUser map[int64]*Digest, teams []domain.Team, period domain.Period, staleAfter time.Duration) error {
+	now := s.now()
+	for _, team := range teams {
+		lead := digestFor(byUser, team.LeadUserID)
+		if lead != nil {
+			status, err := s.store.GetTeamPeriodStatus(ctx, team.ID, period.ID)
+			if err != nil {
+				return err
+			}
+			if status == domain.TeamPeriodStatusForming || status == domain.TeamPeriodStatusInProgress {
+				lead.PendingStatuses = append(lead.PendingStatuses, DigestStatus{Team: team, Period: period, Status: status, Next: statusTransitions[status][0]})
+			}
+		}
+		goals, err := s.store.ListGoalsByTeamPeriod(ctx, team.ID, period.ID)
+		if err != nil {
+			return err
+		}
+		for i := range goals {
+			goal := &goals[i]
+			if goal.TeamID != team.ID {
+				continue
+			}
+			recipients := make([]*Digest, 0, 2)
+			if lead != nil {
+				recipients = append(recipients, lead)
+			}
+			if owner := digestFor(byUser, goal.OwnerUserID); owner != nil && owner != lead {
+				recipients = append(recipients, owner)
+			}
+			if len(recipients) == 0 {
+				continue
+			}
+			if goal.Progress, err = s.GoalProgress(ctx, goal); err != nil {
+				return err
+			}
+			health := s.GoalHealth(*goal, period)
+			for _, digest := range recipients {
+				if health == okr.HealthAtRisk || health == okr.HealthBehind {
+					digest.GoalsAtRisk = append(digest.GoalsAtRisk, DigestGoal{Goal: *goal, TeamName: team.Name, Health: health, Planned: s.PlannedProgress(period)})
+				}
+				for _, kr := range goal.KeyResults {
+					if staleAfter > 0 && now.Sub(kr.UpdatedAt) > staleAfter {
+						digest.StaleKeyResults = append(digest.StaleKeyResults, DigestKeyResult{KeyResult: kr, GoalTitle: goal.Title, TeamName: team.Name})
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkUserExists(ctx context.Context, userID *int64) error {
+	if userID == nil {
+		return nil
+	}
+	if _, err := s.store.GetUser(ctx, *userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: %d", ErrUnknownUser, *userID)
+		}
+		return err
+	}
+	return nil
+}
+
+func digestFor(byUser map[int64]*Digest, userID *int64) *Digest {
+	if userID == nil {
+		return nil
+	}
+	return byUser[*userID]
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+
+	"okrs/internal/domain"
+)
+
+// ExportRow is one key result of an OKR export together with its goal. A goal without key results is exported
+// as a single row with an empty KeyResult.
+type ExportRow struct {
+	TeamName   string
+	Goal       domain.Goal
+	KeyResult  domain.KeyResult
+	Start      *float64
+	Target     *float64
+	Current    *float64
+	Progress   int
+	ShareTeams []TeamShareInfo
+}
+
+// ExportTeamOKRs returns the export rows of the goals a team owns or shares in the period. Goal weights are
+// the weights within the team.
+func (s *Service) ExportTeamOKRs(ctx context.Context, teamID int64, period domain.Period) ([]ExportRow, error) {
+	teamOKR, err := s.GetTeamOKR(ctx, teamID, period.ID, period)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]ExportRow, 0, len(teamOKR.Goals))
+	for _, details := range teamOKR.Goals {
+		rows = appendExportRows(rows, teamOKR.Team.Name, details.Goal, details.ShareTeams)
+	}
+	return rows, nil
+}
+
+// ExportPeriodOKRs returns the export rows of every goal of the period in the order of the period goals page.
+func (s *Service) ExportPeriodOKRs(ctx context.Context, periodID int64) ([]ExportRow, error) {
+	goals, err := s.store.ListGoalsByPeriod(ctx, periodID)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	teamsByID, _, _ := buildTeamHierarchy(teams)
+	rows := make([]ExportRow, 0, len(goals))
+	for _, item := range goals {
+		goal := item.Goal
+		if goal.KeyResults, err = s.store.ListKeyResultsByGoal(ctx, goal.ID); err != nil {
+			return nil, err
+		}
+		if goal.Progress, err = s.GoalProgress(ctx, &goal); err != nil {
+			return nil, err
+		}
+		shares, err := s.listGoalShareTeams(ctx, goal, teamsByID)
+		if err != nil {
+			return nil, err
+		}
+		rows = appendExportRows(rows, item.TeamName, goal, shares)
+	}
+	return rows, nil
+}
+
+func appendExportRows(rows []ExportRow, teamName string, goal domain.Goal, shares []TeamShareInfo) []ExportRow {
+	if len(goal.KeyResults) == 0 {
+		return append(rows, ExportRow{TeamName: teamName, Goal: goal, Progress: goal.Progress, ShareTeams: shares})
+	}
+	for _, kr := range goal.KeyResults {
+		row := ExportRow{TeamName: teamName, Goal: goal, KeyResult: kr, Progress: CalculateKRProgress(kr), ShareTeams: shares}
+		row.Start, row.Target, row.Current = krExportValues(kr)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// krExportValues returns start, target and current values of a key result in the units of KRCurrentValue.
+// Range KRs export their bounds as start and target; an open bound is nil.
+func krExportValues(kr domain.KeyResult) (start, target, current *float64) {
+	value := KRCurrentValue(kr)
+	switch kr.Kind {
+	case domain.KRKindPercent:
+		if kr.Percent != nil {
+			return &kr.Percent.StartValue, &kr.Percent.TargetValue, &value
+		}
+	case domain.KRKindLinear:
+		if kr.Linear != nil {
+			return &kr.Linear.StartValue, &kr.Linear.TargetValue, &value
+		}
+	case domain.KRKindRange:
+		if kr.Range != nil {
+			return kr.Range.MinValue, kr.Range.MaxValue, &value
+		}
+	case domain.KRKindBoolean:
+		zero, one := 0.0, 1.0
+		return &zero, &one, &value
+	case domain.KRKindProject:
+		zero, stages := 0.0, 0.0
+		if kr.Project != nil {
+			stages = float64(len(kr.Project.Stages))
+		}
+		return &zero, &stages, &value
+	}
+	return nil, nil, nil
+}
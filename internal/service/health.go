@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	"okrs/internal/domain"
+	"okrs/internal/okr"
+)
+
+// GoalHealth returns the health of a goal with calculated progress against the planned progress of its period.
+func (s *Service) GoalHealth(goal domain.Goal, period domain.Period) okr.Health {
+	return okr.GoalHealth(goal, s.PlannedProgress(period), s.now(), s.health)
+}
+
+// PlannedProgress returns the share of the period elapsed now, 0..100.
+func (s *Service) PlannedProgress(period domain.Period) int {
+	start, end := okr.PeriodBounds(period, s.zone)
+	return okr.PlannedProgress(s.now(), start, end)
+}
+
+func (s *Service) periodPlannedProgress(ctx context.Context, periodID int64) (int, error) {
+	period, err := s.store.GetPeriod(ctx, periodID)
+	if err != nil {
+		return 0, err
+	}
+	return s.PlannedProgress(period), nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+// ImportIssue is a problem found in an import document; Location points into the document, e.g. "teams[0].goals[1]"
+// or "row 4".
+type ImportIssue struct {
+	Location string
+	Message  string
+}
+
+// ImportInput is a validated import document for one period.
+type ImportInput struct {
+	PeriodID int64
+	Teams    []ImportTeam
+}
+
+// ImportTeam refers to a team by name. A team that does not exist is created with Type under the team named Parent,
+// which may be an existing team or one created earlier in the same document.
+type ImportTeam struct {
+	Location string
+	Name     string
+	Type     domain.TeamType
+	Parent   string
+	Goals    []ImportGoal
+}
+
+type ImportGoal struct {
+	Goal       store.GoalInput
+	KeyResults []ImportKeyResult
+}
+
+type ImportKeyResult struct {
+	KeyResult store.KeyResultInput
+	Meta      KeyResultMetaInput
+}
+
+// ImportReport describes what an import created or, for a dry run or a document with issues, would create.
+type ImportReport struct {
+	DryRun       bool
+	Applied      bool
+	TeamsCreated int
+	Goals        int
+	KeyResults   int
+	Issues       []ImportIssue
+}
+
+// ImportOKRs resolves the teams of the document and checks that the current user may create goals in them.
+// Unless dryRun is set or issues are found, everything is created in one transaction and then audited
+// like the single-goal and single-KR mutations.
+func (s *Service) ImportOKRs(ctx context.Context, input ImportInput, dryRun bool) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun}
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	teamsByName := make(map[string]domain.Team, len(teams))
+	for _, team := range teams {
+		teamsByName[team.Name] = team
+	}
+	batch := store.ImportInput{PeriodID: input.PeriodID, Teams: make([]store.ImportTeamInput, 0, len(input.Teams))}
+	indexByName := make(map[string]int, len(input.Teams))
+	statuses := make(map[int64]domain.TeamPeriodStatus, len(input.Teams))
+	for _, team := range input.Teams {
+		if _, ok := indexByName[team.Name]; ok {
+			report.Issues = append(report.Issues, ImportIssue{Location: team.Location, Message: fmt.Sprintf("Команда «%s» указана дважды", team.Name)})
+			continue
+		}
+		item, issue, err := s.resolveImportTeam(ctx, input.PeriodID, team, teamsByName, indexByName, batch.Teams)
+		if err != nil {
+			return ImportReport{}, err
+		}
+		if issue != "" {
+			report.Issues = append(report.Issues, ImportIssue{Location: team.Location, Message: issue})
+			continue
+		}
+		if item.ID == 0 {
+			report.TeamsCreated++
+		} else if len(team.Goals) > 0 {
+			if statuses[item.ID], err = s.store.GetTeamPeriodStatus(ctx, item.ID, input.PeriodID); err != nil {
+				return ImportReport{}, err
+			}
+		}
+		for _, goal := range team.Goals {
+			importGoal := store.ImportGoalInput{Goal: goal.Goal}
+			for _, kr := range goal.KeyResults {
+				importGoal.KeyResults = append(importGoal.KeyResults, importKeyResultInput(kr))
+			}
+			item.Goals = append(item.Goals, importGoal)
+			report.Goals++
+			report.KeyResults += len(goal.KeyResults)
+		}
+		indexByName[team.Name] = len(batch.Teams)
+		batch.Teams = append(batch.Teams, item)
+	}
+	if dryRun || len(report.Issues) > 0 {
+		return report, nil
+	}
+	created := make(map[int]bool, len(batch.Teams))
+	for i, team := range batch.Teams {
+		created[i] = team.ID == 0
+	}
+	if err := s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.ImportOKRs(ctx, &batch); err != nil {
+			return err
+		}
+		if err := s.auditCreatedOKRs(ctx, batch, created, statuses); err != nil {
+			return err
+		}
+		return s.notifyCreatedOKRs(ctx, batch)
+	}); err != nil {
+		return ImportReport{}, err
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// resolveImportTeam returns the store input of an existing team, or of a team to create, together with
+// a validation message when the team cannot be used.
+func (s *Service) resolveImportTeam(ctx context.Context, periodID int64, team ImportTeam, teamsByName map[string]domain.Team,
+	indexByName map[string]int, resolved []store.ImportTeamInput) (store.ImportTeamInput, string, error) {
+	if existing, ok := teamsByName[team.Name]; ok {
+		if len(team.Goals) > 0 {
+			if err := s.CheckTeamPeriodMutation(ctx, existing.ID, periodID, MutationStructural); err != nil {
+				return store.ImportTeamInput{}, "", fmt.Errorf("team %q: %w", team.Name, err)
+			}
+		}
+		return store.ImportTeamInput{ID: existing.ID}, "", nil
+	}
+	if team.Type == "" {
+		return store.ImportTeamInput{}, fmt.Sprintf("Команда «%s» не найдена; укажите тип, чтобы создать её", team.Name), nil
+	}
+	item := store.ImportTeamInput{Team: store.TeamInput{Name: team.Name, Type: team.Type}}
+	var adminScope *int64
+	if team.Parent != "" {
+		if parent, ok := teamsByName[team.Parent]; ok {
+			item.Team.ParentID = &parent.ID
+			adminScope = &parent.ID
+		} else if index, ok := indexByName[team.Parent]; ok {
+			item.ParentIndex = &index
+			adminScope = importAdminScope(resolved, index)
+		} else {
+			return store.ImportTeamInput{}, fmt.Sprintf("Родительская команда «%s» не найдена", team.Parent), nil
+		}
+	}
+	if err := s.AuthorizeTeamAdmin(ctx, adminScope); err != nil {
+		return store.ImportTeamInput{}, "", fmt.Errorf("team %q: %w", team.Name, err)
+	}
+	return item, "", nil
+}
+
+// importAdminScope returns the nearest existing ancestor of a team created by the import, which is the team
+// whose admin may create the whole new subtree; nil means a global admin is needed.
+func importAdminScope(resolved []store.ImportTeamInput, index int) *int64 {
+	for {
+		team := resolved[index]
+		if team.ID != 0 {
+			return &team.ID
+		}
+		if team.ParentIndex == nil {
+			return team.Team.ParentID
+		}
+		index = *team.ParentIndex
+	}
+}
+
+func importKeyResultInput(kr ImportKeyResult) store.ImportKeyResultInput {
+	meta := kr.Meta
+	return store.ImportKeyResultInput{
+		KeyResult: kr.KeyResult,
+		Percent:   store.PercentMetaInput{StartValue: meta.PercentStart, TargetValue: meta.PercentTarget, CurrentValue: meta.PercentCurrent},
+		Linear:    store.LinearMetaInput{StartValue: meta.LinearStart, TargetValue: meta.LinearTarget, CurrentValue: meta.LinearCurrent},
+		Boolean:   meta.BooleanDone,
+		Range:     store.RangeMetaInput{MinValue: meta.RangeMin, MaxValue: meta.RangeMax, Tolerance: meta.RangeTolerance, CurrentValue: meta.RangeCurrent},
+		Stages:    meta.ProjectStages,
+	}
+}
+
+// auditCreatedOKRs writes the audit events CreateGoal and CreateKeyResultWithMeta write for every entity created
+// by Store.ImportOKRs, including status moves to forming of owner and share teams, in the transaction of the import.
+// created marks the indexes of the batch teams that were created; statuses holds the period statuses before it.
+func (s *Service) auditCreatedOKRs(ctx context.Context, batch store.ImportInput, created map[int]bool, statuses map[int64]domain.TeamPeriodStatus) error {
+	started := make(map[int64]bool)
+	startForming := func(teamID int64, isNew bool) error {
+		if status, ok := statuses[teamID]; started[teamID] || !isNew && !(ok && status == domain.TeamPeriodStatusNoGoals) {
+			return nil
+		}
+		started[teamID] = true
+		return s.AuditStatusChange(ctx, teamID, batch.PeriodID, domain.TeamPeriodStatusNoGoals, domain.TeamPeriodStatusForming)
+	}
+	for i, team := range batch.Teams {
+		if created[i] {
+			if err := s.AuditChange(ctx, domain.AuditEntityTeam, team.ID, domain.AuditActionCreate, nil); err != nil {
+				return err
+			}
+		}
+		if len(team.Goals) == 0 {
+			continue
+		}
+		if err := startForming(team.ID, created[i]); err != nil {
+			return err
+		}
+		for _, goal := range team.Goals {
+			if err := s.AuditChange(ctx, domain.AuditEntityGoal, goal.ID, domain.AuditActionCreate, nil); err != nil {
+				return err
+			}
+			for _, share := range goal.Shares {
+				if err := startForming(share.TeamID, false); err != nil {
+					return err
+				}
+			}
+			for _, kr := range goal.KeyResults {
+				if err := s.AuditChange(ctx, domain.AuditEntityKeyResult, kr.ID, domain.AuditActionCreate, nil); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// notifyCreatedOKRs queues goal.created and records the first KR history points of an import in its transaction.
+func (s *Service) notifyCreatedOKRs(ctx context.Context, batch store.ImportInput) error {
+	for _, team := range batch.Teams {
+		for _, goal := range team.Goals {
+			data := goalWebhookData{GoalID: goal.ID, TeamID: team.ID, PeriodID: batch.PeriodID, Title: goal.Goal.Title}
+			if err := s.emitWebhookEvent(ctx, domain.WebhookEventGoalCreated, data, team.ID); err != nil {
+				return err
+			}
+			for _, kr := range goal.KeyResults {
+				if err := s.RecordKRProgress(ctx, kr.ID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+)
+
+var (
+	// ErrInvalidSignature is returned when an ingest request is not signed with the ingest secret.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidIngest is returned when an ingest payload is stale or does not match the key result kind.
+	ErrInvalidIngest = errors.New("invalid ingest payload")
+	// ErrIdempotencyConflict is returned when an idempotency key is reused with a different payload.
+	ErrIdempotencyConflict = errors.New("idempotency key reused with a different payload")
+)
+
+const (
+	// IngestMaxSkew is how far the timestamp of an ingest payload may be from the server time.
+	IngestMaxSkew = 5 * time.Minute
+	// IngestKeyTTL is how long idempotency keys are remembered.
+	IngestKeyTTL = 7 * 24 * time.Hour
+	// IngestProgressSource is the KR history source of values pushed to the ingest endpoint.
+	IngestProgressSource = "ingest"
+)
+
+// IngestInput is a value pushed for a key result. KeyResultID is the signed KR ID and must match the KR the value
+// is pushed to. Value feeds percent, linear and range KRs, Done boolean KRs and Stages project KRs.
+// Payload is the raw signed body; its hash detects reuse of an idempotency key.
+type IngestInput struct {
+	KeyResultID    int64
+	IdempotencyKey string
+	Payload        []byte
+	Timestamp      time.Time
+	Value          *float64
+	Done           *bool
+	Stages         []ProjectStageUpdate
+}
+
+// WithIngestSecret sets the secret that signs requests to the ingest endpoint; without it ingest is disabled.
+func (s *Service) WithIngestSecret(secret string) *Service {
+	s.ingestSecret = []byte(secret)
+	return s
+}
+
+// VerifyIngestSignature checks the "sha256=<hex>" HMAC of a raw ingest body.
+func (s *Service) VerifyIngestSignature(body []byte, signature string) error {
+	if !auth.CheckSignature(s.ingestSecret, body, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// IngestKRValue applies a pushed value through the progress update of the key result kind. It returns false
+// without changes when the idempotency key was already applied with the same payload. The payload hash is claimed
+// as a key too, with or without an Idempotency-Key: the header is not signed, so a captured request cannot be
+// replayed under a new key. The keys are claimed in the transaction of the update and are released with it.
+func (s *Service) IngestKRValue(ctx context.Context, krID int64, input IngestInput) (bool, error) {
+	now := s.now()
+	if input.KeyResultID != krID {
+		return false, fmt.Errorf("%w: kr_id does not match the key result", ErrInvalidIngest)
+	}
+	if input.Timestamp.IsZero() {
+		return false, fmt.Errorf("%w: timestamp is required", ErrInvalidIngest)
+	}
+	if skew := now.Sub(input.Timestamp); skew > IngestMaxSkew || skew < -IngestMaxSkew {
+		return false, fmt.Errorf("%w: timestamp is more than %s away from server time", ErrInvalidIngest, IngestMaxSkew)
+	}
+	kr, err := s.store.GetKeyResult(ctx, krID)
+	if err != nil {
+		return false, err
+	}
+	apply, err := s.ingestUpdate(kr, input)
+	if err != nil {
+		return false, err
+	}
+	if err := s.store.DeleteIngestKeysBefore(ctx, now.Add(-IngestKeyTTL)); err != nil {
+		return false, err
+	}
+	hash := auth.HashToken(string(input.Payload))
+	keys := []string{"payload:" + hash}
+	if input.IdempotencyKey != "" && input.IdempotencyKey != keys[0] {
+		keys = append([]string{input.IdempotencyKey}, keys...)
+	}
+	applied := false
+	err = s.store.InTx(ctx, func(ctx context.Context) error {
+		for _, key := range keys {
+			claimed, existing, err := s.store.ClaimIngestKey(ctx, krID, key, hash)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				if existing != hash {
+					return ErrIdempotencyConflict
+				}
+				return nil
+			}
+		}
+		applied = true
+		return apply(WithProgressSource(ctx, IngestProgressSource))
+	})
+	return applied && err == nil, err
+}
+
+// ingestUpdate picks the progress update for the key result kind and checks the payload carries its value.
+func (s *Service) ingestUpdate(kr domain.KeyResult, input IngestInput) (func(context.Context) error, error) {
+	switch kr.Kind {
+	case domain.KRKindPercent, domain.KRKindLinear, domain.KRKindRange:
+		if input.Value == nil {
+			return nil, fmt.Errorf("%w: value is required for %s key results", ErrInvalidIngest, kr.Kind)
+		}
+		return func(ctx context.Context) error { return s.UpdateKRProgressPercent(ctx, kr.ID, *input.Value) }, nil
+	case domain.KRKindBoolean:
+		if input.Done == nil {
+			return nil, fmt.Errorf("%w: done is required for BOOLEAN key results", ErrInvalidIngest)
+		}
+		return func(ctx context.Context) error { return s.UpdateKRProgressBoolean(ctx, kr.ID, *input.Done) }, nil
+	case domain.KRKindProject:
+		if len(input.Stages) == 0 {
+			return nil, fmt.Errorf("%w: stages are required for PROJECT key results", ErrInvalidIngest)
+		}
+		return func(ctx context.Context) error { return s.UpdateKRProgressProject(ctx, kr.ID, input.Stages) }, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported kind %s", ErrInvalidIngest, kr.Kind)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"okrs/internal/domain"
+	"okrs/internal/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type progressSourceKey struct{}
+
+// WithProgressSource marks progress updates made with ctx as coming from source in the KR history.
+func WithProgressSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, progressSourceKey{}, source)
+}
+
+// ProgressSource returns the source set by WithProgressSource or domain.ProgressSourceManual.
+func ProgressSource(ctx context.Context) string {
+	if source, ok := ctx.Value(progressSourceKey{}).(string); ok && source != "" {
+		return source
+	}
+	return domain.ProgressSourceManual
+}
+
+// RecordKRProgress appends the current value and progress of a key result to its history.
+// It is called after every progress update so kr_progress_events forms a time series.
+// A change of value or progress since the previous entry is also sent to kr.progress_changed webhooks,
+// to kr.completed webhooks when the key result reaches 100% and updates the health of the teams of the goal.
+func (s *Service) RecordKRProgress(ctx context.Context, krID int64) error {
+	kr, err := s.keyResultWithMeta(ctx, krID)
+	if err != nil {
+		return err
+	}
+	last, err := s.store.LastKRProgressEvent(ctx, krID)
+	hasLast := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	event := store.KRProgressEventInput{
+		KeyResultID: krID,
+		Value:       KRCurrentValue(kr),
+		Progress:    CalculateKRProgress(kr),
+		Source:      ProgressSource(ctx),
+	}
+	if err := s.store.AddKRProgressEvent(ctx, event); err != nil {
+		return err
+	}
+	data := krProgressWebhookData{KeyResultID: krID, GoalID: kr.GoalID, Title: kr.Title, Value: event.Value, Progress: event.Progress, Source: event.Source}
+	if hasLast {
+		if last.Value == event.Value && last.Progress == event.Progress {
+			return nil
+		}
+		data.PreviousProgress = &last.Progress
+	}
+	goal, err := s.store.GetGoal(ctx, kr.GoalID)
+	if err != nil {
+		return err
+	}
+	if err := s.emitWebhookEvent(ctx, domain.WebhookEventKRProgressChanged, data, goal.TeamID); err != nil {
+		return err
+	}
+	if event.Progress >= 100 && (data.PreviousProgress == nil || *data.PreviousProgress < 100) {
+		if err := s.emitWebhookEvent(ctx, domain.WebhookEventKRCompleted, data, goal.TeamID); err != nil {
+			return err
+		}
+	}
+	return s.notifyGoalTeamsHealth(ctx, goal)
+}
+
+// BackfillKRProgressHistory records the current value and progress of every key result without history, such as
+// key results created before the history existed, so their first change has a previous point to compare with.
+// No webhooks are sent. It returns the number of key results backfilled.
+func (s *Service) BackfillKRProgressHistory(ctx context.Context) (int, error) {
+	ids, err := s.store.ListKeyResultIDsWithoutHistory(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, krID := range ids {
+		kr, err := s.keyResultWithMeta(ctx, krID)
+		if err != nil {
+			return i, err
+		}
+		event := store.KRProgressEventInput{
+			KeyResultID: krID,
+			Value:       KRCurrentValue(kr),
+			Progress:    CalculateKRProgress(kr),
+			Source:      ProgressSource(ctx),
+		}
+		if err := s.store.AddKRProgressEvent(ctx, event); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// ListKRProgressHistory returns the progress history of a key result, oldest first.
+func (s *Service) ListKRProgressHistory(ctx context.Context, krID int64) ([]domain.KRProgressEvent, error) {
+	if _, err := s.store.GetKeyResult(ctx, krID); err != nil {
+		return nil, err
+	}
+	return s.store.ListKRProgressEvents(ctx, krID)
+}
+
+// KRCurrentValue returns the measured value of a key result: the current value of percent, linear and range KRs,
+// 1 or 0 for boolean KRs and the number of done stages for project KRs.
+func KRCurrentValue(kr domain.KeyResult) float64 {
+	switch kr.Kind {
+	case domain.KRKindPercent:
+		if kr.Percent != nil {
+			return kr.Percent.CurrentValue
+		}
+	case domain.KRKindLinear:
+		if kr.Linear != nil {
+			return kr.Linear.CurrentValue
+		}
+	case domain.KRKindRange:
+		if kr.Range != nil {
+			return kr.Range.CurrentValue
+		}
+	case domain.KRKindBoolean:
+		if kr.Boolean != nil && kr.Boolean.IsDone {
+			return 1
+		}
+	case domain.KRKindProject:
+		if kr.Project != nil {
+			done := 0
+			for _, stage := range kr.Project.Stages {
+				if stage.IsDone {
+					done++
+				}
+			}
+			return float64(done)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"okrs/internal/domain"
+)
+
+// ErrInvalidStatusTransition is returned when a team period status change is not allowed by the lifecycle.
+var ErrInvalidStatusTransition = errors.New("invalid status transition")
+
+// ErrLocked is returned when a mutation is not allowed by the team period status.
+var ErrLocked = errors.New("team period is locked")
+
+// MutationKind classifies a write so the lifecycle policy can decide whether it is allowed.
+type MutationKind string
+
+const (
+	// MutationStructural covers create/update/delete of goals and KRs, weights, shares and KR meta.
+	MutationStructural MutationKind = "structural"
+	// MutationProgress covers current value updates of key results.
+	MutationProgress MutationKind = "progress"
+	// MutationComment covers goal and KR comments.
+	MutationComment MutationKind = "comment"
+	// MutationReorder covers moving goals and KRs up and down.
+	MutationReorder MutationKind = "reorder"
+)
+
+// statusTransitions lists forward lifecycle transitions available to every user.
+var statusTransitions = map[domain.TeamPeriodStatus][]domain.TeamPeriodStatus{
+	domain.TeamPeriodStatusNoGoals:    {domain.TeamPeriodStatusForming},
+	domain.TeamPeriodStatusForming:    {domain.TeamPeriodStatusInProgress},
+	domain.TeamPeriodStatusInProgress: {domain.TeamPeriodStatusValidated},
+	domain.TeamPeriodStatusValidated:  {domain.TeamPeriodStatusClosed},
+}
+
+// reopenTransitions lists transitions that move a period back and are reserved for admins.
+var reopenTransitions = map[domain.TeamPeriodStatus][]domain.TeamPeriodStatus{
+	domain.TeamPeriodStatusValidated: {domain.TeamPeriodStatusInProgress},
+	domain.TeamPeriodStatusClosed:    {domain.TeamPeriodStatusInProgress},
+}
+
+// CanTransitionStatus reports whether a team period may move from one status to another.
+// Keeping the current status is always allowed.
+func CanTransitionStatus(from, to domain.TeamPeriodStatus, admin bool) bool {
+	if from == to {
+		return true
+	}
+	if containsStatus(statusTransitions[from], to) {
+		return true
+	}
+	return admin && containsStatus(reopenTransitions[from], to)
+}
+
+func validateStatusTransition(from, to domain.TeamPeriodStatus, admin bool) error {
+	if CanTransitionStatus(from, to, admin) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, from, to)
+}
+
+func containsStatus(statuses []domain.TeamPeriodStatus, status domain.TeamPeriodStatus) bool {
+	for _, item := range statuses {
+		if item == status {
+			return true
+		}
+	}
+	return false
+}
+
+// MutationAllowed reports whether a mutation of the given kind is allowed in a team period status.
+// Validated periods only accept progress, comments and reordering; closed periods are read-only.
+func MutationAllowed(status domain.TeamPeriodStatus, kind MutationKind) bool {
+	switch status {
+	case domain.TeamPeriodStatusClosed:
+		return false
+	case domain.TeamPeriodStatusValidated:
+		return kind != MutationStructural
+	default:
+		return true
+	}
+}
+
+// CheckTeamPeriodMutation returns ErrForbidden when the current user may not edit the team
+// and ErrLocked when the team period status forbids the mutation.
+func (s *Service) CheckTeamPeriodMutation(ctx context.Context, teamID, periodID int64, kind MutationKind) error {
+	if err := s.Authorize(ctx, teamID, ActionEdit); err != nil {
+		return err
+	}
+	status, err := s.store.GetTeamPeriodStatus(ctx, teamID, periodID)
+	if err != nil {
+		return err
+	}
+	if !MutationAllowed(status, kind) {
+		return fmt.Errorf("%w: %s mutation in %s period", ErrLocked, kind, status)
+	}
+	return nil
+}
+
+// CheckGoalMutation applies the lifecycle policy of the goal owner team and period.
+func (s *Service) CheckGoalMutation(ctx context.Context, goalID int64, kind MutationKind) error {
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	return s.CheckTeamPeriodMutation(ctx, goal.TeamID, goal.PeriodID, kind)
+}
+
+// CheckKeyResultMutation applies the lifecycle policy of the goal that owns the key result.
+func (s *Service) CheckKeyResultMutation(ctx context.Context, krID int64, kind MutationKind) error {
+	kr, err := s.store.GetKeyResult(ctx, krID)
+	if err != nil {
+		return err
+	}
+	return s.CheckGoalMutation(ctx, kr.GoalID, kind)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+// CreatePeriod creates a period; periods are managed by global admins only.
+func (s *Service) CreatePeriod(ctx context.Context, input store.PeriodInput) (int64, error) {
+	if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+		return 0, err
+	}
+	var periodID int64
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if periodID, err = s.store.CreatePeriod(ctx, input); err != nil {
+			return err
+		}
+		return s.AuditChange(ctx, domain.AuditEntityPeriod, periodID, domain.AuditActionCreate, nil)
+	})
+	return periodID, err
+}
+
+func (s *Service) UpdatePeriod(ctx context.Context, periodID int64, input store.PeriodInput) error {
+	if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityPeriod, periodID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		return s.store.UpdatePeriod(ctx, periodID, input)
+	})
+}
+
+func (s *Service) DeletePeriod(ctx context.Context, periodID int64) error {
+	if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityPeriod, periodID, domain.AuditActionDelete, func(ctx context.Context) error {
+		return s.store.DeletePeriod(ctx, periodID)
+	})
+}
+
+// MovePeriod moves the period one place up (direction -1) or down (1) in the list.
+func (s *Service) MovePeriod(ctx context.Context, periodID int64, direction int) error {
+	if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityPeriod, periodID, domain.AuditActionMove, func(ctx context.Context) error {
+		return s.store.MovePeriod(ctx, periodID, direction)
+	})
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+// ErrForbidden is returned when the current user has no role that allows the action.
+var ErrForbidden = errors.New("forbidden")
+
+// Action is a permission checked against the role assignments of the current user.
+type Action string
+
+// Reading teams, goals and key results is open to every authenticated user and is not an action.
+const (
+	// ActionEdit covers goals, KRs, comments, progress and the forming/in_progress statuses.
+	ActionEdit Action = "edit"
+	// ActionValidate covers moving a team period to validated or closed.
+	ActionValidate Action = "validate"
+	// ActionAdmin covers teams, periods, role assignments and reopening periods.
+	ActionAdmin Action = "admin"
+)
+
+// roleActions lists what each role may do; every role includes the rights of the previous one.
+// A viewer may not change anything.
+var roleActions = map[domain.Role][]Action{
+	domain.RoleViewer:    {},
+	domain.RoleEditor:    {ActionEdit},
+	domain.RoleValidator: {ActionEdit, ActionValidate},
+	domain.RoleAdmin:     {ActionEdit, ActionValidate, ActionAdmin},
+}
+
+// Permissions resolves role assignments of a user against the team hierarchy.
+type Permissions struct {
+	assignments []domain.RoleAssignment
+	teamsByID   map[int64]domain.Team
+}
+
+func NewPermissions(assignments []domain.RoleAssignment, teams []domain.Team) Permissions {
+	teamsByID, _, _ := buildTeamHierarchy(teams)
+	return Permissions{assignments: assignments, teamsByID: teamsByID}
+}
+
+// Can reports whether the action is allowed on the team.
+// Subtree scopes cover the team itself and every descendant reached through ParentID.
+func (p Permissions) Can(action Action, teamID int64) bool {
+	for _, assignment := range p.assignments {
+		if !roleAllows(assignment.Role, action) {
+			continue
+		}
+		switch assignment.Scope {
+		case domain.RoleScopeGlobal:
+			return true
+		case domain.RoleScopeTeam:
+			if assignment.TeamID != nil && *assignment.TeamID == teamID {
+				return true
+			}
+		case domain.RoleScopeSubtree:
+			if assignment.TeamID != nil && p.inSubtree(teamID, *assignment.TeamID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanGlobal reports whether the action is allowed by a global assignment.
+func (p Permissions) CanGlobal(action Action) bool {
+	for _, assignment := range p.assignments {
+		if assignment.Scope == domain.RoleScopeGlobal && roleAllows(assignment.Role, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Permissions) inSubtree(teamID, rootID int64) bool {
+	visited := make(map[int64]bool)
+	current := teamID
+	for !visited[current] {
+		if current == rootID {
+			return true
+		}
+		visited[current] = true
+		team, ok := p.teamsByID[current]
+		if !ok || team.ParentID == nil {
+			return false
+		}
+		current = *team.ParentID
+	}
+	return false
+}
+
+// statusAction returns the action required to move a team period between statuses.
+func statusAction(from, to domain.TeamPeriodStatus) Action {
+	switch {
+	case containsStatus(reopenTransitions[from], to):
+		return ActionAdmin
+	case to == domain.TeamPeriodStatusValidated || to == domain.TeamPeriodStatusClosed:
+		return ActionValidate
+	default:
+		return ActionEdit
+	}
+}
+
+func roleAllows(role domain.Role, action Action) bool {
+	for _, item := range roleActions[role] {
+		if item == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether the role is known.
+func ValidRole(role domain.Role) bool {
+	_, ok := roleActions[role]
+	return ok
+}
+
+// ValidRoleScope reports whether the scope is known.
+func ValidRoleScope(scope domain.RoleScope) bool {
+	switch scope {
+	case domain.RoleScopeGlobal, domain.RoleScopeSubtree, domain.RoleScopeTeam:
+		return true
+	default:
+		return false
+	}
+}
+
+// CurrentPermissions returns the permissions of the user in ctx.
+// The boolean is false for calls without a user, such as background jobs and tests.
+func (s *Service) CurrentPermissions(ctx context.Context) (Permissions, bool, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return Permissions{}, false, nil
+	}
+	assignments, err := s.store.ListRoleAssignments(ctx, user.ID)
+	if err != nil {
+		return Permissions{}, true, err
+	}
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return Permissions{}, true, err
+	}
+	return NewPermissions(assignments, teams), true, nil
+}
+
+// Authorize returns ErrForbidden when the user in ctx may not perform the action on the team.
+// Calls without a user are trusted; the HTTP router guarantees a user for every request.
+func (s *Service) Authorize(ctx context.Context, teamID int64, action Action) error {
+	perms, ok, err := s.CurrentPermissions(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	if !perms.Can(action, teamID) {
+		return fmt.Errorf("%w: %s on team %d", ErrForbidden, action, teamID)
+	}
+	return nil
+}
+
+// AuthorizeGlobal returns ErrForbidden unless the user in ctx has a global assignment for the action.
+func (s *Service) AuthorizeGlobal(ctx context.Context, action Action) error {
+	perms, ok, err := s.CurrentPermissions(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	if !perms.CanGlobal(action) {
+		return fmt.Errorf("%w: global %s", ErrForbidden, action)
+	}
+	return nil
+}
+
+// AuthorizeTeamAdmin checks admin rights for creating or editing a team under parentID.
+// Root teams require a global admin.
+func (s *Service) AuthorizeTeamAdmin(ctx context.Context, parentID *int64) error {
+	if parentID == nil {
+		return s.AuthorizeGlobal(ctx, ActionAdmin)
+	}
+	return s.Authorize(ctx, *parentID, ActionAdmin)
+}
+
+// TeamPermissions summarises what the current user may do in a team.
+type TeamPermissions struct {
+	CanEdit     bool
+	CanValidate bool
+	CanAdmin    bool
+}
+
+func (s *Service) teamPermissions(ctx context.Context, teamID int64) (TeamPermissions, error) {
+	perms, ok, err := s.CurrentPermissions(ctx)
+	if err != nil {
+		return TeamPermissions{}, err
+	}
+	if !ok {
+		return TeamPermissions{CanEdit: true, CanValidate: true, CanAdmin: true}, nil
+	}
+	return TeamPermissions{
+		CanEdit:     perms.Can(ActionEdit, teamID),
+		CanValidate: perms.Can(ActionValidate, teamID),
+		CanAdmin:    perms.Can(ActionAdmin, teamID),
+	}, nil
+}
+
+// ListUsers returns all users; it requires a global admin.
+func (s *Service) ListUsers(ctx context.Context) ([]domain.User, error) {
+	if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+		return nil, err
+	}
+	return s.store.ListUsers(ctx)
+}
+
+// CreateUser creates a password user; it requires a global admin.
+func (s *Service) CreateUser(ctx context.Context, email, name, password string) (int64, error) {
+	if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+		return 0, err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+	input := store.UserInput{Email: auth.NormalizeEmail(email), Name: name, PasswordHash: hash}
+	var id int64
+	err = s.store.InTx(ctx, func(ctx context.Context) error {
+		if id, err = s.store.CreateUser(ctx, input); err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityUser, id, domain.AuditActionCreate, nil, userAuditState{Email: input.Email, Name: input.Name})
+	})
+	return id, err
+}
+
+// ListRoleAssignments returns the roles of a user. Users may always see their own roles.
+func (s *Service) ListRoleAssignments(ctx context.Context, userID int64) ([]domain.RoleAssignment, error) {
+	if user, ok := auth.UserFromContext(ctx); !ok || user.ID != userID {
+		if err := s.AuthorizeGlobal(ctx, ActionAdmin); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.ListRoleAssignments(ctx, userID)
+}
+
+// AssignRole grants a role. Global assignments require a global admin, scoped ones an admin of the team.
+func (s *Service) AssignRole(ctx context.Context, input store.RoleAssignmentInput) (int64, error) {
+	if err := s.authorizeRoleScope(ctx, input.Scope, input.TeamID); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if id, err = s.store.AddRoleAssignment(ctx, input); err != nil {
+			return err
+		}
+		after, err := s.store.GetRoleAssignment(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityRoleAssignment, id, domain.AuditActionCreate, nil, after)
+	})
+	return id, err
+}
+
+// RevokeRole removes a role assignment with the same rules as AssignRole.
+func (s *Service) RevokeRole(ctx context.Context, assignmentID int64) error {
+	assignment, err := s.store.GetRoleAssignment(ctx, assignmentID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeRoleScope(ctx, assignment.Scope, assignment.TeamID); err != nil {
+		return err
+	}
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.DeleteRoleAssignment(ctx, assignmentID); err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityRoleAssignment, assignmentID, domain.AuditActionDelete, assignment, nil)
+	})
+}
+
+func (s *Service) authorizeRoleScope(ctx context.Context, scope domain.RoleScope, teamID *int64) error {
+	if scope == domain.RoleScopeGlobal || teamID == nil {
+		return s.AuthorizeGlobal(ctx, ActionAdmin)
+	}
+	return s.Authorize(ctx, *teamID, ActionAdmin)
+}
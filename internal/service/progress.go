@@ -5,6 +5,20 @@ import (
 	"okrs/internal/okr"
 )
 
+// ApplyProjectSchedule sets the planned progress of project key results and marks their overdue stages at now.
+func (s *Service) ApplyProjectSchedule(keyResults []domain.KeyResult) {
+	now := s.now()
+	for _, kr := range keyResults {
+		if kr.Kind != domain.KRKindProject || kr.Project == nil {
+			continue
+		}
+		kr.Project.PlannedProgress = okr.ProjectPlannedProgress(kr.Project.Stages, now)
+		for i := range kr.Project.Stages {
+			kr.Project.Stages[i].Overdue = okr.StageOverdue(kr.Project.Stages[i], now)
+		}
+	}
+}
+
 func CalculateGoalProgress(goal *domain.Goal) int {
 	for i := range goal.KeyResults {
 		goal.KeyResults[i].Progress = CalculateKRProgress(goal.KeyResults[i])
@@ -34,6 +48,11 @@ func CalculateKRProgress(kr domain.KeyResult) int {
 			return 0
 		}
 		return okr.BooleanProgress(kr.Boolean.IsDone)
+	case domain.KRKindRange:
+		if kr.Range == nil {
+			return 0
+		}
+		return okr.RangeProgress(kr.Range.MinValue, kr.Range.MaxValue, kr.Range.Tolerance, kr.Range.CurrentValue)
 	default:
 		return 0
 	}
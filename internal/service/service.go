@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"okrs/internal/domain"
 	"okrs/internal/okr"
@@ -11,15 +12,22 @@ import (
 )
 
 type Store interface {
+	// InTx runs fn in a transaction that store calls made with its context join.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 	ListTeams(ctx context.Context) ([]domain.Team, error)
 	GetTeam(ctx context.Context, id int64) (domain.Team, error)
 	ListPeriods(ctx context.Context) ([]domain.Period, error)
 	GetPeriod(ctx context.Context, id int64) (domain.Period, error)
 	ListGoalsByTeamPeriod(ctx context.Context, teamID, periodID int64) ([]domain.Goal, error)
+	ListGoalsByPeriod(ctx context.Context, periodID int64) ([]store.GoalWithTeam, error)
+	ListKeyResultsByGoal(ctx context.Context, goalID int64) ([]domain.KeyResult, error)
+	ImportOKRs(ctx context.Context, input *store.ImportInput) error
+	ListGoalOrigins(ctx context.Context, periodID int64) ([]int64, error)
 	ListGoalShares(ctx context.Context, goalID int64) ([]store.GoalShare, error)
 	GetTeamPeriodStatus(ctx context.Context, teamID, periodID int64) (domain.TeamPeriodStatus, error)
 	UpdatePercentCurrent(ctx context.Context, krID int64, current float64) error
 	UpdateLinearCurrent(ctx context.Context, krID int64, current float64) error
+	UpdateRangeCurrent(ctx context.Context, krID int64, current float64) error
 	UpdateBoolean(ctx context.Context, krID int64, done bool) error
 	ListProjectStages(ctx context.Context, krID int64) ([]domain.KRProjectStage, error)
 	UpdateProjectStageDone(ctx context.Context, stageID int64, done bool) error
@@ -30,23 +38,107 @@ type Store interface {
 	AddKeyResultComment(ctx context.Context, krID int64, text string) error
 	GetGoal(ctx context.Context, id int64) (domain.Goal, error)
 	UpdateGoal(ctx context.Context, input store.GoalUpdateInput) error
+	UpdateGoalFields(ctx context.Context, input store.GoalFieldsUpdateInput) error
+	UpdateGoalOwner(ctx context.Context, goalID, teamID int64, weight int) error
+	DeleteGoalShare(ctx context.Context, goalID, teamID int64) error
+	DeleteGoal(ctx context.Context, id int64) error
+	CreateGoal(ctx context.Context, input store.GoalInput) (int64, error)
 	CreateKeyResult(ctx context.Context, input store.KeyResultInput) (int64, error)
 	UpdateKeyResult(ctx context.Context, input store.KeyResultUpdateInput) error
+	UpdateKeyResultWeight(ctx context.Context, krID int64, weight int) error
+	AddProjectStage(ctx context.Context, input store.ProjectStageInput) error
+	DeleteKeyResult(ctx context.Context, id int64) error
 	MoveGoal(ctx context.Context, goalID int64, direction int) error
 	MoveKeyResult(ctx context.Context, krID int64, direction int) error
 	UpsertPercentMeta(ctx context.Context, input store.PercentMetaInput) error
+	GetPercentMeta(ctx context.Context, krID int64) (*domain.KRPercent, []domain.KRPercentCheckpoint, error)
+	ListPercentCheckpoints(ctx context.Context, krID int64) ([]domain.KRPercentCheckpoint, error)
+	AddPercentCheckpoint(ctx context.Context, input store.PercentCheckpointInput) (int64, error)
+	GetPercentCheckpoint(ctx context.Context, id int64) (domain.KRPercentCheckpoint, error)
+	UpdatePercentCheckpoint(ctx context.Context, id int64, input store.PercentCheckpointInput) error
+	DeletePercentCheckpoint(ctx context.Context, krID, id int64) error
 	UpsertLinearMeta(ctx context.Context, input store.LinearMetaInput) error
 	UpsertBooleanMeta(ctx context.Context, krID int64, done bool) error
+	UpsertRangeMeta(ctx context.Context, input store.RangeMetaInput) error
 	ReplaceProjectStages(ctx context.Context, krID int64, stages []store.ProjectStageInput) error
 	SetTeamPeriodStatus(ctx context.Context, teamID, periodID int64, status domain.TeamPeriodStatus) error
+	CreateTeam(ctx context.Context, input store.TeamInput) (int64, error)
+	UpdateTeam(ctx context.Context, input store.TeamInput, id int64) error
+	DeleteTeam(ctx context.Context, id int64) error
+	CreatePeriod(ctx context.Context, input store.PeriodInput) (int64, error)
+	UpdatePeriod(ctx context.Context, periodID int64, input store.PeriodInput) error
+	DeletePeriod(ctx context.Context, periodID int64) error
+	MovePeriod(ctx context.Context, periodID int64, direction int) error
+	ListUsers(ctx context.Context) ([]domain.User, error)
+	CreateUser(ctx context.Context, input store.UserInput) (int64, error)
+	ListRoleAssignments(ctx context.Context, userID int64) ([]domain.RoleAssignment, error)
+	GetRoleAssignment(ctx context.Context, id int64) (domain.RoleAssignment, error)
+	AddRoleAssignment(ctx context.Context, input store.RoleAssignmentInput) (int64, error)
+	DeleteRoleAssignment(ctx context.Context, id int64) error
+	CreateAPIToken(ctx context.Context, input store.APITokenInput) (domain.APIToken, error)
+	ListAPITokens(ctx context.Context, userID int64) ([]domain.APIToken, error)
+	DeleteAPIToken(ctx context.Context, userID, id int64) error
+	GetAPITokenUser(ctx context.Context, tokenHash string) (domain.APIToken, domain.User, error)
+	TouchAPIToken(ctx context.Context, id int64) error
+	AddAuditEvent(ctx context.Context, input store.AuditEventInput) error
+	ListAuditEvents(ctx context.Context, entityType domain.AuditEntity, entityID int64) ([]domain.AuditEvent, error)
+	AddKRProgressEvent(ctx context.Context, input store.KRProgressEventInput) error
+	UpsertKRDataSource(ctx context.Context, input store.KRDataSourceInput) error
+	GetKRDataSource(ctx context.Context, krID int64) (domain.KRDataSource, error)
+	DeleteKRDataSource(ctx context.Context, krID int64) error
+	ClaimIngestKey(ctx context.Context, krID int64, key, payloadHash string) (bool, string, error)
+	DeleteIngestKeysBefore(ctx context.Context, before time.Time) error
+	ListKRProgressEvents(ctx context.Context, krID int64) ([]domain.KRProgressEvent, error)
+	LastKRProgressEvent(ctx context.Context, krID int64) (domain.KRProgressEvent, error)
+	ListKeyResultIDsWithoutHistory(ctx context.Context) ([]int64, error)
+	AddKRCheckIn(ctx context.Context, input store.KRCheckInInput) (domain.KRCheckIn, error)
+	ListKRCheckIns(ctx context.Context, krID int64) ([]domain.KRCheckIn, error)
+	ListTeamLastCheckIns(ctx context.Context, periodID int64) ([]store.TeamLastCheckIn, error)
+	UpdateGoalConfidence(ctx context.Context, goalID int64, confidence *int) error
+	UpdateKeyResultConfidence(ctx context.Context, krID int64, confidence *int) error
+	UpdateGoalAlignment(ctx context.Context, goalID int64, parentGoalID *int64, progressFromChildren bool) error
+	ListChildGoals(ctx context.Context, parentGoalID int64) ([]domain.Goal, error)
+	AddKRDependency(ctx context.Context, krID, dependsOnID int64) error
+	DeleteKRDependency(ctx context.Context, krID, dependsOnID int64) error
+	ListKRDependencyIDs(ctx context.Context, krID int64) ([]int64, error)
+	LockKRDependencies(ctx context.Context) error
+	CreateWebhook(ctx context.Context, input store.WebhookInput) (int64, error)
+	UpdateWebhook(ctx context.Context, id int64, input store.WebhookInput) error
+	GetWebhook(ctx context.Context, id int64) (domain.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	EnqueueWebhookDeliveries(ctx context.Context, event domain.WebhookEvent, teamIDs []int64, payload, chatPayload []byte) error
+	ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]domain.WebhookDelivery, error)
+	GetUser(ctx context.Context, id int64) (domain.User, error)
+	SetGoalOwnerUser(ctx context.Context, goalID int64, userID *int64) error
+	SetTeamLeadUser(ctx context.Context, teamID int64, userID *int64) error
+	SetUserDigestOptOut(ctx context.Context, userID int64, optOut bool) error
+	ListDigestDueUsers(ctx context.Context, sentBefore time.Time) ([]domain.User, error)
+	MarkDigestSent(ctx context.Context, userID int64, sentAt time.Time) error
+	SwapTeamPeriodHealth(ctx context.Context, teamID, periodID int64, health string) (string, error)
 }
 
 type Service struct {
-	store Store
+	store        Store
+	zone         *time.Location
+	health       okr.HealthThresholds
+	ingestSecret []byte
 }
 
 func New(store Store) *Service {
-	return &Service{store: store}
+	return &Service{store: store, health: okr.DefaultHealthThresholds()}
+}
+
+// WithLocation sets the time zone used to split periods into days.
+func (s *Service) WithLocation(zone *time.Location) *Service {
+	s.zone = zone
+	return s
+}
+
+// WithHealthThresholds sets the thresholds used to compute goal and team health.
+func (s *Service) WithHealthThresholds(thresholds okr.HealthThresholds) *Service {
+	s.health = thresholds
+	return s
 }
 
 type TeamNode struct {
@@ -61,6 +153,11 @@ type TeamSummary struct {
 	Indent         int
 	Status         domain.TeamPeriodStatus
 	PeriodProgress int
+	// RollupProgress aggregates the progress of the team and all its descendants with goals,
+	// weighted by Team.RollupWeight or, when it is not set, by the goal weight sum of each team.
+	RollupProgress int
+	Confidence     *int
+	Health         okr.Health
 	GoalsCount     int
 	GoalsWeight    int
 	Goals          []TeamGoalSummary
@@ -71,6 +168,7 @@ type TeamGoalSummary struct {
 	Title      string
 	Weight     int
 	Progress   int
+	Confidence *int
 	ShareTeams []TeamShareInfo
 	Priority   string
 }
@@ -87,14 +185,18 @@ type TeamOKR struct {
 	Period         domain.Period
 	PeriodStatus   domain.TeamPeriodStatus
 	PeriodProgress int
+	Confidence     *int
+	Health         okr.Health
 	GoalsCount     int
 	GoalsWeight    int
 	Goals          []GoalDetails
+	Permissions    TeamPermissions
 }
 
 type GoalDetails struct {
 	Goal       domain.Goal
 	ShareTeams []TeamShareInfo
+	Health     okr.Health
 }
 
 func (s *Service) GetHierarchy(ctx context.Context) ([]TeamNode, error) {
@@ -135,9 +237,13 @@ func (s *Service) GetTeamsWithPeriodSummary(ctx context.Context, periodID int64,
 			filteredRoots = []domain.Team{team}
 		}
 	}
+	planned, err := s.periodPlannedProgress(ctx, periodID)
+	if err != nil {
+		return nil, err
+	}
 	rows := make([]TeamSummary, 0, len(teams))
 	for _, team := range filteredRoots {
-		if err := s.appendTeamSummary(ctx, &rows, team, 0, periodID, childrenMap, teamsByID); err != nil {
+		if _, err := s.appendTeamSummary(ctx, &rows, team, 0, periodID, planned, childrenMap, teamsByID); err != nil {
 			return nil, err
 		}
 	}
@@ -155,7 +261,9 @@ func (s *Service) GetTeamOKR(ctx context.Context, teamID, periodID int64, period
 	}
 	shareInfos := make(map[int64][]TeamShareInfo, len(goals))
 	for i := range goals {
-		goals[i].Progress = CalculateGoalProgress(&goals[i])
+		if goals[i].Progress, err = s.GoalProgress(ctx, &goals[i]); err != nil {
+			return TeamOKR{}, err
+		}
 		shares, err := s.listGoalShareTeams(ctx, goals[i], nil)
 		if err != nil {
 			return TeamOKR{}, err
@@ -171,11 +279,17 @@ func (s *Service) GetTeamOKR(ctx context.Context, teamID, periodID int64, period
 	if err != nil {
 		return TeamOKR{}, err
 	}
+	permissions, err := s.teamPermissions(ctx, teamID)
+	if err != nil {
+		return TeamOKR{}, err
+	}
+	planned := s.PlannedProgress(period)
 	goalDetails := make([]GoalDetails, 0, len(goals))
 	for _, goal := range goals {
 		goalDetails = append(goalDetails, GoalDetails{
 			Goal:       goal,
 			ShareTeams: shareInfos[goal.ID],
+			Health:     s.GoalHealth(goal, period),
 		})
 	}
 	return TeamOKR{
@@ -183,9 +297,12 @@ func (s *Service) GetTeamOKR(ctx context.Context, teamID, periodID int64, period
 		Period:         period,
 		PeriodStatus:   status,
 		PeriodProgress: periodProgress,
+		Confidence:     okr.PeriodConfidence(goals),
+		Health:         okr.PeriodHealth(goals, planned, s.health),
 		GoalsCount:     len(goals),
 		GoalsWeight:    goalsWeight,
 		Goals:          goalDetails,
+		Permissions:    permissions,
 	}, nil
 }
 
@@ -194,14 +311,32 @@ func (s *Service) UpdateKRProgressPercent(ctx context.Context, krID int64, curre
 	if err != nil {
 		return err
 	}
-	switch kr.Kind {
-	case domain.KRKindPercent:
-		return s.store.UpdatePercentCurrent(ctx, krID, current)
-	case domain.KRKindLinear:
-		return s.store.UpdateLinearCurrent(ctx, krID, current)
-	default:
-		return fmt.Errorf("unsupported kr kind for percent update: %s", kr.Kind)
+	if err := s.CheckGoalMutation(ctx, kr.GoalID, MutationProgress); err != nil {
+		return err
 	}
+	return s.writeKRCurrent(ctx, kr, current)
+}
+
+// writeKRCurrent stores the current value of a percent, linear or range key result together with its audit event
+// and history point.
+func (s *Service) writeKRCurrent(ctx context.Context, kr domain.KeyResult, current float64) error {
+	return s.audited(ctx, domain.AuditEntityKeyResult, kr.ID, domain.AuditActionProgress, func(ctx context.Context) error {
+		var err error
+		switch kr.Kind {
+		case domain.KRKindPercent:
+			err = s.store.UpdatePercentCurrent(ctx, kr.ID, current)
+		case domain.KRKindLinear:
+			err = s.store.UpdateLinearCurrent(ctx, kr.ID, current)
+		case domain.KRKindRange:
+			err = s.store.UpdateRangeCurrent(ctx, kr.ID, current)
+		default:
+			err = fmt.Errorf("unsupported kr kind for percent update: %s", kr.Kind)
+		}
+		if err != nil {
+			return err
+		}
+		return s.RecordKRProgress(ctx, kr.ID)
+	})
 }
 
 func (s *Service) UpdateKRProgressBoolean(ctx context.Context, krID int64, done bool) error {
@@ -212,7 +347,19 @@ func (s *Service) UpdateKRProgressBoolean(ctx context.Context, krID int64, done
 	if kr.Kind != domain.KRKindBoolean {
 		return fmt.Errorf("unsupported kr kind for boolean update: %s", kr.Kind)
 	}
-	return s.store.UpdateBoolean(ctx, krID, done)
+	if err := s.CheckGoalMutation(ctx, kr.GoalID, MutationProgress); err != nil {
+		return err
+	}
+	return s.writeKRBoolean(ctx, krID, done)
+}
+
+func (s *Service) writeKRBoolean(ctx context.Context, krID int64, done bool) error {
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionProgress, func(ctx context.Context) error {
+		if err := s.store.UpdateBoolean(ctx, krID, done); err != nil {
+			return err
+		}
+		return s.RecordKRProgress(ctx, krID)
+	})
 }
 
 type ProjectStageUpdate struct {
@@ -228,6 +375,15 @@ func (s *Service) UpdateKRProgressProject(ctx context.Context, krID int64, updat
 	if kr.Kind != domain.KRKindProject {
 		return fmt.Errorf("unsupported kr kind for project update: %s", kr.Kind)
 	}
+	if err := s.CheckGoalMutation(ctx, kr.GoalID, MutationProgress); err != nil {
+		return err
+	}
+	return s.writeKRStages(ctx, krID, updates)
+}
+
+// writeKRStages marks the stages of a project key result done or not and records the history point; updates of
+// other KRs' stages are ignored.
+func (s *Service) writeKRStages(ctx context.Context, krID int64, updates []ProjectStageUpdate) error {
 	stages, err := s.store.ListProjectStages(ctx, krID)
 	if err != nil {
 		return err
@@ -236,14 +392,16 @@ func (s *Service) UpdateKRProgressProject(ctx context.Context, krID int64, updat
 	for _, update := range updates {
 		updatesByID[update.ID] = update
 	}
-	for _, stage := range stages {
-		if update, ok := updatesByID[stage.ID]; ok {
-			if err := s.store.UpdateProjectStageDone(ctx, stage.ID, update.IsDone); err != nil {
-				return err
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionProgress, func(ctx context.Context) error {
+		for _, stage := range stages {
+			if update, ok := updatesByID[stage.ID]; ok {
+				if err := s.store.UpdateProjectStageDone(ctx, stage.ID, update.IsDone); err != nil {
+					return err
+				}
 			}
 		}
-	}
-	return nil
+		return s.RecordKRProgress(ctx, krID)
+	})
 }
 
 type ShareTarget struct {
@@ -252,23 +410,200 @@ type ShareTarget struct {
 }
 
 func (s *Service) ShareGoal(ctx context.Context, goalID int64, targets []ShareTarget) error {
+	if err := s.CheckGoalMutation(ctx, goalID, MutationStructural); err != nil {
+		return err
+	}
 	shares := make([]store.GoalShareInput, 0, len(targets))
 	for _, target := range targets {
 		shares = append(shares, store.GoalShareInput{TeamID: target.TeamID, Weight: target.Weight})
 	}
-	return s.store.ReplaceGoalShares(ctx, goalID, shares)
+	return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionShare, func(ctx context.Context) error {
+		if err := s.store.ReplaceGoalShares(ctx, goalID, shares); err != nil {
+			return err
+		}
+		return s.NotifyGoalShared(ctx, goalID)
+	})
 }
 
 func (s *Service) UpdateGoalWeight(ctx context.Context, goalID, teamID int64, weight int) error {
-	return s.store.UpdateGoalTeamWeight(ctx, goalID, teamID, weight)
+	if err := s.CheckGoalMutation(ctx, goalID, MutationStructural); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionWeight, func(ctx context.Context) error {
+		return s.store.UpdateGoalTeamWeight(ctx, goalID, teamID, weight)
+	})
+}
+
+// UpdateGoalFields changes the fields of a goal and its weight in teamID, the owner team or a team the goal is
+// shared with. The fields belong to the owner team period; the weight also requires the period of teamID.
+func (s *Service) UpdateGoalFields(ctx context.Context, input store.GoalFieldsUpdateInput, teamID int64, weight int) error {
+	goal, err := s.store.GetGoal(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.CheckTeamPeriodMutation(ctx, goal.TeamID, goal.PeriodID, MutationStructural); err != nil {
+		return err
+	}
+	if teamID != goal.TeamID {
+		if err := s.CheckTeamPeriodMutation(ctx, teamID, goal.PeriodID, MutationStructural); err != nil {
+			return err
+		}
+	}
+	return s.audited(ctx, domain.AuditEntityGoal, input.ID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		if err := s.store.UpdateGoalFields(ctx, input); err != nil {
+			return err
+		}
+		return s.store.UpdateGoalTeamWeight(ctx, input.ID, teamID, weight)
+	})
+}
+
+// SetGoalTeams makes teamIDs the teams of the goal and returns its owner team. The owner keeps the goal while it
+// is selected, otherwise the first selected team takes it over; every team keeps the weight it had.
+// The owner team period and the periods of all selected teams must allow structural changes.
+func (s *Service) SetGoalTeams(ctx context.Context, goalID int64, teamIDs []int64) (int64, error) {
+	if len(teamIDs) == 0 {
+		return 0, fmt.Errorf("goal %d needs at least one team", goalID)
+	}
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.CheckTeamPeriodMutation(ctx, goal.TeamID, goal.PeriodID, MutationStructural); err != nil {
+		return 0, err
+	}
+	ownerID := teamIDs[0]
+	for _, teamID := range teamIDs {
+		if teamID == goal.TeamID {
+			ownerID = goal.TeamID
+		}
+		if err := s.CheckTeamPeriodMutation(ctx, teamID, goal.PeriodID, MutationStructural); err != nil {
+			return 0, err
+		}
+	}
+	current, err := s.store.ListGoalShares(ctx, goalID)
+	if err != nil {
+		return 0, err
+	}
+	weights := map[int64]int{goal.TeamID: goal.Weight}
+	for _, share := range current {
+		weights[share.TeamID] = share.Weight
+	}
+	shares := make([]store.GoalShareInput, 0, len(teamIDs))
+	for _, teamID := range teamIDs {
+		if teamID != ownerID {
+			shares = append(shares, store.GoalShareInput{TeamID: teamID, Weight: weights[teamID]})
+		}
+	}
+	if err := s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionShare, func(ctx context.Context) error {
+		if ownerID != goal.TeamID {
+			if err := s.store.UpdateGoalOwner(ctx, goalID, ownerID, weights[ownerID]); err != nil {
+				return err
+			}
+			if err := s.dropStaleAlignment(ctx, goalID); err != nil {
+				return err
+			}
+		}
+		if err := s.store.ReplaceGoalShares(ctx, goalID, shares); err != nil {
+			return err
+		}
+		return s.NotifyGoalShared(ctx, goalID)
+	}); err != nil {
+		return 0, err
+	}
+	return ownerID, nil
+}
+
+// RemoveGoalFromTeam takes the goal off the OKRs of teamID. A team the goal is shared with stops sharing it,
+// an owner team hands it over to the first team it is shared with, and a goal without shares is deleted.
+func (s *Service) RemoveGoalFromTeam(ctx context.Context, goalID, teamID int64) error {
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	if err := s.CheckTeamPeriodMutation(ctx, teamID, goal.PeriodID, MutationStructural); err != nil {
+		return err
+	}
+	if teamID != goal.TeamID {
+		return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionShare, func(ctx context.Context) error {
+			return s.store.DeleteGoalShare(ctx, goalID, teamID)
+		})
+	}
+	shares, err := s.store.ListGoalShares(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	if len(shares) > 0 {
+		owner := shares[0]
+		return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionShare, func(ctx context.Context) error {
+			if err := s.store.UpdateGoalOwner(ctx, goalID, owner.TeamID, owner.Weight); err != nil {
+				return err
+			}
+			if err := s.dropStaleAlignment(ctx, goalID); err != nil {
+				return err
+			}
+			return s.store.DeleteGoalShare(ctx, goalID, owner.TeamID)
+		})
+	}
+	return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionDelete, func(ctx context.Context) error {
+		return s.store.DeleteGoal(ctx, goalID)
+	})
 }
 
 func (s *Service) AddGoalComment(ctx context.Context, goalID int64, text string) error {
-	return s.store.AddGoalComment(ctx, goalID, text)
+	if err := s.CheckGoalMutation(ctx, goalID, MutationComment); err != nil {
+		return err
+	}
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.AddGoalComment(ctx, goalID, text); err != nil {
+			return err
+		}
+		return s.AuditComment(ctx, domain.AuditEntityGoal, goalID, text)
+	})
 }
 
 func (s *Service) AddKeyResultComment(ctx context.Context, krID int64, text string) error {
-	return s.store.AddKeyResultComment(ctx, krID, text)
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationComment); err != nil {
+		return err
+	}
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.AddKeyResultComment(ctx, krID, text); err != nil {
+			return err
+		}
+		return s.AuditComment(ctx, domain.AuditEntityKeyResult, krID, text)
+	})
+}
+
+// CreateGoal adds a goal to the team period and moves a period without goals to forming.
+func (s *Service) CreateGoal(ctx context.Context, input store.GoalInput) (int64, error) {
+	if err := s.CheckTeamPeriodMutation(ctx, input.TeamID, input.PeriodID, MutationStructural); err != nil {
+		return 0, err
+	}
+	status, err := s.store.GetTeamPeriodStatus(ctx, input.TeamID, input.PeriodID)
+	if err != nil {
+		return 0, err
+	}
+	var goalID int64
+	if err := s.store.InTx(ctx, func(ctx context.Context) error {
+		if goalID, err = s.store.CreateGoal(ctx, input); err != nil {
+			return err
+		}
+		if err := s.AuditChange(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionCreate, nil); err != nil {
+			return err
+		}
+		if status == domain.TeamPeriodStatusNoGoals {
+			if err := s.store.SetTeamPeriodStatus(ctx, input.TeamID, input.PeriodID, domain.TeamPeriodStatusForming); err != nil {
+				return err
+			}
+			if err := s.AuditStatusChange(ctx, input.TeamID, input.PeriodID, status, domain.TeamPeriodStatusForming); err != nil {
+				return err
+			}
+		}
+		data := goalWebhookData{GoalID: goalID, TeamID: input.TeamID, PeriodID: input.PeriodID, Title: input.Title}
+		return s.emitWebhookEvent(ctx, domain.WebhookEventGoalCreated, data, input.TeamID)
+	}); err != nil {
+		return 0, err
+	}
+	return goalID, nil
 }
 
 func (s *Service) GetGoal(ctx context.Context, id int64) (domain.Goal, error) {
@@ -276,7 +611,9 @@ func (s *Service) GetGoal(ctx context.Context, id int64) (domain.Goal, error) {
 	if err != nil {
 		return domain.Goal{}, err
 	}
-	goal.Progress = CalculateGoalProgress(&goal)
+	if goal.Progress, err = s.GoalProgress(ctx, &goal); err != nil {
+		return domain.Goal{}, err
+	}
 	return goal, nil
 }
 
@@ -288,37 +625,135 @@ type KeyResultMetaInput struct {
 	LinearTarget   float64
 	LinearCurrent  float64
 	BooleanDone    bool
+	RangeMin       *float64
+	RangeMax       *float64
+	RangeTolerance float64
+	RangeCurrent   float64
 	ProjectStages  []store.ProjectStageInput
 }
 
 func (s *Service) UpdateGoal(ctx context.Context, input store.GoalUpdateInput) error {
-	return s.store.UpdateGoal(ctx, input)
+	if err := s.CheckGoalMutation(ctx, input.ID, MutationStructural); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityGoal, input.ID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		return s.store.UpdateGoal(ctx, input)
+	})
 }
 
 func (s *Service) MoveGoal(ctx context.Context, goalID int64, direction int) error {
-	return s.store.MoveGoal(ctx, goalID, direction)
+	if err := s.CheckGoalMutation(ctx, goalID, MutationReorder); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityGoal, goalID, domain.AuditActionMove, func(ctx context.Context) error {
+		return s.store.MoveGoal(ctx, goalID, direction)
+	})
 }
 
 func (s *Service) MoveKeyResult(ctx context.Context, krID int64, direction int) error {
-	return s.store.MoveKeyResult(ctx, krID, direction)
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationReorder); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionMove, func(ctx context.Context) error {
+		return s.store.MoveKeyResult(ctx, krID, direction)
+	})
 }
 
 func (s *Service) CreateKeyResultWithMeta(ctx context.Context, input store.KeyResultInput, meta KeyResultMetaInput) (int64, error) {
-	krID, err := s.store.CreateKeyResult(ctx, input)
-	if err != nil {
+	if err := s.CheckGoalMutation(ctx, input.GoalID, MutationStructural); err != nil {
 		return 0, err
 	}
-	if err := s.applyKeyResultMeta(ctx, krID, input.Kind, meta); err != nil {
+	var krID int64
+	if err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if krID, err = s.store.CreateKeyResult(ctx, input); err != nil {
+			return err
+		}
+		if err := s.applyKeyResultMeta(ctx, krID, input.Kind, meta); err != nil {
+			return err
+		}
+		if err := s.AuditChange(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionCreate, nil); err != nil {
+			return err
+		}
+		return s.RecordKRProgress(ctx, krID)
+	}); err != nil {
 		return 0, err
 	}
 	return krID, nil
 }
 
 func (s *Service) UpdateKeyResultWithMeta(ctx context.Context, input store.KeyResultUpdateInput, meta KeyResultMetaInput) error {
-	if err := s.store.UpdateKeyResult(ctx, input); err != nil {
+	if err := s.CheckKeyResultMutation(ctx, input.ID, MutationStructural); err != nil {
+		return err
+	}
+	if input.Kind == domain.KRKindPercent {
+		checkpoints, err := s.store.ListPercentCheckpoints(ctx, input.ID)
+		if err != nil {
+			return err
+		}
+		if len(checkpoints) > 0 {
+			if err := validateCheckpoints(meta.PercentStart, meta.PercentTarget, checkpoints); err != nil {
+				return err
+			}
+		}
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, input.ID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		if err := s.store.UpdateKeyResult(ctx, input); err != nil {
+			return err
+		}
+		if err := s.applyKeyResultMeta(ctx, input.ID, input.Kind, meta); err != nil {
+			return err
+		}
+		return s.RecordKRProgress(ctx, input.ID)
+	})
+}
+
+// UpdateKeyResultWeights sets the weights of the key results of a goal in one transaction; KRs missing from
+// weights keep their weight.
+func (s *Service) UpdateKeyResultWeights(ctx context.Context, goalID int64, weights map[int64]int) error {
+	if err := s.CheckGoalMutation(ctx, goalID, MutationStructural); err != nil {
+		return err
+	}
+	keyResults, err := s.store.ListKeyResultsByGoal(ctx, goalID)
+	if err != nil {
 		return err
 	}
-	return s.applyKeyResultMeta(ctx, input.ID, input.Kind, meta)
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		for _, kr := range keyResults {
+			weight, ok := weights[kr.ID]
+			if !ok {
+				continue
+			}
+			if err := s.audited(ctx, domain.AuditEntityKeyResult, kr.ID, domain.AuditActionWeight, func(ctx context.Context) error {
+				return s.store.UpdateKeyResultWeight(ctx, kr.ID, weight)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddProjectStage appends a stage to a PROJECT key result.
+func (s *Service) AddProjectStage(ctx context.Context, input store.ProjectStageInput) error {
+	if err := s.CheckKeyResultMutation(ctx, input.KeyResultID, MutationStructural); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, input.KeyResultID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		if err := s.store.AddProjectStage(ctx, input); err != nil {
+			return err
+		}
+		return s.RecordKRProgress(ctx, input.KeyResultID)
+	})
+}
+
+func (s *Service) DeleteKeyResult(ctx context.Context, krID int64) error {
+	if err := s.CheckKeyResultMutation(ctx, krID, MutationStructural); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityKeyResult, krID, domain.AuditActionDelete, func(ctx context.Context) error {
+		return s.store.DeleteKeyResult(ctx, krID)
+	})
 }
 
 func (s *Service) applyKeyResultMeta(ctx context.Context, krID int64, kind domain.KRKind, meta KeyResultMetaInput) error {
@@ -339,6 +774,14 @@ func (s *Service) applyKeyResultMeta(ctx context.Context, krID int64, kind domai
 		})
 	case domain.KRKindBoolean:
 		return s.store.UpsertBooleanMeta(ctx, krID, meta.BooleanDone)
+	case domain.KRKindRange:
+		return s.store.UpsertRangeMeta(ctx, store.RangeMetaInput{
+			KeyResultID:  krID,
+			MinValue:     meta.RangeMin,
+			MaxValue:     meta.RangeMax,
+			Tolerance:    meta.RangeTolerance,
+			CurrentValue: meta.RangeCurrent,
+		})
 	case domain.KRKindProject:
 		return s.store.ReplaceProjectStages(ctx, krID, meta.ProjectStages)
 	default:
@@ -347,30 +790,70 @@ func (s *Service) applyKeyResultMeta(ctx context.Context, krID int64, kind domai
 }
 
 func (s *Service) UpdateTeamPeriodStatus(ctx context.Context, teamID, periodID int64, status domain.TeamPeriodStatus) error {
-	return s.store.SetTeamPeriodStatus(ctx, teamID, periodID, status)
+	current, err := s.store.GetTeamPeriodStatus(ctx, teamID, periodID)
+	if err != nil {
+		return err
+	}
+	perms, hasUser, err := s.CurrentPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	admin := !hasUser || perms.Can(ActionAdmin, teamID)
+	if err := validateStatusTransition(current, status, admin); err != nil {
+		return err
+	}
+	if current == status {
+		return nil
+	}
+	if action := statusAction(current, status); hasUser && !perms.Can(action, teamID) {
+		return fmt.Errorf("%w: %s on team %d", ErrForbidden, action, teamID)
+	}
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.SetTeamPeriodStatus(ctx, teamID, periodID, status); err != nil {
+			return err
+		}
+		if err := s.AuditStatusChange(ctx, teamID, periodID, current, status); err != nil {
+			return err
+		}
+		var event domain.WebhookEvent
+		switch status {
+		case domain.TeamPeriodStatusValidated:
+			event = domain.WebhookEventTeamPeriodValidated
+		case domain.TeamPeriodStatusClosed:
+			event = domain.WebhookEventTeamPeriodClosed
+		default:
+			return nil
+		}
+		data := teamPeriodWebhookData{TeamID: teamID, PeriodID: periodID, From: current, To: status}
+		return s.emitWebhookEvent(ctx, event, data, teamID)
+	})
 }
 
-func (s *Service) appendTeamSummary(ctx context.Context, rows *[]TeamSummary, team domain.Team, level int, periodID int64, childrenMap map[int64][]domain.Team, teamsByID map[int64]domain.Team) error {
+// appendTeamSummary appends the summary of the team and its descendants and returns their weighted progress for the roll-up.
+func (s *Service) appendTeamSummary(ctx context.Context, rows *[]TeamSummary, team domain.Team, level int, periodID int64, planned int, childrenMap map[int64][]domain.Team, teamsByID map[int64]domain.Team) ([]okr.WeightedProgress, error) {
 	goals, err := s.store.ListGoalsByTeamPeriod(ctx, team.ID, periodID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	status, err := s.store.GetTeamPeriodStatus(ctx, team.ID, periodID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	goalRows := make([]TeamGoalSummary, 0, len(goals))
 	for i := range goals {
-		goals[i].Progress = CalculateGoalProgress(&goals[i])
+		if goals[i].Progress, err = s.GoalProgress(ctx, &goals[i]); err != nil {
+			return nil, err
+		}
 		shareTeams, err := s.listGoalShareTeams(ctx, goals[i], teamsByID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		goalRows = append(goalRows, TeamGoalSummary{
 			ID:         goals[i].ID,
 			Title:      goals[i].Title,
 			Weight:     goals[i].Weight,
 			Progress:   goals[i].Progress,
+			Confidence: okr.GoalConfidence(goals[i]),
 			ShareTeams: shareTeams,
 			Priority:   string(goals[i].Priority),
 		})
@@ -380,6 +863,15 @@ func (s *Service) appendTeamSummary(ctx context.Context, rows *[]TeamSummary, te
 	for _, goal := range goals {
 		goalsWeight += goal.Weight
 	}
+	var subtree []okr.WeightedProgress
+	if len(goals) > 0 {
+		weight := goalsWeight
+		if team.RollupWeight != nil {
+			weight = *team.RollupWeight
+		}
+		subtree = append(subtree, okr.WeightedProgress{Progress: periodProgress, Weight: weight})
+	}
+	index := len(*rows)
 	*rows = append(*rows, TeamSummary{
 		ID:             team.ID,
 		Name:           team.Name,
@@ -387,6 +879,8 @@ func (s *Service) appendTeamSummary(ctx context.Context, rows *[]TeamSummary, te
 		Indent:         level * 24,
 		Status:         status,
 		PeriodProgress: periodProgress,
+		Confidence:     okr.PeriodConfidence(goals),
+		Health:         okr.PeriodHealth(goals, planned, s.health),
 		GoalsCount:     len(goals),
 		GoalsWeight:    goalsWeight,
 		Goals:          goalRows,
@@ -394,11 +888,14 @@ func (s *Service) appendTeamSummary(ctx context.Context, rows *[]TeamSummary, te
 	children := childrenMap[team.ID]
 	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
 	for _, child := range children {
-		if err := s.appendTeamSummary(ctx, rows, child, level+1, periodID, childrenMap, teamsByID); err != nil {
-			return err
+		items, err := s.appendTeamSummary(ctx, rows, child, level+1, periodID, planned, childrenMap, teamsByID)
+		if err != nil {
+			return nil, err
 		}
+		subtree = append(subtree, items...)
 	}
-	return nil
+	(*rows)[index].RollupProgress = okr.RollupProgress(subtree)
+	return subtree, nil
 }
 
 func (s *Service) listGoalShareTeams(ctx context.Context, goal domain.Goal, teamsByID map[int64]domain.Team) ([]TeamShareInfo, error) {
@@ -2,55 +2,199 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"okrs/internal/auth"
 	"okrs/internal/domain"
 	"okrs/internal/store"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type fakeStore struct {
-	keyResults     map[int64]domain.KeyResult
-	percentUpdates map[int64]float64
-	linearUpdates  map[int64]float64
-	booleanUpdates map[int64]bool
-	projectStages  map[int64][]domain.KRProjectStage
-	stageUpdates   map[int64]bool
-	movedGoals     map[int64]int
-	movedKRs       map[int64]int
+	goals           map[int64]domain.Goal
+	teamGoals       map[int64][]domain.Goal
+	shares          map[int64][]store.GoalShare
+	dependencies    map[int64][]int64
+	keyResults      map[int64]domain.KeyResult
+	percentUpdates  map[int64]float64
+	checkpoints     []domain.KRPercentCheckpoint
+	dataSources     map[int64]domain.KRDataSource
+	ingestKeys      map[string]string
+	linearUpdates   map[int64]float64
+	rangeUpdates    map[int64]float64
+	booleanUpdates  map[int64]bool
+	projectStages   map[int64][]domain.KRProjectStage
+	stageUpdates    map[int64]bool
+	movedGoals      map[int64]int
+	movedKRs        map[int64]int
+	statuses        map[int64]domain.TeamPeriodStatus
+	teams           []domain.Team
+	assignments     map[int64][]domain.RoleAssignment
+	apiTokens       map[string]domain.APIToken
+	nextTokenID     int64
+	touchedTokens   map[int64]int
+	audits          []store.AuditEventInput
+	progressEvents  []store.KRProgressEventInput
+	checkIns        []store.KRCheckInInput
+	checkInErr      error
+	lastCheckIns    []store.TeamLastCheckIn
+	webhooks        map[int64]domain.Webhook
+	webhookEvents   []fakeWebhookEvent
+	enqueueErr      error
+	periods         []domain.Period
+	users           map[int64]domain.User
+	digestSent      map[int64]time.Time
+	health          map[int64]string
+	imports         []store.ImportInput
+	transactions    int
+	txDepth         int
+	dependencyLocks int
+}
+
+type fakeWebhookEvent struct {
+	Event   domain.WebhookEvent
+	TeamIDs []int64
+	Payload WebhookPayload
+	Chat    string
 }
 
 func newFakeStore() *fakeStore {
 	return &fakeStore{
+		goals:          make(map[int64]domain.Goal),
+		teamGoals:      make(map[int64][]domain.Goal),
+		shares:         make(map[int64][]store.GoalShare),
+		dependencies:   make(map[int64][]int64),
 		keyResults:     make(map[int64]domain.KeyResult),
 		percentUpdates: make(map[int64]float64),
+		dataSources:    make(map[int64]domain.KRDataSource),
+		ingestKeys:     make(map[string]string),
 		linearUpdates:  make(map[int64]float64),
+		rangeUpdates:   make(map[int64]float64),
 		booleanUpdates: make(map[int64]bool),
 		projectStages:  make(map[int64][]domain.KRProjectStage),
 		stageUpdates:   make(map[int64]bool),
 		movedGoals:     make(map[int64]int),
 		movedKRs:       make(map[int64]int),
+		statuses:       make(map[int64]domain.TeamPeriodStatus),
+		assignments:    make(map[int64][]domain.RoleAssignment),
+		apiTokens:      make(map[string]domain.APIToken),
+		touchedTokens:  make(map[int64]int),
+		webhooks:       make(map[int64]domain.Webhook),
+		users:          make(map[int64]domain.User),
+		digestSent:     make(map[int64]time.Time),
+		health:         make(map[int64]string),
 	}
 }
 
+// InTx drops the history points, webhook events and ingest keys written by a failed callback, as a rollback would.
+func (f *fakeStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	f.transactions++
+	f.txDepth++
+	defer func() { f.txDepth-- }()
+	events, webhookEvents, ingestKeys := len(f.progressEvents), len(f.webhookEvents), maps.Clone(f.ingestKeys)
+	if err := fn(ctx); err != nil {
+		f.progressEvents, f.webhookEvents, f.ingestKeys = f.progressEvents[:events], f.webhookEvents[:webhookEvents], ingestKeys
+		return err
+	}
+	return nil
+}
 func (f *fakeStore) ListTeams(context.Context) ([]domain.Team, error) {
-	return nil, nil
+	return f.teams, nil
 }
-func (f *fakeStore) GetTeam(context.Context, int64) (domain.Team, error) {
+func (f *fakeStore) GetTeam(_ context.Context, id int64) (domain.Team, error) {
+	for _, team := range f.teams {
+		if team.ID == id {
+			return team, nil
+		}
+	}
 	return domain.Team{}, nil
 }
 func (f *fakeStore) ListPeriods(context.Context) ([]domain.Period, error) {
-	return nil, nil
+	return f.periods, nil
 }
-func (f *fakeStore) GetPeriod(context.Context, int64) (domain.Period, error) {
+func (f *fakeStore) GetPeriod(_ context.Context, id int64) (domain.Period, error) {
+	for _, period := range f.periods {
+		if period.ID == id {
+			return period, nil
+		}
+	}
 	return domain.Period{}, nil
 }
-func (f *fakeStore) ListGoalsByTeamPeriod(context.Context, int64, int64) ([]domain.Goal, error) {
-	return nil, nil
+func (f *fakeStore) ListGoalsByTeamPeriod(_ context.Context, teamID, _ int64) ([]domain.Goal, error) {
+	return f.teamGoals[teamID], nil
+}
+func (f *fakeStore) ListGoalsByPeriod(_ context.Context, periodID int64) ([]store.GoalWithTeam, error) {
+	var goals []store.GoalWithTeam
+	for _, team := range f.teams {
+		for _, goal := range f.teamGoals[team.ID] {
+			if goal.TeamID == team.ID && goal.PeriodID == periodID {
+				goal.KeyResults = nil
+				goals = append(goals, store.GoalWithTeam{Goal: goal, TeamName: team.Name, TeamType: team.Type})
+			}
+		}
+	}
+	return goals, nil
 }
-func (f *fakeStore) ListGoalShares(context.Context, int64) ([]store.GoalShare, error) {
+func (f *fakeStore) ListKeyResultsByGoal(_ context.Context, goalID int64) ([]domain.KeyResult, error) {
+	for _, goals := range f.teamGoals {
+		for _, goal := range goals {
+			if goal.ID == goalID {
+				return goal.KeyResults, nil
+			}
+		}
+	}
 	return nil, nil
 }
-func (f *fakeStore) GetTeamPeriodStatus(context.Context, int64, int64) (domain.TeamPeriodStatus, error) {
+func (f *fakeStore) ImportOKRs(_ context.Context, input *store.ImportInput) error {
+	for i := range input.Teams {
+		team := &input.Teams[i]
+		if team.ID == 0 {
+			team.ID = int64(len(f.teams) + 1)
+			f.teams = append(f.teams, domain.Team{ID: team.ID, Name: team.Team.Name, Type: team.Team.Type, ParentID: team.Team.ParentID})
+		}
+		for j := range team.Goals {
+			goal := &team.Goals[j]
+			goal.ID = int64(len(f.goals) + 1)
+			created := domain.Goal{ID: goal.ID, TeamID: team.ID, PeriodID: input.PeriodID, Title: goal.Goal.Title, Weight: goal.Goal.Weight, OriginGoalID: goal.OriginGoalID}
+			for _, share := range goal.Shares {
+				f.shares[goal.ID] = append(f.shares[goal.ID], store.GoalShare{GoalID: goal.ID, TeamID: share.TeamID, Weight: share.Weight})
+			}
+			for k := range goal.KeyResults {
+				kr := &goal.KeyResults[k]
+				kr.ID = int64(len(f.keyResults) + 1)
+				f.keyResults[kr.ID] = domain.KeyResult{ID: kr.ID, GoalID: goal.ID, Title: kr.KeyResult.Title, Weight: kr.KeyResult.Weight, Kind: kr.KeyResult.Kind}
+				created.KeyResults = append(created.KeyResults, f.keyResults[kr.ID])
+			}
+			f.goals[goal.ID] = created
+		}
+	}
+	f.imports = append(f.imports, *input)
+	return nil
+}
+func (f *fakeStore) ListGoalOrigins(_ context.Context, periodID int64) ([]int64, error) {
+	var ids []int64
+	for _, goal := range f.goals {
+		if goal.PeriodID == periodID && goal.OriginGoalID != nil {
+			ids = append(ids, *goal.OriginGoalID)
+		}
+	}
+	return ids, nil
+}
+func (f *fakeStore) ListGoalShares(_ context.Context, goalID int64) ([]store.GoalShare, error) {
+	return f.shares[goalID], nil
+}
+func (f *fakeStore) GetTeamPeriodStatus(_ context.Context, teamID, _ int64) (domain.TeamPeriodStatus, error) {
+	if status, ok := f.statuses[teamID]; ok {
+		return status, nil
+	}
 	return domain.TeamPeriodStatusNoGoals, nil
 }
 func (f *fakeStore) UpdatePercentCurrent(_ context.Context, krID int64, current float64) error {
@@ -61,6 +205,10 @@ func (f *fakeStore) UpdateLinearCurrent(_ context.Context, krID int64, current f
 	f.linearUpdates[krID] = current
 	return nil
 }
+func (f *fakeStore) UpdateRangeCurrent(_ context.Context, krID int64, current float64) error {
+	f.rangeUpdates[krID] = current
+	return nil
+}
 func (f *fakeStore) UpdateBoolean(_ context.Context, krID int64, done bool) error {
 	f.booleanUpdates[krID] = done
 	return nil
@@ -72,12 +220,42 @@ func (f *fakeStore) UpdateProjectStageDone(_ context.Context, stageID int64, don
 	f.stageUpdates[stageID] = done
 	return nil
 }
-func (f *fakeStore) ReplaceGoalShares(context.Context, int64, []store.GoalShareInput) error {
+func (f *fakeStore) ReplaceGoalShares(_ context.Context, goalID int64, shares []store.GoalShareInput) error {
+	f.shares[goalID] = nil
+	for _, share := range shares {
+		f.shares[goalID] = append(f.shares[goalID], store.GoalShare{GoalID: goalID, TeamID: share.TeamID, Weight: share.Weight})
+	}
 	return nil
 }
 func (f *fakeStore) UpdateGoalTeamWeight(context.Context, int64, int64, int) error {
 	return nil
 }
+func (f *fakeStore) UpdateGoalFields(_ context.Context, input store.GoalFieldsUpdateInput) error {
+	goal := f.goals[input.ID]
+	goal.Title = input.Title
+	f.goals[input.ID] = goal
+	return nil
+}
+func (f *fakeStore) UpdateGoalOwner(_ context.Context, goalID, teamID int64, weight int) error {
+	goal := f.goals[goalID]
+	goal.TeamID, goal.Weight = teamID, weight
+	f.goals[goalID] = goal
+	return nil
+}
+func (f *fakeStore) DeleteGoalShare(_ context.Context, goalID, teamID int64) error {
+	shares := f.shares[goalID][:0]
+	for _, share := range f.shares[goalID] {
+		if share.TeamID != teamID {
+			shares = append(shares, share)
+		}
+	}
+	f.shares[goalID] = shares
+	return nil
+}
+func (f *fakeStore) DeleteGoal(_ context.Context, id int64) error {
+	delete(f.goals, id)
+	return nil
+}
 func (f *fakeStore) GetKeyResult(_ context.Context, id int64) (domain.KeyResult, error) {
 	return f.keyResults[id], nil
 }
@@ -87,18 +265,37 @@ func (f *fakeStore) AddGoalComment(context.Context, int64, string) error {
 func (f *fakeStore) AddKeyResultComment(context.Context, int64, string) error {
 	return nil
 }
-func (f *fakeStore) GetGoal(context.Context, int64) (domain.Goal, error) {
-	return domain.Goal{}, nil
+func (f *fakeStore) GetGoal(_ context.Context, id int64) (domain.Goal, error) {
+	return f.goals[id], nil
 }
 func (f *fakeStore) UpdateGoal(context.Context, store.GoalUpdateInput) error {
 	return nil
 }
+func (f *fakeStore) CreateGoal(_ context.Context, input store.GoalInput) (int64, error) {
+	id := int64(len(f.goals) + 1)
+	f.goals[id] = domain.Goal{ID: id, TeamID: input.TeamID, PeriodID: input.PeriodID, Title: input.Title, Weight: input.Weight}
+	return id, nil
+}
 func (f *fakeStore) CreateKeyResult(context.Context, store.KeyResultInput) (int64, error) {
 	return 0, nil
 }
 func (f *fakeStore) UpdateKeyResult(context.Context, store.KeyResultUpdateInput) error {
 	return nil
 }
+func (f *fakeStore) UpdateKeyResultWeight(_ context.Context, krID int64, weight int) error {
+	kr := f.keyResults[krID]
+	kr.Weight = weight
+	f.keyResults[krID] = kr
+	return nil
+}
+func (f *fakeStore) AddProjectStage(_ context.Context, input store.ProjectStageInput) error {
+	f.projectStages[input.KeyResultID] = append(f.projectStages[input.KeyResultID], domain.KRProjectStage{Title: input.Title, Weight: input.Weight})
+	return nil
+}
+func (f *fakeStore) DeleteKeyResult(_ context.Context, id int64) error {
+	delete(f.keyResults, id)
+	return nil
+}
 func (f *fakeStore) MoveGoal(_ context.Context, goalID int64, direction int) error {
 	f.movedGoals[goalID] = direction
 	return nil
@@ -110,16 +307,395 @@ func (f *fakeStore) MoveKeyResult(_ context.Context, krID int64, direction int)
 func (f *fakeStore) UpsertPercentMeta(context.Context, store.PercentMetaInput) error {
 	return nil
 }
+func (f *fakeStore) GetPercentMeta(_ context.Context, krID int64) (*domain.KRPercent, []domain.KRPercentCheckpoint, error) {
+	kr := f.keyResults[krID]
+	if kr.Percent == nil {
+		return nil, nil, pgx.ErrNoRows
+	}
+	meta := *kr.Percent
+	checkpoints, _ := f.ListPercentCheckpoints(context.Background(), krID)
+	return &meta, checkpoints, nil
+}
+func (f *fakeStore) ListPercentCheckpoints(_ context.Context, krID int64) ([]domain.KRPercentCheckpoint, error) {
+	var checkpoints []domain.KRPercentCheckpoint
+	for _, cp := range f.checkpoints {
+		if cp.KeyResultID == krID {
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	return checkpoints, nil
+}
+func (f *fakeStore) AddPercentCheckpoint(_ context.Context, input store.PercentCheckpointInput) (int64, error) {
+	id := int64(len(f.checkpoints) + 1)
+	f.checkpoints = append(f.checkpoints, domain.KRPercentCheckpoint{ID: id, KeyResultID: input.KeyResultID, MetricValue: input.MetricValue, KRPercent: input.KRPercent})
+	return id, nil
+}
+func (f *fakeStore) GetPercentCheckpoint(_ context.Context, id int64) (domain.KRPercentCheckpoint, error) {
+	for _, cp := range f.checkpoints {
+		if cp.ID == id {
+			return cp, nil
+		}
+	}
+	return domain.KRPercentCheckpoint{}, pgx.ErrNoRows
+}
+func (f *fakeStore) UpdatePercentCheckpoint(_ context.Context, id int64, input store.PercentCheckpointInput) error {
+	for i, cp := range f.checkpoints {
+		if cp.ID == id {
+			f.checkpoints[i].MetricValue = input.MetricValue
+			f.checkpoints[i].KRPercent = input.KRPercent
+		}
+	}
+	return nil
+}
+func (f *fakeStore) DeletePercentCheckpoint(_ context.Context, krID, id int64) error {
+	kept := f.checkpoints[:0]
+	for _, cp := range f.checkpoints {
+		if cp.ID != id || cp.KeyResultID != krID {
+			kept = append(kept, cp)
+		}
+	}
+	f.checkpoints = kept
+	return nil
+}
 func (f *fakeStore) UpsertLinearMeta(context.Context, store.LinearMetaInput) error {
 	return nil
 }
+func (f *fakeStore) UpsertRangeMeta(context.Context, store.RangeMetaInput) error {
+	return nil
+}
 func (f *fakeStore) UpsertBooleanMeta(context.Context, int64, bool) error {
 	return nil
 }
 func (f *fakeStore) ReplaceProjectStages(context.Context, int64, []store.ProjectStageInput) error {
 	return nil
 }
-func (f *fakeStore) SetTeamPeriodStatus(context.Context, int64, int64, domain.TeamPeriodStatus) error {
+func (f *fakeStore) SetTeamPeriodStatus(_ context.Context, teamID, _ int64, status domain.TeamPeriodStatus) error {
+	f.statuses[teamID] = status
+	return nil
+}
+func (f *fakeStore) CreateTeam(_ context.Context, input store.TeamInput) (int64, error) {
+	id := int64(len(f.teams) + 1)
+	f.teams = append(f.teams, domain.Team{ID: id, Name: input.Name, Type: input.Type, ParentID: input.ParentID})
+	return id, nil
+}
+func (f *fakeStore) UpdateTeam(_ context.Context, input store.TeamInput, id int64) error {
+	for i := range f.teams {
+		if f.teams[i].ID == id {
+			f.teams[i].Name, f.teams[i].Type, f.teams[i].ParentID = input.Name, input.Type, input.ParentID
+		}
+	}
+	return nil
+}
+func (f *fakeStore) DeleteTeam(_ context.Context, id int64) error {
+	teams := f.teams[:0]
+	for _, team := range f.teams {
+		if team.ID != id {
+			teams = append(teams, team)
+		}
+	}
+	f.teams = teams
+	return nil
+}
+func (f *fakeStore) CreatePeriod(_ context.Context, input store.PeriodInput) (int64, error) {
+	id := int64(len(f.periods) + 1)
+	f.periods = append(f.periods, domain.Period{ID: id, Name: input.Name, StartDate: input.StartDate, EndDate: input.EndDate})
+	return id, nil
+}
+func (f *fakeStore) UpdatePeriod(_ context.Context, periodID int64, input store.PeriodInput) error {
+	for i := range f.periods {
+		if f.periods[i].ID == periodID {
+			f.periods[i].Name, f.periods[i].StartDate, f.periods[i].EndDate = input.Name, input.StartDate, input.EndDate
+		}
+	}
+	return nil
+}
+func (f *fakeStore) DeletePeriod(_ context.Context, periodID int64) error {
+	periods := f.periods[:0]
+	for _, period := range f.periods {
+		if period.ID != periodID {
+			periods = append(periods, period)
+		}
+	}
+	f.periods = periods
+	return nil
+}
+func (f *fakeStore) MovePeriod(context.Context, int64, int) error {
+	return nil
+}
+func (f *fakeStore) ListUsers(context.Context) ([]domain.User, error) {
+	return nil, nil
+}
+func (f *fakeStore) CreateUser(context.Context, store.UserInput) (int64, error) {
+	return 0, nil
+}
+func (f *fakeStore) ListRoleAssignments(_ context.Context, userID int64) ([]domain.RoleAssignment, error) {
+	return f.assignments[userID], nil
+}
+func (f *fakeStore) GetRoleAssignment(context.Context, int64) (domain.RoleAssignment, error) {
+	return domain.RoleAssignment{}, nil
+}
+func (f *fakeStore) AddRoleAssignment(context.Context, store.RoleAssignmentInput) (int64, error) {
+	return 0, nil
+}
+func (f *fakeStore) DeleteRoleAssignment(context.Context, int64) error {
+	return nil
+}
+func (f *fakeStore) CreateAPIToken(_ context.Context, input store.APITokenInput) (domain.APIToken, error) {
+	f.nextTokenID++
+	token := domain.APIToken{ID: f.nextTokenID, UserID: input.UserID, Name: input.Name, ReadOnly: input.ReadOnly, ExpiresAt: input.ExpiresAt}
+	f.apiTokens[input.TokenHash] = token
+	return token, nil
+}
+func (f *fakeStore) ListAPITokens(context.Context, int64) ([]domain.APIToken, error) {
+	return nil, nil
+}
+func (f *fakeStore) DeleteAPIToken(context.Context, int64, int64) error {
+	return nil
+}
+func (f *fakeStore) GetAPITokenUser(_ context.Context, tokenHash string) (domain.APIToken, domain.User, error) {
+	token, ok := f.apiTokens[tokenHash]
+	if !ok {
+		return domain.APIToken{}, domain.User{}, pgx.ErrNoRows
+	}
+	return token, domain.User{ID: token.UserID}, nil
+}
+func (f *fakeStore) TouchAPIToken(_ context.Context, id int64) error {
+	f.touchedTokens[id]++
+	return nil
+}
+func (f *fakeStore) AddAuditEvent(_ context.Context, input store.AuditEventInput) error {
+	f.audits = append(f.audits, input)
+	return nil
+}
+func (f *fakeStore) ListAuditEvents(context.Context, domain.AuditEntity, int64) ([]domain.AuditEvent, error) {
+	return nil, nil
+}
+func (f *fakeStore) AddKRProgressEvent(_ context.Context, input store.KRProgressEventInput) error {
+	f.progressEvents = append(f.progressEvents, input)
+	return nil
+}
+func (f *fakeStore) UpsertKRDataSource(_ context.Context, input store.KRDataSourceInput) error {
+	f.dataSources[input.KeyResultID] = domain.KRDataSource{KeyResultID: input.KeyResultID, Kind: input.Kind, Endpoint: input.Endpoint, Query: input.Query, IntervalMinutes: input.IntervalMinutes}
+	return nil
+}
+func (f *fakeStore) GetKRDataSource(_ context.Context, krID int64) (domain.KRDataSource, error) {
+	source, ok := f.dataSources[krID]
+	if !ok {
+		return domain.KRDataSource{}, pgx.ErrNoRows
+	}
+	return source, nil
+}
+func (f *fakeStore) DeleteKRDataSource(_ context.Context, krID int64) error {
+	delete(f.dataSources, krID)
+	return nil
+}
+func (f *fakeStore) ClaimIngestKey(_ context.Context, krID int64, key, payloadHash string) (bool, string, error) {
+	id := fmt.Sprintf("%d/%s", krID, key)
+	if existing, ok := f.ingestKeys[id]; ok {
+		return false, existing, nil
+	}
+	f.ingestKeys[id] = payloadHash
+	return true, payloadHash, nil
+}
+func (f *fakeStore) DeleteIngestKeysBefore(context.Context, time.Time) error {
+	return nil
+}
+func (f *fakeStore) LastKRProgressEvent(ctx context.Context, krID int64) (domain.KRProgressEvent, error) {
+	events, _ := f.ListKRProgressEvents(ctx, krID)
+	if len(events) == 0 {
+		return domain.KRProgressEvent{}, pgx.ErrNoRows
+	}
+	return events[len(events)-1], nil
+}
+func (f *fakeStore) ListKeyResultIDsWithoutHistory(context.Context) ([]int64, error) {
+	recorded := make(map[int64]bool)
+	for _, event := range f.progressEvents {
+		recorded[event.KeyResultID] = true
+	}
+	var ids []int64
+	for id := range f.keyResults {
+		if !recorded[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+func (f *fakeStore) ListKRProgressEvents(_ context.Context, krID int64) ([]domain.KRProgressEvent, error) {
+	var events []domain.KRProgressEvent
+	for _, event := range f.progressEvents {
+		if event.KeyResultID == krID {
+			events = append(events, domain.KRProgressEvent{KeyResultID: krID, Value: event.Value, Progress: event.Progress, Source: event.Source})
+		}
+	}
+	return events, nil
+}
+func (f *fakeStore) AddKRCheckIn(_ context.Context, input store.KRCheckInInput) (domain.KRCheckIn, error) {
+	if f.checkInErr != nil {
+		return domain.KRCheckIn{}, f.checkInErr
+	}
+	f.checkIns = append(f.checkIns, input)
+	return domain.KRCheckIn{ID: int64(len(f.checkIns)), KeyResultID: input.KeyResultID, Value: input.Value, Progress: input.Progress,
+		Confidence: input.Confidence, Blockers: input.Blockers, NextSteps: input.NextSteps}, nil
+}
+func (f *fakeStore) ListKRCheckIns(context.Context, int64) ([]domain.KRCheckIn, error) {
+	return nil, nil
+}
+func (f *fakeStore) ListTeamLastCheckIns(context.Context, int64) ([]store.TeamLastCheckIn, error) {
+	return f.lastCheckIns, nil
+}
+func (f *fakeStore) UpdateGoalConfidence(_ context.Context, goalID int64, confidence *int) error {
+	goal := f.goals[goalID]
+	goal.Confidence = confidence
+	f.goals[goalID] = goal
+	return nil
+}
+func (f *fakeStore) UpdateKeyResultConfidence(_ context.Context, krID int64, confidence *int) error {
+	kr := f.keyResults[krID]
+	kr.Confidence = confidence
+	f.keyResults[krID] = kr
+	return nil
+}
+func (f *fakeStore) UpdateGoalAlignment(_ context.Context, goalID int64, parentGoalID *int64, progressFromChildren bool) error {
+	goal := f.goals[goalID]
+	goal.ParentGoalID = parentGoalID
+	goal.ProgressFromChildren = progressFromChildren
+	f.goals[goalID] = goal
+	return nil
+}
+func (f *fakeStore) ListChildGoals(_ context.Context, parentGoalID int64) ([]domain.Goal, error) {
+	var children []domain.Goal
+	for _, goal := range f.goals {
+		if goal.ParentGoalID != nil && *goal.ParentGoalID == parentGoalID {
+			children = append(children, goal)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].ID < children[j].ID })
+	return children, nil
+}
+func (f *fakeStore) AddKRDependency(_ context.Context, krID, dependsOnID int64) error {
+	f.dependencies[krID] = append(f.dependencies[krID], dependsOnID)
+	return nil
+}
+func (f *fakeStore) DeleteKRDependency(_ context.Context, krID, dependsOnID int64) error {
+	ids := f.dependencies[krID][:0]
+	for _, id := range f.dependencies[krID] {
+		if id != dependsOnID {
+			ids = append(ids, id)
+		}
+	}
+	f.dependencies[krID] = ids
+	return nil
+}
+func (f *fakeStore) ListKRDependencyIDs(_ context.Context, krID int64) ([]int64, error) {
+	return f.dependencies[krID], nil
+}
+func (f *fakeStore) LockKRDependencies(context.Context) error {
+	if f.txDepth == 0 {
+		return errors.New("advisory lock outside a transaction")
+	}
+	f.dependencyLocks++
+	return nil
+}
+func (f *fakeStore) CreateWebhook(_ context.Context, input store.WebhookInput) (int64, error) {
+	id := int64(len(f.webhooks) + 1)
+	f.webhooks[id] = domain.Webhook{ID: id, URL: input.URL, Secret: input.Secret, Events: input.Events, TeamID: input.TeamID, Active: input.Active, CreatedBy: input.CreatedBy}
+	return id, nil
+}
+func (f *fakeStore) UpdateWebhook(_ context.Context, id int64, input store.WebhookInput) error {
+	webhook, ok := f.webhooks[id]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	webhook.URL, webhook.Secret, webhook.Events, webhook.TeamID, webhook.Active = input.URL, input.Secret, input.Events, input.TeamID, input.Active
+	f.webhooks[id] = webhook
+	return nil
+}
+func (f *fakeStore) GetWebhook(_ context.Context, id int64) (domain.Webhook, error) {
+	webhook, ok := f.webhooks[id]
+	if !ok {
+		return domain.Webhook{}, pgx.ErrNoRows
+	}
+	return webhook, nil
+}
+func (f *fakeStore) ListWebhooks(context.Context) ([]domain.Webhook, error) {
+	webhooks := make([]domain.Webhook, 0, len(f.webhooks))
+	for id := int64(1); id <= int64(len(f.webhooks)); id++ {
+		if webhook, ok := f.webhooks[id]; ok {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+func (f *fakeStore) DeleteWebhook(_ context.Context, id int64) error {
+	if _, ok := f.webhooks[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(f.webhooks, id)
+	return nil
+}
+func (f *fakeStore) EnqueueWebhookDeliveries(_ context.Context, event domain.WebhookEvent, teamIDs []int64, payload, chatPayload []byte) error {
+	if f.enqueueErr != nil {
+		return f.enqueueErr
+	}
+	var decoded WebhookPayload
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return err
+	}
+	var chat ChatMessage
+	if err := json.Unmarshal(chatPayload, &chat); err != nil {
+		return err
+	}
+	f.webhookEvents = append(f.webhookEvents, fakeWebhookEvent{Event: event, TeamIDs: teamIDs, Payload: decoded, Chat: chat.Text})
+	return nil
+}
+func (f *fakeStore) SwapTeamPeriodHealth(_ context.Context, teamID, _ int64, health string) (string, error) {
+	previous := f.health[teamID]
+	f.health[teamID] = health
+	return previous, nil
+}
+func (f *fakeStore) ListWebhookDeliveries(context.Context, int64, int) ([]domain.WebhookDelivery, error) {
+	return nil, nil
+}
+func (f *fakeStore) GetUser(_ context.Context, id int64) (domain.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return domain.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+func (f *fakeStore) SetGoalOwnerUser(_ context.Context, goalID int64, userID *int64) error {
+	goal := f.goals[goalID]
+	goal.OwnerUserID = userID
+	f.goals[goalID] = goal
+	return nil
+}
+func (f *fakeStore) SetTeamLeadUser(_ context.Context, teamID int64, userID *int64) error {
+	for i := range f.teams {
+		if f.teams[i].ID == teamID {
+			f.teams[i].LeadUserID = userID
+		}
+	}
+	return nil
+}
+func (f *fakeStore) SetUserDigestOptOut(_ context.Context, userID int64, optOut bool) error {
+	user := f.users[userID]
+	user.DigestOptOut = optOut
+	f.users[userID] = user
+	return nil
+}
+func (f *fakeStore) ListDigestDueUsers(_ context.Context, sentBefore time.Time) ([]domain.User, error) {
+	var users []domain.User
+	for _, user := range f.users {
+		if sentAt, ok := f.digestSent[user.ID]; !user.DigestOptOut && (!ok || sentAt.Before(sentBefore)) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+func (f *fakeStore) MarkDigestSent(_ context.Context, userID int64, sentAt time.Time) error {
+	f.digestSent[userID] = sentAt
 	return nil
 }
 
@@ -127,6 +703,7 @@ func TestUpdateKRProgressPercent(t *testing.T) {
 	store := newFakeStore()
 	store.keyResults[1] = domain.KeyResult{ID: 1, Kind: domain.KRKindPercent}
 	store.keyResults[2] = domain.KeyResult{ID: 2, Kind: domain.KRKindLinear}
+	store.keyResults[4] = domain.KeyResult{ID: 4, Kind: domain.KRKindRange}
 	service := New(store)
 
 	if err := service.UpdateKRProgressPercent(context.Background(), 1, 42); err != nil {
@@ -141,6 +718,33 @@ func TestUpdateKRProgressPercent(t *testing.T) {
 	if store.linearUpdates[2] != 55 {
 		t.Fatalf("expected linear update")
 	}
+	if err := service.UpdateKRProgressPercent(context.Background(), 4, 180); err != nil {
+		t.Fatalf("update range: %v", err)
+	}
+	if store.rangeUpdates[4] != 180 {
+		t.Fatalf("expected range update")
+	}
+}
+
+func TestApplyProjectSchedule(t *testing.T) {
+	yesterday := time.Now().AddDate(0, 0, -2)
+	nextMonth := time.Now().AddDate(0, 1, 0)
+	krs := []domain.KeyResult{
+		{ID: 1, Kind: domain.KRKindProject, Project: &domain.KRProject{Stages: []domain.KRProjectStage{
+			{ID: 1, Weight: 40, DueDate: &yesterday},
+			{ID: 2, Weight: 60, DueDate: &nextMonth},
+		}}},
+		{ID: 2, Kind: domain.KRKindBoolean},
+	}
+	New(newFakeStore()).ApplyProjectSchedule(krs)
+
+	project := krs[0].Project
+	if project.PlannedProgress != 40 {
+		t.Fatalf("expected planned progress 40, got %d", project.PlannedProgress)
+	}
+	if !project.Stages[0].Overdue || project.Stages[1].Overdue {
+		t.Fatalf("expected only the first stage overdue, got %+v", project.Stages)
+	}
 }
 
 func TestUpdateKRProgressBoolean(t *testing.T) {
@@ -194,3 +798,1448 @@ func TestMoveKeyResult(t *testing.T) {
 		t.Fatalf("expected key result move direction")
 	}
 }
+
+func TestUpdateTeamPeriodStatusTransitions(t *testing.T) {
+	store := newFakeStore()
+	service := New(store)
+	ctx := context.Background()
+
+	steps := []domain.TeamPeriodStatus{
+		domain.TeamPeriodStatusForming,
+		domain.TeamPeriodStatusInProgress,
+		domain.TeamPeriodStatusValidated,
+		domain.TeamPeriodStatusClosed,
+	}
+	for _, status := range steps {
+		if err := service.UpdateTeamPeriodStatus(ctx, 1, 1, status); err != nil {
+			t.Fatalf("transition to %s: %v", status, err)
+		}
+		if store.statuses[1] != status {
+			t.Fatalf("expected status %s, got %s", status, store.statuses[1])
+		}
+	}
+}
+
+func TestUpdateTeamPeriodStatusRejectsIllegalJump(t *testing.T) {
+	store := newFakeStore()
+	store.statuses[1] = domain.TeamPeriodStatusValidated
+	service := New(store)
+
+	err := service.UpdateTeamPeriodStatus(context.Background(), 1, 1, domain.TeamPeriodStatusForming)
+	if !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+	if store.statuses[1] != domain.TeamPeriodStatusValidated {
+		t.Fatalf("expected status to stay validated")
+	}
+}
+
+func TestCanTransitionStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		from   domain.TeamPeriodStatus
+		to     domain.TeamPeriodStatus
+		admin  bool
+		expect bool
+	}{
+		{name: "same status", from: domain.TeamPeriodStatusForming, to: domain.TeamPeriodStatusForming, expect: true},
+		{name: "skip forming", from: domain.TeamPeriodStatusNoGoals, to: domain.TeamPeriodStatusInProgress, expect: false},
+		{name: "back to forming", from: domain.TeamPeriodStatusInProgress, to: domain.TeamPeriodStatusForming, expect: false},
+		{name: "reopen validated", from: domain.TeamPeriodStatusValidated, to: domain.TeamPeriodStatusInProgress, expect: false},
+		{name: "admin reopen validated", from: domain.TeamPeriodStatusValidated, to: domain.TeamPeriodStatusInProgress, admin: true, expect: true},
+		{name: "admin reopen closed", from: domain.TeamPeriodStatusClosed, to: domain.TeamPeriodStatusInProgress, admin: true, expect: true},
+		{name: "admin closed to forming", from: domain.TeamPeriodStatusClosed, to: domain.TeamPeriodStatusForming, admin: true, expect: false},
+	}
+	for _, tc := range cases {
+		if got := CanTransitionStatus(tc.from, tc.to, tc.admin); got != tc.expect {
+			t.Fatalf("%s: expected %v got %v", tc.name, tc.expect, got)
+		}
+	}
+}
+
+func TestMutationAllowed(t *testing.T) {
+	cases := []struct {
+		status domain.TeamPeriodStatus
+		kind   MutationKind
+		expect bool
+	}{
+		{status: domain.TeamPeriodStatusInProgress, kind: MutationStructural, expect: true},
+		{status: domain.TeamPeriodStatusValidated, kind: MutationStructural, expect: false},
+		{status: domain.TeamPeriodStatusValidated, kind: MutationProgress, expect: true},
+		{status: domain.TeamPeriodStatusValidated, kind: MutationComment, expect: true},
+		{status: domain.TeamPeriodStatusValidated, kind: MutationReorder, expect: true},
+		{status: domain.TeamPeriodStatusClosed, kind: MutationProgress, expect: false},
+		{status: domain.TeamPeriodStatusClosed, kind: MutationComment, expect: false},
+	}
+	for _, tc := range cases {
+		if got := MutationAllowed(tc.status, tc.kind); got != tc.expect {
+			t.Fatalf("%s/%s: expected %v got %v", tc.status, tc.kind, tc.expect, got)
+		}
+	}
+}
+
+func TestValidatedPeriodLocksStructureOnly(t *testing.T) {
+	store := newFakeStore()
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1}
+	store.keyResults[2] = domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindPercent}
+	store.statuses[7] = domain.TeamPeriodStatusValidated
+	service := New(store)
+	ctx := context.Background()
+
+	if err := service.UpdateKRProgressPercent(ctx, 2, 30); err != nil {
+		t.Fatalf("update progress: %v", err)
+	}
+	err := service.UpdateGoal(ctx, storeGoalUpdate(1))
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+}
+
+func TestClosedPeriodIsReadOnly(t *testing.T) {
+	store := newFakeStore()
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1}
+	store.keyResults[2] = domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindBoolean}
+	store.statuses[7] = domain.TeamPeriodStatusClosed
+	service := New(store)
+	ctx := context.Background()
+
+	if err := service.UpdateKRProgressBoolean(ctx, 2, true); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked for progress, got %v", err)
+	}
+	if err := service.AddKeyResultComment(ctx, 2, "note"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked for comment, got %v", err)
+	}
+	if store.booleanUpdates[2] {
+		t.Fatalf("expected no boolean update")
+	}
+}
+
+func storeGoalUpdate(goalID int64) store.GoalUpdateInput {
+	return store.GoalUpdateInput{ID: goalID, Title: "Goal"}
+}
+
+func TestPermissionsCan(t *testing.T) {
+	teams := []domain.Team{
+		{ID: 1, Name: "Company"},
+		{ID: 2, Name: "Unit A", ParentID: int64Ptr(1)},
+		{ID: 3, Name: "Team A1", ParentID: int64Ptr(2)},
+		{ID: 4, Name: "Unit B", ParentID: int64Ptr(1)},
+	}
+	perms := NewPermissions([]domain.RoleAssignment{
+		{Role: domain.RoleEditor, Scope: domain.RoleScopeSubtree, TeamID: int64Ptr(2)},
+		{Role: domain.RoleViewer, Scope: domain.RoleScopeGlobal},
+		{Role: domain.RoleValidator, Scope: domain.RoleScopeTeam, TeamID: int64Ptr(3)},
+	}, teams)
+
+	cases := []struct {
+		name   string
+		action Action
+		teamID int64
+		expect bool
+	}{
+		{name: "edit own unit", action: ActionEdit, teamID: 2, expect: true},
+		{name: "edit child team", action: ActionEdit, teamID: 3, expect: true},
+		{name: "edit sibling unit", action: ActionEdit, teamID: 4, expect: false},
+		{name: "edit parent", action: ActionEdit, teamID: 1, expect: false},
+		{name: "validate single team", action: ActionValidate, teamID: 3, expect: true},
+		{name: "validate own unit", action: ActionValidate, teamID: 2, expect: false},
+		{name: "admin", action: ActionAdmin, teamID: 3, expect: false},
+	}
+	for _, tc := range cases {
+		if got := perms.Can(tc.action, tc.teamID); got != tc.expect {
+			t.Fatalf("%s: expected %v got %v", tc.name, tc.expect, got)
+		}
+	}
+	if perms.CanGlobal(ActionEdit) {
+		t.Fatalf("expected no global edit")
+	}
+}
+
+func TestOnlyValidatorsValidatePeriod(t *testing.T) {
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: 1, Name: "Team"}}
+	store.statuses[1] = domain.TeamPeriodStatusInProgress
+	store.assignments[10] = []domain.RoleAssignment{{Role: domain.RoleEditor, Scope: domain.RoleScopeTeam, TeamID: int64Ptr(1)}}
+	store.assignments[11] = []domain.RoleAssignment{{Role: domain.RoleValidator, Scope: domain.RoleScopeTeam, TeamID: int64Ptr(1)}}
+	service := New(store)
+	editor := auth.WithUser(context.Background(), domain.User{ID: 10})
+	validator := auth.WithUser(context.Background(), domain.User{ID: 11})
+
+	err := service.UpdateTeamPeriodStatus(editor, 1, 1, domain.TeamPeriodStatusValidated)
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for editor, got %v", err)
+	}
+	if err := service.UpdateTeamPeriodStatus(validator, 1, 1, domain.TeamPeriodStatusValidated); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	err = service.UpdateTeamPeriodStatus(validator, 1, 1, domain.TeamPeriodStatusInProgress)
+	if !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("expected reopen to be reserved for admins, got %v", err)
+	}
+}
+
+func TestViewerCannotEditGoals(t *testing.T) {
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: 7, Name: "Team"}}
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1}
+	store.keyResults[2] = domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindPercent}
+	store.assignments[10] = []domain.RoleAssignment{{Role: domain.RoleViewer, Scope: domain.RoleScopeGlobal}}
+	service := New(store)
+	ctx := auth.WithUser(context.Background(), domain.User{ID: 10})
+
+	if err := service.UpdateKRProgressPercent(ctx, 2, 30); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, ok := store.percentUpdates[2]; ok {
+		t.Fatalf("expected no percent update")
+	}
+}
+
+func TestAPITokenRoundTrip(t *testing.T) {
+	store := newFakeStore()
+	service := New(store)
+	ctx := auth.WithUser(context.Background(), domain.User{ID: 5})
+
+	raw, token, err := service.CreateAPIToken(ctx, "ci", true, nil)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if _, ok := store.apiTokens[raw]; ok {
+		t.Fatalf("expected raw token not to be stored")
+	}
+	user, resolved, err := service.AuthenticateAPIToken(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if user.ID != 5 || resolved.ID != token.ID || !resolved.ReadOnly {
+		t.Fatalf("unexpected token owner %+v / %+v", user, resolved)
+	}
+	if store.touchedTokens[token.ID] != 1 {
+		t.Fatalf("expected last used timestamp to be updated")
+	}
+	if _, _, err := service.AuthenticateAPIToken(context.Background(), "unknown"); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("expected ErrInvalidAPIToken, got %v", err)
+	}
+}
+
+func TestProgressUpdateIsAudited(t *testing.T) {
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: 7, Name: "Team"}}
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1}
+	store.keyResults[2] = domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindBoolean}
+	store.assignments[10] = []domain.RoleAssignment{{Role: domain.RoleEditor, Scope: domain.RoleScopeGlobal}}
+	service := New(store)
+	ctx := auth.WithUser(context.Background(), domain.User{ID: 10})
+
+	if err := service.UpdateKRProgressBoolean(ctx, 2, true); err != nil {
+		t.Fatalf("update boolean: %v", err)
+	}
+	if len(store.audits) != 1 {
+		t.Fatalf("expected one audit event, got %d", len(store.audits))
+	}
+	event := store.audits[0]
+	if event.EntityType != domain.AuditEntityKeyResult || event.EntityID != 2 || event.Action != domain.AuditActionProgress {
+		t.Fatalf("unexpected audit event %+v", event)
+	}
+	if event.ActorID == nil || *event.ActorID != 10 {
+		t.Fatalf("expected actor 10, got %v", event.ActorID)
+	}
+	if len(event.Before) == 0 || len(event.After) == 0 {
+		t.Fatalf("expected before and after state")
+	}
+}
+
+func TestRemoveGoalFromTeam(t *testing.T) {
+	shares := []store.GoalShare{{GoalID: 1, TeamID: 8, Weight: 30}, {GoalID: 1, TeamID: 9, Weight: 10}}
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: 7, Name: "Core"}, {ID: 8, Name: "Mobile"}, {ID: 9, Name: "Web"}}
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1, Weight: 60}
+	store.shares[1] = shares
+	service := New(store)
+	ctx := context.Background()
+
+	if err := service.RemoveGoalFromTeam(ctx, 1, 9); err != nil {
+		t.Fatalf("remove share: %v", err)
+	}
+	if shares := store.shares[1]; len(shares) != 1 || shares[0].TeamID != 8 {
+		t.Fatalf("expected only the Mobile share to remain, got %+v", shares)
+	}
+	if err := service.RemoveGoalFromTeam(ctx, 1, 7); err != nil {
+		t.Fatalf("remove owner: %v", err)
+	}
+	if goal := store.goals[1]; goal.TeamID != 8 || goal.Weight != 30 || len(store.shares[1]) != 0 {
+		t.Fatalf("expected Mobile to take over the goal, got %+v / %+v", goal, store.shares[1])
+	}
+	if err := service.RemoveGoalFromTeam(ctx, 1, 8); err != nil {
+		t.Fatalf("remove last team: %v", err)
+	}
+	if _, ok := store.goals[1]; ok {
+		t.Fatalf("expected the goal to be deleted")
+	}
+	var actions []domain.AuditAction
+	for _, event := range store.audits {
+		actions = append(actions, event.Action)
+	}
+	expected := []domain.AuditAction{domain.AuditActionShare, domain.AuditActionShare, domain.AuditActionDelete}
+	if fmt.Sprint(actions) != fmt.Sprint(expected) || store.transactions != 3 {
+		t.Fatalf("expected %v in 3 transactions, got %v in %d", expected, actions, store.transactions)
+	}
+}
+
+func TestTeamAndPeriodChangesAreAudited(t *testing.T) {
+	mobile := store.TeamInput{Name: "Mobile", Type: domain.TeamTypeTeam, ParentID: int64Ptr(7)}
+	root := store.TeamInput{Name: "Mobile", Type: domain.TeamTypeTeam}
+	period := store.PeriodInput{Name: "Q3"}
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: 7, Name: "Core"}}
+	store.assignments[10] = []domain.RoleAssignment{{Role: domain.RoleAdmin, Scope: domain.RoleScopeSubtree, TeamID: int64Ptr(7)}}
+	service := New(store)
+	ctx := auth.WithUser(context.Background(), domain.User{ID: 10})
+
+	teamID, err := service.CreateTeam(ctx, mobile)
+	if err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+	if err := service.UpdateTeam(ctx, teamID, root); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected moving a team to the root to need global admin, got %v", err)
+	}
+	if _, err := service.CreatePeriod(ctx, period); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected periods to need global admin, got %v", err)
+	}
+	if err := service.DeleteTeam(ctx, teamID); err != nil {
+		t.Fatalf("delete team: %v", err)
+	}
+	if len(store.teams) != 1 || len(store.audits) != 2 || store.transactions != 2 {
+		t.Fatalf("expected a created and deleted team with 2 audited transactions, got %+v / %d / %d", store.teams, len(store.audits), store.transactions)
+	}
+	if create, remove := store.audits[0], store.audits[1]; create.Action != domain.AuditActionCreate || remove.Action != domain.AuditActionDelete || remove.EntityID != teamID {
+		t.Fatalf("unexpected audit events %+v", store.audits)
+	}
+}
+
+func TestProgressUpdateRecordsHistory(t *testing.T) {
+	store := newFakeStore()
+	kr := domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindLinear, Linear: &domain.KRLinear{StartValue: 0, TargetValue: 200, CurrentValue: 50}}
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1, KeyResults: []domain.KeyResult{kr}}
+	store.keyResults[2] = kr
+	service := New(store)
+
+	if err := service.UpdateKRProgressPercent(context.Background(), 2, 50); err != nil {
+		t.Fatalf("update linear: %v", err)
+	}
+	if len(store.progressEvents) != 1 {
+		t.Fatalf("expected one progress event, got %d", len(store.progressEvents))
+	}
+	event := store.progressEvents[0]
+	if event.KeyResultID != 2 || event.Value != 50 || event.Progress != 25 || event.Source != domain.ProgressSourceManual {
+		t.Fatalf("unexpected progress event %+v", event)
+	}
+	ctx := WithProgressSource(context.Background(), DataSourceProgressSource(domain.DataSourcePromQL))
+	if err := service.UpdateKRProgressPercent(ctx, 2, 100); err != nil {
+		t.Fatalf("update linear from data source: %v", err)
+	}
+	if source := store.progressEvents[1].Source; source != "data_source:PROMQL" {
+		t.Fatalf("expected data source in history, got %q", source)
+	}
+}
+
+func TestBackfillKRProgressHistory(t *testing.T) {
+	store := newFakeStore()
+	seeded := domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindLinear, Linear: &domain.KRLinear{StartValue: 0, TargetValue: 200, CurrentValue: 50}}
+	recorded := domain.KeyResult{ID: 3, GoalID: 1, Kind: domain.KRKindBoolean, Boolean: &domain.KRBoolean{IsDone: true}}
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1, KeyResults: []domain.KeyResult{seeded, recorded}}
+	store.keyResults[2] = seeded
+	store.keyResults[3] = recorded
+	service := New(store)
+	ctx := context.Background()
+	if err := service.RecordKRProgress(ctx, 3); err != nil {
+		t.Fatalf("record progress: %v", err)
+	}
+	webhookEvents := len(store.webhookEvents)
+
+	backfilled, err := service.BackfillKRProgressHistory(ctx)
+	if err != nil || backfilled != 1 {
+		t.Fatalf("expected one key result backfilled, got %d (%v)", backfilled, err)
+	}
+	if event := store.progressEvents[1]; event.KeyResultID != 2 || event.Value != 50 || event.Progress != 25 || event.Source != domain.ProgressSourceManual {
+		t.Fatalf("unexpected backfilled event %+v", event)
+	}
+	if len(store.webhookEvents) != webhookEvents {
+		t.Fatalf("backfill must not send webhooks")
+	}
+	if backfilled, err := service.BackfillKRProgressHistory(ctx); err != nil || backfilled != 0 {
+		t.Fatalf("expected a repeated backfill to do nothing, got %d (%v)", backfilled, err)
+	}
+}
+
+func TestIngestKRValue(t *testing.T) {
+	store := newFakeStore()
+	percent := domain.KeyResult{ID: 1, GoalID: 1, Kind: domain.KRKindPercent, Percent: &domain.KRPercent{StartValue: 0, TargetValue: 100}}
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1, KeyResults: []domain.KeyResult{percent}}
+	store.keyResults[1] = percent
+	store.keyResults[2] = domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindBoolean}
+	service := New(store).WithIngestSecret("s3cret")
+	ctx := context.Background()
+
+	body := []byte(`{"kr_id":1,"value":42}`)
+	if err := service.VerifyIngestSignature(body, auth.Sign([]byte("s3cret"), body)); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if err := service.VerifyIngestSignature(body, auth.Sign([]byte("other"), body)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected foreign signature to be rejected, got %v", err)
+	}
+	if err := New(store).VerifyIngestSignature(body, auth.Sign(nil, body)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ingest without secret to be disabled, got %v", err)
+	}
+
+	value := 42.0
+	input := IngestInput{KeyResultID: 1, IdempotencyKey: "run-1", Payload: body, Timestamp: time.Now(), Value: &value}
+	if _, err := service.IngestKRValue(ctx, 2, input); !errors.Is(err, ErrInvalidIngest) {
+		t.Fatalf("expected a payload signed for another KR to be rejected, got %v", err)
+	}
+	stale := input
+	stale.Timestamp = time.Now().Add(-time.Hour)
+	if _, err := service.IngestKRValue(ctx, 1, stale); !errors.Is(err, ErrInvalidIngest) {
+		t.Fatalf("expected stale payload to be rejected, got %v", err)
+	}
+	boolean := input
+	boolean.KeyResultID = 2
+	if _, err := service.IngestKRValue(ctx, 2, boolean); !errors.Is(err, ErrInvalidIngest) {
+		t.Fatalf("expected value for boolean KR to be rejected, got %v", err)
+	}
+	applied, err := service.IngestKRValue(ctx, 1, input)
+	if err != nil || !applied {
+		t.Fatalf("expected ingest to apply, got %v (%v)", applied, err)
+	}
+	if store.percentUpdates[1] != 42 || len(store.progressEvents) != 1 || store.progressEvents[0].Source != IngestProgressSource {
+		t.Fatalf("unexpected update %v, history %+v", store.percentUpdates, store.progressEvents)
+	}
+	if applied, err := service.IngestKRValue(ctx, 1, input); err != nil || applied {
+		t.Fatalf("expected retry to be a no-op, got %v (%v)", applied, err)
+	}
+	if len(store.progressEvents) != 1 {
+		t.Fatalf("expected retry not to write history, got %+v", store.progressEvents)
+	}
+	rekeyed := input
+	rekeyed.IdempotencyKey = "run-replayed"
+	if applied, err := service.IngestKRValue(ctx, 1, rekeyed); err != nil || applied {
+		t.Fatalf("expected a replayed payload under a new key to be a no-op, got %v (%v)", applied, err)
+	}
+	changed := input
+	changed.Payload = []byte(`{"kr_id":1,"value":43}`)
+	if _, err := service.IngestKRValue(ctx, 1, changed); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Fatalf("expected reused key to conflict, got %v", err)
+	}
+	unkeyed := changed
+	unkeyed.IdempotencyKey = ""
+	if applied, err := service.IngestKRValue(ctx, 1, unkeyed); err != nil || !applied {
+		t.Fatalf("expected ingest without a key to apply, got %v (%v)", applied, err)
+	}
+	if applied, err := service.IngestKRValue(ctx, 1, unkeyed); err != nil || applied {
+		t.Fatalf("expected a replayed payload without a key to be a no-op, got %v (%v)", applied, err)
+	}
+
+	store.statuses[7] = domain.TeamPeriodStatusClosed
+	locked := input
+	locked.IdempotencyKey = "run-2"
+	locked.Payload = []byte(`{"kr_id":1,"value":44}`)
+	if _, err := service.IngestKRValue(ctx, 1, locked); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected closed period to reject ingest, got %v", err)
+	}
+	if _, ok := store.ingestKeys["1/run-2"]; ok {
+		t.Fatalf("expected failed ingest to release its idempotency key")
+	}
+	if _, ok := store.ingestKeys["1/payload:"+auth.HashToken(string(locked.Payload))]; ok {
+		t.Fatalf("expected failed ingest to release its payload key")
+	}
+}
+
+func TestSetKRDataSource(t *testing.T) {
+	store := newFakeStore()
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1}
+	store.keyResults[1] = domain.KeyResult{ID: 1, GoalID: 1, Kind: domain.KRKindPercent}
+	store.keyResults[2] = domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindBoolean}
+	store.assignments[10] = []domain.RoleAssignment{{Role: domain.RoleEditor, Scope: domain.RoleScopeTeam, TeamID: int64Ptr(7)}}
+	service := New(store)
+	ctx := context.Background()
+
+	invalid := []DataSourceInput{
+		{Kind: "CSV", Endpoint: "http://metrics.local", Query: "value"},
+		{Kind: domain.DataSourceHTTPJSON, Endpoint: "metrics.local", Query: "value"},
+		{Kind: domain.DataSourcePromQL, Endpoint: "http://prometheus.local", Query: " "},
+		{Kind: domain.DataSourceSQL, Endpoint: "analytics", Query: "SELECT 1", IntervalMinutes: -5},
+		{Kind: domain.DataSourceHTTPJSON, Endpoint: "http://localhost:8080/metrics", Query: "value"},
+		{Kind: domain.DataSourceHTTPJSON, Endpoint: "http://169.254.169.254/latest/meta-data", Query: "value"},
+		{Kind: domain.DataSourcePromQL, Endpoint: "http://10.0.0.5:9090", Query: "up"},
+		{Kind: domain.DataSourcePromQL, Endpoint: "http://[::1]:9090", Query: "up"},
+	}
+	for _, input := range invalid {
+		if err := service.SetKRDataSource(ctx, 1, input); !errors.Is(err, ErrInvalidDataSource) {
+			t.Fatalf("expected %+v to be rejected, got %v", input, err)
+		}
+	}
+	input := DataSourceInput{Kind: domain.DataSourceSQL, Endpoint: "Analytics", Query: "SELECT count(*) FROM orders"}
+	editor := auth.WithUser(ctx, domain.User{ID: 10})
+	if err := service.SetKRDataSource(editor, 1, input); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected sql data source to require an admin, got %v", err)
+	}
+	if err := service.SetKRDataSource(ctx, 2, input); !errors.Is(err, ErrInvalidDataSource) {
+		t.Fatalf("expected boolean KR to be rejected, got %v", err)
+	}
+	if err := service.SetKRDataSource(ctx, 1, input); err != nil {
+		t.Fatalf("set data source: %v", err)
+	}
+	source, err := service.GetKRDataSource(ctx, 1)
+	if err != nil {
+		t.Fatalf("get data source: %v", err)
+	}
+	if source.Kind != domain.DataSourceSQL || source.Endpoint != "analytics" || source.IntervalMinutes != DefaultDataSourceInterval {
+		t.Fatalf("unexpected data source %+v", source)
+	}
+	if err := service.DeleteKRDataSource(ctx, 1); err != nil {
+		t.Fatalf("delete data source: %v", err)
+	}
+	if _, err := service.GetKRDataSource(ctx, 1); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected data source to be removed, got %v", err)
+	}
+}
+
+func TestKRCurrentValue(t *testing.T) {
+	project := domain.KeyResult{Kind: domain.KRKindProject, Project: &domain.KRProject{Stages: []domain.KRProjectStage{{IsDone: true}, {IsDone: false}, {IsDone: true}}}}
+	if value := KRCurrentValue(project); value != 2 {
+		t.Fatalf("expected 2 done stages, got %v", value)
+	}
+	boolean := domain.KeyResult{Kind: domain.KRKindBoolean, Boolean: &domain.KRBoolean{IsDone: true}}
+	if value := KRCurrentValue(boolean); value != 1 {
+		t.Fatalf("expected 1 for done boolean, got %v", value)
+	}
+	if value := KRCurrentValue(domain.KeyResult{Kind: domain.KRKindPercent}); value != 0 {
+		t.Fatalf("expected 0 without meta, got %v", value)
+	}
+}
+
+func TestCheckInUpdatesProgress(t *testing.T) {
+	store := newFakeStore()
+	kr := domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindPercent, Percent: &domain.KRPercent{StartValue: 0, TargetValue: 100, CurrentValue: 40}}
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1, KeyResults: []domain.KeyResult{kr}}
+	store.keyResults[2] = kr
+	service := New(store)
+	value := 40.0
+
+	if _, err := service.CheckInKeyResult(context.Background(), 2, CheckInInput{Value: &value, Confidence: 11}); !errors.Is(err, ErrInvalidCheckIn) {
+		t.Fatalf("expected ErrInvalidCheckIn, got %v", err)
+	}
+	if len(store.percentUpdates) != 0 || len(store.checkIns) != 0 {
+		t.Fatalf("rejected check-in must not write")
+	}
+	store.checkInErr = errors.New("insert failed")
+	if _, err := service.CheckInKeyResult(context.Background(), 2, CheckInInput{Value: &value, Confidence: 7}); !errors.Is(err, store.checkInErr) {
+		t.Fatalf("expected the insert error, got %v", err)
+	}
+	if len(store.progressEvents) != 0 || len(store.webhookEvents) != 0 {
+		t.Fatalf("a failed check-in must not record history or send webhooks")
+	}
+	store.checkInErr = nil
+	transactions := store.transactions
+	checkIn, err := service.CheckInKeyResult(context.Background(), 2, CheckInInput{Value: &value, Confidence: 7, Blockers: " none "})
+	if err != nil {
+		t.Fatalf("check in: %v", err)
+	}
+	if store.percentUpdates[2] != 40 || len(store.progressEvents) != 1 || store.transactions == transactions {
+		t.Fatalf("expected progress update through the regular path in a transaction")
+	}
+	if len(store.checkIns) != 1 || checkIn.Progress != 40 || checkIn.Confidence != 7 || checkIn.Blockers != "none" {
+		t.Fatalf("unexpected check-in %+v", checkIn)
+	}
+}
+
+func TestSetConfidence(t *testing.T) {
+	store := newFakeStore()
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1}
+	store.keyResults[2] = domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindBoolean}
+	service := New(store)
+	invalid, valid := 11, 4
+
+	if err := service.SetGoalConfidence(context.Background(), 1, &invalid); !errors.Is(err, ErrInvalidConfidence) {
+		t.Fatalf("expected ErrInvalidConfidence, got %v", err)
+	}
+	if err := service.SetKeyResultConfidence(context.Background(), 2, &valid); err != nil {
+		t.Fatalf("set kr confidence: %v", err)
+	}
+	if got := store.keyResults[2].Confidence; got == nil || *got != 4 {
+		t.Fatalf("expected kr confidence 4, got %v", got)
+	}
+	if len(store.audits) != 1 || store.audits[0].Action != domain.AuditActionConfidence {
+		t.Fatalf("expected one confidence audit event, got %+v", store.audits)
+	}
+	store.statuses[7] = domain.TeamPeriodStatusClosed
+	if err := service.SetGoalConfidence(context.Background(), 1, nil); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked in closed period, got %v", err)
+	}
+}
+
+func TestGoalAlignment(t *testing.T) {
+	cluster, unit := int64(1), int64(2)
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: cluster}, {ID: unit, ParentID: &cluster}, {ID: 3, ParentID: &unit}, {ID: 4}}
+	store.goals[10] = domain.Goal{ID: 10, TeamID: cluster, PeriodID: 1}
+	store.goals[20] = domain.Goal{ID: 20, TeamID: unit, PeriodID: 1, Weight: 30, KeyResults: []domain.KeyResult{{Weight: 100, Kind: domain.KRKindBoolean, Boolean: &domain.KRBoolean{IsDone: true}}}}
+	store.goals[30] = domain.Goal{ID: 30, TeamID: 3, PeriodID: 1, Weight: 10}
+	store.goals[40] = domain.Goal{ID: 40, TeamID: 4, PeriodID: 1}
+	store.goals[50] = domain.Goal{ID: 50, TeamID: cluster, PeriodID: 2}
+	service := New(store)
+	ctx := context.Background()
+
+	for _, parentID := range []int64{40, 50, 30, 20} {
+		parent := parentID
+		if err := service.SetGoalAlignment(ctx, 20, GoalAlignmentInput{ParentGoalID: &parent}); !errors.Is(err, ErrInvalidAlignment) {
+			t.Fatalf("parent %d: expected ErrInvalidAlignment, got %v", parentID, err)
+		}
+	}
+	clusterGoal, unitGoal := int64(10), int64(20)
+	if err := service.SetGoalAlignment(ctx, 20, GoalAlignmentInput{ParentGoalID: &clusterGoal}); err != nil {
+		t.Fatalf("align to cluster goal: %v", err)
+	}
+	if err := service.SetGoalAlignment(ctx, 30, GoalAlignmentInput{ParentGoalID: &unitGoal}); err != nil {
+		t.Fatalf("align to unit goal: %v", err)
+	}
+	if err := service.SetGoalAlignment(ctx, 10, GoalAlignmentInput{ProgressFromChildren: true}); err != nil {
+		t.Fatalf("enable roll-up: %v", err)
+	}
+	if len(store.audits) != 3 || store.audits[0].Action != domain.AuditActionAlign {
+		t.Fatalf("expected three align audit events, got %+v", store.audits)
+	}
+
+	goal, err := service.GetGoal(ctx, 10)
+	if err != nil {
+		t.Fatalf("get goal: %v", err)
+	}
+	if goal.Progress != 100 {
+		t.Fatalf("expected progress rolled up from children 100, got %d", goal.Progress)
+	}
+	tree, err := service.GoalTree(ctx, 30)
+	if err != nil {
+		t.Fatalf("goal tree: %v", err)
+	}
+	if len(tree.Ancestors) != 2 || tree.Ancestors[0].Goal.ID != 10 || tree.Ancestors[0].Goal.Progress != 100 || tree.Ancestors[1].Goal.ID != 20 {
+		t.Fatalf("unexpected ancestors %+v", tree.Ancestors)
+	}
+	tree, err = service.GoalTree(ctx, 10)
+	if err != nil {
+		t.Fatalf("goal tree: %v", err)
+	}
+	if len(tree.Root.Children) != 1 || len(tree.Root.Children[0].Children) != 1 || tree.Root.Children[0].Children[0].Goal.ID != 30 {
+		t.Fatalf("unexpected tree %+v", tree.Root)
+	}
+}
+
+func TestOwnerChangeDropsStaleAlignment(t *testing.T) {
+	cluster, unit, clusterGoal, unitGoal := int64(1), int64(2), int64(10), int64(20)
+	shares := []store.GoalShare{{GoalID: 31, TeamID: unit, Weight: 10}}
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: cluster}, {ID: unit, ParentID: &cluster}, {ID: 3, ParentID: &unit}, {ID: 4}}
+	store.goals[10] = domain.Goal{ID: 10, TeamID: cluster, PeriodID: 1}
+	store.goals[20] = domain.Goal{ID: 20, TeamID: unit, PeriodID: 1, ParentGoalID: &clusterGoal}
+	store.goals[30] = domain.Goal{ID: 30, TeamID: 3, PeriodID: 1, ParentGoalID: &unitGoal}
+	store.goals[31] = domain.Goal{ID: 31, TeamID: 3, PeriodID: 1, ParentGoalID: &clusterGoal}
+	store.shares[31] = shares
+	service := New(store)
+	ctx := context.Background()
+
+	if err := service.RemoveGoalFromTeam(ctx, 31, 3); err != nil {
+		t.Fatalf("remove owner: %v", err)
+	}
+	if goal := store.goals[31]; goal.TeamID != unit || goal.ParentGoalID == nil || *goal.ParentGoalID != clusterGoal {
+		t.Fatalf("expected the link to the cluster goal to survive the handover to an ancestor team, got %+v", goal)
+	}
+	if _, err := service.SetGoalTeams(ctx, 20, []int64{4}); err != nil {
+		t.Fatalf("move goal: %v", err)
+	}
+	if goal := store.goals[20]; goal.TeamID != 4 || goal.ParentGoalID != nil {
+		t.Fatalf("expected the link to the cluster goal to be removed, got %+v", goal)
+	}
+	if goal := store.goals[30]; goal.ParentGoalID != nil {
+		t.Fatalf("expected the goal aligned to the moved goal to lose its link, got %+v", goal)
+	}
+	var actions []domain.AuditAction
+	for _, event := range store.audits {
+		actions = append(actions, event.Action)
+	}
+	expected := []domain.AuditAction{domain.AuditActionShare, domain.AuditActionAlign, domain.AuditActionShare}
+	if fmt.Sprint(actions) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, actions)
+	}
+}
+
+func TestTeamSummaryRollup(t *testing.T) {
+	cluster, unit := int64(1), int64(2)
+	fixed := 10
+	done := domain.KeyResult{Weight: 100, Kind: domain.KRKindBoolean, Boolean: &domain.KRBoolean{IsDone: true}}
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: cluster, Name: "Cluster"}, {ID: unit, Name: "Unit", ParentID: &cluster}, {ID: 3, Name: "A", ParentID: &unit}, {ID: 4, Name: "B", ParentID: &unit, RollupWeight: &fixed}}
+	store.teamGoals[3] = []domain.Goal{{ID: 30, Weight: 30, KeyResults: []domain.KeyResult{done}}}
+	store.teamGoals[4] = []domain.Goal{{ID: 40, Weight: 100, KeyResults: []domain.KeyResult{{Weight: 100, Kind: domain.KRKindBoolean}}}}
+	service := New(store)
+
+	rows, err := service.GetTeamsWithPeriodSummary(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(rows))
+	}
+	// A: 100% with goal weight 30, B: 0% with the fixed weight 10.
+	want := map[int64][2]int{cluster: {0, 75}, unit: {0, 75}, 3: {100, 100}, 4: {0, 0}}
+	for _, row := range rows {
+		if got := [2]int{row.PeriodProgress, row.RollupProgress}; got != want[row.ID] {
+			t.Fatalf("team %d: expected own/rollup %v, got %v", row.ID, want[row.ID], got)
+		}
+	}
+}
+
+func TestKRDependencies(t *testing.T) {
+	store := newFakeStore()
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1, KeyResults: []domain.KeyResult{
+		{ID: 1, GoalID: 1, Kind: domain.KRKindBoolean},
+		{ID: 2, GoalID: 1, Kind: domain.KRKindBoolean},
+		{ID: 3, GoalID: 1, Kind: domain.KRKindBoolean, Boolean: &domain.KRBoolean{IsDone: true}},
+	}}
+	for _, kr := range store.goals[1].KeyResults {
+		store.keyResults[kr.ID] = kr
+	}
+	service := New(store)
+	ctx := context.Background()
+
+	if err := service.AddKRDependency(ctx, 1, 2); err != nil {
+		t.Fatalf("add dependency: %v", err)
+	}
+	if err := service.AddKRDependency(ctx, 2, 3); err != nil {
+		t.Fatalf("add dependency: %v", err)
+	}
+	if err := service.AddKRDependency(ctx, 3, 1); !errors.Is(err, ErrInvalidDependency) {
+		t.Fatalf("expected cycle to be rejected, got %v", err)
+	}
+	if err := service.AddKRDependency(ctx, 1, 1); !errors.Is(err, ErrInvalidDependency) {
+		t.Fatalf("expected self dependency to be rejected, got %v", err)
+	}
+	if err := service.AddKRDependency(ctx, 1, 3); err != nil {
+		t.Fatalf("add dependency: %v", err)
+	}
+	items, err := service.ListKRDependencies(ctx, 1)
+	if err != nil {
+		t.Fatalf("list dependencies: %v", err)
+	}
+	if len(items) != 2 || items[0].KeyResult.ID != 2 || items[1].KeyResult.Progress != 100 {
+		t.Fatalf("unexpected dependencies %+v", items)
+	}
+	if err := service.RemoveKRDependency(ctx, 1, 2); err != nil {
+		t.Fatalf("remove dependency: %v", err)
+	}
+	if got := store.dependencies[1]; len(got) != 1 || got[0] != 3 {
+		t.Fatalf("expected only dependency on 3, got %v", got)
+	}
+	if len(store.audits) != 4 || store.audits[0].Action != domain.AuditActionDependency {
+		t.Fatalf("expected four dependency audit events, got %+v", store.audits)
+	}
+}
+
+func TestKRDependencyReverseEdge(t *testing.T) {
+	store := newFakeStore()
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1, KeyResults: []domain.KeyResult{
+		{ID: 1, GoalID: 1, Kind: domain.KRKindBoolean},
+		{ID: 2, GoalID: 1, Kind: domain.KRKindBoolean},
+	}}
+	for _, kr := range store.goals[1].KeyResults {
+		store.keyResults[kr.ID] = kr
+	}
+	service := New(store)
+	ctx := context.Background()
+
+	if err := service.AddKRDependency(ctx, 1, 2); err != nil {
+		t.Fatalf("add dependency: %v", err)
+	}
+	if err := service.AddKRDependency(ctx, 2, 1); !errors.Is(err, ErrInvalidDependency) {
+		t.Fatalf("expected the reverse edge to be rejected, got %v", err)
+	}
+	if len(store.dependencies[2]) != 0 {
+		t.Fatalf("expected no reverse dependency, got %v", store.dependencies[2])
+	}
+	if store.dependencyLocks != 2 {
+		t.Fatalf("expected both checks to hold the dependency lock in their transaction, got %d locks", store.dependencyLocks)
+	}
+	if len(store.audits) != 1 {
+		t.Fatalf("expected only the accepted dependency to be audited, got %+v", store.audits)
+	}
+}
+
+func TestPercentCheckpoints(t *testing.T) {
+	store := newFakeStore()
+	kr := domain.KeyResult{ID: 1, GoalID: 1, Kind: domain.KRKindPercent, Percent: &domain.KRPercent{StartValue: 100, TargetValue: 0, CurrentValue: 100}}
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 7, PeriodID: 1, KeyResults: []domain.KeyResult{kr}}
+	store.keyResults[1] = kr
+	store.keyResults[2] = domain.KeyResult{ID: 2, GoalID: 1, Kind: domain.KRKindBoolean}
+	service := New(store)
+	ctx := context.Background()
+
+	first, err := service.AddPercentCheckpoint(ctx, 1, CheckpointInput{MetricValue: 80, KRPercent: 30})
+	if err != nil {
+		t.Fatalf("add checkpoint: %v", err)
+	}
+	if _, err := service.AddPercentCheckpoint(ctx, 1, CheckpointInput{MetricValue: 50, KRPercent: 70}); err != nil {
+		t.Fatalf("add checkpoint: %v", err)
+	}
+	invalid := []CheckpointInput{
+		{MetricValue: 100, KRPercent: 10},
+		{MetricValue: 0, KRPercent: 90},
+		{MetricValue: 120, KRPercent: 10},
+		{MetricValue: 60, KRPercent: 101},
+		{MetricValue: 80, KRPercent: 40},
+		{MetricValue: 40, KRPercent: 60},
+	}
+	for _, input := range invalid {
+		if _, err := service.AddPercentCheckpoint(ctx, 1, input); !errors.Is(err, ErrInvalidCheckpoint) {
+			t.Fatalf("expected %+v to be rejected, got %v", input, err)
+		}
+	}
+	if _, err := service.UpdatePercentCheckpoint(ctx, 1, first.ID, CheckpointInput{MetricValue: 90, KRPercent: 80}); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Fatalf("expected decreasing percents to be rejected, got %v", err)
+	}
+	if _, err := service.UpdatePercentCheckpoint(ctx, 1, first.ID, CheckpointInput{MetricValue: 90, KRPercent: 20}); err != nil {
+		t.Fatalf("update checkpoint: %v", err)
+	}
+	if _, err := service.AddPercentCheckpoint(ctx, 2, CheckpointInput{MetricValue: 1, KRPercent: 1}); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Fatalf("expected checkpoint on boolean KR to be rejected, got %v", err)
+	}
+	if err := service.DeletePercentCheckpoint(ctx, 2, first.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected checkpoint of another KR to be not found, got %v", err)
+	}
+	if err := service.DeletePercentCheckpoint(ctx, 1, first.ID); err != nil {
+		t.Fatalf("delete checkpoint: %v", err)
+	}
+	items, err := service.ListPercentCheckpoints(ctx, 1)
+	if err != nil {
+		t.Fatalf("list checkpoints: %v", err)
+	}
+	if len(items) != 1 || items[0].MetricValue != 50 || items[0].KRPercent != 70 {
+		t.Fatalf("unexpected checkpoints %+v", items)
+	}
+	if len(store.audits) != 4 {
+		t.Fatalf("expected four audit events, got %+v", store.audits)
+	}
+}
+
+func TestTeamsMissingCheckIn(t *testing.T) {
+	recent := time.Now()
+	old := WeekStart(recent).Add(-time.Hour)
+	lastCheckIns := []store.TeamLastCheckIn{{TeamID: 1, LastCheckInAt: &recent}, {TeamID: 2, LastCheckInAt: &old}, {TeamID: 3}}
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: 1, Name: "A"}, {ID: 2, Name: "B"}, {ID: 3, Name: "C"}}
+	store.lastCheckIns = lastCheckIns
+	service := New(store)
+
+	items, _, err := service.TeamsMissingCheckIn(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("missing check-ins: %v", err)
+	}
+	if len(items) != 2 || items[0].Team.ID != 2 || items[1].Team.ID != 3 {
+		t.Fatalf("unexpected teams %+v", items)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+	if got := WeekStart(sunday); !got.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected monday 2024-03-04, got %v", got)
+	}
+	monday := time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC)
+	if got := WeekStart(monday); !got.Equal(time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected monday 2024-03-11, got %v", got)
+	}
+}
+
+func TestBurnupSeries(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 4, 23, 59, 59, 0, time.UTC)
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	goal := domain.Goal{KeyResults: []domain.KeyResult{{ID: 1, Weight: 50}, {ID: 2, Weight: 50}}}
+	events := map[int64][]domain.KRProgressEvent{
+		1: {{Progress: 40, CreatedAt: start.Add(2 * time.Hour)}, {Progress: 80, CreatedAt: now.Add(time.Hour)}},
+		2: {{Progress: 20, CreatedAt: start.Add(30 * time.Hour)}},
+	}
+	points := burnupSeries(start, end, now, func(at time.Time) int {
+		return goalProgressAt(goal, events, at)
+	})
+	if len(points) != 4 {
+		t.Fatalf("expected 4 daily points, got %d", len(points))
+	}
+	if points[3].Planned != 100 || points[0].Planned != 25 {
+		t.Fatalf("unexpected planned values %d..%d", points[0].Planned, points[3].Planned)
+	}
+	if points[0].Actual == nil || *points[0].Actual != 20 {
+		t.Fatalf("expected actual 20 on the first day, got %v", points[0].Actual)
+	}
+	if points[1].Actual == nil || *points[1].Actual != 30 {
+		t.Fatalf("expected actual 30 up to now, got %v", points[1].Actual)
+	}
+	if points[2].Actual != nil || points[3].Actual != nil {
+		t.Fatalf("expected no actual values after now")
+	}
+}
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+func TestWebhookRegistry(t *testing.T) {
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: 1, Name: "Unit"}, {ID: 2, Name: "Team", ParentID: int64Ptr(1)}}
+	store.assignments[10] = []domain.RoleAssignment{{Role: domain.RoleAdmin, Scope: domain.RoleScopeSubtree, TeamID: int64Ptr(1)}}
+	store.assignments[11] = []domain.RoleAssignment{{Role: domain.RoleAdmin, Scope: domain.RoleScopeGlobal}}
+	service := New(store)
+	teamAdmin := auth.WithUser(context.Background(), domain.User{ID: 10})
+	globalAdmin := auth.WithUser(context.Background(), domain.User{ID: 11})
+
+	if _, err := service.CreateWebhook(teamAdmin, WebhookInput{URL: "https://hooks.example.com/okr", Active: true}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected team admin to be denied a global webhook, got %v", err)
+	}
+	for _, input := range []WebhookInput{
+		{URL: "ftp://hooks.example.com", TeamID: int64Ptr(2)},
+		{URL: "http://127.0.0.1:8080/admin", TeamID: int64Ptr(2)},
+		{URL: "http://169.254.169.254/latest/meta-data", TeamID: int64Ptr(2)},
+		{URL: "https://localhost/hook", TeamID: int64Ptr(2)},
+		{URL: "https://hooks.example.com", Events: []domain.WebhookEvent{"goal.deleted"}, TeamID: int64Ptr(2)},
+	} {
+		if _, err := service.CreateWebhook(teamAdmin, input); !errors.Is(err, ErrInvalidWebhook) {
+			t.Fatalf("expected ErrInvalidWebhook for %+v, got %v", input, err)
+		}
+	}
+	teamHook, err := service.CreateWebhook(teamAdmin, WebhookInput{
+		URL:    " https://hooks.example.com/team ",
+		Events: []domain.WebhookEvent{domain.WebhookEventGoalCreated, domain.WebhookEventGoalCreated},
+		TeamID: int64Ptr(2),
+		Active: true,
+	})
+	if err != nil {
+		t.Fatalf("create team webhook: %v", err)
+	}
+	if teamHook.URL != "https://hooks.example.com/team" || teamHook.Secret == "" || len(teamHook.Events) != 1 || *teamHook.CreatedBy != 10 {
+		t.Fatalf("unexpected webhook %+v", teamHook)
+	}
+	if _, err := service.CreateWebhook(globalAdmin, WebhookInput{URL: "https://hooks.example.com/all", Secret: "s3cret", Active: true}); err != nil {
+		t.Fatalf("create global webhook: %v", err)
+	}
+	visible, err := service.ListWebhooks(teamAdmin)
+	if err != nil || len(visible) != 1 || visible[0].ID != teamHook.ID {
+		t.Fatalf("expected team admin to see only the team webhook, got %+v (%v)", visible, err)
+	}
+	if _, err := service.ListWebhookDeliveries(teamAdmin, 2); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected global webhook log to be forbidden, got %v", err)
+	}
+
+	updated, err := service.UpdateWebhook(teamAdmin, teamHook.ID, WebhookInput{URL: "https://hooks.example.com/team", TeamID: int64Ptr(1)})
+	if err != nil {
+		t.Fatalf("update webhook: %v", err)
+	}
+	if updated.Secret != teamHook.Secret || updated.Active || *updated.TeamID != 1 {
+		t.Fatalf("expected secret to be kept on update, got %+v", updated)
+	}
+	if err := service.DeleteWebhook(teamAdmin, teamHook.ID); err != nil {
+		t.Fatalf("delete webhook: %v", err)
+	}
+	if err := service.DeleteWebhook(teamAdmin, teamHook.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected deleted webhook to be missing, got %v", err)
+	}
+	for _, audit := range store.audits {
+		if audit.EntityType == domain.AuditEntityWebhook && strings.Contains(string(audit.After)+string(audit.Before), teamHook.Secret) {
+			t.Fatalf("webhook secret leaked into the audit log")
+		}
+	}
+}
+
+func TestMutationsEmitWebhookEvents(t *testing.T) {
+	input := store.GoalInput{TeamID: 2, PeriodID: 1, Title: "Grow", Weight: 50}
+	store := newFakeStore()
+	store.teams = []domain.Team{{ID: 1, Name: "Unit"}, {ID: 2, Name: "Team", ParentID: int64Ptr(1)}, {ID: 3, Name: "Other"}}
+	service := New(store)
+	ctx := context.Background()
+
+	goalID, err := service.CreateGoal(ctx, input)
+	if err != nil {
+		t.Fatalf("create goal: %v", err)
+	}
+	if store.statuses[2] != domain.TeamPeriodStatusForming {
+		t.Fatalf("expected the first goal to start forming, got %s", store.statuses[2])
+	}
+	if err := service.ShareGoal(ctx, goalID, []ShareTarget{{TeamID: 3, Weight: 20}}); err != nil {
+		t.Fatalf("share goal: %v", err)
+	}
+	kr := domain.KeyResult{ID: 5, GoalID: goalID, Title: "Revenue", Kind: domain.KRKindPercent, Percent: &domain.KRPercent{StartValue: 0, TargetValue: 100}}
+	store.keyResults[5] = kr
+	goal := store.goals[goalID]
+	goal.KeyResults = []domain.KeyResult{kr}
+	store.goals[goalID] = goal
+	if err := service.UpdateKRProgressPercent(ctx, 5, 0); err != nil {
+		t.Fatalf("record progress: %v", err)
+	}
+	if err := service.RecordKRProgress(ctx, 5); err != nil {
+		t.Fatalf("record unchanged progress: %v", err)
+	}
+	for _, status := range []domain.TeamPeriodStatus{domain.TeamPeriodStatusInProgress, domain.TeamPeriodStatusValidated} {
+		if err := service.UpdateTeamPeriodStatus(ctx, 2, 1, status); err != nil {
+			t.Fatalf("move to %s: %v", status, err)
+		}
+	}
+
+	var events []domain.WebhookEvent
+	for _, event := range store.webhookEvents {
+		events = append(events, event.Event)
+	}
+	expected := []domain.WebhookEvent{
+		domain.WebhookEventGoalCreated,
+		domain.WebhookEventGoalShared,
+		domain.WebhookEventKRProgressChanged,
+		domain.WebhookEventTeamPeriodValidated,
+	}
+	if fmt.Sprint(events) != fmt.Sprint(expected) {
+		t.Fatalf("expected events %v, got %v", expected, events)
+	}
+	if created := store.webhookEvents[0]; fmt.Sprint(created.TeamIDs) != "[2 1]" || created.Payload.TeamID != 2 {
+		t.Fatalf("expected goal.created to reach the team and its parent, got %+v", created)
+	}
+	if shared := store.webhookEvents[1]; fmt.Sprint(shared.TeamIDs) != "[2 1 3]" {
+		t.Fatalf("expected goal.shared to reach the shared team, got %+v", shared)
+	}
+	data := store.webhookEvents[2].Payload.Data.(map[string]any)
+	if data["kr_id"] != float64(5) || data["previous_progress"] != nil || data["source"] != domain.ProgressSourceManual {
+		t.Fatalf("unexpected progress payload %+v", data)
+	}
+
+	store.enqueueErr = errors.New("queue is down")
+	input.TeamID = 3
+	if _, err := service.CreateGoal(ctx, input); !errors.Is(err, store.enqueueErr) {
+		t.Fatalf("expected a webhook error to fail the goal transaction, got %v", err)
+	}
+	if err := service.UpdateTeamPeriodStatus(ctx, 2, 1, domain.TeamPeriodStatusClosed); !errors.Is(err, store.enqueueErr) {
+		t.Fatalf("expected a webhook error to fail the status transaction, got %v", err)
+	}
+}
+
+func TestChatWebhookNotifications(t *testing.T) {
+	now := time.Now()
+	store := newFakeStore()
+	store.periods = []domain.Period{{ID: 1, Name: "Q1", StartDate: now.AddDate(0, 0, -45), EndDate: now.AddDate(0, 0, 45)}}
+	store.teams = []domain.Team{{ID: 1, Name: "Core"}}
+	service := New(store)
+	ctx := context.Background()
+
+	if _, err := service.CreateWebhook(ctx, WebhookInput{URL: "https://chat.example.com/hooks/1", Format: domain.WebhookFormatChat, Active: true}); !errors.Is(err, ErrInvalidWebhook) {
+		t.Fatalf("expected a chat webhook without a team to be rejected, got %v", err)
+	}
+	if _, err := service.CreateWebhook(ctx, WebhookInput{URL: "https://chat.example.com/hooks/1", Format: "xml", TeamID: int64Ptr(1)}); !errors.Is(err, ErrInvalidWebhook) {
+		t.Fatalf("expected an unknown format to be rejected, got %v", err)
+	}
+	hook, err := service.CreateWebhook(ctx, WebhookInput{URL: "https://chat.example.com/hooks/1", Format: domain.WebhookFormatChat, TeamID: int64Ptr(1), Active: true})
+	if err != nil {
+		t.Fatalf("create chat webhook: %v", err)
+	}
+	if fmt.Sprint(hook.Events) != fmt.Sprint(ChatWebhookEvents) {
+		t.Fatalf("expected chat webhook to default to %v, got %v", ChatWebhookEvents, hook.Events)
+	}
+
+	setProgress := func(current float64) {
+		kr := domain.KeyResult{ID: 5, GoalID: 1, Title: "Revenue", Weight: 100, Kind: domain.KRKindPercent, Percent: &domain.KRPercent{StartValue: 0, TargetValue: 100, CurrentValue: current}}
+		goal := domain.Goal{ID: 1, TeamID: 1, PeriodID: 1, Title: "Grow", Weight: 100, KeyResults: []domain.KeyResult{kr}}
+		store.keyResults[5] = kr
+		store.goals[1] = goal
+		store.teamGoals[1] = []domain.Goal{goal}
+		if err := service.RecordKRProgress(ctx, 5); err != nil {
+			t.Fatalf("record progress %v: %v", current, err)
+		}
+	}
+	setProgress(0)
+	setProgress(10)
+	setProgress(100)
+	setProgress(0)
+
+	var events []domain.WebhookEvent
+	for _, event := range store.webhookEvents {
+		events = append(events, event.Event)
+	}
+	expected := []domain.WebhookEvent{
+		domain.WebhookEventKRProgressChanged,
+		domain.WebhookEventTeamBehindPlan,
+		domain.WebhookEventKRProgressChanged,
+		domain.WebhookEventKRProgressChanged,
+		domain.WebhookEventKRCompleted,
+		domain.WebhookEventKRProgressChanged,
+		domain.WebhookEventTeamBehindPlan,
+	}
+	if fmt.Sprint(events) != fmt.Sprint(expected) {
+		t.Fatalf("expected events %v, got %v", expected, events)
+	}
+	if chat := store.webhookEvents[1].Chat; !strings.HasPrefix(chat, "Core отстаёт от плана в периоде Q1: прогресс 0% при плане") {
+		t.Fatalf("unexpected behind plan message %q", chat)
+	}
+	if chat := store.webhookEvents[2].Chat; chat != "Core: прогресс KR «Revenue» 10% (было 0%)" {
+		t.Fatalf("unexpected progress message %q", chat)
+	}
+	if chat := store.webhookEvents[4].Chat; chat != "Core: KR «Revenue» выполнен" {
+		t.Fatalf("unexpected completion message %q", chat)
+	}
+}
+
+func TestCheckTeamsHealth(t *testing.T) {
+	now := time.Now()
+	store := newFakeStore()
+	store.periods = []domain.Period{
+		{ID: 1, Name: "Q1 <draft>", StartDate: now.AddDate(0, 0, -45), EndDate: now.AddDate(0, 0, 45)},
+		{ID: 2, Name: "Q2", StartDate: now.AddDate(0, 0, 50), EndDate: now.AddDate(0, 0, 140)},
+	}
+	store.teams = []domain.Team{{ID: 1, Name: "R&D <!channel>"}, {ID: 2, Name: "Empty"}}
+	kr := domain.KeyResult{ID: 5, GoalID: 1, Title: "Revenue", Weight: 100, Kind: domain.KRKindPercent, Percent: &domain.KRPercent{StartValue: 0, TargetValue: 100}}
+	goal := domain.Goal{ID: 1, TeamID: 1, PeriodID: 1, Title: "Grow", Weight: 100, KeyResults: []domain.KeyResult{kr}}
+	store.keyResults[5] = kr
+	store.goals[1] = goal
+	store.teamGoals[1] = []domain.Goal{goal}
+	service := New(store)
+	ctx := context.Background()
+
+	notified, err := service.CheckTeamsHealth(ctx)
+	if err != nil {
+		t.Fatalf("check health: %v", err)
+	}
+	if notified != 1 || len(store.webhookEvents) != 1 || store.webhookEvents[0].Event != domain.WebhookEventTeamBehindPlan {
+		t.Fatalf("expected one team.behind_plan, got %d %+v", notified, store.webhookEvents)
+	}
+	if chat := store.webhookEvents[0].Chat; !strings.HasPrefix(chat, "R&amp;D &lt;!channel&gt; отстаёт от плана в периоде Q1 &lt;draft&gt;:") {
+		t.Fatalf("expected names to be escaped, got %q", chat)
+	}
+	if notified, err := service.CheckTeamsHealth(ctx); err != nil || notified != 0 {
+		t.Fatalf("expected a team that stays behind not to be notified again, got %d %v", notified, err)
+	}
+}
+
+func TestOwnerAndLeadReferences(t *testing.T) {
+	store := newFakeStore()
+	store.goals[1] = domain.Goal{ID: 1, TeamID: 2, PeriodID: 1}
+	store.teams = []domain.Team{{ID: 2, Name: "Team"}}
+	store.users[7] = domain.User{ID: 7, Email: "owner@example.com"}
+	store.users[8] = domain.User{ID: 8, Email: "other@example.com"}
+	store.assignments[7] = []domain.RoleAssignment{{Role: domain.RoleEditor, Scope: domain.RoleScopeTeam, TeamID: int64Ptr(2)}}
+	service := New(store)
+	ctx := context.Background()
+
+	if err := service.SetGoalOwner(ctx, 1, int64Ptr(99)); !errors.Is(err, ErrUnknownUser) {
+		t.Fatalf("expected ErrUnknownUser, got %v", err)
+	}
+	if err := service.SetGoalOwner(ctx, 1, int64Ptr(7)); err != nil {
+		t.Fatalf("set owner: %v", err)
+	}
+	if owner := store.goals[1].OwnerUserID; owner == nil || *owner != 7 {
+		t.Fatalf("expected goal owner 7, got %v", owner)
+	}
+	editor := auth.WithUser(ctx, domain.User{ID: 7})
+	if err := service.SetTeamLead(editor, 2, int64Ptr(7)); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected editor to be denied setting the lead, got %v", err)
+	}
+	if err := service.SetTeamLead(ctx, 2, int64Ptr(7)); err != nil {
+		t.Fatalf("set lead: %v", err)
+	}
+	if lead := store.teams[0].LeadUserID; lead == nil || *lead != 7 {
+		t.Fatalf("expected team lead 7, got %v", lead)
+	}
+
+	if err := service.SetDigestOptOut(editor, 8, true); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected opt-out of another user to be forbidden, got %v", err)
+	}
+	if err := service.SetDigestOptOut(editor, 7, true); err != nil {
+		t.Fatalf("opt out: %v", err)
+	}
+	if !store.users[7].DigestOptOut {
+		t.Fatalf("expected user to be opted out")
+	}
+	last := store.audits[len(store.audits)-1]
+	if last.EntityType != domain.AuditEntityUser || !strings.Contains(string(last.After), `"DigestOptOut":true`) {
+		t.Fatalf("expected opt-out to be audited, got %+v", last)
+	}
+}
+
+func TestDueDigests(t *testing.T) {
+	now := time.Now()
+	store := newFakeStore()
+	store.periods = []domain.Period{
+		{ID: 1, Name: "Past", StartDate: now.AddDate(0, -4, 0), EndDate: now.AddDate(0, 0, -20)},
+		{ID: 2, Name: "Current", StartDate: now.AddDate(0, 0, -45), EndDate: now.AddDate(0, 0, 45)},
+		{ID: 3, Name: "Next", StartDate: now.AddDate(0, 0, 46), EndDate: now.AddDate(0, 0, 120)},
+	}
+	store.teams = []domain.Team{{ID: 1, Name: "Team", LeadUserID: int64Ptr(1)}, {ID: 2, Name: "Other"}}
+	store.users[1] = domain.User{ID: 1, Email: "lead@example.com"}
+	store.users[2] = domain.User{ID: 2, Email: "owner@example.com"}
+	store.users[3] = domain.User{ID: 3, Email: "quiet@example.com", DigestOptOut: true}
+	store.digestSent[2] = now.Add(-time.Hour)
+	store.statuses[1] = domain.TeamPeriodStatusInProgress
+	stale := domain.KeyResult{ID: 10, Title: "Stale", Weight: 100, Kind: domain.KRKindBoolean, UpdatedAt: now.AddDate(0, 0, -10)}
+	fresh := domain.KeyResult{ID: 11, Title: "Fresh", Weight: 100, Kind: domain.KRKindBoolean, Boolean: &domain.KRBoolean{IsDone: true}, UpdatedAt: now}
+	store.teamGoals[1] = []domain.Goal{
+		{ID: 1, TeamID: 1, PeriodID: 2, Title: "Behind", OwnerUserID: int64Ptr(2), UpdatedAt: now, KeyResults: []domain.KeyResult{stale}},
+		{ID: 2, TeamID: 1, PeriodID: 2, Title: "Done", UpdatedAt: now, KeyResults: []domain.KeyResult{fresh}},
+		{ID: 3, TeamID: 2, PeriodID: 2, Title: "Shared in", OwnerUserID: int64Ptr(1), UpdatedAt: now, KeyResults: []domain.KeyResult{stale}},
+	}
+	service := New(store)
+	ctx := context.Background()
+
+	digests, err := service.DueDigests(ctx, 7*24*time.Hour, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("due digests: %v", err)
+	}
+	if len(digests) != 1 || digests[0].User.ID != 1 {
+		t.Fatalf("expected only the lead to be due, got %+v", digests)
+	}
+	digest := digests[0]
+	if len(digest.StaleKeyResults) != 1 || digest.StaleKeyResults[0].KeyResult.ID != 10 || digest.StaleKeyResults[0].TeamName != "Team" {
+		t.Fatalf("unexpected stale key results %+v", digest.StaleKeyResults)
+	}
+	if len(digest.GoalsAtRisk) != 1 || digest.GoalsAtRisk[0].Goal.ID != 1 || digest.GoalsAtRisk[0].Health != "behind" {
+		t.Fatalf("unexpected goals at risk %+v", digest.GoalsAtRisk)
+	}
+	var pending []string
+	for _, item := range digest.PendingStatuses {
+		pending = append(pending, fmt.Sprintf("%s:%s->%s", item.Period.Name, item.Status, item.Next))
+	}
+	if fmt.Sprint(pending) != "[Current:in_progress->validated Past:in_progress->validated]" {
+		t.Fatalf("unexpected pending statuses %v", pending)
+	}
+
+	store.digestSent[2] = now.AddDate(0, 0, -8)
+	if err := service.MarkDigestSent(ctx, 1); err != nil {
+		t.Fatalf("mark sent: %v", err)
+	}
+	digests, err = service.DueDigests(ctx, 7*24*time.Hour, 7*24*time.Hour)
+	if err != nil || len(digests) != 1 || digests[0].User.ID != 2 {
+		t.Fatalf("expected only the owner to be due, got %+v (%v)", digests, err)
+	}
+	if owner := digests[0]; len(owner.GoalsAtRisk) != 1 || len(owner.StaleKeyResults) != 1 || len(owner.PendingStatuses) != 0 {
+		t.Fatalf("expected the owner to get only the owned goal, got %+v", owner)
+	}
+}
+
+func TestExportOKRs(t *testing.T) {
+	shares := []store.GoalShare{{GoalID: 1, TeamID: 2, Weight: 40}}
+	maxLatency := 300.0
+	store := newFakeStore()
+	store.periods = []domain.Period{{ID: 1, Name: "Q1"}}
+	store.teams = []domain.Team{{ID: 1, Name: "Core"}, {ID: 2, Name: "Sales"}}
+	revenue := domain.KeyResult{ID: 10, Title: "Revenue", Weight: 100, Kind: domain.KRKindLinear, Linear: &domain.KRLinear{StartValue: 100, TargetValue: 200, CurrentValue: 150}}
+	launch := domain.KeyResult{ID: 11, Title: "Launch", Weight: 50, Kind: domain.KRKindProject, Project: &domain.KRProject{Stages: []domain.KRProjectStage{{Weight: 50, IsDone: true}, {Weight: 50}}}}
+	latency := domain.KeyResult{ID: 12, Title: "Latency", Weight: 50, Kind: domain.KRKindRange, Range: &domain.KRRange{MaxValue: &maxLatency, CurrentValue: 250}}
+	grow := domain.Goal{ID: 1, TeamID: 1, PeriodID: 1, Title: "Grow", Weight: 70, KeyResults: []domain.KeyResult{revenue}}
+	ship := domain.Goal{ID: 2, TeamID: 1, PeriodID: 1, Title: "Ship", Weight: 30, KeyResults: []domain.KeyResult{launch, latency}}
+	empty := domain.Goal{ID: 3, TeamID: 2, PeriodID: 1, Title: "Plan", Weight: 100}
+	store.teamGoals[1] = []domain.Goal{grow, ship}
+	store.teamGoals[2] = []domain.Goal{empty, grow}
+	store.shares[1] = shares
+	service := New(store)
+	ctx := context.Background()
+
+	rows, err := service.ExportTeamOKRs(ctx, 1, store.periods[0])
+	if err != nil {
+		t.Fatalf("export team: %v", err)
+	}
+	var lines []string
+	for _, row := range rows {
+		lines = append(lines, fmt.Sprintf("%s/%s/%s %v-%v:%v %d%%", row.TeamName, row.Goal.Title, row.KeyResult.Title, floatValue(row.Start), floatValue(row.Target), floatValue(row.Current), row.Progress))
+	}
+	expected := []string{"Core/Grow/Revenue 100-200:150 50%", "Core/Ship/Launch 0-2:1 50%", "Core/Ship/Latency <nil>-300:250 100%"}
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Fatalf("expected rows %v, got %v", expected, lines)
+	}
+	if shares := rows[0].ShareTeams; len(shares) != 2 || shares[1].Name != "Sales" || shares[1].Weight != 40 {
+		t.Fatalf("expected share teams with weights, got %+v", shares)
+	}
+
+	rows, err = service.ExportPeriodOKRs(ctx, 1)
+	if err != nil {
+		t.Fatalf("export period: %v", err)
+	}
+	lines = nil
+	for _, row := range rows {
+		lines = append(lines, fmt.Sprintf("%s/%s/%s goal %d%%", row.TeamName, row.Goal.Title, row.KeyResult.Title, row.Goal.Progress))
+	}
+	expected = []string{"Core/Grow/Revenue goal 50%", "Core/Ship/Launch goal 75%", "Core/Ship/Latency goal 75%", "Sales/Plan/ goal 0%"}
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Fatalf("expected rows %v, got %v", expected, lines)
+	}
+}
+
+func floatValue(value *float64) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func TestImportOKRs(t *testing.T) {
+	kr := ImportKeyResult{
+		KeyResult: store.KeyResultInput{Title: "Revenue", Weight: 100, Kind: domain.KRKindPercent},
+		Meta:      KeyResultMetaInput{PercentTarget: 100},
+	}
+	input := ImportInput{PeriodID: 1, Teams: []ImportTeam{
+		{Location: "teams[0]", Name: "Core", Goals: []ImportGoal{{Goal: store.GoalInput{Title: "Grow", Weight: 60}, KeyResults: []ImportKeyResult{kr}}}},
+		{Location: "teams[1]", Name: "Mobile", Type: domain.TeamTypeTeam, Parent: "Unit", Goals: []ImportGoal{{Goal: store.GoalInput{Title: "Ship", Weight: 40}}}},
+		{Location: "teams[2]", Name: "iOS", Type: domain.TeamTypeTeam, Parent: "Mobile"},
+	}}
+	store := newFakeStore()
+	store.periods = []domain.Period{{ID: 1, Name: "Q1"}}
+	store.teams = []domain.Team{{ID: 1, Name: "Unit"}, {ID: 2, Name: "Core", ParentID: int64Ptr(1)}, {ID: 3, Name: "Other"}}
+	store.assignments[10] = []domain.RoleAssignment{{Role: domain.RoleAdmin, Scope: domain.RoleScopeSubtree, TeamID: int64Ptr(1)}}
+	store.assignments[11] = []domain.RoleAssignment{{Role: domain.RoleEditor, Scope: domain.RoleScopeTeam, TeamID: int64Ptr(2)}}
+	service := New(store)
+	admin := auth.WithUser(context.Background(), domain.User{ID: 10})
+	editor := auth.WithUser(context.Background(), domain.User{ID: 11})
+
+	report, err := service.ImportOKRs(admin, input, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if report.Applied || report.TeamsCreated != 2 || report.Goals != 2 || report.KeyResults != 1 || len(report.Issues) != 0 {
+		t.Fatalf("unexpected dry run report %+v", report)
+	}
+	if len(store.imports) != 0 {
+		t.Fatalf("expected dry run to write nothing, got %+v", store.imports)
+	}
+
+	if _, err := service.ImportOKRs(editor, input, false); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected an editor to be denied new teams, got %v", err)
+	}
+
+	invalid := ImportInput{PeriodID: 1, Teams: []ImportTeam{
+		{Location: "row 2", Name: "Ghost"},
+		{Location: "row 3", Name: "Web", Type: domain.TeamTypeTeam, Parent: "Nowhere"},
+		{Location: "row 4", Name: "Core"},
+		{Location: "row 5", Name: "Core"},
+	}}
+	report, err = service.ImportOKRs(admin, invalid, false)
+	if err != nil {
+		t.Fatalf("import with issues: %v", err)
+	}
+	if report.Applied || len(report.Issues) != 3 || len(store.imports) != 0 {
+		t.Fatalf("expected three issues and nothing written, got %+v", report)
+	}
+	if issue := report.Issues[0]; issue.Location != "row 2" || !strings.Contains(issue.Message, "укажите тип") {
+		t.Fatalf("unexpected missing team issue %+v", issue)
+	}
+
+	report, err = service.ImportOKRs(admin, input, false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !report.Applied || len(store.imports) != 1 {
+		t.Fatalf("expected the import to be applied once, got %+v", report)
+	}
+	applied := store.imports[0]
+	if mobile, ios := applied.Teams[1], applied.Teams[2]; mobile.ID != 4 || *mobile.Team.ParentID != 1 || ios.ParentIndex == nil || *ios.ParentIndex != 1 {
+		t.Fatalf("unexpected created teams %+v %+v", mobile, ios)
+	}
+	if meta := applied.Teams[0].Goals[0].KeyResults[0].Percent; meta.TargetValue != 100 {
+		t.Fatalf("expected percent meta to be passed through, got %+v", meta)
+	}
+	var actions []string
+	for _, event := range store.audits {
+		actions = append(actions, fmt.Sprintf("%s:%s", event.EntityType, event.Action))
+	}
+	expected := []string{
+		"team:status", "goal:create", "key_result:create",
+		"team:create", "team:status", "goal:create",
+		"team:create",
+	}
+	if fmt.Sprint(actions) != fmt.Sprint(expected) {
+		t.Fatalf("expected audits %v, got %v", expected, actions)
+	}
+	var events []domain.WebhookEvent
+	for _, event := range store.webhookEvents {
+		events = append(events, event.Event)
+	}
+	if fmt.Sprint(events) != fmt.Sprint([]domain.WebhookEvent{domain.WebhookEventGoalCreated, domain.WebhookEventKRProgressChanged, domain.WebhookEventGoalCreated}) {
+		t.Fatalf("unexpected webhook events %v", events)
+	}
+}
+
+func TestCopyAndRollOverGoals(t *testing.T) {
+	grow := domain.Goal{ID: 1, TeamID: 2, PeriodID: 1, Title: "Grow", Weight: 60, KeyResults: []domain.KeyResult{
+		{ID: 5, GoalID: 1, Title: "Revenue", Weight: 100, Kind: domain.KRKindPercent, Percent: &domain.KRPercent{StartValue: 0, TargetValue: 100, CurrentValue: 40}},
+	}}
+	done := domain.Goal{ID: 2, TeamID: 2, PeriodID: 1, Title: "Done", Weight: 40, KeyResults: []domain.KeyResult{
+		{ID: 6, GoalID: 2, Title: "Signed", Weight: 100, Kind: domain.KRKindBoolean, Boolean: &domain.KRBoolean{IsDone: true}},
+	}}
+	shared := domain.Goal{ID: 3, TeamID: 3, PeriodID: 1, Title: "Shared", Weight: 20}
+	shares := []store.GoalShare{{GoalID: 1, TeamID: 3, Weight: 20}}
+	store := newFakeStore()
+	now := time.Now()
+	store.periods = []domain.Period{
+		{ID: 1, Name: "Q1", StartDate: now.AddDate(0, -3, 0), EndDate: now},
+		{ID: 2, Name: "Q2", StartDate: now.AddDate(0, 0, 1), EndDate: now.AddDate(0, 3, 0)},
+	}
+	store.teams = []domain.Team{{ID: 1, Name: "Unit"}, {ID: 2, Name: "Core", ParentID: int64Ptr(1)}, {ID: 3, Name: "Sales"}}
+	store.goals[1], store.goals[2], store.goals[3] = grow, done, shared
+	store.teamGoals[2] = []domain.Goal{grow, done, shared}
+	store.keyResults[5], store.keyResults[6] = grow.KeyResults[0], done.KeyResults[0]
+	store.shares[1] = shares
+	service := New(store)
+	ctx := context.Background()
+
+	if _, err := service.CopyGoal(ctx, 1, 1, false); !errors.Is(err, ErrInvalidCopy) {
+		t.Fatalf("expected a copy into the same period to be rejected, got %v", err)
+	}
+	store.statuses[3] = domain.TeamPeriodStatusClosed
+	if _, err := service.RollOverGoals(ctx, 2, 1, 2, false); !errors.Is(err, ErrLocked) || len(store.imports) != 0 {
+		t.Fatalf("expected a locked share team period to reject the rollover, got %v", err)
+	}
+	delete(store.statuses, 3)
+
+	result, err := service.RollOverGoals(ctx, 2, 1, 2, false)
+	if err != nil {
+		t.Fatalf("roll over: %v", err)
+	}
+	if fmt.Sprint(result.GoalIDs) != "[4]" || result.Skipped != 0 {
+		t.Fatalf("expected only the unfinished own goal to be copied, got %+v", result)
+	}
+	copied := store.imports[0].Teams[0].Goals[0]
+	if *copied.OriginGoalID != 1 || copied.Goal.Title != "Grow" || fmt.Sprint(copied.Shares) != "[{3 20}]" {
+		t.Fatalf("unexpected copy %+v", copied)
+	}
+	if meta := copied.KeyResults[0].Percent; meta.TargetValue != 100 || meta.CurrentValue != 0 {
+		t.Fatalf("expected the copy to start over, got %+v", meta)
+	}
+	var moved []int64
+	for _, event := range store.audits {
+		if event.Action == domain.AuditActionStatus {
+			moved = append(moved, event.EntityID)
+		}
+	}
+	if fmt.Sprint(moved) != "[2 3]" {
+		t.Fatalf("expected the owner and share team periods to move to forming, got %v", moved)
+	}
+	var events []domain.WebhookEvent
+	for _, event := range store.webhookEvents {
+		events = append(events, event.Event)
+	}
+	if fmt.Sprint(events) != fmt.Sprint([]domain.WebhookEvent{domain.WebhookEventGoalCreated, domain.WebhookEventKRProgressChanged, domain.WebhookEventGoalShared}) {
+		t.Fatalf("unexpected webhook events %v", events)
+	}
+
+	result, err = service.RollOverGoals(ctx, 2, 1, 2, false)
+	if err != nil {
+		t.Fatalf("repeat roll over: %v", err)
+	}
+	if len(result.GoalIDs) != 0 || result.Skipped != 1 || len(store.imports) != 1 {
+		t.Fatalf("expected the repeated rollover to skip the copied goal, got %+v", result)
+	}
+
+	goalID, err := service.CopyGoal(ctx, 1, 2, true)
+	if err != nil {
+		t.Fatalf("copy goal: %v", err)
+	}
+	if goalID != 5 || store.imports[1].Teams[0].Goals[0].KeyResults[0].Percent.CurrentValue != 40 {
+		t.Fatalf("expected the copy to keep the current value, got %d %+v", goalID, store.imports[1])
+	}
+}
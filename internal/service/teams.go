@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+// CreateTeam creates a team under input.ParentID; it requires admin rights on the parent, or global admin for a root team.
+func (s *Service) CreateTeam(ctx context.Context, input store.TeamInput) (int64, error) {
+	if err := s.AuthorizeTeamAdmin(ctx, input.ParentID); err != nil {
+		return 0, err
+	}
+	var teamID int64
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if teamID, err = s.store.CreateTeam(ctx, input); err != nil {
+			return err
+		}
+		return s.AuditChange(ctx, domain.AuditEntityTeam, teamID, domain.AuditActionCreate, nil)
+	})
+	return teamID, err
+}
+
+// UpdateTeam requires admin rights on the team and, when the parent changes, on the new parent.
+func (s *Service) UpdateTeam(ctx context.Context, teamID int64, input store.TeamInput) error {
+	if err := s.Authorize(ctx, teamID, ActionAdmin); err != nil {
+		return err
+	}
+	team, err := s.store.GetTeam(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	if !sameParent(team.ParentID, input.ParentID) {
+		if err := s.AuthorizeTeamAdmin(ctx, input.ParentID); err != nil {
+			return err
+		}
+	}
+	return s.audited(ctx, domain.AuditEntityTeam, teamID, domain.AuditActionUpdate, func(ctx context.Context) error {
+		return s.store.UpdateTeam(ctx, input, teamID)
+	})
+}
+
+func (s *Service) DeleteTeam(ctx context.Context, teamID int64) error {
+	if err := s.Authorize(ctx, teamID, ActionAdmin); err != nil {
+		return err
+	}
+	return s.audited(ctx, domain.AuditEntityTeam, teamID, domain.AuditActionDelete, func(ctx context.Context) error {
+		return s.store.DeleteTeam(ctx, teamID)
+	})
+}
+
+func sameParent(left, right *int64) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	return *left == *right
+}
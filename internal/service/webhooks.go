@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/okr"
+	"okrs/internal/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidWebhook is returned when a webhook has no valid URL, an unknown format or subscribes to an unknown event.
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// WebhookDeliveryLogLimit is the number of latest deliveries returned for a webhook.
+const WebhookDeliveryLogLimit = 100
+
+// ChatWebhookEvents are the events a chat webhook receives when it subscribes to none.
+var ChatWebhookEvents = []domain.WebhookEvent{
+	domain.WebhookEventGoalShared,
+	domain.WebhookEventKRCompleted,
+	domain.WebhookEventTeamPeriodValidated,
+	domain.WebhookEventTeamBehindPlan,
+}
+
+// WebhookInput is a webhook subscription. An empty Secret generates one on create and keeps the current one on update.
+// An empty Format is json.
+type WebhookInput struct {
+	URL    string
+	Secret string
+	Format domain.WebhookFormat
+	Events []domain.WebhookEvent
+	TeamID *int64
+	Active bool
+}
+
+// WebhookPayload is the JSON body of every delivery. Data depends on the event.
+type WebhookPayload struct {
+	Event      domain.WebhookEvent `json:"event"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	TeamID     int64               `json:"team_id"`
+	Data       any                 `json:"data"`
+}
+
+// goalWebhookData is the payload data of goal.created.
+type goalWebhookData struct {
+	GoalID   int64  `json:"goal_id"`
+	TeamID   int64  `json:"team_id"`
+	PeriodID int64  `json:"period_id"`
+	Title    string `json:"title"`
+}
+
+// goalSharedWebhookData is the payload data of goal.shared: the goal and every team it is shared with now.
+type goalSharedWebhookData struct {
+	goalWebhookData
+	Shares []shareWebhookData `json:"shares"`
+}
+
+type shareWebhookData struct {
+	TeamID int64 `json:"team_id"`
+	Weight int   `json:"weight"`
+}
+
+// krProgressWebhookData is the payload data of kr.progress_changed and kr.completed.
+type krProgressWebhookData struct {
+	KeyResultID      int64   `json:"kr_id"`
+	GoalID           int64   `json:"goal_id"`
+	Title            string  `json:"title"`
+	Value            float64 `json:"value"`
+	Progress         int     `json:"progress"`
+	PreviousProgress *int    `json:"previous_progress"`
+	Source           string  `json:"source"`
+}
+
+// teamPeriodWebhookData is the payload data of team period status events.
+type teamPeriodWebhookData struct {
+	TeamID   int64                   `json:"team_id"`
+	PeriodID int64                   `json:"period_id"`
+	From     domain.TeamPeriodStatus `json:"from"`
+	To       domain.TeamPeriodStatus `json:"to"`
+}
+
+// teamHealthWebhookData is the payload data of team.behind_plan.
+type teamHealthWebhookData struct {
+	TeamID   int64      `json:"team_id"`
+	PeriodID int64      `json:"period_id"`
+	Progress int        `json:"progress"`
+	Planned  int        `json:"planned"`
+	Health   okr.Health `json:"health"`
+}
+
+// webhookAuditState is the audited state of a webhook; the secret is never recorded.
+type webhookAuditState struct {
+	URL    string
+	Format domain.WebhookFormat
+	Events []domain.WebhookEvent
+	TeamID *int64
+	Active bool
+}
+
+// ValidWebhookEvent reports whether webhooks can subscribe to the event.
+func ValidWebhookEvent(event domain.WebhookEvent) bool {
+	switch event {
+	case domain.WebhookEventGoalCreated, domain.WebhookEventKRProgressChanged, domain.WebhookEventGoalShared,
+		domain.WebhookEventTeamPeriodValidated, domain.WebhookEventTeamPeriodClosed,
+		domain.WebhookEventKRCompleted, domain.WebhookEventTeamBehindPlan:
+		return true
+	default:
+		return false
+	}
+}
+
+// ListWebhooks returns the webhooks the user in ctx administers: global ones for global admins
+// and team-scoped ones for admins of the team.
+func (s *Service) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	webhooks, err := s.store.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	perms, ok, err := s.CurrentPermissions(ctx)
+	if err != nil || !ok {
+		return webhooks, err
+	}
+	visible := make([]domain.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		if webhook.TeamID == nil && perms.CanGlobal(ActionAdmin) || webhook.TeamID != nil && perms.Can(ActionAdmin, *webhook.TeamID) {
+			visible = append(visible, webhook)
+		}
+	}
+	return visible, nil
+}
+
+// GetWebhook returns a webhook the user in ctx administers.
+func (s *Service) GetWebhook(ctx context.Context, id int64) (domain.Webhook, error) {
+	webhook, err := s.store.GetWebhook(ctx, id)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	if err := s.authorizeWebhookScope(ctx, webhook.TeamID); err != nil {
+		return domain.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+// CreateWebhook registers a webhook. Global webhooks require a global admin, team-scoped ones an admin of the team.
+func (s *Service) CreateWebhook(ctx context.Context, input WebhookInput) (domain.Webhook, error) {
+	if err := validateWebhook(&input); err != nil {
+		return domain.Webhook{}, err
+	}
+	if err := s.authorizeWebhookScope(ctx, input.TeamID); err != nil {
+		return domain.Webhook{}, err
+	}
+	if input.Secret == "" {
+		secret, err := auth.NewToken()
+		if err != nil {
+			return domain.Webhook{}, err
+		}
+		input.Secret = secret
+	}
+	storeInput := store.WebhookInput{URL: input.URL, Secret: input.Secret, Format: input.Format, Events: input.Events, TeamID: input.TeamID, Active: input.Active}
+	if user, ok := auth.UserFromContext(ctx); ok {
+		storeInput.CreatedBy = &user.ID
+	}
+	var webhook domain.Webhook
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		id, err := s.store.CreateWebhook(ctx, storeInput)
+		if err != nil {
+			return err
+		}
+		if webhook, err = s.store.GetWebhook(ctx, id); err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityWebhook, id, domain.AuditActionCreate, nil, webhookAudit(webhook))
+	})
+	return webhook, err
+}
+
+// UpdateWebhook replaces the subscription of a webhook; moving it to another scope requires admin rights on both.
+func (s *Service) UpdateWebhook(ctx context.Context, id int64, input WebhookInput) (domain.Webhook, error) {
+	if err := validateWebhook(&input); err != nil {
+		return domain.Webhook{}, err
+	}
+	before, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	if err := s.authorizeWebhookScope(ctx, input.TeamID); err != nil {
+		return domain.Webhook{}, err
+	}
+	if input.Secret == "" {
+		input.Secret = before.Secret
+	}
+	var webhook domain.Webhook
+	err = s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.UpdateWebhook(ctx, id, store.WebhookInput{
+			URL:    input.URL,
+			Secret: input.Secret,
+			Format: input.Format,
+			Events: input.Events,
+			TeamID: input.TeamID,
+			Active: input.Active,
+		}); err != nil {
+			return err
+		}
+		var err error
+		if webhook, err = s.store.GetWebhook(ctx, id); err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityWebhook, id, domain.AuditActionUpdate, webhookAudit(before), webhookAudit(webhook))
+	})
+	return webhook, err
+}
+
+// DeleteWebhook removes a webhook together with its pending deliveries and delivery log.
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) error {
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.DeleteWebhook(ctx, id); err != nil {
+			return err
+		}
+		return s.Audit(ctx, domain.AuditEntityWebhook, id, domain.AuditActionDelete, webhookAudit(webhook), nil)
+	})
+}
+
+// ListWebhookDeliveries returns the latest deliveries of a webhook, newest first.
+func (s *Service) ListWebhookDeliveries(ctx context.Context, id int64) ([]domain.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.store.ListWebhookDeliveries(ctx, id, WebhookDeliveryLogLimit)
+}
+
+func (s *Service) authorizeWebhookScope(ctx context.Context, teamID *int64) error {
+	if teamID == nil {
+		return s.AuthorizeGlobal(ctx, ActionAdmin)
+	}
+	if _, err := s.store.GetTeam(ctx, *teamID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: unknown team %d", ErrInvalidWebhook, *teamID)
+		}
+		return err
+	}
+	return s.Authorize(ctx, *teamID, ActionAdmin)
+}
+
+// NotifyGoalShared sends the current shares of a goal to goal.shared webhooks of the owner and the shared teams.
+func (s *Service) NotifyGoalShared(ctx context.Context, goalID int64) error {
+	goal, err := s.store.GetGoal(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	shares, err := s.store.ListGoalShares(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	data := goalSharedWebhookData{
+		goalWebhookData: goalWebhookData{GoalID: goal.ID, TeamID: goal.TeamID, PeriodID: goal.PeriodID, Title: goal.Title},
+		Shares:          []shareWebhookData{},
+	}
+	teamIDs := []int64{goal.TeamID}
+	for _, share := range shares {
+		data.Shares = append(data.Shares, shareWebhookData{TeamID: share.TeamID, Weight: share.Weight})
+		teamIDs = append(teamIDs, share.TeamID)
+	}
+	return s.emitWebhookEvent(ctx, domain.WebhookEventGoalShared, data, teamIDs...)
+}
+
+// emitWebhookEvent queues the event for the webhooks scoped to any of the teams or their ancestors.
+// The first team is the one reported in the payload.
+func (s *Service) emitWebhookEvent(ctx context.Context, event domain.WebhookEvent, data any, teamIDs ...int64) error {
+	teams, err := s.store.ListTeams(ctx)
+	if err != nil {
+		return err
+	}
+	teamsByID, _, _ := buildTeamHierarchy(teams)
+	scope := make([]int64, 0, len(teamIDs))
+	seen := make(map[int64]bool)
+	for _, teamID := range teamIDs {
+		for current := &teamID; current != nil && !seen[*current]; {
+			seen[*current] = true
+			scope = append(scope, *current)
+			current = teamsByID[*current].ParentID
+		}
+	}
+	payload, err := json.Marshal(WebhookPayload{Event: event, OccurredAt: s.now(), TeamID: teamIDs[0], Data: data})
+	if err != nil {
+		return err
+	}
+	text, err := s.chatText(ctx, data, teamsByID[teamIDs[0]].Name, teamsByID)
+	if err != nil {
+		return err
+	}
+	chatPayload, err := json.Marshal(ChatMessage{Text: text})
+	if err != nil {
+		return err
+	}
+	return s.store.EnqueueWebhookDeliveries(ctx, event, scope, payload, chatPayload)
+}
+
+// validateWebhook trims the input and checks the URL, the format and the event filter. Like data source endpoints,
+// the URL may not name a loopback or private address; the dispatcher client checks resolved host names.
+// Chat webhooks belong to a team and default to ChatWebhookEvents.
+func validateWebhook(input *WebhookInput) error {
+	input.URL = strings.TrimSpace(input.URL)
+	input.Secret = strings.TrimSpace(input.Secret)
+	parsed, err := url.Parse(input.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an http(s) URL", ErrInvalidWebhook)
+	}
+	if !publicHost(parsed.Hostname()) {
+		return fmt.Errorf("%w: url must not point to a loopback or private address", ErrInvalidWebhook)
+	}
+	switch input.Format {
+	case "":
+		input.Format = domain.WebhookFormatJSON
+	case domain.WebhookFormatJSON:
+	case domain.WebhookFormatChat:
+		if input.TeamID == nil {
+			return fmt.Errorf("%w: chat webhooks require a team", ErrInvalidWebhook)
+		}
+		if len(input.Events) == 0 {
+			input.Events = ChatWebhookEvents
+		}
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidWebhook, input.Format)
+	}
+	seen := make(map[domain.WebhookEvent]bool, len(input.Events))
+	events := make([]domain.WebhookEvent, 0, len(input.Events))
+	for _, event := range input.Events {
+		if !ValidWebhookEvent(event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	input.Events = events
+	return nil
+}
+
+func webhookAudit(webhook domain.Webhook) webhookAuditState {
+	return webhookAuditState{URL: webhook.URL, Format: webhook.Format, Events: webhook.Events, TeamID: webhook.TeamID, Active: webhook.Active}
+}
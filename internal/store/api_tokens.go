@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"okrs/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type APITokenInput struct {
+	UserID    int64
+	Name      string
+	TokenHash string
+	ReadOnly  bool
+	ExpiresAt *time.Time
+}
+
+const apiTokenColumns = `t.id, t.user_id, t.name, t.read_only, t.expires_at, t.last_used_at, t.created_at`
+
+func (s *Store) CreateAPIToken(ctx context.Context, input APITokenInput) (domain.APIToken, error) {
+	row := s.conn(ctx).QueryRow(ctx, `
+		INSERT INTO api_tokens AS t (user_id, name, token_hash, read_only, expires_at)
+		VALUES ($1,$2,$3,$4,$5)
+		RETURNING `+apiTokenColumns,
+		input.UserID, input.Name, input.TokenHash, input.ReadOnly, input.ExpiresAt,
+	)
+	return scanAPIToken(row)
+}
+
+func (s *Store) ListAPITokens(ctx context.Context, userID int64) ([]domain.APIToken, error) {
+	rows, err := s.conn(ctx).Query(ctx, `SELECT `+apiTokenColumns+` FROM api_tokens t WHERE t.user_id=$1 ORDER BY t.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []domain.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// DeleteAPIToken removes a token of the user. It returns pgx.ErrNoRows when the user has no such token.
+func (s *Store) DeleteAPIToken(ctx context.Context, userID, id int64) error {
+	res, err := s.conn(ctx).Exec(ctx, `DELETE FROM api_tokens WHERE id=$1 AND user_id=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetAPITokenUser returns a token that has not expired together with its owner.
+func (s *Store) GetAPITokenUser(ctx context.Context, tokenHash string) (domain.APIToken, domain.User, error) {
+	var token domain.APIToken
+	var user domain.User
+	var expiresAt, lastUsedAt sql.NullTime
+	row := s.conn(ctx).QueryRow(ctx, `
+		SELECT `+apiTokenColumns+`, u.id, u.email, u.name, u.password_hash, u.digest_opt_out, u.created_at, u.updated_at
+		FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash=$1 AND (t.expires_at IS NULL OR t.expires_at > NOW())`, tokenHash)
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.ReadOnly, &expiresAt, &lastUsedAt, &token.CreatedAt,
+		&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.DigestOptOut, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return domain.APIToken{}, domain.User{}, err
+	}
+	token.ExpiresAt = nullTimePtr(expiresAt)
+	token.LastUsedAt = nullTimePtr(lastUsedAt)
+	return token, user, nil
+}
+
+func (s *Store) TouchAPIToken(ctx context.Context, id int64) error {
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE api_tokens SET last_used_at=NOW() WHERE id=$1`, id)
+	return err
+}
+
+func scanAPIToken(row pgx.Row) (domain.APIToken, error) {
+	var token domain.APIToken
+	var expiresAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.ReadOnly, &expiresAt, &lastUsedAt, &token.CreatedAt); err != nil {
+		return domain.APIToken{}, err
+	}
+	token.ExpiresAt = nullTimePtr(expiresAt)
+	token.LastUsedAt = nullTimePtr(lastUsedAt)
+	return token, nil
+}
+
+func nullTimePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
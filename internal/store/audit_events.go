@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"okrs/internal/domain"
+)
+
+type AuditEventInput struct {
+	ActorID    *int64
+	EntityType domain.AuditEntity
+	EntityID   int64
+	Action     domain.AuditAction
+	Before     []byte
+	After      []byte
+}
+
+func (s *Store) AddAuditEvent(ctx context.Context, input AuditEventInput) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		INSERT INTO audit_events (actor_id, entity_type, entity_id, action, before, after)
+		VALUES ($1,$2,$3,$4,$5,$6)`,
+		input.ActorID, input.EntityType, input.EntityID, input.Action, input.Before, input.After,
+	)
+	return err
+}
+
+// ListAuditEvents returns the events of an entity, newest first.
+func (s *Store) ListAuditEvents(ctx context.Context, entityType domain.AuditEntity, entityID int64) ([]domain.AuditEvent, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT e.id, e.actor_id, COALESCE(u.name, ''), e.entity_type, e.entity_id, e.action, e.before, e.after, e.created_at
+		FROM audit_events e
+		LEFT JOIN users u ON u.id = e.actor_id
+		WHERE e.entity_type=$1 AND e.entity_id=$2
+		ORDER BY e.created_at DESC, e.id DESC`, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.AuditEvent
+	for rows.Next() {
+		var event domain.AuditEvent
+		var actorID sql.NullInt64
+		var before, after []byte
+		if err := rows.Scan(&event.ID, &actorID, &event.ActorName, &event.EntityType, &event.EntityID, &event.Action, &before, &after, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			value := actorID.Int64
+			event.ActorID = &value
+		}
+		event.Before = before
+		event.After = after
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -20,7 +20,7 @@ type GoalShareInput struct {
 }
 
 func (s *Store) ListGoalShares(ctx context.Context, goalID int64) ([]GoalShare, error) {
-	rows, err := s.DB.Query(ctx, `SELECT goal_id, team_id, weight, sort_order FROM goal_shares WHERE goal_id=$1`, goalID)
+	rows, err := s.conn(ctx).Query(ctx, `SELECT goal_id, team_id, weight, sort_order FROM goal_shares WHERE goal_id=$1`, goalID)
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +38,7 @@ func (s *Store) ListGoalShares(ctx context.Context, goalID int64) ([]GoalShare,
 
 func (s *Store) GetGoalShare(ctx context.Context, goalID, teamID int64) (GoalShare, error) {
 	var share GoalShare
-	row := s.DB.QueryRow(ctx, `SELECT goal_id, team_id, weight, sort_order FROM goal_shares WHERE goal_id=$1 AND team_id=$2`, goalID, teamID)
+	row := s.conn(ctx).QueryRow(ctx, `SELECT goal_id, team_id, weight, sort_order FROM goal_shares WHERE goal_id=$1 AND team_id=$2`, goalID, teamID)
 	if err := row.Scan(&share.GoalID, &share.TeamID, &share.Weight, &share.SortOrder); err != nil {
 		return GoalShare{}, err
 	}
@@ -46,7 +46,7 @@ func (s *Store) GetGoalShare(ctx context.Context, goalID, teamID int64) (GoalSha
 }
 
 func (s *Store) ReplaceGoalShares(ctx context.Context, goalID int64, shares []GoalShareInput) error {
-	tx, err := s.DB.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -79,12 +79,12 @@ func (s *Store) ReplaceGoalShares(ctx context.Context, goalID int64, shares []Go
 }
 
 func (s *Store) DeleteGoalShare(ctx context.Context, goalID, teamID int64) error {
-	_, err := s.DB.Exec(ctx, `DELETE FROM goal_shares WHERE goal_id=$1 AND team_id=$2`, goalID, teamID)
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM goal_shares WHERE goal_id=$1 AND team_id=$2`, goalID, teamID)
 	return err
 }
 
 func (s *Store) UpdateGoalTeamWeight(ctx context.Context, goalID, teamID int64, weight int) error {
-	res, err := s.DB.Exec(ctx, `UPDATE goals SET weight=$1, updated_at=NOW() WHERE id=$2 AND team_id=$3`, weight, goalID, teamID)
+	res, err := s.conn(ctx).Exec(ctx, `UPDATE goals SET weight=$1, updated_at=NOW() WHERE id=$2 AND team_id=$3`, weight, goalID, teamID)
 	if err != nil {
 		return err
 	}
@@ -92,7 +92,7 @@ func (s *Store) UpdateGoalTeamWeight(ctx context.Context, goalID, teamID int64,
 		return nil
 	}
 
-	_, err = s.DB.Exec(ctx, `UPDATE goal_shares SET weight=$1, updated_at=NOW() WHERE goal_id=$2 AND team_id=$3`, weight, goalID, teamID)
+	_, err = s.conn(ctx).Exec(ctx, `UPDATE goal_shares SET weight=$1, updated_at=NOW() WHERE goal_id=$2 AND team_id=$3`, weight, goalID, teamID)
 	return err
 }
 
@@ -10,8 +10,12 @@ import (
 )
 
 func (s *Store) CreateGoal(ctx context.Context, input GoalInput) (int64, error) {
+	return createGoal(ctx, s.DB, input)
+}
+
+func createGoal(ctx context.Context, db querier, input GoalInput) (int64, error) {
 	var id int64
-	err := s.DB.QueryRow(ctx, `
+	err := db.QueryRow(ctx, `
 		INSERT INTO goals (team_id, period_id, title, description, priority, weight, work_type, focus_type, owner_text, sort_order)
 		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9, (SELECT COALESCE(MAX(sort_order), 0) + 1 FROM goals WHERE team_id=$1 AND period_id=$2))
 		RETURNING id`,
@@ -21,10 +25,10 @@ func (s *Store) CreateGoal(ctx context.Context, input GoalInput) (int64, error)
 }
 
 func (s *Store) ListGoalsByTeamPeriod(ctx context.Context, teamID, periodID int64) ([]domain.Goal, error) {
-	rows, err := s.DB.Query(ctx, `
+	rows, err := s.conn(ctx).Query(ctx, `
 		SELECT g.id, g.team_id, g.period_id, g.title, g.description, g.priority,
 		       COALESCE(gs.weight, g.weight) AS weight,
-		       g.work_type, g.focus_type, g.owner_text, g.created_at, g.updated_at,
+		       g.work_type, g.focus_type, g.owner_text, g.owner_user_id, g.confidence, g.parent_goal_id, g.progress_from_children, g.origin_goal_id, g.created_at, g.updated_at,
 		       COALESCE(gs.sort_order, g.sort_order) AS team_sort_order
 		FROM goals g
 		LEFT JOIN goal_shares gs ON gs.goal_id = g.id AND gs.team_id = $1
@@ -39,7 +43,7 @@ func (s *Store) ListGoalsByTeamPeriod(ctx context.Context, teamID, periodID int6
 	for rows.Next() {
 		var goal domain.Goal
 		var sortOrder int
-		if err := rows.Scan(&goal.ID, &goal.TeamID, &goal.PeriodID, &goal.Title, &goal.Description, &goal.Priority, &goal.Weight, &goal.WorkType, &goal.FocusType, &goal.OwnerText, &goal.CreatedAt, &goal.UpdatedAt, &sortOrder); err != nil {
+		if err := rows.Scan(&goal.ID, &goal.TeamID, &goal.PeriodID, &goal.Title, &goal.Description, &goal.Priority, &goal.Weight, &goal.WorkType, &goal.FocusType, &goal.OwnerText, &goal.OwnerUserID, &goal.Confidence, &goal.ParentGoalID, &goal.ProgressFromChildren, &goal.OriginGoalID, &goal.CreatedAt, &goal.UpdatedAt, &sortOrder); err != nil {
 			return nil, err
 		}
 		goals = append(goals, goal)
@@ -59,7 +63,7 @@ func (s *Store) ListGoalsByTeamPeriod(ctx context.Context, teamID, periodID int6
 }
 
 func (s *Store) MoveGoal(ctx context.Context, goalID int64, direction int) error {
-	tx, err := s.DB.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -107,10 +111,10 @@ func (s *Store) MoveGoal(ctx context.Context, goalID int64, direction int) error
 
 func (s *Store) GetGoal(ctx context.Context, id int64) (domain.Goal, error) {
 	var goal domain.Goal
-	row := s.DB.QueryRow(ctx, `
-		SELECT id, team_id, period_id, title, description, priority, weight, work_type, focus_type, owner_text, created_at, updated_at
+	row := s.conn(ctx).QueryRow(ctx, `
+		SELECT id, team_id, period_id, title, description, priority, weight, work_type, focus_type, owner_text, owner_user_id, confidence, parent_goal_id, progress_from_children, origin_goal_id, created_at, updated_at
 		FROM goals WHERE id=$1`, id)
-	if err := row.Scan(&goal.ID, &goal.TeamID, &goal.PeriodID, &goal.Title, &goal.Description, &goal.Priority, &goal.Weight, &goal.WorkType, &goal.FocusType, &goal.OwnerText, &goal.CreatedAt, &goal.UpdatedAt); err != nil {
+	if err := row.Scan(&goal.ID, &goal.TeamID, &goal.PeriodID, &goal.Title, &goal.Description, &goal.Priority, &goal.Weight, &goal.WorkType, &goal.FocusType, &goal.OwnerText, &goal.OwnerUserID, &goal.Confidence, &goal.ParentGoalID, &goal.ProgressFromChildren, &goal.OriginGoalID, &goal.CreatedAt, &goal.UpdatedAt); err != nil {
 		return domain.Goal{}, err
 	}
 	krs, err := s.ListKeyResultsByGoal(ctx, goal.ID)
@@ -123,7 +127,7 @@ func (s *Store) GetGoal(ctx context.Context, id int64) (domain.Goal, error) {
 }
 
 func (s *Store) DeleteGoal(ctx context.Context, id int64) error {
-	_, err := s.DB.Exec(ctx, `DELETE FROM goals WHERE id=$1`, id)
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM goals WHERE id=$1`, id)
 	return err
 }
 
@@ -156,8 +160,8 @@ type GoalFieldsUpdateInput struct {
 }
 
 func (s *Store) ListGoalsByPeriod(ctx context.Context, periodID int64) ([]GoalWithTeam, error) {
-	rows, err := s.DB.Query(ctx, `
-		SELECT g.id, g.team_id, g.period_id, g.title, g.description, g.priority, g.weight, g.work_type, g.focus_type, g.owner_text, g.created_at, g.updated_at,
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT g.id, g.team_id, g.period_id, g.title, g.description, g.priority, g.weight, g.work_type, g.focus_type, g.owner_text, g.owner_user_id, g.confidence, g.parent_goal_id, g.progress_from_children, g.origin_goal_id, g.created_at, g.updated_at,
 		       t.name, t.team_type, p.name
 		FROM goals g
 		JOIN teams t ON t.id = g.team_id
@@ -174,7 +178,7 @@ func (s *Store) ListGoalsByPeriod(ctx context.Context, periodID int64) ([]GoalWi
 		var teamName string
 		var teamType domain.TeamType
 		var periodName string
-		if err := rows.Scan(&goal.ID, &goal.TeamID, &goal.PeriodID, &goal.Title, &goal.Description, &goal.Priority, &goal.Weight, &goal.WorkType, &goal.FocusType, &goal.OwnerText, &goal.CreatedAt, &goal.UpdatedAt, &teamName, &teamType, &periodName); err != nil {
+		if err := rows.Scan(&goal.ID, &goal.TeamID, &goal.PeriodID, &goal.Title, &goal.Description, &goal.Priority, &goal.Weight, &goal.WorkType, &goal.FocusType, &goal.OwnerText, &goal.OwnerUserID, &goal.Confidence, &goal.ParentGoalID, &goal.ProgressFromChildren, &goal.OriginGoalID, &goal.CreatedAt, &goal.UpdatedAt, &teamName, &teamType, &periodName); err != nil {
 			return nil, err
 		}
 		results = append(results, GoalWithTeam{Goal: goal, TeamName: teamName, TeamType: teamType, PeriodName: periodName})
@@ -182,8 +186,26 @@ func (s *Store) ListGoalsByPeriod(ctx context.Context, periodID int64) ([]GoalWi
 	return results, rows.Err()
 }
 
+// ListGoalOrigins returns the IDs of the goals that goals of the period were copied from.
+func (s *Store) ListGoalOrigins(ctx context.Context, periodID int64) ([]int64, error) {
+	rows, err := s.conn(ctx).Query(ctx, `SELECT origin_goal_id FROM goals WHERE period_id=$1 AND origin_goal_id IS NOT NULL`, periodID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func (s *Store) UpdateGoal(ctx context.Context, input GoalUpdateInput) error {
-	_, err := s.DB.Exec(ctx, `
+	_, err := s.conn(ctx).Exec(ctx, `
 		UPDATE goals
 		SET title=$1, description=$2, priority=$3, weight=$4, work_type=$5, focus_type=$6, owner_text=$7, updated_at=NOW()
 		WHERE id=$8`,
@@ -193,7 +215,7 @@ func (s *Store) UpdateGoal(ctx context.Context, input GoalUpdateInput) error {
 }
 
 func (s *Store) UpdateGoalFields(ctx context.Context, input GoalFieldsUpdateInput) error {
-	_, err := s.DB.Exec(ctx, `
+	_, err := s.conn(ctx).Exec(ctx, `
 		UPDATE goals
 		SET title=$1, description=$2, priority=$3, work_type=$4, focus_type=$5, owner_text=$6, updated_at=NOW()
 		WHERE id=$7`,
@@ -203,7 +225,7 @@ func (s *Store) UpdateGoalFields(ctx context.Context, input GoalFieldsUpdateInpu
 }
 
 func (s *Store) UpdateGoalOwner(ctx context.Context, goalID, teamID int64, weight int) error {
-	_, err := s.DB.Exec(ctx, `
+	_, err := s.conn(ctx).Exec(ctx, `
 		UPDATE goals
 		SET team_id=$1, weight=$2, updated_at=NOW()
 		WHERE id=$3`,
@@ -212,13 +234,78 @@ func (s *Store) UpdateGoalOwner(ctx context.Context, goalID, teamID int64, weigh
 	return err
 }
 
+// SetGoalOwnerUser links the goal to the user who owns it; nil clears the link.
+func (s *Store) SetGoalOwnerUser(ctx context.Context, goalID int64, userID *int64) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		UPDATE goals
+		SET owner_user_id=$1, updated_at=NOW()
+		WHERE id=$2`,
+		userID, goalID,
+	)
+	return err
+}
+
+func (s *Store) UpdateGoalConfidence(ctx context.Context, goalID int64, confidence *int) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		UPDATE goals
+		SET confidence=$1, updated_at=NOW()
+		WHERE id=$2`,
+		confidence, goalID,
+	)
+	return err
+}
+
+func (s *Store) UpdateGoalAlignment(ctx context.Context, goalID int64, parentGoalID *int64, progressFromChildren bool) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		UPDATE goals
+		SET parent_goal_id=$1, progress_from_children=$2, updated_at=NOW()
+		WHERE id=$3`,
+		parentGoalID, progressFromChildren, goalID,
+	)
+	return err
+}
+
+// ListChildGoals returns the goals aligned to the parent goal with their key results, ordered by team and goal order.
+func (s *Store) ListChildGoals(ctx context.Context, parentGoalID int64) ([]domain.Goal, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT id, team_id, period_id, title, description, priority, weight, work_type, focus_type, owner_text, owner_user_id, confidence, parent_goal_id, progress_from_children, origin_goal_id, created_at, updated_at
+		FROM goals
+		WHERE parent_goal_id=$1
+		ORDER BY team_id, sort_order, id`, parentGoalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := make([]domain.Goal, 0)
+	for rows.Next() {
+		var goal domain.Goal
+		if err := rows.Scan(&goal.ID, &goal.TeamID, &goal.PeriodID, &goal.Title, &goal.Description, &goal.Priority, &goal.Weight, &goal.WorkType, &goal.FocusType, &goal.OwnerText, &goal.OwnerUserID, &goal.Confidence, &goal.ParentGoalID, &goal.ProgressFromChildren, &goal.OriginGoalID, &goal.CreatedAt, &goal.UpdatedAt); err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range goals {
+		krs, err := s.ListKeyResultsByGoal(ctx, goals[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		goals[i].KeyResults = krs
+	}
+	return goals, nil
+}
+
 func (s *Store) AddGoalComment(ctx context.Context, goalID int64, text string) error {
-	_, err := s.DB.Exec(ctx, `INSERT INTO goal_comments (goal_id, text) VALUES ($1,$2)`, goalID, text)
+	_, err := s.conn(ctx).Exec(ctx, `INSERT INTO goal_comments (goal_id, text) VALUES ($1,$2)`, goalID, text)
 	return err
 }
 
 func (s *Store) ListGoalComments(ctx context.Context, goalID int64) ([]domain.GoalComment, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, goal_id, text, created_at FROM goal_comments WHERE goal_id=$1 ORDER BY created_at DESC`, goalID)
+	rows, err := s.conn(ctx).Query(ctx, `SELECT id, goal_id, text, created_at FROM goal_comments WHERE goal_id=$1 ORDER BY created_at DESC`, goalID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+
+	"okrs/internal/domain"
+)
+
+// ImportInput is a batch of teams, goals and key results that ImportOKRs creates in one period.
+type ImportInput struct {
+	PeriodID int64
+	Teams    []ImportTeamInput
+}
+
+// ImportTeamInput refers to an existing team by ID or, with a zero ID, creates Team. ParentIndex points to
+// a team created earlier in the same import and replaces Team.ParentID.
+type ImportTeamInput struct {
+	ID          int64
+	Team        TeamInput
+	ParentIndex *int
+	Goals       []ImportGoalInput
+}
+
+// ImportGoalInput is a goal of the team; TeamID and PeriodID of Goal are filled in by ImportOKRs.
+// A goal copied from another period sets OriginGoalID and carries the owner user and shares of its origin.
+type ImportGoalInput struct {
+	ID           int64
+	Goal         GoalInput
+	OriginGoalID *int64
+	OwnerUserID  *int64
+	Shares       []GoalShareInput
+	KeyResults   []ImportKeyResultInput
+}
+
+// ImportKeyResultInput is a key result with the meta of its kind; meta of other kinds is ignored.
+type ImportKeyResultInput struct {
+	ID        int64
+	KeyResult KeyResultInput
+	Percent   PercentMetaInput
+	Linear    LinearMetaInput
+	Boolean   bool
+	Range     RangeMetaInput
+	Stages    []ProjectStageInput
+}
+
+// ImportOKRs creates the teams, goals, shares and key results of the input in a single transaction and fills in
+// their IDs. Team periods of owner and share teams without goals move to forming. Nothing is written when any
+// insert fails.
+func (s *Store) ImportOKRs(ctx context.Context, input *ImportInput) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for i := range input.Teams {
+		team := &input.Teams[i]
+		if team.ID == 0 {
+			if team.ParentIndex != nil {
+				parentID := input.Teams[*team.ParentIndex].ID
+				team.Team.ParentID = &parentID
+			}
+			if team.ID, err = createTeam(ctx, tx, team.Team); err != nil {
+				return err
+			}
+		}
+		if len(team.Goals) == 0 {
+			continue
+		}
+		if err := startTeamPeriodForming(ctx, tx, team.ID, input.PeriodID); err != nil {
+			return err
+		}
+		for j := range team.Goals {
+			goal := &team.Goals[j]
+			goal.Goal.TeamID = team.ID
+			goal.Goal.PeriodID = input.PeriodID
+			if goal.ID, err = createGoal(ctx, tx, goal.Goal); err != nil {
+				return err
+			}
+			if goal.OriginGoalID != nil || goal.OwnerUserID != nil {
+				if _, err := tx.Exec(ctx, `UPDATE goals SET origin_goal_id=$1, owner_user_id=$2 WHERE id=$3`, goal.OriginGoalID, goal.OwnerUserID, goal.ID); err != nil {
+					return err
+				}
+			}
+			for _, share := range goal.Shares {
+				if err := upsertGoalShare(ctx, tx, goal.ID, share); err != nil {
+					return err
+				}
+				if err := startTeamPeriodForming(ctx, tx, share.TeamID, input.PeriodID); err != nil {
+					return err
+				}
+			}
+			for k := range goal.KeyResults {
+				if err := importKeyResult(ctx, tx, goal.ID, &goal.KeyResults[k]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// startTeamPeriodForming moves the team period to forming unless it already has goals.
+func startTeamPeriodForming(ctx context.Context, db querier, teamID, periodID int64) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO team_period_statuses (team_id, period_id, status)
+		VALUES ($1,$2,'forming')
+		ON CONFLICT (team_id, period_id)
+		DO UPDATE SET status=EXCLUDED.status WHERE team_period_statuses.status='no_goals'`,
+		teamID, periodID,
+	)
+	return err
+}
+
+func importKeyResult(ctx context.Context, db querier, goalID int64, kr *ImportKeyResultInput) error {
+	var err error
+	kr.KeyResult.GoalID = goalID
+	if kr.ID, err = createKeyResult(ctx, db, kr.KeyResult); err != nil {
+		return err
+	}
+	switch kr.KeyResult.Kind {
+	case domain.KRKindPercent:
+		kr.Percent.KeyResultID = kr.ID
+		return upsertPercentMeta(ctx, db, kr.Percent)
+	case domain.KRKindLinear:
+		kr.Linear.KeyResultID = kr.ID
+		return upsertLinearMeta(ctx, db, kr.Linear)
+	case domain.KRKindBoolean:
+		return upsertBooleanMeta(ctx, db, kr.ID, kr.Boolean)
+	case domain.KRKindRange:
+		kr.Range.KeyResultID = kr.ID
+		return upsertRangeMeta(ctx, db, kr.Range)
+	case domain.KRKindProject:
+		for i := range kr.Stages {
+			kr.Stages[i].KeyResultID = kr.ID
+			if err := addProjectStage(ctx, db, kr.Stages[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// ClaimIngestKey records an idempotency key of a key result. When the key is already taken it returns
+// false and the payload hash stored with it.
+func (s *Store) ClaimIngestKey(ctx context.Context, krID int64, key, payloadHash string) (bool, string, error) {
+	tag, err := s.conn(ctx).Exec(ctx, `
+		INSERT INTO ingest_requests (key_result_id, idempotency_key, payload_hash)
+		VALUES ($1,$2,$3)
+		ON CONFLICT DO NOTHING`, krID, key, payloadHash)
+	if err != nil {
+		return false, "", err
+	}
+	if tag.RowsAffected() == 1 {
+		return true, payloadHash, nil
+	}
+	var existing string
+	err = s.conn(ctx).QueryRow(ctx, `
+		SELECT payload_hash FROM ingest_requests
+		WHERE key_result_id=$1 AND idempotency_key=$2`, krID, key).Scan(&existing)
+	return false, existing, err
+}
+
+// DeleteIngestKeysBefore forgets idempotency keys claimed before the given time.
+func (s *Store) DeleteIngestKeysBefore(ctx context.Context, before time.Time) error {
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM ingest_requests WHERE created_at < $1`, before)
+	return err
+}
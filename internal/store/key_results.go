@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"time"
 
 	"okrs/internal/domain"
 
@@ -10,8 +11,12 @@ import (
 )
 
 func (s *Store) CreateKeyResult(ctx context.Context, input KeyResultInput) (int64, error) {
+	return createKeyResult(ctx, s.conn(ctx), input)
+}
+
+func createKeyResult(ctx context.Context, db querier, input KeyResultInput) (int64, error) {
 	var id int64
-	err := s.DB.QueryRow(ctx, `
+	err := db.QueryRow(ctx, `
 		INSERT INTO key_results (goal_id, title, description, weight, kind, sort_order)
 		VALUES ($1,$2,$3,$4,$5, (SELECT COALESCE(MAX(sort_order), 0) + 1 FROM key_results WHERE goal_id=$1))
 		RETURNING id`,
@@ -29,8 +34,8 @@ type KeyResultUpdateInput struct {
 }
 
 func (s *Store) ListKeyResultsByGoal(ctx context.Context, goalID int64) ([]domain.KeyResult, error) {
-	rows, err := s.DB.Query(ctx, `
-		SELECT id, goal_id, title, description, weight, kind, sort_order, created_at, updated_at
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT id, goal_id, title, description, weight, kind, confidence, sort_order, created_at, updated_at
 		FROM key_results WHERE goal_id=$1 ORDER BY sort_order, id`, goalID)
 	if err != nil {
 		return nil, err
@@ -39,7 +44,7 @@ func (s *Store) ListKeyResultsByGoal(ctx context.Context, goalID int64) ([]domai
 	var krs []domain.KeyResult
 	for rows.Next() {
 		var kr domain.KeyResult
-		if err := rows.Scan(&kr.ID, &kr.GoalID, &kr.Title, &kr.Description, &kr.Weight, &kr.Kind, &kr.SortOrder, &kr.CreatedAt, &kr.UpdatedAt); err != nil {
+		if err := rows.Scan(&kr.ID, &kr.GoalID, &kr.Title, &kr.Description, &kr.Weight, &kr.Kind, &kr.Confidence, &kr.SortOrder, &kr.CreatedAt, &kr.UpdatedAt); err != nil {
 			return nil, err
 		}
 		krs = append(krs, kr)
@@ -82,6 +87,14 @@ func (s *Store) ListKeyResultsByGoal(ctx context.Context, goalID int64) ([]domai
 			if meta != nil {
 				kr.Boolean = meta
 			}
+		case domain.KRKindRange:
+			meta, err := s.GetRangeMeta(ctx, kr.ID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return nil, err
+			}
+			if meta != nil {
+				kr.Range = meta
+			}
 		}
 
 		comments, _ := s.LastKeyResultComments(ctx, kr.ID)
@@ -92,13 +105,13 @@ func (s *Store) ListKeyResultsByGoal(ctx context.Context, goalID int64) ([]domai
 }
 
 func (s *Store) AddKeyResultComment(ctx context.Context, krID int64, text string) error {
-	_, err := s.DB.Exec(ctx, `INSERT INTO key_result_comments (key_result_id, text) VALUES ($1,$2)`, krID, text)
+	_, err := s.conn(ctx).Exec(ctx, `INSERT INTO key_result_comments (key_result_id, text) VALUES ($1,$2)`, krID, text)
 	return err
 }
 
 func (s *Store) LastKeyResultComments(ctx context.Context, krID int64) ([]domain.KeyResultComment, error) {
 	const Limit = 3
-	rows, err := s.DB.Query(ctx, `SELECT id, key_result_id, text, created_at FROM key_result_comments WHERE key_result_id=$1 ORDER BY created_at DESC LIMIT $2`, krID, Limit)
+	rows, err := s.conn(ctx).Query(ctx, `SELECT id, key_result_id, text, created_at FROM key_result_comments WHERE key_result_id=$1 ORDER BY created_at DESC LIMIT $2`, krID, Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -115,23 +128,30 @@ func (s *Store) LastKeyResultComments(ctx context.Context, krID int64) ([]domain
 }
 
 func (s *Store) AddProjectStage(ctx context.Context, input ProjectStageInput) error {
-	_, err := s.DB.Exec(ctx, `
-		INSERT INTO kr_project_stages (key_result_id, title, weight, is_done, sort_order)
-		VALUES ($1,$2,$3,$4,$5)`,
-		input.KeyResultID, input.Title, input.Weight, input.IsDone, input.SortOrder,
-	)
-	if err != nil {
+	if err := addProjectStage(ctx, s.conn(ctx), input); err != nil {
 		return err
 	}
 	return s.touchKeyResultUpdatedAt(ctx, input.KeyResultID)
 }
 
+func addProjectStage(ctx context.Context, db querier, input ProjectStageInput) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO kr_project_stages (key_result_id, title, weight, is_done, sort_order, due_date, done_at)
+		VALUES ($1,$2,$3,$4,$5,$6, CASE WHEN $4 THEN NOW() END)`,
+		input.KeyResultID, input.Title, input.Weight, input.IsDone, input.SortOrder, input.DueDate,
+	)
+	return err
+}
+
 func (s *Store) UpdateProjectStageDone(ctx context.Context, stageID int64, done bool) error {
-	_, err := s.DB.Exec(ctx, `UPDATE kr_project_stages SET is_done=$1 WHERE id=$2`, done, stageID)
+	_, err := s.conn(ctx).Exec(ctx, `
+		UPDATE kr_project_stages
+		SET is_done=$1, done_at=CASE WHEN $1 THEN COALESCE(done_at, NOW()) END
+		WHERE id=$2`, done, stageID)
 	if err != nil {
 		return err
 	}
-	_, err = s.DB.Exec(ctx, `
+	_, err = s.conn(ctx).Exec(ctx, `
 		UPDATE key_results
 		SET updated_at=NOW()
 		WHERE id=(SELECT key_result_id FROM kr_project_stages WHERE id=$1)`, stageID)
@@ -139,8 +159,8 @@ func (s *Store) UpdateProjectStageDone(ctx context.Context, stageID int64, done
 }
 
 func (s *Store) ListProjectStages(ctx context.Context, krID int64) ([]domain.KRProjectStage, error) {
-	rows, err := s.DB.Query(ctx, `
-		SELECT id, key_result_id, title, weight, is_done, sort_order
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT id, key_result_id, title, weight, is_done, sort_order, due_date, done_at
 		FROM kr_project_stages WHERE key_result_id=$1 ORDER BY sort_order`, krID)
 	if err != nil {
 		return nil, err
@@ -149,7 +169,7 @@ func (s *Store) ListProjectStages(ctx context.Context, krID int64) ([]domain.KRP
 	var stages []domain.KRProjectStage
 	for rows.Next() {
 		var stage domain.KRProjectStage
-		if err := rows.Scan(&stage.ID, &stage.KeyResultID, &stage.Title, &stage.Weight, &stage.IsDone, &stage.SortOrder); err != nil {
+		if err := rows.Scan(&stage.ID, &stage.KeyResultID, &stage.Title, &stage.Weight, &stage.IsDone, &stage.SortOrder, &stage.DueDate, &stage.DoneAt); err != nil {
 			return nil, err
 		}
 		stages = append(stages, stage)
@@ -157,16 +177,27 @@ func (s *Store) ListProjectStages(ctx context.Context, krID int64) ([]domain.KRP
 	return stages, rows.Err()
 }
 
+// ReplaceProjectStages rewrites the stages of a key result. A stage that stays done keeps its done_at,
+// matched by title.
 func (s *Store) ReplaceProjectStages(ctx context.Context, krID int64, stages []ProjectStageInput) error {
-	_, err := s.DB.Exec(ctx, `DELETE FROM kr_project_stages WHERE key_result_id=$1`, krID)
+	existing, err := s.ListProjectStages(ctx, krID)
 	if err != nil {
 		return err
 	}
+	doneAt := make(map[string]*time.Time, len(existing))
+	for _, stage := range existing {
+		if stage.IsDone {
+			doneAt[stage.Title] = stage.DoneAt
+		}
+	}
+	if _, err := s.conn(ctx).Exec(ctx, `DELETE FROM kr_project_stages WHERE key_result_id=$1`, krID); err != nil {
+		return err
+	}
 	for _, stage := range stages {
-		if _, err := s.DB.Exec(ctx, `
-			INSERT INTO kr_project_stages (key_result_id, title, weight, is_done, sort_order)
-			VALUES ($1,$2,$3,$4,$5)`,
-			krID, stage.Title, stage.Weight, stage.IsDone, stage.SortOrder,
+		if _, err := s.conn(ctx).Exec(ctx, `
+			INSERT INTO kr_project_stages (key_result_id, title, weight, is_done, sort_order, due_date, done_at)
+			VALUES ($1,$2,$3,$4,$5,$6, CASE WHEN $4 THEN COALESCE($7, NOW()) END)`,
+			krID, stage.Title, stage.Weight, stage.IsDone, stage.SortOrder, stage.DueDate, doneAt[stage.Title],
 		); err != nil {
 			return err
 		}
@@ -175,7 +206,7 @@ func (s *Store) ReplaceProjectStages(ctx context.Context, krID int64, stages []P
 }
 
 func (s *Store) UpdateKeyResult(ctx context.Context, input KeyResultUpdateInput) error {
-	_, err := s.DB.Exec(ctx, `
+	_, err := s.conn(ctx).Exec(ctx, `
 		UPDATE key_results
 		SET title=$1, description=$2, weight=$3, kind=$4, updated_at=NOW()
 		WHERE id=$5`,
@@ -185,7 +216,7 @@ func (s *Store) UpdateKeyResult(ctx context.Context, input KeyResultUpdateInput)
 }
 
 func (s *Store) UpdateKeyResultWeight(ctx context.Context, krID int64, weight int) error {
-	_, err := s.DB.Exec(ctx, `
+	_, err := s.conn(ctx).Exec(ctx, `
 		UPDATE key_results
 		SET weight=$1, updated_at=NOW()
 		WHERE id=$2`,
@@ -194,8 +225,25 @@ func (s *Store) UpdateKeyResultWeight(ctx context.Context, krID int64, weight in
 	return err
 }
 
+func (s *Store) UpdateKeyResultConfidence(ctx context.Context, krID int64, confidence *int) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		UPDATE key_results
+		SET confidence=$1, updated_at=NOW()
+		WHERE id=$2`,
+		confidence, krID,
+	)
+	return err
+}
+
 func (s *Store) UpsertPercentMeta(ctx context.Context, input PercentMetaInput) error {
-	_, err := s.DB.Exec(ctx, `
+	if err := upsertPercentMeta(ctx, s.conn(ctx), input); err != nil {
+		return err
+	}
+	return s.touchKeyResultUpdatedAt(ctx, input.KeyResultID)
+}
+
+func upsertPercentMeta(ctx context.Context, db querier, input PercentMetaInput) error {
+	_, err := db.Exec(ctx, `
 		INSERT INTO kr_percent_meta (key_result_id, start_value, target_value, current_value)
 		VALUES ($1,$2,$3,$4)
 		ON CONFLICT (key_result_id) DO UPDATE SET
@@ -204,14 +252,11 @@ func (s *Store) UpsertPercentMeta(ctx context.Context, input PercentMetaInput) e
 			current_value=EXCLUDED.current_value`,
 		input.KeyResultID, input.StartValue, input.TargetValue, input.CurrentValue,
 	)
-	if err != nil {
-		return err
-	}
-	return s.touchKeyResultUpdatedAt(ctx, input.KeyResultID)
+	return err
 }
 
 func (s *Store) UpdatePercentCurrent(ctx context.Context, krID int64, current float64) error {
-	_, err := s.DB.Exec(ctx, `UPDATE kr_percent_meta SET current_value=$1 WHERE key_result_id=$2`, current, krID)
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE kr_percent_meta SET current_value=$1 WHERE key_result_id=$2`, current, krID)
 	if err != nil {
 		return err
 	}
@@ -219,7 +264,14 @@ func (s *Store) UpdatePercentCurrent(ctx context.Context, krID int64, current fl
 }
 
 func (s *Store) UpsertLinearMeta(ctx context.Context, input LinearMetaInput) error {
-	_, err := s.DB.Exec(ctx, `
+	if err := upsertLinearMeta(ctx, s.conn(ctx), input); err != nil {
+		return err
+	}
+	return s.touchKeyResultUpdatedAt(ctx, input.KeyResultID)
+}
+
+func upsertLinearMeta(ctx context.Context, db querier, input LinearMetaInput) error {
+	_, err := db.Exec(ctx, `
 		INSERT INTO kr_linear_meta (key_result_id, start_value, target_value, current_value)
 		VALUES ($1,$2,$3,$4)
 		ON CONFLICT (key_result_id) DO UPDATE SET
@@ -228,14 +280,40 @@ func (s *Store) UpsertLinearMeta(ctx context.Context, input LinearMetaInput) err
 			current_value=EXCLUDED.current_value`,
 		input.KeyResultID, input.StartValue, input.TargetValue, input.CurrentValue,
 	)
+	return err
+}
+
+func (s *Store) UpdateLinearCurrent(ctx context.Context, krID int64, current float64) error {
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE kr_linear_meta SET current_value=$1 WHERE key_result_id=$2`, current, krID)
 	if err != nil {
 		return err
 	}
+	return s.touchKeyResultUpdatedAt(ctx, krID)
+}
+
+func (s *Store) UpsertRangeMeta(ctx context.Context, input RangeMetaInput) error {
+	if err := upsertRangeMeta(ctx, s.conn(ctx), input); err != nil {
+		return err
+	}
 	return s.touchKeyResultUpdatedAt(ctx, input.KeyResultID)
 }
 
-func (s *Store) UpdateLinearCurrent(ctx context.Context, krID int64, current float64) error {
-	_, err := s.DB.Exec(ctx, `UPDATE kr_linear_meta SET current_value=$1 WHERE key_result_id=$2`, current, krID)
+func upsertRangeMeta(ctx context.Context, db querier, input RangeMetaInput) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO kr_range_meta (key_result_id, min_value, max_value, tolerance, current_value)
+		VALUES ($1,$2,$3,$4,$5)
+		ON CONFLICT (key_result_id) DO UPDATE SET
+			min_value=EXCLUDED.min_value,
+			max_value=EXCLUDED.max_value,
+			tolerance=EXCLUDED.tolerance,
+			current_value=EXCLUDED.current_value`,
+		input.KeyResultID, input.MinValue, input.MaxValue, input.Tolerance, input.CurrentValue,
+	)
+	return err
+}
+
+func (s *Store) UpdateRangeCurrent(ctx context.Context, krID int64, current float64) error {
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE kr_range_meta SET current_value=$1 WHERE key_result_id=$2`, current, krID)
 	if err != nil {
 		return err
 	}
@@ -248,20 +326,43 @@ func (s *Store) UpdateBoolean(ctx context.Context, krID int64, done bool) error
 
 func (s *Store) GetKeyResult(ctx context.Context, id int64) (domain.KeyResult, error) {
 	var kr domain.KeyResult
-	row := s.DB.QueryRow(ctx, `
-		SELECT id, goal_id, title, description, weight, kind, sort_order, created_at, updated_at
+	row := s.conn(ctx).QueryRow(ctx, `
+		SELECT id, goal_id, title, description, weight, kind, confidence, sort_order, created_at, updated_at
 		FROM key_results WHERE id=$1`, id)
-	if err := row.Scan(&kr.ID, &kr.GoalID, &kr.Title, &kr.Description, &kr.Weight, &kr.Kind, &kr.SortOrder, &kr.CreatedAt, &kr.UpdatedAt); err != nil {
+	if err := row.Scan(&kr.ID, &kr.GoalID, &kr.Title, &kr.Description, &kr.Weight, &kr.Kind, &kr.Confidence, &kr.SortOrder, &kr.CreatedAt, &kr.UpdatedAt); err != nil {
 		return domain.KeyResult{}, err
 	}
 	return kr, nil
 }
 
-func (s *Store) AddPercentCheckpoint(ctx context.Context, input PercentCheckpointInput) error {
-	_, err := s.DB.Exec(ctx, `
+func (s *Store) AddPercentCheckpoint(ctx context.Context, input PercentCheckpointInput) (int64, error) {
+	var id int64
+	err := s.conn(ctx).QueryRow(ctx, `
 		INSERT INTO kr_percent_checkpoints (key_result_id, metric_value, kr_percent)
-		VALUES ($1,$2,$3)`,
+		VALUES ($1,$2,$3)
+		RETURNING id`,
 		input.KeyResultID, input.MetricValue, input.KRPercent,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, s.touchKeyResultUpdatedAt(ctx, input.KeyResultID)
+}
+
+func (s *Store) GetPercentCheckpoint(ctx context.Context, id int64) (domain.KRPercentCheckpoint, error) {
+	var cp domain.KRPercentCheckpoint
+	row := s.conn(ctx).QueryRow(ctx, `SELECT id, key_result_id, metric_value, kr_percent FROM kr_percent_checkpoints WHERE id=$1`, id)
+	if err := row.Scan(&cp.ID, &cp.KeyResultID, &cp.MetricValue, &cp.KRPercent); err != nil {
+		return domain.KRPercentCheckpoint{}, err
+	}
+	return cp, nil
+}
+
+func (s *Store) UpdatePercentCheckpoint(ctx context.Context, id int64, input PercentCheckpointInput) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		UPDATE kr_percent_checkpoints SET metric_value=$1, kr_percent=$2
+		WHERE id=$3 AND key_result_id=$4`,
+		input.MetricValue, input.KRPercent, id, input.KeyResultID,
 	)
 	if err != nil {
 		return err
@@ -269,9 +370,17 @@ func (s *Store) AddPercentCheckpoint(ctx context.Context, input PercentCheckpoin
 	return s.touchKeyResultUpdatedAt(ctx, input.KeyResultID)
 }
 
+func (s *Store) DeletePercentCheckpoint(ctx context.Context, krID, id int64) error {
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM kr_percent_checkpoints WHERE id=$1 AND key_result_id=$2`, id, krID)
+	if err != nil {
+		return err
+	}
+	return s.touchKeyResultUpdatedAt(ctx, krID)
+}
+
 func (s *Store) GetPercentMeta(ctx context.Context, krID int64) (*domain.KRPercent, []domain.KRPercentCheckpoint, error) {
 	var meta domain.KRPercent
-	row := s.DB.QueryRow(ctx, `SELECT start_value, target_value, current_value FROM kr_percent_meta WHERE key_result_id=$1`, krID)
+	row := s.conn(ctx).QueryRow(ctx, `SELECT start_value, target_value, current_value FROM kr_percent_meta WHERE key_result_id=$1`, krID)
 	if err := row.Scan(&meta.StartValue, &meta.TargetValue, &meta.CurrentValue); err != nil {
 		return nil, nil, err
 	}
@@ -284,15 +393,24 @@ func (s *Store) GetPercentMeta(ctx context.Context, krID int64) (*domain.KRPerce
 
 func (s *Store) GetLinearMeta(ctx context.Context, krID int64) (*domain.KRLinear, error) {
 	var meta domain.KRLinear
-	row := s.DB.QueryRow(ctx, `SELECT start_value, target_value, current_value FROM kr_linear_meta WHERE key_result_id=$1`, krID)
+	row := s.conn(ctx).QueryRow(ctx, `SELECT start_value, target_value, current_value FROM kr_linear_meta WHERE key_result_id=$1`, krID)
 	if err := row.Scan(&meta.StartValue, &meta.TargetValue, &meta.CurrentValue); err != nil {
 		return nil, err
 	}
 	return &meta, nil
 }
 
+func (s *Store) GetRangeMeta(ctx context.Context, krID int64) (*domain.KRRange, error) {
+	var meta domain.KRRange
+	row := s.conn(ctx).QueryRow(ctx, `SELECT min_value, max_value, tolerance, current_value FROM kr_range_meta WHERE key_result_id=$1`, krID)
+	if err := row.Scan(&meta.MinValue, &meta.MaxValue, &meta.Tolerance, &meta.CurrentValue); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
 func (s *Store) ListPercentCheckpoints(ctx context.Context, krID int64) ([]domain.KRPercentCheckpoint, error) {
-	rows, err := s.DB.Query(ctx, `
+	rows, err := s.conn(ctx).Query(ctx, `
 		SELECT id, key_result_id, metric_value, kr_percent
 		FROM kr_percent_checkpoints WHERE key_result_id=$1 ORDER BY metric_value`, krID)
 	if err != nil {
@@ -311,21 +429,25 @@ func (s *Store) ListPercentCheckpoints(ctx context.Context, krID int64) ([]domai
 }
 
 func (s *Store) UpsertBooleanMeta(ctx context.Context, krID int64, done bool) error {
-	_, err := s.DB.Exec(ctx, `
+	if err := upsertBooleanMeta(ctx, s.conn(ctx), krID, done); err != nil {
+		return err
+	}
+	return s.touchKeyResultUpdatedAt(ctx, krID)
+}
+
+func upsertBooleanMeta(ctx context.Context, db querier, krID int64, done bool) error {
+	_, err := db.Exec(ctx, `
 		INSERT INTO kr_boolean_meta (key_result_id, is_done)
 		VALUES ($1,$2)
 		ON CONFLICT (key_result_id) DO UPDATE SET is_done=EXCLUDED.is_done`,
 		krID, done,
 	)
-	if err != nil {
-		return err
-	}
-	return s.touchKeyResultUpdatedAt(ctx, krID)
+	return err
 }
 
 func (s *Store) GetBooleanMeta(ctx context.Context, krID int64) (*domain.KRBoolean, error) {
 	var meta domain.KRBoolean
-	row := s.DB.QueryRow(ctx, `SELECT is_done FROM kr_boolean_meta WHERE key_result_id=$1`, krID)
+	row := s.conn(ctx).QueryRow(ctx, `SELECT is_done FROM kr_boolean_meta WHERE key_result_id=$1`, krID)
 	if err := row.Scan(&meta.IsDone); err != nil {
 		return nil, err
 	}
@@ -333,12 +455,12 @@ func (s *Store) GetBooleanMeta(ctx context.Context, krID int64) (*domain.KRBoole
 }
 
 func (s *Store) DeleteKeyResult(ctx context.Context, id int64) error {
-	_, err := s.DB.Exec(ctx, `DELETE FROM key_results WHERE id=$1`, id)
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM key_results WHERE id=$1`, id)
 	return err
 }
 
 func (s *Store) MoveKeyResult(ctx context.Context, krID int64, direction int) error {
-	tx, err := s.DB.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -384,6 +506,6 @@ func (s *Store) MoveKeyResult(ctx context.Context, krID int64, direction int) er
 }
 
 func (s *Store) touchKeyResultUpdatedAt(ctx context.Context, krID int64) error {
-	_, err := s.DB.Exec(ctx, `UPDATE key_results SET updated_at=NOW() WHERE id=$1`, krID)
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE key_results SET updated_at=NOW() WHERE id=$1`, krID)
 	return err
 }
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"okrs/internal/domain"
+)
+
+type KRCheckInInput struct {
+	KeyResultID int64
+	AuthorID    *int64
+	Value       float64
+	Progress    int
+	Confidence  int
+	Blockers    string
+	NextSteps   string
+}
+
+// TeamLastCheckIn is the time of the latest check-in on key results of goals owned by a team.
+type TeamLastCheckIn struct {
+	TeamID        int64
+	LastCheckInAt *time.Time
+}
+
+func (s *Store) AddKRCheckIn(ctx context.Context, input KRCheckInInput) (domain.KRCheckIn, error) {
+	checkIn := domain.KRCheckIn{
+		KeyResultID: input.KeyResultID,
+		AuthorID:    input.AuthorID,
+		Value:       input.Value,
+		Progress:    input.Progress,
+		Confidence:  input.Confidence,
+		Blockers:    input.Blockers,
+		NextSteps:   input.NextSteps,
+	}
+	err := s.conn(ctx).QueryRow(ctx, `
+		INSERT INTO kr_check_ins (key_result_id, author_id, value, progress, confidence, blockers, next_steps)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id, created_at`,
+		input.KeyResultID, input.AuthorID, input.Value, input.Progress, input.Confidence, input.Blockers, input.NextSteps,
+	).Scan(&checkIn.ID, &checkIn.CreatedAt)
+	return checkIn, err
+}
+
+// ListKRCheckIns returns the check-ins of a key result, newest first.
+func (s *Store) ListKRCheckIns(ctx context.Context, krID int64) ([]domain.KRCheckIn, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT c.id, c.key_result_id, c.author_id, COALESCE(u.name, ''), c.value, c.progress, c.confidence, c.blockers, c.next_steps, c.created_at
+		FROM kr_check_ins c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.key_result_id=$1
+		ORDER BY c.created_at DESC, c.id DESC`, krID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkIns []domain.KRCheckIn
+	for rows.Next() {
+		var checkIn domain.KRCheckIn
+		var authorID sql.NullInt64
+		if err := rows.Scan(&checkIn.ID, &checkIn.KeyResultID, &authorID, &checkIn.AuthorName, &checkIn.Value, &checkIn.Progress,
+			&checkIn.Confidence, &checkIn.Blockers, &checkIn.NextSteps, &checkIn.CreatedAt); err != nil {
+			return nil, err
+		}
+		if authorID.Valid {
+			value := authorID.Int64
+			checkIn.AuthorID = &value
+		}
+		checkIns = append(checkIns, checkIn)
+	}
+	return checkIns, rows.Err()
+}
+
+// ListTeamLastCheckIns returns, for every team owning goals with key results in the period,
+// the time of the latest check-in on those key results.
+func (s *Store) ListTeamLastCheckIns(ctx context.Context, periodID int64) ([]TeamLastCheckIn, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT g.team_id, MAX(c.created_at)
+		FROM goals g
+		JOIN key_results kr ON kr.goal_id = g.id
+		LEFT JOIN kr_check_ins c ON c.key_result_id = kr.id
+		WHERE g.period_id=$1
+		GROUP BY g.team_id
+		ORDER BY g.team_id`, periodID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []TeamLastCheckIn
+	for rows.Next() {
+		var item TeamLastCheckIn
+		var last sql.NullTime
+		if err := rows.Scan(&item.TeamID, &last); err != nil {
+			return nil, err
+		}
+		if last.Valid {
+			value := last.Time
+			item.LastCheckInAt = &value
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
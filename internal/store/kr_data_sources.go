@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"okrs/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type KRDataSourceInput struct {
+	KeyResultID     int64
+	Kind            domain.DataSourceKind
+	Endpoint        string
+	Query           string
+	IntervalMinutes int
+}
+
+// DataSourceRunInput is the outcome of one fetch; Value is nil when the fetch or the update failed.
+type DataSourceRunInput struct {
+	KeyResultID int64
+	RunAt       time.Time
+	Value       *float64
+	Error       string
+}
+
+const krDataSourceColumns = `key_result_id, kind, endpoint, query, interval_minutes, last_run_at, last_value, last_error, updated_at`
+
+// UpsertKRDataSource binds the key result to a data source; changing the binding resets the last run so it is fetched on the next tick.
+func (s *Store) UpsertKRDataSource(ctx context.Context, input KRDataSourceInput) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		INSERT INTO kr_data_sources (key_result_id, kind, endpoint, query, interval_minutes)
+		VALUES ($1,$2,$3,$4,$5)
+		ON CONFLICT (key_result_id) DO UPDATE
+		SET kind=EXCLUDED.kind, endpoint=EXCLUDED.endpoint, query=EXCLUDED.query, interval_minutes=EXCLUDED.interval_minutes,
+			last_run_at=NULL, last_value=NULL, last_error='', updated_at=NOW()`,
+		input.KeyResultID, input.Kind, input.Endpoint, input.Query, input.IntervalMinutes,
+	)
+	return err
+}
+
+func (s *Store) GetKRDataSource(ctx context.Context, krID int64) (domain.KRDataSource, error) {
+	row := s.conn(ctx).QueryRow(ctx, `SELECT `+krDataSourceColumns+` FROM kr_data_sources WHERE key_result_id=$1`, krID)
+	return scanKRDataSource(row)
+}
+
+func (s *Store) DeleteKRDataSource(ctx context.Context, krID int64) error {
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM kr_data_sources WHERE key_result_id=$1`, krID)
+	return err
+}
+
+// ListDueKRDataSources returns the data sources never run or last run at least interval_minutes before now.
+func (s *Store) ListDueKRDataSources(ctx context.Context, now time.Time) ([]domain.KRDataSource, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT `+krDataSourceColumns+`
+		FROM kr_data_sources
+		WHERE last_run_at IS NULL OR last_run_at + make_interval(mins => interval_minutes) <= $1
+		ORDER BY last_run_at NULLS FIRST, key_result_id`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sources []domain.KRDataSource
+	for rows.Next() {
+		source, err := scanKRDataSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, rows.Err()
+}
+
+// RecordKRDataSourceRun stores the outcome of a fetch. A failed run keeps the last successful value.
+func (s *Store) RecordKRDataSourceRun(ctx context.Context, input DataSourceRunInput) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		UPDATE kr_data_sources
+		SET last_run_at=$2, last_value=COALESCE($3, last_value), last_error=$4
+		WHERE key_result_id=$1`,
+		input.KeyResultID, input.RunAt, input.Value, input.Error,
+	)
+	return err
+}
+
+func scanKRDataSource(row pgx.Row) (domain.KRDataSource, error) {
+	var source domain.KRDataSource
+	err := row.Scan(&source.KeyResultID, &source.Kind, &source.Endpoint, &source.Query, &source.IntervalMinutes,
+		&source.LastRunAt, &source.LastValue, &source.LastError, &source.UpdatedAt)
+	return source, err
+}
@@ -0,0 +1,48 @@
+package store
+
+import "context"
+
+// krDependencyLockKey is the advisory lock key that serializes changes of the KR dependency graph.
+const krDependencyLockKey = 0x6b72646570
+
+// LockKRDependencies blocks other transactions that lock the dependency graph until the current transaction ends,
+// so a cycle check and the insert it allows see the same graph. It must run inside Store.InTx.
+func (s *Store) LockKRDependencies(ctx context.Context) error {
+	_, err := s.conn(ctx).Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(krDependencyLockKey))
+	return err
+}
+
+// AddKRDependency records that the key result is blocked by depends_on_id. Adding an existing dependency is a no-op.
+func (s *Store) AddKRDependency(ctx context.Context, krID, dependsOnID int64) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		INSERT INTO kr_dependencies (key_result_id, depends_on_id)
+		VALUES ($1,$2)
+		ON CONFLICT DO NOTHING`, krID, dependsOnID)
+	return err
+}
+
+func (s *Store) DeleteKRDependency(ctx context.Context, krID, dependsOnID int64) error {
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM kr_dependencies WHERE key_result_id=$1 AND depends_on_id=$2`, krID, dependsOnID)
+	return err
+}
+
+// ListKRDependencyIDs returns the ids of the key results the key result depends on, oldest dependency first.
+func (s *Store) ListKRDependencyIDs(ctx context.Context, krID int64) ([]int64, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT depends_on_id FROM kr_dependencies
+		WHERE key_result_id=$1
+		ORDER BY created_at, depends_on_id`, krID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
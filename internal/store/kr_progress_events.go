@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+
+	"okrs/internal/domain"
+)
+
+type KRProgressEventInput struct {
+	KeyResultID int64
+	Value       float64
+	Progress    int
+	Source      string
+}
+
+func (s *Store) AddKRProgressEvent(ctx context.Context, input KRProgressEventInput) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		INSERT INTO kr_progress_events (key_result_id, value, progress, source)
+		VALUES ($1,$2,$3,$4)`,
+		input.KeyResultID, input.Value, input.Progress, input.Source,
+	)
+	return err
+}
+
+// LastKRProgressEvent returns the latest history entry of a key result or pgx.ErrNoRows without history.
+func (s *Store) LastKRProgressEvent(ctx context.Context, krID int64) (domain.KRProgressEvent, error) {
+	var event domain.KRProgressEvent
+	err := s.conn(ctx).QueryRow(ctx, `
+		SELECT id, key_result_id, value, progress, source, created_at
+		FROM kr_progress_events
+		WHERE key_result_id=$1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`, krID,
+	).Scan(&event.ID, &event.KeyResultID, &event.Value, &event.Progress, &event.Source, &event.CreatedAt)
+	return event, err
+}
+
+// ListKRProgressEvents returns the progress history of a key result, oldest first.
+func (s *Store) ListKRProgressEvents(ctx context.Context, krID int64) ([]domain.KRProgressEvent, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT id, key_result_id, value, progress, source, created_at
+		FROM kr_progress_events
+		WHERE key_result_id=$1
+		ORDER BY created_at, id`, krID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.KRProgressEvent
+	for rows.Next() {
+		var event domain.KRProgressEvent
+		if err := rows.Scan(&event.ID, &event.KeyResultID, &event.Value, &event.Progress, &event.Source, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// ListKeyResultIDsWithoutHistory returns the key results that have no progress history yet, for example those
+// created before kr_progress_events existed.
+func (s *Store) ListKeyResultIDsWithoutHistory(ctx context.Context) ([]int64, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT kr.id
+		FROM key_results kr
+		WHERE NOT EXISTS (SELECT 1 FROM kr_progress_events e WHERE e.key_result_id = kr.id)
+		ORDER BY kr.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
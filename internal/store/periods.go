@@ -8,7 +8,7 @@ import (
 )
 
 func (s *Store) ListPeriods(ctx context.Context) ([]domain.Period, error) {
-	rows, err := s.DB.Query(ctx, `
+	rows, err := s.conn(ctx).Query(ctx, `
 		SELECT id, name, start_date, end_date, sort_order, created_at, updated_at
 		FROM periods
 		ORDER BY sort_order, start_date, id`)
@@ -30,7 +30,7 @@ func (s *Store) ListPeriods(ctx context.Context) ([]domain.Period, error) {
 
 func (s *Store) GetPeriod(ctx context.Context, periodID int64) (domain.Period, error) {
 	var period domain.Period
-	row := s.DB.QueryRow(ctx, `
+	row := s.conn(ctx).QueryRow(ctx, `
 		SELECT id, name, start_date, end_date, sort_order, created_at, updated_at
 		FROM periods
 		WHERE id=$1`, periodID)
@@ -42,7 +42,7 @@ func (s *Store) GetPeriod(ctx context.Context, periodID int64) (domain.Period, e
 
 func (s *Store) FindPeriodForDate(ctx context.Context, date time.Time) (domain.Period, error) {
 	var period domain.Period
-	row := s.DB.QueryRow(ctx, `
+	row := s.conn(ctx).QueryRow(ctx, `
 		SELECT id, name, start_date, end_date, sort_order, created_at, updated_at
 		FROM periods
 		WHERE $1::date BETWEEN start_date AND end_date
@@ -56,7 +56,7 @@ func (s *Store) FindPeriodForDate(ctx context.Context, date time.Time) (domain.P
 
 func (s *Store) CreatePeriod(ctx context.Context, input PeriodInput) (int64, error) {
 	var id int64
-	row := s.DB.QueryRow(ctx, `
+	row := s.conn(ctx).QueryRow(ctx, `
 		INSERT INTO periods (name, start_date, end_date, sort_order)
 		VALUES ($1, $2, $3, COALESCE((SELECT MAX(sort_order) + 1 FROM periods), 1))
 		RETURNING id`, input.Name, input.StartDate, input.EndDate)
@@ -67,7 +67,7 @@ func (s *Store) CreatePeriod(ctx context.Context, input PeriodInput) (int64, err
 }
 
 func (s *Store) MovePeriod(ctx context.Context, periodID int64, direction int) error {
-	tx, err := s.DB.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -110,7 +110,7 @@ func (s *Store) MovePeriod(ctx context.Context, periodID int64, direction int) e
 }
 
 func (s *Store) UpdatePeriod(ctx context.Context, periodID int64, input PeriodInput) error {
-	_, err := s.DB.Exec(ctx, `
+	_, err := s.conn(ctx).Exec(ctx, `
 		UPDATE periods
 		SET name=$1, start_date=$2, end_date=$3, updated_at=NOW()
 		WHERE id=$4`, input.Name, input.StartDate, input.EndDate, periodID)
@@ -118,6 +118,6 @@ func (s *Store) UpdatePeriod(ctx context.Context, periodID int64, input PeriodIn
 }
 
 func (s *Store) DeletePeriod(ctx context.Context, periodID int64) error {
-	_, err := s.DB.Exec(ctx, `DELETE FROM periods WHERE id=$1`, periodID)
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM periods WHERE id=$1`, periodID)
 	return err
 }
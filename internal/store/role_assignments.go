@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"okrs/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type RoleAssignmentInput struct {
+	UserID int64
+	Role   domain.Role
+	Scope  domain.RoleScope
+	TeamID *int64
+}
+
+func (s *Store) ListRoleAssignments(ctx context.Context, userID int64) ([]domain.RoleAssignment, error) {
+	rows, err := s.conn(ctx).Query(ctx, `SELECT id, user_id, role, scope, team_id, created_at FROM role_assignments WHERE user_id=$1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []domain.RoleAssignment
+	for rows.Next() {
+		assignment, err := scanRoleAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments, rows.Err()
+}
+
+func (s *Store) GetRoleAssignment(ctx context.Context, id int64) (domain.RoleAssignment, error) {
+	row := s.conn(ctx).QueryRow(ctx, `SELECT id, user_id, role, scope, team_id, created_at FROM role_assignments WHERE id=$1`, id)
+	return scanRoleAssignment(row)
+}
+
+func (s *Store) AddRoleAssignment(ctx context.Context, input RoleAssignmentInput) (int64, error) {
+	var id int64
+	err := s.conn(ctx).QueryRow(ctx, `
+		INSERT INTO role_assignments (user_id, role, scope, team_id)
+		VALUES ($1,$2,$3,$4)
+		ON CONFLICT (user_id, role, scope, COALESCE(team_id, 0)) DO UPDATE SET role=EXCLUDED.role
+		RETURNING id`,
+		input.UserID, input.Role, input.Scope, input.TeamID,
+	).Scan(&id)
+	return id, err
+}
+
+func (s *Store) DeleteRoleAssignment(ctx context.Context, id int64) error {
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM role_assignments WHERE id=$1`, id)
+	return err
+}
+
+func scanRoleAssignment(row pgx.Row) (domain.RoleAssignment, error) {
+	var assignment domain.RoleAssignment
+	var teamID sql.NullInt64
+	if err := row.Scan(&assignment.ID, &assignment.UserID, &assignment.Role, &assignment.Scope, &teamID, &assignment.CreatedAt); err != nil {
+		return domain.RoleAssignment{}, err
+	}
+	if teamID.Valid {
+		value := teamID.Int64
+		assignment.TeamID = &value
+	}
+	return assignment, nil
+}
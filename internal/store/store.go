@@ -1,10 +1,13 @@
 package store
 
 import (
+	"context"
 	"time"
 
 	"okrs/internal/domain"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -12,10 +15,53 @@ type Store struct {
 	DB *pgxpool.Pool
 }
 
+// querier is implemented by both the pool and a transaction, so store calls can run inside InTx and ImportOKRs.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// txKey carries the transaction started by InTx in a context.
+type txKey struct{}
+
 func New(db *pgxpool.Pool) *Store {
 	return &Store{DB: db}
 }
 
+// InTx runs fn in a transaction: every store call made with the context passed to fn joins it, and nothing
+// is written when fn fails. Calls nested in another InTx reuse the outer transaction.
+func (s *Store) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// conn returns the transaction of ctx, or the pool outside InTx.
+func (s *Store) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return s.DB
+}
+
+// begin starts a transaction, or a savepoint inside the transaction of ctx.
+func (s *Store) begin(ctx context.Context) (pgx.Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Begin(ctx)
+	}
+	return s.DB.Begin(ctx)
+}
+
 type GoalInput struct {
 	TeamID      int64
 	PeriodID    int64
@@ -48,6 +94,7 @@ type ProjectStageInput struct {
 	Weight      int
 	SortOrder   int
 	IsDone      bool
+	DueDate     *time.Time
 }
 
 type PercentMetaInput struct {
@@ -69,3 +116,11 @@ type LinearMetaInput struct {
 	TargetValue  float64
 	CurrentValue float64
 }
+
+type RangeMetaInput struct {
+	KeyResultID  int64
+	MinValue     *float64
+	MaxValue     *float64
+	Tolerance    float64
+	CurrentValue float64
+}
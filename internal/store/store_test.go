@@ -3,9 +3,11 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -104,6 +106,197 @@ func TestStoreCRUD(t *testing.T) {
 	if len(goals[0].KeyResults) != 1 {
 		t.Fatalf("expected 1 kr got %d", len(goals[0].KeyResults))
 	}
+
+	user, err := s.EnsureUser(ctx, "qa@example.com", "QA")
+	if err != nil {
+		t.Fatalf("ensure user: %v", err)
+	}
+	if err := s.CreateSession(ctx, "token-hash", user.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	sessionUser, err := s.GetSessionUser(ctx, "token-hash")
+	if err != nil {
+		t.Fatalf("session user: %v", err)
+	}
+	if sessionUser.ID != user.ID {
+		t.Fatalf("expected session user %d got %d", user.ID, sessionUser.ID)
+	}
+	if _, err := s.AddRoleAssignment(ctx, RoleAssignmentInput{UserID: user.ID, Role: domain.RoleEditor, Scope: domain.RoleScopeSubtree, TeamID: &teamID}); err != nil {
+		t.Fatalf("add role: %v", err)
+	}
+	if _, err := s.AddRoleAssignment(ctx, RoleAssignmentInput{UserID: user.ID, Role: domain.RoleEditor, Scope: domain.RoleScopeSubtree, TeamID: &teamID}); err != nil {
+		t.Fatalf("add duplicate role: %v", err)
+	}
+	assignments, err := s.ListRoleAssignments(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("list roles: %v", err)
+	}
+	if len(assignments) != 1 || assignments[0].TeamID == nil || *assignments[0].TeamID != teamID {
+		t.Fatalf("expected one subtree role, got %+v", assignments)
+	}
+	apiToken, err := s.CreateAPIToken(ctx, APITokenInput{UserID: user.ID, Name: "ci", TokenHash: "api-hash", ReadOnly: true})
+	if err != nil {
+		t.Fatalf("create api token: %v", err)
+	}
+	if err := s.TouchAPIToken(ctx, apiToken.ID); err != nil {
+		t.Fatalf("touch api token: %v", err)
+	}
+	resolvedToken, tokenUser, err := s.GetAPITokenUser(ctx, "api-hash")
+	if err != nil {
+		t.Fatalf("api token user: %v", err)
+	}
+	if tokenUser.ID != user.ID || !resolvedToken.ReadOnly || resolvedToken.LastUsedAt == nil {
+		t.Fatalf("unexpected api token %+v for user %d", resolvedToken, tokenUser.ID)
+	}
+	if err := s.DeleteAPIToken(ctx, user.ID+1, apiToken.ID); err == nil {
+		t.Fatalf("expected foreign token delete to fail")
+	}
+	if err := s.DeleteAPIToken(ctx, user.ID, apiToken.ID); err != nil {
+		t.Fatalf("delete api token: %v", err)
+	}
+	if err := s.AddAuditEvent(ctx, AuditEventInput{ActorID: &user.ID, EntityType: domain.AuditEntityGoal, EntityID: goalID, Action: domain.AuditActionCreate, After: []byte(`{"Title":"Goal"}`)}); err != nil {
+		t.Fatalf("add audit event: %v", err)
+	}
+	events, err := s.ListAuditEvents(ctx, domain.AuditEntityGoal, goalID)
+	if err != nil {
+		t.Fatalf("list audit events: %v", err)
+	}
+	if len(events) != 1 || events[0].ActorName != "QA" || events[0].Before != nil || len(events[0].After) == 0 {
+		t.Fatalf("unexpected audit events %+v", events)
+	}
+	if pending, err := s.ListKeyResultIDsWithoutHistory(ctx); err != nil || !slices.Contains(pending, krID) {
+		t.Fatalf("expected the key result to have no history yet, got %v (%v)", pending, err)
+	}
+	for _, progress := range []int{0, 100} {
+		if err := s.AddKRProgressEvent(ctx, KRProgressEventInput{KeyResultID: krID, Value: float64(progress / 100), Progress: progress, Source: domain.ProgressSourceManual}); err != nil {
+			t.Fatalf("add progress event: %v", err)
+		}
+	}
+	history, err := s.ListKRProgressEvents(ctx, krID)
+	if err != nil {
+		t.Fatalf("list progress events: %v", err)
+	}
+	if len(history) != 2 || history[0].Progress != 0 || history[1].Progress != 100 {
+		t.Fatalf("expected progress history oldest first, got %+v", history)
+	}
+	if last, err := s.LastKRProgressEvent(ctx, krID); err != nil || last.ID != history[1].ID {
+		t.Fatalf("expected the latest progress event, got %+v (%v)", last, err)
+	}
+	if pending, err := s.ListKeyResultIDsWithoutHistory(ctx); err != nil || slices.Contains(pending, krID) {
+		t.Fatalf("expected the key result to have history, got %v (%v)", pending, err)
+	}
+	if err := s.UpsertKRDataSource(ctx, KRDataSourceInput{KeyResultID: krID, Kind: domain.DataSourceHTTPJSON, Endpoint: "http://metrics.local/kr", Query: "data.value", IntervalMinutes: 30}); err != nil {
+		t.Fatalf("upsert data source: %v", err)
+	}
+	runAt := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	if due, err := s.ListDueKRDataSources(ctx, runAt); err != nil || len(due) != 1 {
+		t.Fatalf("expected never run data source to be due, got %+v (%v)", due, err)
+	}
+	fetched := 42.0
+	if err := s.RecordKRDataSourceRun(ctx, DataSourceRunInput{KeyResultID: krID, RunAt: runAt, Value: &fetched}); err != nil {
+		t.Fatalf("record data source run: %v", err)
+	}
+	if due, err := s.ListDueKRDataSources(ctx, runAt.Add(10*time.Minute)); err != nil || len(due) != 0 {
+		t.Fatalf("expected data source not due before its interval, got %+v (%v)", due, err)
+	}
+	if err := s.RecordKRDataSourceRun(ctx, DataSourceRunInput{KeyResultID: krID, RunAt: runAt.Add(30 * time.Minute), Error: "timeout"}); err != nil {
+		t.Fatalf("record failed data source run: %v", err)
+	}
+	source, err := s.GetKRDataSource(ctx, krID)
+	if err != nil {
+		t.Fatalf("get data source: %v", err)
+	}
+	if source.LastValue == nil || *source.LastValue != 42 || source.LastError != "timeout" {
+		t.Fatalf("expected failed run to keep the last value, got %+v", source)
+	}
+	confidence := 6
+	if err := s.UpdateGoalConfidence(ctx, goalID, &confidence); err != nil {
+		t.Fatalf("update goal confidence: %v", err)
+	}
+	if err := s.UpdateKeyResultConfidence(ctx, krID, &confidence); err != nil {
+		t.Fatalf("update kr confidence: %v", err)
+	}
+	confidentGoal, err := s.GetGoal(ctx, goalID)
+	if err != nil {
+		t.Fatalf("get goal: %v", err)
+	}
+	if confidentGoal.Confidence == nil || *confidentGoal.Confidence != 6 || len(confidentGoal.KeyResults) == 0 || confidentGoal.KeyResults[0].Confidence == nil {
+		t.Fatalf("expected stored confidence, got %+v", confidentGoal)
+	}
+	projectKRID, err := s.CreateKeyResult(ctx, KeyResultInput{GoalID: goalID, Title: "Project", Weight: 0, Kind: domain.KRKindProject})
+	if err != nil {
+		t.Fatalf("create project kr: %v", err)
+	}
+	dueDate := time.Date(2024, 8, 15, 0, 0, 0, 0, time.UTC)
+	if err := s.ReplaceProjectStages(ctx, projectKRID, []ProjectStageInput{{Title: "Design", Weight: 100, SortOrder: 1, DueDate: &dueDate}}); err != nil {
+		t.Fatalf("replace stages: %v", err)
+	}
+	stages, err := s.ListProjectStages(ctx, projectKRID)
+	if err != nil || len(stages) != 1 {
+		t.Fatalf("list stages: %v %+v", err, stages)
+	}
+	if err := s.UpdateProjectStageDone(ctx, stages[0].ID, true); err != nil {
+		t.Fatalf("update stage: %v", err)
+	}
+	stages, err = s.ListProjectStages(ctx, projectKRID)
+	if err != nil {
+		t.Fatalf("list stages: %v", err)
+	}
+	if stages[0].DueDate == nil || !stages[0].DueDate.Equal(dueDate) || stages[0].DoneAt == nil {
+		t.Fatalf("expected due date and done_at, got %+v", stages[0])
+	}
+	childID, err := s.CreateGoal(ctx, GoalInput{TeamID: teamID, PeriodID: periodID, Title: "Child", Priority: domain.PriorityP2, Weight: 10, WorkType: domain.WorkTypeDelivery, FocusType: domain.FocusStability})
+	if err != nil {
+		t.Fatalf("create child goal: %v", err)
+	}
+	if err := s.UpdateGoalAlignment(ctx, childID, &goalID, true); err != nil {
+		t.Fatalf("update alignment: %v", err)
+	}
+	children, err := s.ListChildGoals(ctx, goalID)
+	if err != nil {
+		t.Fatalf("list child goals: %v", err)
+	}
+	if len(children) != 1 || children[0].ID != childID || children[0].ParentGoalID == nil || !children[0].ProgressFromChildren {
+		t.Fatalf("expected aligned child goal, got %+v", children)
+	}
+	if err := s.DeleteGoal(ctx, childID); err != nil {
+		t.Fatalf("delete child goal: %v", err)
+	}
+	checkIn, err := s.AddKRCheckIn(ctx, KRCheckInInput{KeyResultID: krID, AuthorID: &user.ID, Value: 1, Progress: 100, Confidence: 8, Blockers: "none"})
+	if err != nil {
+		t.Fatalf("add check-in: %v", err)
+	}
+	checkIns, err := s.ListKRCheckIns(ctx, krID)
+	if err != nil {
+		t.Fatalf("list check-ins: %v", err)
+	}
+	if len(checkIns) != 1 || checkIns[0].ID != checkIn.ID || checkIns[0].AuthorName != "QA" || checkIns[0].Confidence != 8 {
+		t.Fatalf("unexpected check-ins %+v", checkIns)
+	}
+	lastCheckIns, err := s.ListTeamLastCheckIns(ctx, periodID)
+	if err != nil {
+		t.Fatalf("list team check-ins: %v", err)
+	}
+	if len(lastCheckIns) != 1 || lastCheckIns[0].TeamID != teamID || lastCheckIns[0].LastCheckInAt == nil {
+		t.Fatalf("unexpected team check-ins %+v", lastCheckIns)
+	}
+	errAudit := errors.New("audit failed")
+	err = s.InTx(ctx, func(ctx context.Context) error {
+		if err := s.UpdateGoalTeamWeight(ctx, goalID, teamID, 99); err != nil {
+			return err
+		}
+		return errAudit
+	})
+	if !errors.Is(err, errAudit) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+	rolledBack, err := s.GetGoal(ctx, goalID)
+	if err != nil {
+		t.Fatalf("get goal after rollback: %v", err)
+	}
+	if rolledBack.Weight == 99 {
+		t.Fatalf("expected the weight change to be rolled back")
+	}
 }
 
 func runMigrations(databaseURL string) error {
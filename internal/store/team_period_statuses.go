@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 
 	"okrs/internal/domain"
@@ -11,7 +12,7 @@ import (
 
 func (s *Store) GetTeamPeriodStatus(ctx context.Context, teamID, periodID int64) (domain.TeamPeriodStatus, error) {
 	var status domain.TeamPeriodStatus
-	row := s.DB.QueryRow(ctx, `SELECT status FROM team_period_statuses WHERE team_id=$1 AND period_id=$2`, teamID, periodID)
+	row := s.conn(ctx).QueryRow(ctx, `SELECT status FROM team_period_statuses WHERE team_id=$1 AND period_id=$2`, teamID, periodID)
 	if err := row.Scan(&status); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.TeamPeriodStatusNoGoals, nil
@@ -22,7 +23,7 @@ func (s *Store) GetTeamPeriodStatus(ctx context.Context, teamID, periodID int64)
 }
 
 func (s *Store) SetTeamPeriodStatus(ctx context.Context, teamID, periodID int64, status domain.TeamPeriodStatus) error {
-	_, err := s.DB.Exec(ctx, `
+	_, err := s.conn(ctx).Exec(ctx, `
 		INSERT INTO team_period_statuses (team_id, period_id, status)
 		VALUES ($1,$2,$3)
 		ON CONFLICT (team_id, period_id)
@@ -31,3 +32,21 @@ func (s *Store) SetTeamPeriodStatus(ctx context.Context, teamID, periodID int64,
 	)
 	return err
 }
+
+// SwapTeamPeriodHealth stores the latest health of a team period and returns the previous one,
+// or an empty string when none was stored.
+func (s *Store) SwapTeamPeriodHealth(ctx context.Context, teamID, periodID int64, health string) (string, error) {
+	var previous sql.NullString
+	err := s.conn(ctx).QueryRow(ctx, `
+		WITH previous AS (
+			SELECT last_health FROM team_period_statuses WHERE team_id=$1 AND period_id=$2 FOR UPDATE
+		)
+		INSERT INTO team_period_statuses (team_id, period_id, status, last_health)
+		VALUES ($1,$2,'no_goals',$3)
+		ON CONFLICT (team_id, period_id)
+		DO UPDATE SET last_health=EXCLUDED.last_health
+		RETURNING (SELECT last_health FROM previous)`,
+		teamID, periodID, health,
+	).Scan(&previous)
+	return previous.String, err
+}
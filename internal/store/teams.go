@@ -8,7 +8,7 @@ import (
 )
 
 func (s *Store) ListTeams(ctx context.Context) ([]domain.Team, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, name, team_type, parent_id, lead, description, created_at, updated_at FROM teams ORDER BY name`)
+	rows, err := s.conn(ctx).Query(ctx, `SELECT id, name, team_type, parent_id, lead, lead_user_id, description, rollup_weight, created_at, updated_at FROM teams ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
@@ -18,7 +18,7 @@ func (s *Store) ListTeams(ctx context.Context) ([]domain.Team, error) {
 	for rows.Next() {
 		var team domain.Team
 		var parentID sql.NullInt64
-		if err := rows.Scan(&team.ID, &team.Name, &team.Type, &parentID, &team.Lead, &team.Description, &team.CreatedAt, &team.UpdatedAt); err != nil {
+		if err := rows.Scan(&team.ID, &team.Name, &team.Type, &parentID, &team.Lead, &team.LeadUserID, &team.Description, &team.RollupWeight, &team.CreatedAt, &team.UpdatedAt); err != nil {
 			return nil, err
 		}
 		if parentID.Valid {
@@ -33,8 +33,8 @@ func (s *Store) ListTeams(ctx context.Context) ([]domain.Team, error) {
 func (s *Store) GetTeam(ctx context.Context, id int64) (domain.Team, error) {
 	var team domain.Team
 	var parentID sql.NullInt64
-	row := s.DB.QueryRow(ctx, `SELECT id, name, team_type, parent_id, lead, description, created_at, updated_at FROM teams WHERE id=$1`, id)
-	if err := row.Scan(&team.ID, &team.Name, &team.Type, &parentID, &team.Lead, &team.Description, &team.CreatedAt, &team.UpdatedAt); err != nil {
+	row := s.conn(ctx).QueryRow(ctx, `SELECT id, name, team_type, parent_id, lead, lead_user_id, description, rollup_weight, created_at, updated_at FROM teams WHERE id=$1`, id)
+	if err := row.Scan(&team.ID, &team.Name, &team.Type, &parentID, &team.Lead, &team.LeadUserID, &team.Description, &team.RollupWeight, &team.CreatedAt, &team.UpdatedAt); err != nil {
 		return domain.Team{}, err
 	}
 	if parentID.Valid {
@@ -45,25 +45,36 @@ func (s *Store) GetTeam(ctx context.Context, id int64) (domain.Team, error) {
 }
 
 type TeamInput struct {
-	Name        string
-	Type        domain.TeamType
-	ParentID    *int64
-	Lead        string
-	Description string
+	Name         string
+	Type         domain.TeamType
+	ParentID     *int64
+	Lead         string
+	Description  string
+	RollupWeight *int
 }
 
 func (s *Store) CreateTeam(ctx context.Context, input TeamInput) (int64, error) {
+	return createTeam(ctx, s.conn(ctx), input)
+}
+
+func createTeam(ctx context.Context, db querier, input TeamInput) (int64, error) {
 	var id int64
-	err := s.DB.QueryRow(ctx, `INSERT INTO teams (name, team_type, parent_id, lead, description) VALUES ($1,$2,$3,$4,$5) RETURNING id`, input.Name, input.Type, input.ParentID, input.Lead, input.Description).Scan(&id)
+	err := db.QueryRow(ctx, `INSERT INTO teams (name, team_type, parent_id, lead, description, rollup_weight) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`, input.Name, input.Type, input.ParentID, input.Lead, input.Description, input.RollupWeight).Scan(&id)
 	return id, err
 }
 
 func (s *Store) UpdateTeam(ctx context.Context, input TeamInput, id int64) error {
-	_, err := s.DB.Exec(ctx, `UPDATE teams SET name=$1, team_type=$2, parent_id=$3, lead=$4, description=$5, updated_at=NOW() WHERE id=$6`, input.Name, input.Type, input.ParentID, input.Lead, input.Description, id)
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE teams SET name=$1, team_type=$2, parent_id=$3, lead=$4, description=$5, rollup_weight=$6, updated_at=NOW() WHERE id=$7`, input.Name, input.Type, input.ParentID, input.Lead, input.Description, input.RollupWeight, id)
+	return err
+}
+
+// SetTeamLeadUser links the team to the user who leads it; nil clears the link.
+func (s *Store) SetTeamLeadUser(ctx context.Context, teamID int64, userID *int64) error {
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE teams SET lead_user_id=$1, updated_at=NOW() WHERE id=$2`, userID, teamID)
 	return err
 }
 
 func (s *Store) DeleteTeam(ctx context.Context, id int64) error {
-	_, err := s.DB.Exec(ctx, `DELETE FROM teams WHERE id=$1`, id)
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM teams WHERE id=$1`, id)
 	return err
 }
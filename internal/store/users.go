@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"okrs/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type UserInput struct {
+	Email        string
+	Name         string
+	PasswordHash string
+}
+
+const userColumns = `id, email, name, password_hash, digest_opt_out, created_at, updated_at`
+
+func (s *Store) CreateUser(ctx context.Context, input UserInput) (int64, error) {
+	var id int64
+	err := s.conn(ctx).QueryRow(ctx, `INSERT INTO users (email, name, password_hash) VALUES ($1,$2,$3) RETURNING id`, input.Email, input.Name, input.PasswordHash).Scan(&id)
+	return id, err
+}
+
+func (s *Store) GetUser(ctx context.Context, id int64) (domain.User, error) {
+	var user domain.User
+	row := s.conn(ctx).QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id=$1`, id)
+	err := row.Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.DigestOptOut, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	var user domain.User
+	row := s.conn(ctx).QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE email=$1`, email)
+	err := row.Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.DigestOptOut, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
+
+// EnsureUser returns the user with the email, creating one without a password if needed.
+func (s *Store) EnsureUser(ctx context.Context, email, name string) (domain.User, error) {
+	var user domain.User
+	row := s.conn(ctx).QueryRow(ctx, `
+		INSERT INTO users (email, name)
+		VALUES ($1,$2)
+		ON CONFLICT (email) DO UPDATE SET email=EXCLUDED.email
+		RETURNING `+userColumns, email, name)
+	err := row.Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.DigestOptOut, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
+
+func (s *Store) UpdateUserPassword(ctx context.Context, id int64, passwordHash string) error {
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE users SET password_hash=$1, updated_at=NOW() WHERE id=$2`, passwordHash, id)
+	return err
+}
+
+func (s *Store) CreateSession(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error {
+	_, err := s.conn(ctx).Exec(ctx, `INSERT INTO sessions (token_hash, user_id, expires_at) VALUES ($1,$2,$3)`, tokenHash, userID, expiresAt)
+	return err
+}
+
+func (s *Store) GetSessionUser(ctx context.Context, tokenHash string) (domain.User, error) {
+	var user domain.User
+	row := s.conn(ctx).QueryRow(ctx, `
+		SELECT u.id, u.email, u.name, u.password_hash, u.digest_opt_out, u.created_at, u.updated_at
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash=$1 AND s.expires_at > NOW()`, tokenHash)
+	err := row.Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.DigestOptOut, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
+
+func (s *Store) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM sessions WHERE token_hash=$1`, tokenHash)
+	return err
+}
+
+func (s *Store) DeleteExpiredSessions(ctx context.Context) error {
+	_, err := s.conn(ctx).Exec(ctx, `DELETE FROM sessions WHERE expires_at <= NOW()`)
+	return err
+}
+
+func (s *Store) ListUsers(ctx context.Context) ([]domain.User, error) {
+	rows, err := s.conn(ctx).Query(ctx, `SELECT `+userColumns+` FROM users ORDER BY email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.DigestOptOut, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// SetUserDigestOptOut turns the weekly email digest of the user off or back on.
+func (s *Store) SetUserDigestOptOut(ctx context.Context, userID int64, optOut bool) error {
+	res, err := s.conn(ctx).Exec(ctx, `UPDATE users SET digest_opt_out=$1, updated_at=NOW() WHERE id=$2`, optOut, userID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ListDigestDueUsers returns the users who have not opted out of the digest and have not received one since sentBefore.
+func (s *Store) ListDigestDueUsers(ctx context.Context, sentBefore time.Time) ([]domain.User, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE NOT digest_opt_out AND (digest_sent_at IS NULL OR digest_sent_at < $1)
+		ORDER BY id`, sentBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.DigestOptOut, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// MarkDigestSent records when the user was last considered for the digest.
+func (s *Store) MarkDigestSent(ctx context.Context, userID int64, sentAt time.Time) error {
+	_, err := s.conn(ctx).Exec(ctx, `UPDATE users SET digest_sent_at=$1 WHERE id=$2`, sentAt, userID)
+	return err
+}
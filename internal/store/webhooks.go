@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"okrs/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type WebhookInput struct {
+	URL       string
+	Secret    string
+	Format    domain.WebhookFormat
+	Events    []domain.WebhookEvent
+	TeamID    *int64
+	Active    bool
+	CreatedBy *int64
+}
+
+// PendingWebhookDelivery is a claimed delivery together with the endpoint and secret of its webhook.
+type PendingWebhookDelivery struct {
+	Delivery domain.WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+// WebhookAttemptInput is the outcome of one delivery attempt. StatusCode is nil when no response was received.
+type WebhookAttemptInput struct {
+	DeliveryID    int64
+	Status        domain.WebhookDeliveryStatus
+	AttemptedAt   time.Time
+	NextAttemptAt time.Time
+	StatusCode    *int
+	Error         string
+}
+
+const webhookColumns = `id, url, secret, format, events, team_id, active, created_by, created_at`
+
+const webhookDeliveryColumns = `d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+func (s *Store) CreateWebhook(ctx context.Context, input WebhookInput) (int64, error) {
+	var id int64
+	err := s.conn(ctx).QueryRow(ctx, `
+		INSERT INTO webhooks (url, secret, format, events, team_id, active, created_by)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id`,
+		input.URL, input.Secret, string(input.Format), webhookEventStrings(input.Events), input.TeamID, input.Active, input.CreatedBy,
+	).Scan(&id)
+	return id, err
+}
+
+// UpdateWebhook replaces the subscription of a webhook. It returns pgx.ErrNoRows when the webhook does not exist.
+func (s *Store) UpdateWebhook(ctx context.Context, id int64, input WebhookInput) error {
+	res, err := s.conn(ctx).Exec(ctx, `
+		UPDATE webhooks SET url=$2, secret=$3, format=$4, events=$5, team_id=$6, active=$7
+		WHERE id=$1`,
+		id, input.URL, input.Secret, string(input.Format), webhookEventStrings(input.Events), input.TeamID, input.Active,
+	)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Store) GetWebhook(ctx context.Context, id int64) (domain.Webhook, error) {
+	row := s.conn(ctx).QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id=$1`, id)
+	return scanWebhook(row)
+}
+
+func (s *Store) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	rows, err := s.conn(ctx).Query(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []domain.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook removes a webhook with its delivery log. It returns pgx.ErrNoRows when the webhook does not exist.
+func (s *Store) DeleteWebhook(ctx context.Context, id int64) error {
+	res, err := s.conn(ctx).Exec(ctx, `DELETE FROM webhooks WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// EnqueueWebhookDeliveries queues the payload for every active webhook subscribed to the event whose team scope
+// is empty or one of teamIDs. Chat webhooks get chatPayload instead.
+func (s *Store) EnqueueWebhookDeliveries(ctx context.Context, event domain.WebhookEvent, teamIDs []int64, payload, chatPayload []byte) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $1, CASE WHEN format = 'chat' THEN $4::jsonb ELSE $3::jsonb END FROM webhooks
+		WHERE active
+		  AND (cardinality(events) = 0 OR $1 = ANY(events))
+		  AND (team_id IS NULL OR team_id = ANY($2))`,
+		string(event), teamIDs, payload, chatPayload,
+	)
+	return err
+}
+
+// ClaimDueWebhookDeliveries returns up to limit pending deliveries of active webhooks due at now and moves their
+// next attempt to leaseUntil, so a concurrent worker does not send them again while they are in flight.
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]PendingWebhookDelivery, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		UPDATE webhook_deliveries d SET next_attempt_at=$2
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT q.id FROM webhook_deliveries q
+			JOIN webhooks qw ON qw.id = q.webhook_id
+			WHERE q.status = 'pending' AND q.next_attempt_at <= $1 AND qw.active
+			ORDER BY q.next_attempt_at, q.id
+			LIMIT $3
+			FOR UPDATE OF q SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns+`, w.url, w.secret`,
+		now, leaseUntil, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []PendingWebhookDelivery
+	for rows.Next() {
+		var item PendingWebhookDelivery
+		delivery := &item.Delivery
+		if err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+			&delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivery.DeliveredAt,
+			&item.URL, &item.Secret); err != nil {
+			return nil, err
+		}
+		pending = append(pending, item)
+	}
+	return pending, rows.Err()
+}
+
+// RecordWebhookAttempt stores the outcome of a delivery attempt and counts it.
+func (s *Store) RecordWebhookAttempt(ctx context.Context, input WebhookAttemptInput) error {
+	_, err := s.conn(ctx).Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status=$2, attempts=attempts+1, next_attempt_at=$3, last_status_code=$4, last_error=$5,
+			delivered_at=CASE WHEN $2 = 'delivered' THEN $6 ELSE delivered_at END
+		WHERE id=$1`,
+		input.DeliveryID, string(input.Status), input.NextAttemptAt, input.StatusCode, input.Error, input.AttemptedAt,
+	)
+	return err
+}
+
+// ListWebhookDeliveries returns the latest deliveries of a webhook, newest first.
+func (s *Store) ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries d
+		WHERE d.webhook_id=$1
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $2`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		if err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+			&delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivery.DeliveredAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func scanWebhook(row pgx.Row) (domain.Webhook, error) {
+	var webhook domain.Webhook
+	var events []string
+	if err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &webhook.Format, &events, &webhook.TeamID, &webhook.Active, &webhook.CreatedBy, &webhook.CreatedAt); err != nil {
+		return domain.Webhook{}, err
+	}
+	for _, event := range events {
+		webhook.Events = append(webhook.Events, domain.WebhookEvent(event))
+	}
+	return webhook, nil
+}
+
+func webhookEventStrings(events []domain.WebhookEvent) []string {
+	values := make([]string, 0, len(events))
+	for _, event := range events {
+		values = append(values, string(event))
+	}
+	return values
+}
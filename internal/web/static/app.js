@@ -9,8 +9,13 @@
   const goalWorkOptions = ['Discovery', 'Delivery'];
   const goalFocusOptions = ['PROFITABILITY', 'STABILITY', 'SPEED_EFFICIENCY', 'TECH_INDEPENDENCE'];
   const lockedPeriodStatuses = ['validated', 'closed'];
+  const lockedPeriodMessage = 'Статус периода не позволяет вносить эти изменения';
+  const forbiddenMessage = 'Недостаточно прав для этого действия';
 
+  const canEditTeam = () => state.teamOKR?.permissions?.can_edit !== false;
   const isPeriodLocked = () => lockedPeriodStatuses.includes(state.teamOKR?.period_status);
+  const isReadOnly = () => isPeriodLocked() || !canEditTeam();
+  const canEditConfidence = () => state.teamOKR?.period_status !== 'closed' && canEditTeam();
 
   const pluralize = (count, forms) => {
     const mod10 = count % 10;
@@ -45,8 +50,16 @@
     return date.toLocaleDateString('ru-RU', { year: 'numeric', month: 'long', day: 'numeric' });
   };
 
+  const redirectToLogin = () => {
+    const next = `${window.location.pathname}${window.location.search}`;
+    window.location.assign(`/login?next=${encodeURIComponent(next)}`);
+  };
+
   const fetchJSON = async (url, options = {}) => {
     const response = await fetch(url, options);
+    if (response.status === 401) {
+      redirectToLogin();
+    }
     const payload = await response.json();
     if (!response.ok) {
       const message = payload?.error?.message || 'Request failed';
@@ -154,6 +167,84 @@
     });
   };
 
+  const confidenceBadgeClass = (value) => {
+    if (value === null || value === undefined) return 'text-bg-light border';
+    if (value <= 3) return 'text-bg-danger';
+    if (value <= 6) return 'text-bg-warning';
+    return 'text-bg-success';
+  };
+
+  const healthBadgeClass = (health) => {
+    switch (health) {
+      case 'on_track':
+        return 'text-bg-success';
+      case 'at_risk':
+        return 'text-bg-warning';
+      case 'behind':
+        return 'text-bg-danger';
+      default:
+        return 'text-bg-secondary';
+    }
+  };
+
+  const renderHealthBadge = (health, label) => {
+    const badge = document.createElement('span');
+    badge.className = `badge ${healthBadgeClass(health)}`;
+    badge.textContent = label || 'Нет данных';
+    return badge;
+  };
+
+  const formatRangeBounds = (range) => {
+    const min = range?.min_value;
+    const max = range?.max_value;
+    if (min !== null && min !== undefined && max !== null && max !== undefined) return `${min} … ${max}`;
+    if (min !== null && min !== undefined) return `≥ ${min}`;
+    if (max !== null && max !== undefined) return `≤ ${max}`;
+    return '—';
+  };
+
+  const formatConfidence = (value) => (value === null || value === undefined ? '—' : `${value}/10`);
+
+  const renderConfidenceControl = (value, rollup, url) => {
+    if (!canEditConfidence()) {
+      const badge = document.createElement('span');
+      const shown = value ?? rollup;
+      badge.className = `badge ${confidenceBadgeClass(shown)}`;
+      badge.textContent = `Уверенность ${formatConfidence(shown)}`;
+      return badge;
+    }
+    const select = document.createElement('select');
+    select.className = 'form-select form-select-sm w-auto';
+    select.title = 'Уверенность владельца';
+    const empty = createOption('', rollup === null || rollup === undefined ? 'Уверенность —' : `Уверенность ~${rollup}`);
+    select.appendChild(empty);
+    for (let i = 0; i <= 10; i += 1) {
+      select.appendChild(createOption(String(i), `Уверенность ${i}/10`));
+    }
+    select.value = value === null || value === undefined ? '' : String(value);
+    select.addEventListener('change', async () => {
+      select.disabled = true;
+      try {
+        await fetchJSON(url, {
+          method: 'POST',
+          headers: jsonHeaders,
+          body: JSON.stringify({ confidence: select.value === '' ? null : Number(select.value) }),
+        });
+        await reloadTeamOKR();
+      } catch (error) {
+        if (error.details?.code === 'LOCKED') {
+          window.alert(lockedPeriodMessage);
+        } else if (error.details?.code === 'FORBIDDEN') {
+          window.alert(forbiddenMessage);
+        } else {
+          window.alert(error.message);
+        }
+        select.disabled = false;
+      }
+    });
+    return select;
+  };
+
   const renderOKRPage = (data, summaryEl, goalsEl, actionsEl) => {
     state.teamOKR = data;
     renderSummary(data, summaryEl);
@@ -198,7 +289,7 @@
 
     const titleWrap = document.createElement('div');
     titleWrap.className = 'd-flex flex-column';
-    if (isPeriodLocked()) {
+    if (isReadOnly()) {
       const title = document.createElement('span');
       title.className = 'fw-semibold';
       title.textContent = goal.title;
@@ -214,11 +305,11 @@
 
     const menu = renderGoalMenu(goal);
 
-    header.append(priority, weight, krWeightBadge);
+    header.append(priority, weight, krWeightBadge, renderHealthBadge(goal.health, goal.health_label));
     if (goal.share_teams && goal.share_teams.length > 1) {
       header.appendChild(renderSharedGoalBadge(goal));
     }
-    header.append(titleWrap, menu);
+    header.append(titleWrap, renderConfidenceControl(goal.confidence, goal.confidence_rollup, `/api/v1/goals/${goal.id}/confidence`), menu);
 
     const description = document.createElement('p');
     description.className = 'text-muted mb-2';
@@ -264,7 +355,7 @@
 
     const actions = document.createElement('div');
     actions.className = 'mt-3';
-    if (!isPeriodLocked()) {
+    if (!isReadOnly()) {
       const addKRButton = document.createElement('button');
       addKRButton.type = 'button';
       addKRButton.className = 'btn btn-outline-primary btn-sm align-self-start';
@@ -331,7 +422,7 @@
     titleCell.className = 'okr-kr-title-col';
     const titleWrap = document.createElement('div');
     titleWrap.className = 'd-flex flex-column align-items-start';
-    if (isPeriodLocked()) {
+    if (isReadOnly()) {
       const title = document.createElement('span');
       title.className = 'fw-semibold';
       title.textContent = kr.title;
@@ -359,6 +450,9 @@
     progress.className = 'badge text-bg-light border';
     progress.textContent = `${kr.progress}%`;
     progressCell.appendChild(progress);
+    const confidence = renderConfidenceControl(kr.confidence, null, `/api/v1/krs/${kr.id}/confidence`);
+    confidence.classList.add('mt-1');
+    progressCell.appendChild(confidence);
 
     const actionsCell = document.createElement('td');
     actionsCell.className = 'text-end';
@@ -425,8 +519,9 @@
       return panel;
     }
 
-    if (kr.measure.kind === 'PERCENT' || kr.measure.kind === 'LINEAR') {
-      const meta = kr.measure.percent || kr.measure.linear;
+    if (kr.measure.kind === 'PERCENT' || kr.measure.kind === 'LINEAR' || kr.measure.kind === 'RANGE') {
+      const isRange = kr.measure.kind === 'RANGE';
+      const meta = kr.measure.percent || kr.measure.linear || kr.measure.range;
       const row = document.createElement('div');
       row.className = 'row g-2';
 
@@ -447,12 +542,12 @@
       targetCol.className = 'col-7';
       const targetLabel = document.createElement('label');
       targetLabel.className = 'form-label';
-      targetLabel.textContent = 'Целевое значение';
+      targetLabel.textContent = isRange ? 'Диапазон' : 'Целевое значение';
       const targetInput = document.createElement('input');
-      targetInput.type = 'number';
+      targetInput.type = isRange ? 'text' : 'number';
       targetInput.step = 'any';
       targetInput.className = 'form-control';
-      targetInput.value = meta?.target_value ?? 0;
+      targetInput.value = isRange ? formatRangeBounds(meta) : meta?.target_value ?? 0;
       targetInput.disabled = true;
       targetLabel.appendChild(targetInput);
       targetCol.appendChild(targetLabel);
@@ -554,14 +649,26 @@
 
         const label = document.createElement('label');
         label.className = 'form-check-label';
-        label.textContent = `${stage.title} (${stage.weight}%)`;
+        label.textContent = `${stage.title} (${stage.weight}%)${stage.due_date ? ` · до ${stage.due_date}` : ''}`;
 
         const wrapper = document.createElement('div');
         wrapper.className = 'form-check';
         wrapper.append(checkbox, label);
+        if (stage.overdue) {
+          wrapper.classList.add('text-danger');
+          const overdue = document.createElement('span');
+          overdue.className = 'badge text-bg-danger ms-2';
+          overdue.textContent = 'Просрочен';
+          wrapper.appendChild(overdue);
+        }
         form.appendChild(wrapper);
       });
 
+      const plan = document.createElement('div');
+      plan.className = 'text-muted small';
+      plan.textContent = `План ${kr.measure.project?.planned_progress ?? 0}% / факт ${kr.progress ?? 0}%`;
+      form.appendChild(plan);
+
       const button = document.createElement('button');
       button.type = 'submit';
       button.className = 'btn btn-primary btn-sm align-self-start';
@@ -668,11 +775,23 @@
       }
       statusSelect.appendChild(opt);
     });
+    statusSelect.disabled = !canEditTeam();
     statusSelect.addEventListener('change', () => updatePeriodStatus(statusSelect.value));
 
     status.append(statusLabel, statusSelect);
 
-    summaryEl.append(title, progressRow, counts, weight, status);
+    const confidence = document.createElement('div');
+    confidence.className = 'd-flex justify-content-between';
+    confidence.innerHTML = `<span class="text-muted">Уверенность</span><span class="badge ${confidenceBadgeClass(data.confidence)}">${formatConfidence(data.confidence)}</span>`;
+
+    const health = document.createElement('div');
+    health.className = 'd-flex justify-content-between';
+    const healthLabel = document.createElement('span');
+    healthLabel.className = 'text-muted';
+    healthLabel.textContent = 'Состояние';
+    health.append(healthLabel, renderHealthBadge(data.health, data.health_label));
+
+    summaryEl.append(title, progressRow, counts, weight, confidence, health, status);
   };
 
   const renderOKRActions = (data, actionsEl) => {
@@ -680,7 +799,7 @@
     const wrapper = document.createElement('div');
     wrapper.className = 'd-flex flex-wrap gap-2';
 
-    if (!isPeriodLocked()) {
+    if (!isReadOnly()) {
       const addGoalButton = document.createElement('button');
       addGoalButton.type = 'button';
       addGoalButton.className = 'btn btn-primary';
@@ -704,13 +823,15 @@
     const menu = document.createElement('ul');
     menu.className = 'dropdown-menu dropdown-menu-end';
 
-    if (!isPeriodLocked()) {
+    if (!isReadOnly()) {
       menu.appendChild(buildMenuButton('Редактировать', () => openGoalModal(goal)));
       menu.appendChild(buildMenuButton('Шарить', () => openShareGoalModal(goal)));
     }
-    menu.appendChild(buildMenuButton('Переместить вверх', () => moveGoal(goal.id, 'move-up')));
-    menu.appendChild(buildMenuButton('Переместить вниз', () => moveGoal(goal.id, 'move-down')));
-    if (!isPeriodLocked()) {
+    if (canEditTeam()) {
+      menu.appendChild(buildMenuButton('Переместить вверх', () => moveGoal(goal.id, 'move-up')));
+      menu.appendChild(buildMenuButton('Переместить вниз', () => moveGoal(goal.id, 'move-down')));
+    }
+    if (!isReadOnly()) {
       menu.appendChild(buildMenuForm(`/goals/${goal.id}/delete`, buildReturnFields(), true));
     }
 
@@ -731,12 +852,14 @@
     const menu = document.createElement('ul');
     menu.className = 'dropdown-menu dropdown-menu-end';
 
-    if (!isPeriodLocked()) {
+    if (!isReadOnly()) {
       menu.appendChild(buildMenuButton('Редактировать', () => openKRModal(kr)));
     }
-    menu.appendChild(buildMenuButton('Переместить вверх', () => moveKeyResult(kr.id, 'move-up')));
-    menu.appendChild(buildMenuButton('Переместить вниз', () => moveKeyResult(kr.id, 'move-down')));
-    if (!isPeriodLocked()) {
+    if (canEditTeam()) {
+      menu.appendChild(buildMenuButton('Переместить вверх', () => moveKeyResult(kr.id, 'move-up')));
+      menu.appendChild(buildMenuButton('Переместить вниз', () => moveKeyResult(kr.id, 'move-down')));
+    }
+    if (!isReadOnly()) {
       menu.appendChild(buildMenuForm(`/key-results/${kr.id}/delete`, buildReturnFields(), true));
     }
 
@@ -886,8 +1009,14 @@
       body.append(key, value);
     });
     const response = await fetch(url, { method: 'POST', body });
+    if (response.status === 401) {
+      redirectToLogin();
+    }
     if (!response.ok) {
-      throw new Error('Request failed');
+      const payload = await response.json().catch(() => null);
+      const error = new Error(payload?.error?.message || 'Request failed');
+      error.details = payload?.error;
+      throw error;
     }
   };
 
@@ -896,7 +1025,11 @@
       await postFormData(`/api/v1/goals/${goalID}/${direction}`, {});
       await reloadTeamOKR();
     } catch (error) {
-      // noop
+      if (error.details?.code === 'LOCKED') {
+        window.alert(lockedPeriodMessage);
+      } else if (error.details?.code === 'FORBIDDEN') {
+        window.alert(forbiddenMessage);
+      }
     }
   };
 
@@ -905,7 +1038,11 @@
       await postFormData(`/api/v1/krs/${krID}/${direction}`, {});
       await reloadTeamOKR();
     } catch (error) {
-      // noop
+      if (error.details?.code === 'LOCKED') {
+        window.alert(lockedPeriodMessage);
+      } else if (error.details?.code === 'FORBIDDEN') {
+        window.alert(forbiddenMessage);
+      }
     }
   };
 
@@ -926,7 +1063,12 @@
       });
       await reloadTeamOKR();
     } catch (error) {
-      // noop
+      if (error.details?.code === 'CONFLICT') {
+        window.alert('Недопустимый переход статуса периода');
+      } else if (error.details?.code === 'FORBIDDEN') {
+        window.alert(forbiddenMessage);
+      }
+      await reloadTeamOKR();
     }
   };
 
@@ -1170,6 +1312,71 @@
     renderRows();
   };
 
+  const readCheckpointRow = (row) => ({
+    metric_value: parseFloat(row.querySelector('[data-checkpoint-metric]').value),
+    percent: parseInt(row.querySelector('[data-checkpoint-percent]').value, 10),
+  });
+
+  const setupCheckpointEditor = (editor, krID, initialItems) => {
+    const list = editor.querySelector('[data-checkpoint-list]');
+    const errorEl = editor.querySelector('[data-checkpoint-error]');
+    const baseURL = `/api/v1/krs/${krID}/checkpoints`;
+    const showError = (message) => {
+      errorEl.textContent = message || '';
+      errorEl.hidden = !message;
+    };
+    const renderItems = (items) => {
+      list.innerHTML = items.length ? '' : '<div class="text-muted small">Точек нет — прогресс считается линейно от Start к Target</div>';
+      items.forEach((item) => {
+        const row = document.createElement('div');
+        row.className = 'row g-2 align-items-center';
+        row.innerHTML = `
+          <div class="col-md-5">
+            <input class="form-control form-control-sm" data-checkpoint-metric type="number" step="any" value="${item.metric_value}" />
+          </div>
+          <div class="col-md-3">
+            <input class="form-control form-control-sm" data-checkpoint-percent type="number" min="0" max="100" value="${item.percent}" />
+          </div>
+          <div class="col-md-4 d-flex gap-1">
+            <button type="button" class="btn btn-outline-primary btn-sm" data-checkpoint-save>Сохранить</button>
+            <button type="button" class="btn btn-outline-danger btn-sm" data-checkpoint-delete>Удалить</button>
+          </div>`;
+        row.querySelector('[data-checkpoint-save]').addEventListener('click', () => {
+          send(`${baseURL}/${item.id}`, readCheckpointRow(row));
+        });
+        row.querySelector('[data-checkpoint-delete]').addEventListener('click', () => {
+          send(`${baseURL}/${item.id}/delete`);
+        });
+        list.appendChild(row);
+      });
+    };
+    const send = async (url, payload) => {
+      showError('');
+      try {
+        await fetchJSON(url, {
+          method: 'POST',
+          headers: jsonHeaders,
+          body: payload ? JSON.stringify(payload) : undefined,
+        });
+        const response = await fetchJSON(baseURL);
+        renderItems(response.items || []);
+        await reloadTeamOKR();
+      } catch (error) {
+        showError(error.message);
+      }
+    };
+    const newRow = editor.querySelector('[data-checkpoint-new]');
+    editor.querySelector('[data-checkpoint-add]').addEventListener('click', async () => {
+      await send(baseURL, readCheckpointRow(newRow));
+      if (errorEl.hidden) {
+        newRow.querySelectorAll('input').forEach((input) => {
+          input.value = '';
+        });
+      }
+    });
+    renderItems(initialItems);
+  };
+
   const openKRModalWithAction = (kr, action, titleText) => {
     const goalMetaSource = state.teamOKR?.goals?.find((goal) => goal.id === kr.goal_id);
     const goalPriority = kr.goal_priority || goalMetaSource?.priority || '';
@@ -1190,9 +1397,32 @@
           <input class="form-control" value="${escapeHTML(goalFocusType)}" disabled />
         </div>
       </div>`;
-    const kindOptions = ['PERCENT', 'LINEAR', 'BOOLEAN', 'PROJECT'];
+    const kindOptions = ['PERCENT', 'LINEAR', 'RANGE', 'BOOLEAN', 'PROJECT'];
     const normalizedKind = (kr.kind || kr.measure?.kind || 'PERCENT').toUpperCase();
     const selectedKind = kindOptions.includes(normalizedKind) ? normalizedKind : 'PERCENT';
+    const checkpointEditor =
+      kr.id && normalizedKind === 'PERCENT'
+        ? `
+        <div class="vstack gap-2 border rounded p-2" data-checkpoint-editor>
+          <div class="fw-semibold small">Контрольные точки</div>
+          <div class="form-text mt-0">Значение метрики строго между Start и Target, % KR не убывает от Start к Target.</div>
+          <div class="vstack gap-2" data-checkpoint-list></div>
+          <div class="row g-2 align-items-end" data-checkpoint-new>
+            <div class="col-md-5">
+              <label class="form-label small">Значение метрики</label>
+              <input class="form-control form-control-sm" data-checkpoint-metric type="number" step="any" />
+            </div>
+            <div class="col-md-3">
+              <label class="form-label small">% KR</label>
+              <input class="form-control form-control-sm" data-checkpoint-percent type="number" min="0" max="100" />
+            </div>
+            <div class="col-md-4">
+              <button type="button" class="btn btn-outline-secondary btn-sm" data-checkpoint-add>Добавить точку</button>
+            </div>
+          </div>
+          <div class="text-danger small" data-checkpoint-error hidden></div>
+        </div>`
+        : '';
     const percentSection = `
       <div data-kind-section="PERCENT" class="vstack gap-2">
         <div class="row g-3">
@@ -1209,6 +1439,7 @@
             <input class="form-control" name="percent_current" type="number" step="any" value="${kr.measure?.percent?.current_value ?? 0}" />
           </div>
         </div>
+        ${checkpointEditor}
       </div>`;
     const linearSection = `
       <div data-kind-section="LINEAR" class="vstack gap-2">
@@ -1227,6 +1458,27 @@
           </div>
         </div>
       </div>`;
+    const rangeSection = `
+      <div data-kind-section="RANGE" class="vstack gap-2">
+        <div class="row g-3">
+          <div class="col-md-3">
+            <label class="form-label">Min</label>
+            <input class="form-control" name="range_min" type="number" step="any" placeholder="без границы" value="${kr.measure?.range?.min_value ?? ''}" />
+          </div>
+          <div class="col-md-3">
+            <label class="form-label">Max</label>
+            <input class="form-control" name="range_max" type="number" step="any" placeholder="без границы" value="${kr.measure?.range?.max_value ?? ''}" />
+          </div>
+          <div class="col-md-3">
+            <label class="form-label">Допуск</label>
+            <input class="form-control" name="range_tolerance" type="number" step="any" min="0" value="${kr.measure?.range?.tolerance ?? 0}" />
+          </div>
+          <div class="col-md-3">
+            <label class="form-label">Current</label>
+            <input class="form-control" name="range_current" type="number" step="any" value="${kr.measure?.range?.current_value ?? 0}" />
+          </div>
+        </div>
+      </div>`;
     const booleanSection = `
       <div data-kind-section="BOOLEAN" class="form-check">
         <input class="form-check-input" type="checkbox" name="boolean_done" value="true" ${kr.measure?.boolean?.is_done ? 'checked' : ''} />
@@ -1236,14 +1488,18 @@
       .map(
         (stage) => `
           <div class="row g-2 align-items-end" data-stage-row>
-            <div class="col-md-6">
+            <div class="col-md-5">
               <label class="form-label">Шаг</label>
               <input class="form-control" name="step_title[]" value="${escapeHTML(stage.title)}" />
             </div>
-            <div class="col-md-3">
+            <div class="col-md-2">
               <label class="form-label">Вес</label>
               <input class="form-control" name="step_weight[]" type="number" value="${stage.weight}" />
             </div>
+            <div class="col-md-2">
+              <label class="form-label">Срок</label>
+              <input class="form-control" name="step_due[]" type="date" value="${stage.due_date ?? ''}" />
+            </div>
             <div class="col-md-3 form-check">
               <input class="form-check-input" type="checkbox" name="step_done[]" value="true" ${stage.is_done ? 'checked' : ''} />
               <label class="form-check-label">Готово</label>
@@ -1283,6 +1539,7 @@
         ${goalMeta}
         ${percentSection}
         ${linearSection}
+        ${rangeSection}
         ${booleanSection}
         ${projectSection}
         <input type="hidden" name="return" value="${buildReturnURL()}" />
@@ -1300,6 +1557,10 @@
     kindSelect.value = selectedKind;
     updateSections();
     kindSelect.addEventListener('change', updateSections);
+    const checkpointEditorEl = form.querySelector('[data-checkpoint-editor]');
+    if (checkpointEditorEl) {
+      setupCheckpointEditor(checkpointEditorEl, kr.id, kr.measure?.percent?.checkpoints || []);
+    }
     const addStageButton = form.querySelector('[data-add-stage]');
     if (addStageButton) {
       addStageButton.addEventListener('click', () => {
@@ -1308,14 +1569,18 @@
         const row = document.createElement('div');
         row.className = 'row g-2 align-items-end';
         row.innerHTML = `
-          <div class="col-md-6">
+          <div class="col-md-5">
             <label class="form-label">Шаг</label>
             <input class="form-control" name="step_title[]" />
           </div>
-          <div class="col-md-3">
+          <div class="col-md-2">
             <label class="form-label">Вес</label>
             <input class="form-control" name="step_weight[]" type="number" value="0" />
           </div>
+          <div class="col-md-2">
+            <label class="form-label">Срок</label>
+            <input class="form-control" name="step_due[]" type="date" />
+          </div>
           <div class="col-md-3 form-check">
             <input class="form-check-input" type="checkbox" name="step_done[]" value="true" />
             <label class="form-check-label">Готово</label>
@@ -1345,6 +1610,7 @@
       measure: {
         percent: { start_value: 0, target_value: 100, current_value: 0 },
         linear: { start_value: 0, target_value: 100, current_value: 0 },
+        range: { min_value: null, max_value: null, tolerance: 0, current_value: 0 },
         boolean: { is_done: false },
         project: { stages: [] },
       },
@@ -1426,6 +1692,23 @@
     value.className = 'fw-semibold';
     value.textContent = `${team.period_progress}%`;
     wrapper.append(progressBar, value);
+    if (team.rollup_progress !== undefined && team.rollup_progress !== team.period_progress) {
+      const rollup = document.createElement('span');
+      rollup.className = 'text-muted small text-nowrap';
+      rollup.title = 'Агрегированный прогресс с дочерними командами';
+      rollup.textContent = `Σ ${team.rollup_progress}%`;
+      wrapper.appendChild(rollup);
+    }
+    if (team.health && team.health !== 'no_data') {
+      wrapper.appendChild(renderHealthBadge(team.health, team.health_label));
+    }
+    if (team.confidence !== null && team.confidence !== undefined) {
+      const confidence = document.createElement('span');
+      confidence.className = `badge ${confidenceBadgeClass(team.confidence)}`;
+      confidence.title = 'Уверенность';
+      confidence.textContent = formatConfidence(team.confidence);
+      wrapper.appendChild(confidence);
+    }
     cell.appendChild(wrapper);
     return cell;
   };
@@ -1542,6 +1825,111 @@
     return option;
   };
 
+  const svgNS = 'http://www.w3.org/2000/svg';
+
+  const createSVG = (name, attrs) => {
+    const el = document.createElementNS(svgNS, name);
+    Object.entries(attrs).forEach(([key, value]) => el.setAttribute(key, value));
+    return el;
+  };
+
+  const renderBurnup = (container, items) => {
+    container.innerHTML = '';
+    if (!items.length) {
+      container.innerHTML = '<p class="text-muted mb-0">Нет данных для графика</p>';
+      return;
+    }
+    const width = 640;
+    const height = 220;
+    const pad = { top: 10, right: 10, bottom: 24, left: 36 };
+    const plotWidth = width - pad.left - pad.right;
+    const plotHeight = height - pad.top - pad.bottom;
+    const x = (index) => pad.left + (items.length > 1 ? (index / (items.length - 1)) * plotWidth : plotWidth / 2);
+    const y = (value) => pad.top + plotHeight - (value / 100) * plotHeight;
+
+    const svg = createSVG('svg', {
+      viewBox: `0 0 ${width} ${height}`,
+      class: 'w-100',
+      role: 'img',
+      'aria-label': 'Burn-up',
+    });
+
+    [0, 50, 100].forEach((value) => {
+      svg.appendChild(createSVG('line', {
+        x1: pad.left, x2: width - pad.right, y1: y(value), y2: y(value), stroke: '#dee2e6',
+      }));
+      const label = createSVG('text', {
+        x: pad.left - 6, y: y(value) + 4, 'text-anchor': 'end', 'font-size': 11, fill: '#6c757d',
+      });
+      label.textContent = `${value}%`;
+      svg.appendChild(label);
+    });
+
+    [0, items.length - 1].forEach((index) => {
+      const label = createSVG('text', {
+        x: x(index), y: height - 6, 'text-anchor': index === 0 ? 'start' : 'end', 'font-size': 11, fill: '#6c757d',
+      });
+      label.textContent = items[index].date;
+      svg.appendChild(label);
+    });
+
+    const planned = items.map((item, index) => `${x(index)},${y(item.planned)}`).join(' ');
+    svg.appendChild(createSVG('polyline', {
+      points: planned, fill: 'none', stroke: '#adb5bd', 'stroke-width': 2, 'stroke-dasharray': '6 4',
+    }));
+
+    const actual = items
+      .map((item, index) => (item.actual === null ? null : `${x(index)},${y(item.actual)}`))
+      .filter(Boolean)
+      .join(' ');
+    if (actual) {
+      svg.appendChild(createSVG('polyline', {
+        points: actual, fill: 'none', stroke: '#0d6efd', 'stroke-width': 2,
+      }));
+    }
+
+    const legend = document.createElement('div');
+    legend.className = 'small text-muted d-flex gap-3';
+    legend.innerHTML = '<span><span style="color:#adb5bd">- - -</span> План</span><span><span class="text-primary">———</span> Факт</span>';
+
+    container.appendChild(svg);
+    container.appendChild(legend);
+  };
+
+  const loadBurnupCharts = (root = document) => {
+    root.querySelectorAll('[data-burnup-url]').forEach((container) => {
+      fetchJSON(container.dataset.burnupUrl)
+        .then((payload) => renderBurnup(container, payload.items || []))
+        .catch((error) => {
+          container.innerHTML = `<p class="text-danger mb-0">${error.message}</p>`;
+        });
+    });
+  };
+
+  const renderMissingCheckIns = (payload, card, periodID) => {
+    const list = card.querySelector('[data-missing-checkins-list]');
+    list.innerHTML = '';
+    const items = payload.items || [];
+    card.hidden = items.length === 0;
+    const hint = document.createElement('p');
+    hint.className = 'small text-muted mb-2';
+    hint.textContent = `Неделя с ${formatAbsoluteDate(payload.week_start)}`;
+    list.appendChild(hint);
+    const wrap = document.createElement('div');
+    wrap.className = 'd-flex flex-wrap gap-2';
+    items.forEach((item) => {
+      const link = document.createElement('a');
+      link.className = 'badge text-bg-warning text-decoration-none';
+      link.href = `/teams/${item.team_id}/okr?period_id=${periodID}`;
+      link.textContent = item.team_name;
+      link.title = item.last_check_in_at
+        ? `Последний check-in ${formatRelativeUpdate(item.last_check_in_at)}`
+        : 'Check-in ещё не было';
+      wrap.appendChild(link);
+    });
+    list.appendChild(wrap);
+  };
+
   let reloadTeamOKR = async () => { };
 
   const initTeamsPage = () => {
@@ -1573,6 +1961,18 @@
       const payload = await fetchJSON(url.toString());
       renderTeamsList(payload, tbody, periodSelect.value);
       initPopovers();
+      loadMissingCheckIns();
+    };
+
+    const loadMissingCheckIns = () => {
+      const card = page.querySelector('[data-missing-checkins]');
+      const url = new URL('/api/v1/checkins/missing', window.location.origin);
+      url.searchParams.set('period_id', periodSelect.value);
+      fetchJSON(url.toString())
+        .then((payload) => renderMissingCheckIns(payload, card, periodSelect.value))
+        .catch(() => {
+          card.hidden = true;
+        });
     };
 
     const selectedTeam = page.dataset.selectedTeam || 'ALL';
@@ -1604,6 +2004,50 @@
     hierarchySelect.addEventListener('change', applyFilters);
   };
 
+  const initRolloverForm = (form, teamID, periodID) => {
+    const select = form.querySelector('select[name="target_period_id"]');
+    const keepCurrent = form.querySelector('input[name="keep_current"]');
+    const button = form.querySelector('button[type="submit"]');
+    const status = form.querySelector('[data-rollover-status]');
+    loadPeriods()
+      .then((periods) => renderPeriodSelect(select, periods.filter((period) => String(period.id) !== String(periodID))))
+      .catch(() => {
+        select.disabled = true;
+      });
+
+    form.addEventListener('submit', async (event) => {
+      event.preventDefault();
+      const url = new URL(`/api/v1/teams/${teamID}/rollover`, window.location.origin);
+      url.searchParams.set('period_id', periodID);
+      url.searchParams.set('target_period_id', select.value);
+      if (keepCurrent.checked) {
+        url.searchParams.set('keep_current', 'true');
+      }
+      button.disabled = true;
+      status.className = 'small text-muted';
+      status.textContent = 'Перенос...';
+      try {
+        const payload = await fetchJSON(url.toString(), { method: 'POST' });
+        status.className = 'small text-success';
+        status.textContent = `Перенесено целей: ${payload.goal_ids.length}`;
+        if (payload.skipped) {
+          status.textContent += `, перенесены ранее: ${payload.skipped}`;
+        }
+      } catch (error) {
+        status.className = 'small text-danger';
+        if (error.details?.code === 'LOCKED') {
+          status.textContent = lockedPeriodMessage;
+        } else if (error.details?.code === 'FORBIDDEN') {
+          status.textContent = forbiddenMessage;
+        } else {
+          status.textContent = error.message;
+        }
+      } finally {
+        button.disabled = false;
+      }
+    });
+  };
+
   const initTeamOKRPage = () => {
     const page = document.querySelector('[data-page="team-okr"]');
     if (!page) return;
@@ -1612,6 +2056,10 @@
     const goalsEl = page.querySelector('[data-okr-goals]');
     const teamID = page.dataset.teamId;
     const periodID = page.dataset.periodId;
+    const rolloverForm = document.querySelector('[data-rollover-form]');
+    if (rolloverForm) {
+      initRolloverForm(rolloverForm, teamID, periodID);
+    }
 
     const load = async () => {
       const url = new URL(`/api/v1/teams/${teamID}/okrs`, window.location.origin);
@@ -1620,7 +2068,10 @@
       renderOKRPage(payload, summaryEl, goalsEl, actionsEl);
     };
 
-    reloadTeamOKR = load;
+    reloadTeamOKR = async () => {
+      await load();
+      loadBurnupCharts(page);
+    };
 
     load().catch((error) => {
       summaryEl.innerHTML = `<p class="text-danger mb-0">${error.message}</p>`;
@@ -1631,6 +2082,7 @@
   document.addEventListener('DOMContentLoaded', () => {
     initTeamsPage();
     initTeamOKRPage();
+    loadBurnupCharts();
   });
 
   const initPopovers = () => {
@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+const (
+	// SignatureHeader carries the "sha256=<hex>" HMAC of the delivery body signed with the webhook secret.
+	SignatureHeader = "X-OKR-Signature"
+	// EventHeader names the event of the delivery.
+	EventHeader = "X-OKR-Event"
+	// DeliveryHeader carries the delivery id; it stays the same across retries.
+	DeliveryHeader = "X-OKR-Delivery"
+
+	// MaxAttempts is the number of attempts after which a delivery is marked failed.
+	MaxAttempts = 8
+	// deliveryTimeout bounds a single request so a slow endpoint does not hold back the queue.
+	deliveryTimeout = 10 * time.Second
+	// leaseDuration keeps claimed deliveries from being picked up again while they are in flight.
+	leaseDuration = time.Minute
+	// batchSize is the number of deliveries claimed per tick.
+	batchSize = 50
+	// maxDrainBytes limits the response body read to reuse the connection; the body itself is never stored.
+	maxDrainBytes = 64 << 10
+)
+
+// Store claims due deliveries and records their attempts; *store.Store implements it.
+type Store interface {
+	ClaimDueWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]store.PendingWebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, input store.WebhookAttemptInput) error
+}
+
+// Dispatcher periodically sends queued webhook deliveries and schedules retries with exponential backoff.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewDispatcher(store Store, client *http.Client, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{store: store, client: client, logger: logger, now: time.Now}
+}
+
+// Backoff returns the delay before the next attempt of a delivery that has failed attempts times:
+// 30 seconds doubled for every further failure, capped at six hours.
+func Backoff(attempts int) time.Duration {
+	const (
+		base    = 30 * time.Second
+		ceiling = 6 * time.Hour
+	)
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= ceiling {
+			return ceiling
+		}
+	}
+	return delay
+}
+
+// Run calls DeliverDue immediately and then every tick until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		d.DeliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends every due delivery once and returns the number of successful deliveries.
+func (d *Dispatcher) DeliverDue(ctx context.Context) int {
+	now := d.now()
+	pending, err := d.store.ClaimDueWebhookDeliveries(ctx, now, now.Add(leaseDuration), batchSize)
+	if err != nil {
+		d.logger.Error("claim webhook deliveries", slog.String("error", err.Error()))
+		return 0
+	}
+	delivered := 0
+	for _, item := range pending {
+		attempt := store.WebhookAttemptInput{DeliveryID: item.Delivery.ID, AttemptedAt: d.now()}
+		statusCode, err := d.send(ctx, item)
+		if statusCode != 0 {
+			attempt.StatusCode = &statusCode
+		}
+		attempts := item.Delivery.Attempts + 1
+		switch {
+		case err == nil:
+			attempt.Status = domain.WebhookDeliveryDelivered
+			attempt.NextAttemptAt = attempt.AttemptedAt
+			delivered++
+		case attempts >= MaxAttempts:
+			attempt.Status = domain.WebhookDeliveryFailed
+			attempt.NextAttemptAt = attempt.AttemptedAt
+			attempt.Error = err.Error()
+		default:
+			attempt.Status = domain.WebhookDeliveryPending
+			attempt.NextAttemptAt = attempt.AttemptedAt.Add(Backoff(attempts))
+			attempt.Error = err.Error()
+		}
+		if err != nil {
+			d.logger.Warn("webhook delivery failed",
+				slog.Int64("delivery_id", item.Delivery.ID),
+				slog.Int64("webhook_id", item.Delivery.WebhookID),
+				slog.Int("attempt", attempts),
+				slog.String("error", err.Error()))
+		}
+		if err := d.store.RecordWebhookAttempt(ctx, attempt); err != nil {
+			d.logger.Error("record webhook attempt", slog.Int64("delivery_id", item.Delivery.ID), slog.String("error", err.Error()))
+		}
+	}
+	return delivered
+}
+
+// send posts the signed payload and returns the response status; any non-2xx status is an error. The response body
+// is not part of the error: the last error is shown to webhook admins, who must not read what the endpoint returns.
+func (d *Dispatcher) send(ctx context.Context, item store.PendingWebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, item.URL, bytes.NewReader(item.Delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(item.Delivery.Event))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(item.Delivery.ID, 10))
+	req.Header.Set(SignatureHeader, auth.Sign([]byte(item.Secret), item.Delivery.Payload))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"okrs/internal/auth"
+	"okrs/internal/domain"
+	"okrs/internal/store"
+)
+
+type fakeStore struct {
+	pending  []store.PendingWebhookDelivery
+	attempts []store.WebhookAttemptInput
+}
+
+func (f *fakeStore) ClaimDueWebhookDeliveries(context.Context, time.Time, time.Time, int) ([]store.PendingWebhookDelivery, error) {
+	return f.pending, nil
+}
+
+func (f *fakeStore) RecordWebhookAttempt(_ context.Context, input store.WebhookAttemptInput) error {
+	f.attempts = append(f.attempts, input)
+	return nil
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		7:  32 * time.Minute,
+		12: 6 * time.Hour,
+	}
+	for attempts, want := range cases {
+		if got := Backoff(attempts); got != want {
+			t.Fatalf("attempt %d: expected %s, got %s", attempts, want, got)
+		}
+	}
+}
+
+func TestDeliverDue(t *testing.T) {
+	payload := []byte(`{"event":"goal.created"}`)
+	var received http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !auth.CheckSignature([]byte("secret"), body, r.Header.Get(SignatureHeader)) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	delivery := func(id int64, attempts int) domain.WebhookDelivery {
+		return domain.WebhookDelivery{ID: id, WebhookID: 1, Event: domain.WebhookEventGoalCreated, Payload: payload, Attempts: attempts}
+	}
+	store := &fakeStore{pending: []store.PendingWebhookDelivery{
+		{Delivery: delivery(1, 0), URL: server.URL + "/ok", Secret: "secret"},
+		{Delivery: delivery(2, 2), URL: server.URL + "/down", Secret: "secret"},
+		{Delivery: delivery(3, MaxAttempts-1), URL: server.URL + "/down", Secret: "secret"},
+	}}
+	dispatcher := NewDispatcher(store, server.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	dispatcher.now = func() time.Time { return now }
+
+	if delivered := dispatcher.DeliverDue(context.Background()); delivered != 1 {
+		t.Fatalf("expected one delivery, got %d", delivered)
+	}
+	if received.Get(EventHeader) != "goal.created" || received.Get(DeliveryHeader) != "1" {
+		t.Fatalf("unexpected headers %v", received)
+	}
+	if len(store.attempts) != 3 {
+		t.Fatalf("expected three attempts, got %+v", store.attempts)
+	}
+	if ok := store.attempts[0]; ok.Status != domain.WebhookDeliveryDelivered || ok.StatusCode == nil || *ok.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected successful attempt %+v", ok)
+	}
+	retry := store.attempts[1]
+	if retry.Status != domain.WebhookDeliveryPending || !retry.NextAttemptAt.Equal(now.Add(2*time.Minute)) || retry.Error != "unexpected status 503" {
+		t.Fatalf("expected retry with backoff and no response body, got %+v", retry)
+	}
+	if failed := store.attempts[2]; failed.Status != domain.WebhookDeliveryFailed || *failed.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected last attempt to fail the delivery, got %+v", failed)
+	}
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// HealthChecker recalculates the health of teams in running periods and queues team.behind_plan;
+// *service.Service implements it.
+type HealthChecker interface {
+	CheckTeamsHealth(ctx context.Context) (int, error)
+}
+
+// HealthJob periodically checks team health, so team.behind_plan is sent when the planned progress outgrows the
+// team and not only when a key result changes.
+type HealthJob struct {
+	checker HealthChecker
+	logger  *slog.Logger
+}
+
+func NewHealthJob(checker HealthChecker, logger *slog.Logger) *HealthJob {
+	return &HealthJob{checker: checker, logger: logger}
+}
+
+// Run calls CheckDue immediately and then every tick until ctx is done.
+func (j *HealthJob) Run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		j.CheckDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckDue checks team health once and returns the number of teams that have fallen behind the plan.
+func (j *HealthJob) CheckDue(ctx context.Context) int {
+	notified, err := j.checker.CheckTeamsHealth(ctx)
+	if err != nil {
+		j.logger.Error("check team health", slog.String("error", err.Error()))
+	}
+	return notified
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS role_assignments (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'validator', 'admin')),
+  scope TEXT NOT NULL CHECK (scope IN ('global', 'subtree', 'team')),
+  team_id INTEGER REFERENCES teams(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CHECK ((scope = 'global') = (team_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS role_assignments_unique_idx
+  ON role_assignments(user_id, role, scope, COALESCE(team_id, 0));
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS kr_progress_events (
+  id SERIAL PRIMARY KEY,
+  key_result_id INTEGER NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+  value DOUBLE PRECISION NOT NULL,
+  progress INTEGER NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS kr_progress_events_kr_idx ON kr_progress_events(key_result_id, created_at);
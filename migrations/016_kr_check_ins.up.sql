@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS kr_check_ins (
+  id SERIAL PRIMARY KEY,
+  key_result_id INTEGER NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+  author_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  value DOUBLE PRECISION NOT NULL,
+  progress INTEGER NOT NULL,
+  confidence INTEGER NOT NULL CHECK (confidence BETWEEN 1 AND 10),
+  blockers TEXT NOT NULL DEFAULT '',
+  next_steps TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS kr_check_ins_kr_idx ON kr_check_ins(key_result_id, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE key_results DROP COLUMN IF EXISTS confidence;
+ALTER TABLE goals DROP COLUMN IF EXISTS confidence;
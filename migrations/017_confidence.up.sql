@@ -0,0 +1,2 @@
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS confidence INTEGER CHECK (confidence BETWEEN 0 AND 10);
+ALTER TABLE key_results ADD COLUMN IF NOT EXISTS confidence INTEGER CHECK (confidence BETWEEN 0 AND 10);
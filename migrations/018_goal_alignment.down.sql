@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS goals_parent_goal_id_idx;
+ALTER TABLE goals DROP COLUMN IF EXISTS progress_from_children;
+ALTER TABLE goals DROP COLUMN IF EXISTS parent_goal_id;
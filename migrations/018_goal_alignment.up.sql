@@ -0,0 +1,3 @@
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS parent_goal_id BIGINT REFERENCES goals(id) ON DELETE SET NULL;
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS progress_from_children BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS goals_parent_goal_id_idx ON goals(parent_goal_id);
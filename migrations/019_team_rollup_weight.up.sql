@@ -0,0 +1 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS rollup_weight INTEGER CHECK (rollup_weight BETWEEN 0 AND 100);
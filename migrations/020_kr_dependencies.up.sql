@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS kr_dependencies (
+  key_result_id INTEGER NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+  depends_on_id INTEGER NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (key_result_id, depends_on_id),
+  CHECK (key_result_id <> depends_on_id)
+);
+
+CREATE INDEX IF NOT EXISTS kr_dependencies_depends_on_idx ON kr_dependencies(depends_on_id);
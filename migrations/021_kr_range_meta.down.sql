@@ -0,0 +1 @@
+DROP TABLE IF EXISTS kr_range_meta;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS kr_range_meta (
+  key_result_id INTEGER PRIMARY KEY REFERENCES key_results(id) ON DELETE CASCADE,
+  min_value DOUBLE PRECISION,
+  max_value DOUBLE PRECISION,
+  tolerance DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (tolerance >= 0),
+  current_value DOUBLE PRECISION NOT NULL,
+  CHECK (min_value IS NOT NULL OR max_value IS NOT NULL)
+);
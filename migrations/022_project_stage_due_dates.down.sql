@@ -0,0 +1,2 @@
+ALTER TABLE kr_project_stages DROP COLUMN IF EXISTS done_at;
+ALTER TABLE kr_project_stages DROP COLUMN IF EXISTS due_date;
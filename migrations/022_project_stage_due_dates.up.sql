@@ -0,0 +1,7 @@
+ALTER TABLE kr_project_stages ADD COLUMN IF NOT EXISTS due_date DATE;
+ALTER TABLE kr_project_stages ADD COLUMN IF NOT EXISTS done_at TIMESTAMPTZ;
+
+UPDATE kr_project_stages s
+SET done_at = kr.updated_at
+FROM key_results kr
+WHERE kr.id = s.key_result_id AND s.is_done AND s.done_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE kr_progress_events DROP COLUMN IF EXISTS source;
+
+DROP TABLE IF EXISTS kr_data_sources;
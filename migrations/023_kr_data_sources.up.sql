@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS kr_data_sources (
+  key_result_id INTEGER PRIMARY KEY REFERENCES key_results(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK (kind IN ('HTTP_JSON', 'PROMQL', 'SQL')),
+  endpoint TEXT NOT NULL,
+  query TEXT NOT NULL,
+  interval_minutes INTEGER NOT NULL DEFAULT 60 CHECK (interval_minutes > 0),
+  last_run_at TIMESTAMPTZ,
+  last_value DOUBLE PRECISION,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE kr_progress_events ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'manual';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS ingest_requests (
+  key_result_id INTEGER NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+  idempotency_key TEXT NOT NULL,
+  payload_hash TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (key_result_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS ingest_requests_created_idx ON ingest_requests(created_at);
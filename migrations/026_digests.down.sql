@@ -0,0 +1,9 @@
+ALTER TABLE users
+  DROP COLUMN IF EXISTS digest_sent_at,
+  DROP COLUMN IF EXISTS digest_opt_out;
+
+ALTER TABLE teams
+  DROP COLUMN IF EXISTS lead_user_id;
+
+ALTER TABLE goals
+  DROP COLUMN IF EXISTS owner_user_id;
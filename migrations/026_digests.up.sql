@@ -0,0 +1,12 @@
+ALTER TABLE goals
+  ADD COLUMN IF NOT EXISTS owner_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS lead_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS digest_opt_out BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS digest_sent_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS goals_owner_user_idx ON goals(owner_user_id);
+CREATE INDEX IF NOT EXISTS teams_lead_user_idx ON teams(lead_user_id);
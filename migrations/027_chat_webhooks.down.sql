@@ -0,0 +1,5 @@
+ALTER TABLE team_period_statuses
+  DROP COLUMN IF EXISTS last_health;
+
+ALTER TABLE webhooks
+  DROP COLUMN IF EXISTS format;
@@ -0,0 +1,5 @@
+ALTER TABLE webhooks
+  ADD COLUMN IF NOT EXISTS format TEXT NOT NULL DEFAULT 'json' CHECK (format IN ('json', 'chat'));
+
+ALTER TABLE team_period_statuses
+  ADD COLUMN IF NOT EXISTS last_health TEXT;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS goals_origin_goal_id_idx;
+ALTER TABLE goals DROP COLUMN IF EXISTS origin_goal_id;
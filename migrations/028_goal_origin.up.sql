@@ -0,0 +1,2 @@
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS origin_goal_id BIGINT REFERENCES goals(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS goals_origin_goal_id_idx ON goals(origin_goal_id);